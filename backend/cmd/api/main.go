@@ -1,14 +1,39 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
 	httpHandler "notes-app/backend/internal/delivery/http"
 	"notes-app/backend/internal/delivery/http/middleware"
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/delivery/http/router"
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	domainAudit "notes-app/backend/internal/domain/audit"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/domain/tx"
+	domainUser "notes-app/backend/internal/domain/user"
 	"notes-app/backend/internal/infrastructure/config"
+	"notes-app/backend/internal/infrastructure/logger"
+	"notes-app/backend/internal/infrastructure/metrics"
 	"notes-app/backend/internal/infrastructure/repository/postgres"
+	"notes-app/backend/internal/infrastructure/repository/sqlite"
+	"notes-app/backend/internal/infrastructure/tracing"
+	"notes-app/backend/internal/usecase/admin"
+	"notes-app/backend/internal/usecase/attachment"
+	"notes-app/backend/internal/usecase/audit"
+	"notes-app/backend/internal/usecase/export"
+	"notes-app/backend/internal/usecase/importer"
+	"notes-app/backend/internal/usecase/note"
+	"notes-app/backend/internal/usecase/notebook"
+	"notes-app/backend/internal/usecase/tag"
 	"notes-app/backend/internal/usecase/user"
 
 	"github.com/joho/godotenv"
@@ -17,48 +42,236 @@ import (
 func main() {
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		slog.Error("error loading .env file", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	// Load configuration
 	cfg := config.LoadConfig()
 
-	// Debug: Print database configuration
-	log.Printf("Database Config - Host: %s, Port: %d, User: %s, DBName: %s\n",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.DBName)
+	// Initialize logger
+	log, err := logger.New(logger.Config{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	}, os.Stdout)
+	if err != nil {
+		slog.Error("invalid logging configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.SetDefault(log)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Error("invalid tracing configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	log.Debug("database config",
+		slog.String("driver", cfg.Database.Driver),
+		slog.String("path", cfg.Database.Path),
+		slog.String("host", cfg.Database.Host),
+		slog.Int("port", cfg.Database.Port),
+		slog.String("user", cfg.Database.User),
+		slog.String("db_name", cfg.Database.DBName),
+	)
 
 	// Initialize database
 	db, err := config.NewDatabase(cfg.Database)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Error("failed to connect to database", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer db.Close()
-	log.Printf("Database connected")
-	// Initialize repository
-	userRepo := postgres.NewUserRepository(db)
-	log.Printf("User repository initialized")
+	log.Info("database connected", slog.String("driver", cfg.Database.Driver))
+
+	// Initialize metrics
+	appMetrics := metrics.New()
+	dbName := cfg.Database.DBName
+	if cfg.Database.Driver == config.DriverSQLite {
+		dbName = cfg.Database.Path
+	}
+	appMetrics.RegisterDB(db, dbName)
+
+	// Initialize repositories
+	var (
+		userRepo       domainUser.Repository
+		auditRepo      domainAudit.Repository
+		noteRepo       domainNote.Repository
+		tagRepo        domainTag.Repository
+		notebookRepo   domainNotebook.Repository
+		attachmentRepo domainAttachment.Repository
+		txManager      tx.Manager
+	)
+	userLog := log.With(slog.String("component", "user_repository"))
+	auditLog := log.With(slog.String("component", "audit_repository"))
+	noteLog := log.With(slog.String("component", "note_repository"))
+	tagLog := log.With(slog.String("component", "tag_repository"))
+	notebookLog := log.With(slog.String("component", "notebook_repository"))
+	attachmentLog := log.With(slog.String("component", "attachment_repository"))
+	txLog := log.With(slog.String("component", "tx"))
+	switch cfg.Database.Driver {
+	case config.DriverSQLite:
+		// The SQLite schema ships with the binary, so a fresh file is
+		// usable without running scripts/migrate.sh
+		if err := sqlite.Migrate(context.Background(), db); err != nil {
+			log.Error("failed to migrate database", slog.Any("error", err))
+			os.Exit(1)
+		}
+		userRepo = sqlite.NewUserRepository(db, userLog)
+		auditRepo = sqlite.NewAuditRepository(db, auditLog)
+		noteRepo = sqlite.NewNoteRepository(db, noteLog)
+		tagRepo = sqlite.NewTagRepository(db, tagLog)
+		notebookRepo = sqlite.NewNotebookRepository(db, notebookLog)
+		attachmentRepo = sqlite.NewAttachmentRepository(db, attachmentLog)
+		txManager = sqlite.NewTxManager(db, sqlite.TxConfig{MaxRetries: 3}, txLog)
+	default:
+		userRepo = postgres.NewUserRepository(db, userLog)
+		auditRepo = postgres.NewAuditRepository(db, auditLog)
+		noteRepo = postgres.NewNoteRepository(db, noteLog)
+		tagRepo = postgres.NewTagRepository(db, tagLog)
+		notebookRepo = postgres.NewNotebookRepository(db, notebookLog)
+		attachmentRepo = postgres.NewAttachmentRepository(db, attachmentLog)
+
+		// Transactions use REPEATABLE READ so concurrent read-modify-write
+		// use cases fail with a serialization error and are retried instead
+		// of overwriting each other
+		txManager = postgres.NewTxManager(db, postgres.TxConfig{
+			Isolation:  sql.LevelRepeatableRead,
+			MaxRetries: 3,
+		}, txLog)
+	}
+
+	// Initialize attachment storage
+	blobs, err := config.NewBlobStore(cfg.Attachments.Store)
+	if err != nil {
+		log.Error("invalid blob store configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	log.Info("blob store ready", slog.String("driver", cfg.Attachments.Store.Driver))
+
 	// Initialize use case
-	userUseCase := user.NewUseCase(userRepo, user.Config{
+	auditRecorder := audit.NewRecorder(auditRepo, log.With(slog.String("component", "audit")))
+	userUseCase := user.NewUseCase(userRepo, txManager, user.Config{
 		JWTSecret: cfg.JWT.Secret,
-	})
+	}, log.With(slog.String("component", "user_usecase")), appMetrics, auditRecorder)
+	auditUseCase := audit.NewUseCase(auditRepo, auditRecorder, log.With(slog.String("component", "audit_usecase")))
+	adminUseCase := admin.NewUseCase(userRepo, txManager, auditRecorder, log.With(slog.String("component", "admin_usecase")), noteRepo, attachmentRepo)
+	linkSecret := cfg.Attachments.LinkSecret
+	if linkSecret == "" {
+		linkSecret = cfg.JWT.Secret
+	}
+	imageProcessor := attachment.NewImageProcessor(attachmentRepo, blobs, txManager, attachment.ImageConfig{
+		Workers:       cfg.Attachments.Images.Workers,
+		ThumbnailSize: cfg.Attachments.Images.ThumbnailSize,
+		DisplayWidth:  cfg.Attachments.Images.DisplayWidth,
+		MaxPixels:     cfg.Attachments.Images.MaxPixels,
+	}, log.With(slog.String("component", "image_processor")))
+	attachmentUseCase := attachment.NewUseCase(attachmentRepo, noteRepo, notebookRepo, blobs, imageProcessor, attachment.Config{
+		MaxBytes:     int64(cfg.Attachments.MaxBytes),
+		AllowedTypes: cfg.Attachments.AllowedTypes,
+		LinkSecret:   linkSecret,
+		LinkTTL:      time.Duration(cfg.Attachments.LinkTTLMinutes) * time.Minute,
+	}, log.With(slog.String("component", "attachment_usecase")))
+	noteUseCase := note.NewUseCase(noteRepo, tagRepo, notebookRepo, attachmentUseCase, txManager, auditRecorder, appMetrics, log.With(slog.String("component", "note_usecase")))
+	tagUseCase := tag.NewUseCase(tagRepo, noteRepo, txManager, log.With(slog.String("component", "tag_usecase")))
+	notebookUseCase := notebook.NewUseCase(notebookRepo, noteRepo, userRepo, txManager, auditRecorder, log.With(slog.String("component", "notebook_usecase")))
+	exportUseCase := export.NewUseCase(noteUseCase, notebookUseCase, attachmentUseCase, log.With(slog.String("component", "export_usecase")))
+	importUseCase := importer.NewUseCase(noteUseCase, notebookUseCase, attachmentUseCase, importer.Config{
+		MaxBytes:      int64(cfg.Import.MaxBytes),
+		MaxNotes:      cfg.Import.MaxNotes,
+		AttachmentURL: httpHandler.AttachmentURL,
+	}, log.With(slog.String("component", "import_usecase")))
+
+	// Error documentation links
+	response.SetDocsBaseURL(cfg.Server.ErrorDocsURL)
 
 	// Initialize handler
-	userHandler := httpHandler.NewUserHandler(userUseCase)
+	if err := httpHandler.CheckRequests(); err != nil {
+		log.Error("invalid request validation rules", slog.Any("error", err))
+		os.Exit(1)
+	}
+	handlers := httpHandler.Handlers{
+		User:       httpHandler.NewUserHandler(userUseCase, log.With(slog.String("component", "user_handler"))),
+		Admin:      httpHandler.NewAdminHandler(adminUseCase, log.With(slog.String("component", "admin_handler"))),
+		Audit:      httpHandler.NewAuditHandler(auditUseCase, log.With(slog.String("component", "audit_handler"))),
+		Note:       httpHandler.NewNoteHandler(noteUseCase, log.With(slog.String("component", "note_handler"))),
+		Tag:        httpHandler.NewTagHandler(tagUseCase, log.With(slog.String("component", "tag_handler"))),
+		Notebook:   httpHandler.NewNotebookHandler(notebookUseCase, log.With(slog.String("component", "notebook_handler"))),
+		Attachment: httpHandler.NewAttachmentHandler(attachmentUseCase, log.With(slog.String("component", "attachment_handler"))),
+		Export:     httpHandler.NewExportHandler(exportUseCase, log.With(slog.String("component", "export_handler"))),
+		Import:     httpHandler.NewImportHandler(importUseCase, log.With(slog.String("component", "import_handler"))),
+	}
 
-	// Create router (using default mux for simplicity)
-	mux := http.NewServeMux()
+	// Create router; middleware registered with Use runs for every route.
+	// Recovery comes last so a panic in a route handler is logged with its
+	// trace and counted as a 500 by the logs and metrics.
+	httpLog := log.With(slog.String("component", "http"))
+	rt := router.New()
+	rt.Use(
+		middleware.TracingMiddleware,
+		middleware.LoggingMiddleware(httpLog),
+		middleware.MetricsMiddleware(appMetrics),
+		middleware.RecoveryMiddleware(httpLog),
+	)
 
 	// Set up routes
-	mux.HandleFunc("/api/v1/auth/register", userHandler.Register)
-	mux.HandleFunc("/api/v1/auth/login", userHandler.Login)
+	rt.Handle(http.MethodGet, "/metrics", appMetrics.Handler())
+	httpHandler.RegisterRoutes(rt, handlers, middleware.AuthMiddleware(cfg.JWT.Secret, userUseCase))
+
+	for _, route := range rt.Routes() {
+		log.Debug("route registered", slog.String("method", route.Method), slog.String("path", route.Path))
+	}
 
 	// Create middleware chain
-	handler := middleware.CORSMiddleware(cfg.Server.AllowedOrigins)(mux)
+	corsPolicy := middleware.DefaultCORSPolicy(cfg.Server.AllowedOrigins)
+	corsPolicy.MaxAge = time.Duration(cfg.Server.CORSMaxAge) * time.Second
+	corsPolicy.RouteMethods = rt.AllowedMethods
+	if err := corsPolicy.Validate(); err != nil {
+		log.Error("invalid CORS configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	securityPolicy := middleware.DefaultSecurityPolicy()
+	securityPolicy.HSTSMaxAge = cfg.Server.Security.HSTSMaxAge
+	securityPolicy.ContentSecurityPolicy = cfg.Server.Security.ContentSecurityPolicy
+	securityPolicy.FrameAncestors = cfg.Server.Security.FrameAncestors
+	securityPolicy.ReferrerPolicy = cfg.Server.Security.ReferrerPolicy
+
+	handler := middleware.CORSMiddleware(corsPolicy)(rt)
+	handler = middleware.MaxBytesMiddleware(int64(cfg.Server.MaxBodyBytes))(handler)
+	handler = middleware.SecurityHeadersMiddleware(securityPolicy)(handler)
+	handler = middleware.ClientMiddleware(handler)
+	// Panics in the middleware above and in the router itself still get
+	// a JSON error carrying the request ID
+	handler = middleware.RecoveryMiddleware(httpLog)(handler)
+	handler = middleware.RequestIDMiddleware(handler)
+
+	// Purge notes that outlived the trash retention in the background
+	if cfg.Trash.RetentionDays > 0 && cfg.Trash.PurgeIntervalMinutes > 0 {
+		purger := note.NewPurger(noteRepo, time.Duration(cfg.Trash.RetentionDays)*24*time.Hour, log.With(slog.String("component", "trash_purger")))
+		go purger.Run(context.Background(), time.Duration(cfg.Trash.PurgeIntervalMinutes)*time.Minute)
+	}
+
+	// Process uploaded images in the background
+	go imageProcessor.Run(context.Background())
+
+	// Delete attachments no note embeds anymore in the background
+	if cfg.Attachments.GCIntervalMinutes > 0 {
+		collector := attachment.NewCollector(attachmentRepo, blobs, txManager, time.Duration(cfg.Attachments.GCGraceHours)*time.Hour, log.With(slog.String("component", "attachment_gc")))
+		go collector.Run(context.Background(), time.Duration(cfg.Attachments.GCIntervalMinutes)*time.Minute)
+	}
 
 	// Start the server
 	serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
-	log.Printf("Server starting on %s", serverAddr)
+	log.Info("server starting", slog.String("addr", serverAddr))
 	if err := http.ListenAndServe(serverAddr, handler); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		log.Error("failed to start server", slog.Any("error", err))
+		os.Exit(1)
 	}
 }
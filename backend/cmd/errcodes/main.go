@@ -0,0 +1,62 @@
+// Command errcodes writes the machine-readable and human-readable listings
+// of every API error code from the catalog in the response package.
+//
+// Run it through go generate from internal/delivery/http/response.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"notes-app/backend/internal/delivery/http/response"
+)
+
+func main() {
+	out := flag.String("out", "docs", "directory to write error-codes.json and error-codes.md to")
+	flag.Parse()
+
+	codes := response.Catalog()
+
+	data, err := json.MarshalIndent(codes, "", "  ")
+	if err != nil {
+		log.Fatalf("encoding catalog: %v", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(filepath.Join(*out, "error-codes.json"), data, 0o644); err != nil {
+		log.Fatalf("writing json listing: %v", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# API Error Codes\n\n")
+	b.WriteString("<!-- Code generated by cmd/errcodes. DO NOT EDIT. -->\n\n")
+	b.WriteString("Every error response carries one of the codes below in `errors[].code`.\n")
+	b.WriteString("Retryable errors may succeed if the same request is sent again later.\n\n")
+	b.WriteString("| Code | HTTP status | Message | Retryable |\n")
+	b.WriteString("|------|-------------|---------|-----------|\n")
+	for _, c := range codes {
+		fmt.Fprintf(&b, "| [`%s`](#%s) | %d | %s | %s |\n",
+			c.Code, strings.ToLower(string(c.Code)), c.Status, c.Message, yesNo(c.Retryable))
+	}
+	for _, c := range codes {
+		fmt.Fprintf(&b, "\n## %s\n\n", strings.ToLower(string(c.Code)))
+		fmt.Fprintf(&b, "- Code: `%s`\n", c.Code)
+		fmt.Fprintf(&b, "- HTTP status: %d\n", c.Status)
+		fmt.Fprintf(&b, "- Message: %s\n", c.Message)
+		fmt.Fprintf(&b, "- Retryable: %s\n", yesNo(c.Retryable))
+	}
+	if err := os.WriteFile(filepath.Join(*out, "error-codes.md"), []byte(b.String()), 0o644); err != nil {
+		log.Fatalf("writing markdown listing: %v", err)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/errs"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/usecase/admin"
+
+	"github.com/google/uuid"
+)
+
+// AdminHandler handles HTTP requests for account administration
+type AdminHandler struct {
+	adminUseCase admin.UseCase
+	logger       *slog.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminUseCase admin.UseCase, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminUseCase: adminUseCase,
+		logger:       logger,
+	}
+}
+
+// AdminUserResponse describes an account to administrators
+type AdminUserResponse struct {
+	ID                string     `json:"id"`
+	Email             string     `json:"email"`
+	Role              string     `json:"role"`
+	Disabled          bool       `json:"disabled"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	DisabledAt        *time.Time `json:"disabledAt,omitempty"`
+	SessionsRevokedAt *time.Time `json:"sessionsRevokedAt,omitempty"`
+}
+
+// StorageUsageResponse reports the storage an account uses
+type StorageUsageResponse struct {
+	UserID string `json:"userId"`
+	domainUser.StorageUsage
+	TotalBytes int64 `json:"totalBytes"`
+}
+
+func newAdminUserResponse(u *domainUser.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:                u.ID,
+		Email:             u.Email,
+		Role:              string(u.Role),
+		Disabled:          u.Disabled(),
+		CreatedAt:         u.CreatedAt,
+		DisabledAt:        u.DisabledAt,
+		SessionsRevokedAt: u.SessionsRevokedAt,
+	}
+}
+
+// ListUsers handles paginated user search. Query parameters: q matches
+// part of the email, role and status (active or disabled) filter, page
+// and perPage paginate.
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := request.ParsePage(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domainUser.ListFilter{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Role:   domainUser.Role(query.Get("role")),
+		Status: domainUser.Status(query.Get("status")),
+		Offset: page.Offset(),
+		Limit:  page.PerPage,
+	}
+
+	var details []*errs.Error
+	if filter.Role != "" && !filter.Role.Valid() {
+		details = append(details, request.InvalidQueryParam("role"))
+	}
+	if filter.Status != "" && filter.Status != domainUser.StatusActive && filter.Status != domainUser.StatusDisabled {
+		details = append(details, request.InvalidQueryParam("status"))
+	}
+	if len(details) > 0 {
+		response.Fail(w, r, errs.Validation(details...))
+		return
+	}
+
+	users, total, err := h.adminUseCase.ListUsers(r.Context(), filter)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "listing users failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	data := make([]AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		data = append(data, newAdminUserResponse(u))
+	}
+	response.JSONWithMeta(w, r, http.StatusOK, data, &response.Meta{
+		Total:   total,
+		Page:    page.Number,
+		PerPage: page.PerPage,
+	})
+}
+
+// DisableUser handles account suspension
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.adminUseCase.DisableUser(r.Context(), id)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "disabling user failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newAdminUserResponse(u))
+}
+
+// EnableUser handles account reactivation
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.adminUseCase.EnableUser(r.Context(), id)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "enabling user failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newAdminUserResponse(u))
+}
+
+// ForceLogout handles revoking every session of an account
+func (h *AdminHandler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUseCase.ForceLogout(r.Context(), id); err != nil {
+		h.logger.WarnContext(r.Context(), "force logout failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StorageUsage handles the storage usage report of an account
+func (h *AdminHandler) StorageUsage(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.adminUseCase.StorageUsage(r.Context(), id)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "storage usage failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, StorageUsageResponse{
+		UserID:       id,
+		StorageUsage: usage,
+		TotalBytes:   usage.TotalBytes(),
+	})
+}
+
+// userID reads the {id} path parameter. IDs that are not UUIDs cannot
+// exist and are reported as not found.
+func (h *AdminHandler) userID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if err := uuid.Validate(id); err != nil {
+		response.Fail(w, r, domainUser.ErrUserNotFound)
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,80 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+func TestAdminHandler(t *testing.T) {
+	s := newServer(t)
+	userToken := s.login(t, "jane@example.com", "")
+	supportToken := s.login(t, "support@example.com", domainUser.RoleSupport)
+	adminToken := s.login(t, "admin@example.com", domainUser.RoleAdmin)
+	jane, _ := s.users.GetByEmail(context.Background(), "jane@example.com")
+
+	t.Run("list requires a permission", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodGet, "/api/v1/admin/users", userToken, "")
+		if status != http.StatusForbidden || resp.Errors[0].Code != "FORBIDDEN" {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("list is paginated", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodGet, "/api/v1/admin/users?perPage=2&page=1", supportToken, "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+		var users []httpHandler.AdminUserResponse
+		json.Unmarshal(resp.Data, &users)
+		if len(users) != 2 || resp.Meta["total"] != 3 || resp.Meta["perPage"] != 2 || resp.Meta["page"] != 1 {
+			t.Errorf("users = %d, meta = %v", len(users), resp.Meta)
+		}
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodGet, "/api/v1/admin/users?role=root&perPage=1000", supportToken, "")
+		if status != http.StatusUnprocessableEntity || len(resp.Errors[0].Details) != 1 {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("support cannot disable", func(t *testing.T) {
+		status, _ := s.do(t, http.MethodPost, "/api/v1/admin/users/"+jane.ID+"/disable", supportToken, "")
+		if status != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", status)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/admin/users/not-a-uuid/disable", adminToken, "")
+		if status != http.StatusNotFound || resp.Errors[0].Code != "USER_NOT_FOUND" {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("disable locks the account out", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/admin/users/"+jane.ID+"/disable", adminToken, "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+		status, resp = s.do(t, http.MethodGet, "/api/v1/auth/me", userToken, "")
+		if status != http.StatusForbidden || resp.Errors[0].Code != "ACCOUNT_DISABLED" {
+			t.Fatalf("me: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("activity lists the caller's events", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodGet, "/api/v1/auth/activity", adminToken, "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+		if resp.Meta["total"] == 0 {
+			t.Error("no activity recorded for the admin")
+		}
+	})
+}
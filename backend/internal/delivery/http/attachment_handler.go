@@ -0,0 +1,286 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/usecase/attachment"
+)
+
+// attachmentsPath is where attachments are served. Notes embed
+// attachments by their content URL under it.
+const attachmentsPath = "/api/v1/attachments/"
+
+// AttachmentURL returns the content URL notes embed an attachment with
+func AttachmentURL(id string) string {
+	return attachmentsPath + id + "/content"
+}
+
+// errFileRequired reports an upload without a file part
+var errFileRequired = errs.Validation(
+	errs.New(errs.CodeFieldRequired, "file is required").
+		WithTarget("/file").
+		WithParam("field", "file"),
+)
+
+// AttachmentHandler handles HTTP requests for attachments
+type AttachmentHandler struct {
+	attachmentUseCase attachment.UseCase
+	logger            *slog.Logger
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentUseCase attachment.UseCase, logger *slog.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentUseCase: attachmentUseCase,
+		logger:            logger,
+	}
+}
+
+// AttachmentResponse describes an attachment. URL is the address to embed
+// in notes and needs authentication; DownloadURL works without
+// credentials until DownloadExpiresAt, for clients that cannot send them
+// such as an img element.
+//
+// Images are processed after the upload: Processing goes from pending to
+// done, or failed for images that cannot be decoded. Once done, Width and
+// Height hold the upright size and Variants the smaller renditions, for
+// embeds to pick from.
+type AttachmentResponse struct {
+	ID                string            `json:"id"`
+	Filename          string            `json:"filename"`
+	ContentType       string            `json:"contentType"`
+	Size              int64             `json:"size"`
+	Width             int               `json:"width,omitempty"`
+	Height            int               `json:"height,omitempty"`
+	Processing        string            `json:"processing,omitempty"`
+	Variants          []VariantResponse `json:"variants,omitempty"`
+	URL               string            `json:"url"`
+	DownloadURL       string            `json:"downloadUrl"`
+	DownloadExpiresAt time.Time         `json:"downloadExpiresAt"`
+	CreatedAt         time.Time         `json:"createdAt"`
+}
+
+// VariantResponse describes a rendition of an image attachment; its URLs
+// work like those of the attachment
+type VariantResponse struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+func newAttachmentResponse(a *domainAttachment.Attachment, link attachment.Link) AttachmentResponse {
+	signature := "expires=" + strconv.FormatInt(link.Expires.Unix(), 10) + "&signature=" + link.Signature
+	resp := AttachmentResponse{
+		ID:                a.ID,
+		Filename:          a.Filename,
+		ContentType:       a.ContentType,
+		Size:              a.Size,
+		Width:             a.Width,
+		Height:            a.Height,
+		Processing:        string(a.Processing),
+		URL:               AttachmentURL(a.ID),
+		DownloadURL:       attachmentsPath + a.ID + "/download?" + signature,
+		DownloadExpiresAt: link.Expires.UTC(),
+		CreatedAt:         a.CreatedAt,
+	}
+	for _, v := range a.Variants {
+		resp.Variants = append(resp.Variants, VariantResponse{
+			Name:        v.Name,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
+			Size:        v.Size,
+			URL:         attachmentsPath + a.ID + "/content?variant=" + v.Name,
+			DownloadURL: attachmentsPath + a.ID + "/download?variant=" + v.Name + "&" + signature,
+		})
+	}
+	return resp
+}
+
+// Upload handles attachment uploads, sent as multipart/form-data with the
+// file in a part named file
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		response.Fail(w, r, errs.ErrUnsupportedMedia.WithParam("expected", "multipart/form-data"))
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			response.Fail(w, r, errFileRequired)
+			return
+		}
+		if err != nil {
+			h.logger.WarnContext(r.Context(), "invalid multipart body", slog.Any("error", err))
+			response.Fail(w, r, uploadError(err))
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		a, link, err := h.attachmentUseCase.Upload(r.Context(), userID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.logger.WarnContext(r.Context(), "uploading attachment failed", slog.Any("error", err))
+			response.Fail(w, r, uploadError(err))
+			return
+		}
+
+		response.JSON(w, r, http.StatusCreated, newAttachmentResponse(a, link))
+		return
+	}
+}
+
+// Get handles attachment metadata retrieval, with a fresh download link
+func (h *AttachmentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	a, link, err := h.attachmentUseCase.Get(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "loading attachment failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newAttachmentResponse(a, link))
+}
+
+// Content handles authenticated attachment downloads. The variant query
+// parameter picks a rendition of an image.
+func (h *AttachmentHandler) Content(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	variant, ok := variantParam(w, r)
+	if !ok {
+		return
+	}
+
+	a, v, content, err := h.attachmentUseCase.Open(r.Context(), userID, r.PathValue("id"), variant)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "opening attachment failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+	h.serve(w, r, a, v, variant, content)
+}
+
+// Download handles attachment downloads through a signed link, without
+// authentication
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	variant, ok := variantParam(w, r)
+	if !ok {
+		return
+	}
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		response.Fail(w, r, domainAttachment.ErrInvalidLink)
+		return
+	}
+	link := attachment.Link{
+		Expires:   time.Unix(expires, 0),
+		Signature: r.URL.Query().Get("signature"),
+	}
+
+	a, v, content, err := h.attachmentUseCase.OpenLink(r.Context(), r.PathValue("id"), variant, link)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "opening attachment link failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+	h.serve(w, r, a, v, variant, content)
+}
+
+// variantParam reads the variant query parameter, which must name a
+// variant when set
+func variantParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	variant := r.URL.Query().Get("variant")
+	if variant != "" && !slices.Contains(domainAttachment.Variants, variant) {
+		response.Fail(w, r, errs.Validation(request.InvalidQueryParam("variant")))
+		return "", false
+	}
+	return variant, true
+}
+
+// serve writes the content of an attachment, or of the variant v. Images
+// are shown inline, anything else is downloaded. Processing can replace
+// the content of an image once, changing its checksum, so the checksum
+// is a strong ETag; for the same reason the length is left to the
+// server rather than taken from the attachment. Content is only cached
+// once processing is over: until then clients revalidate it, as the
+// image and the variants it lacks are yet to change.
+func (h *AttachmentHandler) serve(w http.ResponseWriter, r *http.Request, a *domainAttachment.Attachment, v *domainAttachment.Variant, variant string, content io.ReadCloser) {
+	defer content.Close()
+
+	etag, contentType := `"`+a.Checksum+`"`, a.ContentType
+	if v != nil {
+		etag, contentType = `"`+a.Checksum+"-"+v.Name+`"`, v.ContentType
+	}
+	w.Header().Set("ETag", etag)
+	if a.Processing == domainAttachment.ProcessingPending {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	disposition := "attachment"
+	if a.IsImage() {
+		disposition = "inline"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}); value != "" {
+		disposition = value
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		h.logger.WarnContext(r.Context(), "sending attachment failed", slog.Any("error", err))
+	}
+}
+
+// uploadError reports a body that crossed the request size limit as
+// PAYLOAD_TOO_LARGE and a malformed multipart body as an invalid request
+func uploadError(err error) error {
+	var maxErr *http.MaxBytesError
+	var coded *errs.Error
+	switch {
+	case errors.As(err, &maxErr):
+		return errs.ErrPayloadTooLarge.WithParam("limit", strconv.FormatInt(maxErr.Limit, 10))
+	case errors.As(err, &coded):
+		return err
+	default:
+		return errs.ErrInvalidRequest
+	}
+}
@@ -0,0 +1,167 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+)
+
+func TestAttachmentHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+	otherToken := s.login(t, "john@example.com", "")
+
+	encodePNG := func(w, h int) string {
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+		return buf.String()
+	}
+	pixel := encodePNG(1, 1)
+
+	upload := func(t *testing.T, filename, content string) (int, response) {
+		t.Helper()
+		return s.upload(t, token, filename, content)
+	}
+	fetch := s.fetch
+
+	status, resp := upload(t, "pixel.png", pixel)
+	if status != http.StatusCreated {
+		t.Fatalf("upload: status %d, errors %+v", status, resp.Errors)
+	}
+	var a httpHandler.AttachmentResponse
+	json.Unmarshal(resp.Data, &a)
+	if a.ContentType != "image/png" || a.Size != int64(len(pixel)) || a.Filename != "pixel.png" {
+		t.Fatalf("attachment = %+v", a)
+	}
+
+	t.Run("rejected uploads", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			content    string
+			wantStatus int
+			wantCode   string
+		}{
+			{"type not allowed", "%PDF-1.4\n", http.StatusUnsupportedMediaType, "ATTACHMENT_TYPE_NOT_ALLOWED"},
+			{"too large", strings.Repeat("a", 65<<10), http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"},
+			{"damaged image", "\x89PNG\r\n\x1a\ngarbage", http.StatusUnprocessableEntity, "INVALID_IMAGE"},
+			{"empty", "", http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				status, resp := upload(t, "file", tt.content)
+				if status != tt.wantStatus || len(resp.Errors) != 1 || resp.Errors[0].Code != tt.wantCode {
+					t.Fatalf("status = %d, errors %+v, want %d %s", status, resp.Errors, tt.wantStatus, tt.wantCode)
+				}
+			})
+		}
+
+		status, resp := s.do(t, http.MethodPost, "/api/v1/attachments", token, `{}`)
+		if status != http.StatusUnsupportedMediaType {
+			t.Errorf("JSON body: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("content is private to readers", func(t *testing.T) {
+		rec := fetch(t, a.URL, token)
+		if rec.Code != http.StatusOK || rec.Body.String() != pixel {
+			t.Fatalf("owner: status = %d, %d bytes", rec.Code, rec.Body.Len())
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != `inline; filename=pixel.png` {
+			t.Errorf("Content-Disposition = %q", got)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, a.URL, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+		cached := httptest.NewRecorder()
+		s.handler.ServeHTTP(cached, req)
+		if cached.Code != http.StatusNotModified {
+			t.Errorf("revalidation: status = %d, want 304", cached.Code)
+		}
+
+		if rec := fetch(t, a.URL, otherToken); rec.Code != http.StatusNotFound {
+			t.Errorf("other user: status = %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("signed links", func(t *testing.T) {
+		if rec := fetch(t, a.DownloadURL, ""); rec.Code != http.StatusOK || rec.Body.String() != pixel {
+			t.Fatalf("download: status = %d, %d bytes", rec.Code, rec.Body.Len())
+		}
+		tampered := strings.Replace(a.DownloadURL, "/"+a.ID+"/", "/00000000-0000-0000-0000-000000000000/", 1)
+		if rec := fetch(t, tampered, ""); rec.Code != http.StatusForbidden {
+			t.Errorf("other attachment: status = %d, want 403", rec.Code)
+		}
+		if rec := fetch(t, a.DownloadURL+"x", ""); rec.Code != http.StatusForbidden {
+			t.Errorf("tampered signature: status = %d, want 403", rec.Code)
+		}
+	})
+
+	t.Run("images are processed", func(t *testing.T) {
+		status, resp := upload(t, "wide.png", encodePNG(600, 300))
+		if status != http.StatusCreated {
+			t.Fatalf("upload: status %d, errors %+v", status, resp.Errors)
+		}
+		var wide httpHandler.AttachmentResponse
+		json.Unmarshal(resp.Data, &wide)
+
+		for deadline := time.Now().Add(5 * time.Second); wide.Processing == "pending" && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+			_, resp := s.do(t, http.MethodGet, "/api/v1/attachments/"+wide.ID, token, "")
+			json.Unmarshal(resp.Data, &wide)
+		}
+		if wide.Processing != "done" || wide.Width != 600 || wide.Height != 300 || len(wide.Variants) != 2 {
+			t.Fatalf("attachment = %+v", wide)
+		}
+		for _, v := range wide.Variants {
+			want := map[string][2]int{"display": {400, 200}, "thumbnail": {64, 32}}[v.Name]
+			if v.Width != want[0] || v.Height != want[1] {
+				t.Errorf("%s variant = %+v, want %dx%d", v.Name, v, want[0], want[1])
+			}
+		}
+
+		// Processed images no longer change, so clients may keep them
+		if rec := fetch(t, wide.URL, token); rec.Header().Get("Cache-Control") != "private, max-age=86400" {
+			t.Errorf("processed image: Cache-Control = %q", rec.Header().Get("Cache-Control"))
+		}
+		rec := fetch(t, wide.Variants[1].URL, token)
+		config, err := png.DecodeConfig(rec.Body)
+		if rec.Code != http.StatusOK || err != nil || config.Width != 64 {
+			t.Errorf("thumbnail: status = %d, config %+v, err %v", rec.Code, config, err)
+		}
+		if rec := fetch(t, wide.URL+"?variant=poster", token); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("unknown variant: status = %d, want 422", rec.Code)
+		}
+		// The pixel is too small for variants, so they fall back to it
+		if rec := fetch(t, a.URL+"?variant=display", token); rec.Code != http.StatusOK || rec.Body.String() != pixel {
+			t.Errorf("missing variant: status = %d, %d bytes", rec.Code, rec.Body.Len())
+		}
+	})
+
+	t.Run("embedding shares with the note's readers", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notebooks", token, `{"name":"Shared"}`)
+		if status != http.StatusCreated {
+			t.Fatalf("create notebook: status %d, errors %+v", status, resp.Errors)
+		}
+		var nb httpHandler.NotebookResponse
+		json.Unmarshal(resp.Data, &nb)
+		s.do(t, http.MethodPut, "/api/v1/notebooks/"+nb.ID+"/collaborators", token, `{"email":"john@example.com","role":"viewer"}`)
+
+		body := `{"title":"pixel","notebookId":"` + nb.ID + `","content":{"ops":[{"insert":{"image":"` + a.URL + `"}},{"insert":"\n"}]}}`
+		if status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, body); status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+
+		if rec := fetch(t, a.URL, otherToken); rec.Code != http.StatusOK {
+			t.Errorf("collaborator: status = %d, want 200", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+	auditUseCase "notes-app/backend/internal/usecase/audit"
+
+	"github.com/google/uuid"
+)
+
+// AuditHandler handles HTTP requests for the audit log
+type AuditHandler struct {
+	auditUseCase auditUseCase.UseCase
+	logger       *slog.Logger
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditUseCase auditUseCase.UseCase, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+		logger:       logger,
+	}
+}
+
+// Query handles the administrator audit log search. Query parameters:
+// actorId, userId, action, targetType, targetId, since and until
+// (RFC 3339), page and perPage.
+func (h *AuditHandler) Query(w http.ResponseWriter, r *http.Request) {
+	page, err := request.ParsePage(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		ActorID:    query.Get("actorId"),
+		Action:     audit.Action(query.Get("action")),
+		TargetType: query.Get("targetType"),
+		TargetID:   query.Get("targetId"),
+		UserID:     query.Get("userId"),
+		Offset:     page.Offset(),
+		Limit:      page.PerPage,
+	}
+
+	var details []*errs.Error
+	if filter.ActorID != "" && uuid.Validate(filter.ActorID) != nil {
+		details = append(details, request.InvalidQueryParam("actorId"))
+	}
+	if filter.UserID != "" && uuid.Validate(filter.UserID) != nil {
+		details = append(details, request.InvalidQueryParam("userId"))
+	}
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		details = append(details, request.InvalidQueryParam("since"))
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		details = append(details, request.InvalidQueryParam("until"))
+	}
+	if len(details) > 0 {
+		response.Fail(w, r, errs.Validation(details...))
+		return
+	}
+
+	events, total, err := h.auditUseCase.Query(r.Context(), filter)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "audit query failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	h.respond(w, r, events, total, page)
+}
+
+// Activity handles the caller's own account activity
+func (h *AuditHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.Fail(w, r, errs.ErrUnauthorized)
+		return
+	}
+
+	page, err := request.ParsePage(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	events, total, err := h.auditUseCase.AccountActivity(r.Context(), principal.UserID, page.Offset(), page.PerPage)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "account activity failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	h.respond(w, r, events, total, page)
+}
+
+// respond sends a page of events
+func (h *AuditHandler) respond(w http.ResponseWriter, r *http.Request, events []*audit.Event, total int, page request.Page) {
+	if events == nil {
+		events = []*audit.Event{}
+	}
+	response.JSONWithMeta(w, r, http.StatusOK, events, &response.Meta{
+		Total:   total,
+		Page:    page.Number,
+		PerPage: page.PerPage,
+	})
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
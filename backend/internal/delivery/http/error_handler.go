@@ -0,0 +1,13 @@
+package http
+
+import (
+	"net/http"
+
+	"notes-app/backend/internal/delivery/http/response"
+)
+
+// ErrorCodes lists every error code the API can return, with its HTTP
+// status, message template, documentation URL and retryability
+func ErrorCodes(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, r, http.StatusOK, response.Catalog())
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/usecase/export"
+)
+
+// ExportHandler handles HTTP requests exporting notes
+type ExportHandler struct {
+	exportUseCase export.UseCase
+	logger        *slog.Logger
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportUseCase export.UseCase, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportUseCase: exportUseCase,
+		logger:        logger,
+	}
+}
+
+// NoteExportRequest represents the body of a bulk export: the notes to
+// export and their format, markdown by default
+type NoteExportRequest struct {
+	NoteIDs []string `json:"noteIds" validate:"required,min=1,max=500"`
+	Format  string   `json:"format" validate:"oneof=markdown html text json pdf"`
+}
+
+// Note handles exporting a single note as a file to download. The format
+// query parameter is markdown (the default), html, text, json or pdf.
+func (h *ExportHandler) Note(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	format := export.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = export.FormatMarkdown
+	}
+	if !slices.Contains(export.Formats, format) {
+		response.Fail(w, r, errs.Validation(request.InvalidQueryParam("format")))
+		return
+	}
+
+	file, err := h.exportUseCase.Note(r.Context(), userID, r.PathValue("id"), format)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "exporting note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+	h.send(w, r, file)
+}
+
+// Notebook handles exporting the notes of a notebook as a single file to
+// download. The format query parameter can only be pdf, the default.
+func (h *ExportHandler) Notebook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && export.Format(format) != export.FormatPDF {
+		response.Fail(w, r, errs.Validation(request.InvalidQueryParam("format")))
+		return
+	}
+
+	file, err := h.exportUseCase.Notebook(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "exporting notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+	h.send(w, r, file)
+}
+
+// send writes an exported file as a download. The codes of its warnings
+// are listed in the X-Export-Warnings header.
+func (h *ExportHandler) send(w http.ResponseWriter, r *http.Request, file *export.File) {
+	if len(file.Warnings) > 0 {
+		codes := make([]string, 0, len(file.Warnings))
+		for _, warning := range file.Warnings {
+			codes = append(codes, string(errs.CodeOf(warning)))
+			h.logger.InfoContext(r.Context(), "export incomplete", slog.Any("warning", warning))
+		}
+		w.Header().Set("X-Export-Warnings", strings.Join(codes, ", "))
+	}
+	w.Header().Set("Content-Disposition", contentDisposition(file.Name))
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(file.Data); err != nil {
+		h.logger.WarnContext(r.Context(), "sending export failed", slog.Any("error", err))
+	}
+}
+
+// Archive handles exporting several notes as a zip archive, along with
+// the attachments they embed. The archive is streamed as it is built, so
+// a failure midway can only cut the response short.
+func (h *ExportHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req NoteExportRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+	format := export.Format(req.Format)
+	if format == "" {
+		format = export.FormatMarkdown
+	}
+	ids := make([]string, 0, len(req.NoteIDs))
+	for _, id := range req.NoteIDs {
+		ids = append(ids, strings.TrimSpace(id))
+	}
+
+	archive, err := h.exportUseCase.Archive(r.Context(), userID, ids, format)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "exporting notes failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	name := "notes-" + time.Now().UTC().Format("20060102-150405") + ".zip"
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := archive.Write(r.Context(), w); err != nil {
+		h.logger.ErrorContext(r.Context(), "writing export archive failed", slog.Any("error", err))
+	}
+}
+
+// contentDisposition returns the header value making clients download a
+// file under the given name
+func contentDisposition(name string) string {
+	if value := mime.FormatMediaType("attachment", map[string]string{"filename": name}); value != "" {
+		return value
+	}
+	return "attachment"
+}
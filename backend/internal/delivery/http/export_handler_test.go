@@ -0,0 +1,185 @@
+package http_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+)
+
+func TestExportHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+	otherToken := s.login(t, "john@example.com", "")
+
+	status, resp := s.upload(t, token, "packing.txt", "socks\n")
+	if status != http.StatusCreated {
+		t.Fatalf("upload: status %d, errors %+v", status, resp.Errors)
+	}
+	var a httpHandler.AttachmentResponse
+	json.Unmarshal(resp.Data, &a)
+
+	var ids []string
+	for _, body := range []string{
+		`{"title":"Trip","content":{"ops":[{"insert":"Plan"},{"insert":"\n","attributes":{"header":2}},{"insert":"list","attributes":{"link":"` + a.URL + `"}},{"insert":"\n"}]}}`,
+		`{"title":"Trip","content":{"ops":[{"insert":"Second\n"}]}}`,
+	} {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, body)
+		if status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		ids = append(ids, n.ID)
+	}
+
+	t.Run("single note", func(t *testing.T) {
+		rec := s.fetch(t, "/api/v1/notes/"+ids[0]+"/export", token)
+		want := "# Trip\n\n## Plan\n\n[list](" + a.URL + ")\n"
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Fatalf("status = %d, body %q, want %q", rec.Code, rec.Body.String(), want)
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=Trip.md" {
+			t.Errorf("Content-Disposition = %q", got)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "text/markdown; charset=utf-8" {
+			t.Errorf("Content-Type = %q", got)
+		}
+
+		if rec := s.fetch(t, "/api/v1/notes/"+ids[0]+"/export?format=docx", token); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("unknown format: status = %d, want 422", rec.Code)
+		}
+		if rec := s.fetch(t, "/api/v1/notes/"+ids[0]+"/export", otherToken); rec.Code != http.StatusNotFound {
+			t.Errorf("other user: status = %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		var pixel bytes.Buffer
+		png.Encode(&pixel, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+		status, resp := s.upload(t, token, "pixel.png", pixel.String())
+		if status != http.StatusCreated {
+			t.Fatalf("upload: status %d, errors %+v", status, resp.Errors)
+		}
+		var img httpHandler.AttachmentResponse
+		json.Unmarshal(resp.Data, &img)
+
+		status, resp = s.do(t, http.MethodPost, "/api/v1/notebooks", token, `{"name":"Holiday"}`)
+		if status != http.StatusCreated {
+			t.Fatalf("create notebook: status %d, errors %+v", status, resp.Errors)
+		}
+		var nb httpHandler.NotebookResponse
+		json.Unmarshal(resp.Data, &nb)
+		var n httpHandler.NoteResponse
+		for _, title := range []string{"Day 1", "Day 2"} {
+			body := `{"title":"` + title + `","notebookId":"` + nb.ID + `","content":{"ops":[{"insert":{"image":"` + img.URL + `"}},{"insert":"\n"}]}}`
+			status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, body)
+			if status != http.StatusCreated {
+				t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+			}
+			json.Unmarshal(resp.Data, &n)
+		}
+
+		for _, tt := range []struct {
+			path        string
+			disposition string
+			pages       string
+		}{
+			{"/api/v1/notes/" + n.ID + "/export?format=pdf", `attachment; filename="Day 2.pdf"`, "/Count 1 >>"},
+			{"/api/v1/notebooks/" + nb.ID + "/export", "attachment; filename=Holiday.pdf", "/Count 2 >>"},
+		} {
+			rec := s.fetch(t, tt.path, token)
+			body := rec.Body.String()
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(body, "%PDF-") {
+				t.Fatalf("%s: status = %d, Content-Type %q", tt.path, rec.Code, rec.Header().Get("Content-Type"))
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != tt.disposition {
+				t.Errorf("%s: Content-Disposition = %q", tt.path, got)
+			}
+			if !strings.Contains(body, tt.pages) || !strings.Contains(body, "/Subtype /Image") {
+				t.Errorf("%s: document lacks its pages or image", tt.path)
+			}
+		}
+
+		status, resp = s.do(t, http.MethodPost, "/api/v1/notes", token, `{"title":"Deniz","content":{"ops":[{"insert":"Dalgalar 🌊\n"}]}}`)
+		if status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+		json.Unmarshal(resp.Data, &n)
+		rec := s.fetch(t, "/api/v1/notes/"+n.ID+"/export?format=pdf", token)
+		if got := rec.Header().Get("X-Export-Warnings"); rec.Code != http.StatusOK || got != "EXPORT_MISSING_CHARACTERS" {
+			t.Errorf("emoji: status = %d, X-Export-Warnings = %q", rec.Code, got)
+		}
+
+		if rec := s.fetch(t, "/api/v1/notebooks/"+nb.ID+"/export?format=html", token); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("notebook as html: status = %d, want 422", rec.Code)
+		}
+		if rec := s.fetch(t, "/api/v1/notebooks/"+nb.ID+"/export", otherToken); rec.Code != http.StatusNotFound {
+			t.Errorf("other user: status = %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/notes/export",
+			strings.NewReader(`{"noteIds":["`+ids[0]+`","`+ids[1]+`"],"format":"html"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+			t.Fatalf("status = %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("invalid archive: %v", err)
+		}
+		files := map[string]string{}
+		for _, f := range zr.File {
+			r, _ := f.Open()
+			data, _ := io.ReadAll(r)
+			files[f.Name] = string(data)
+		}
+		attachmentPath := "attachments/" + a.ID + "/packing.txt"
+		if files[attachmentPath] != "socks\n" {
+			t.Errorf("archive lacks the attachment: %v", zr.File)
+		}
+		if !strings.Contains(files["Trip.html"], `<a href="`+attachmentPath+`">list</a>`) {
+			t.Errorf("Trip.html does not link to the bundled attachment:\n%s", files["Trip.html"])
+		}
+		if !strings.Contains(files["Trip (2).html"], "<p>Second</p>") {
+			t.Errorf("Trip (2).html = %q", files["Trip (2).html"])
+		}
+	})
+
+	t.Run("archive validation", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			body       string
+			wantStatus int
+		}{
+			{"no notes", `{"noteIds":[]}`, http.StatusUnprocessableEntity},
+			{"unknown format", `{"noteIds":["` + ids[0] + `"],"format":"docx"}`, http.StatusUnprocessableEntity},
+			{"note of another user", `{"noteIds":["` + ids[0] + `"]}`, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				token := token
+				if tt.wantStatus == http.StatusNotFound {
+					token = otherToken
+				}
+				if status, resp := s.do(t, http.MethodPost, "/api/v1/notes/export", token, tt.body); status != tt.wantStatus {
+					t.Errorf("status = %d, errors %+v, want %d", status, resp.Errors, tt.wantStatus)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+	"notes-app/backend/internal/delivery/http/middleware"
+	"notes-app/backend/internal/delivery/http/router"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/blob"
+	"notes-app/backend/internal/infrastructure/repository/memory"
+	"notes-app/backend/internal/usecase/admin"
+	"notes-app/backend/internal/usecase/attachment"
+	auditUseCase "notes-app/backend/internal/usecase/audit"
+	"notes-app/backend/internal/usecase/export"
+	"notes-app/backend/internal/usecase/importer"
+	"notes-app/backend/internal/usecase/note"
+	"notes-app/backend/internal/usecase/notebook"
+	"notes-app/backend/internal/usecase/tag"
+	"notes-app/backend/internal/usecase/user"
+)
+
+const secret = "test-secret"
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type nopMetrics struct{}
+
+func (nopMetrics) LoginSucceeded()           {}
+func (nopMetrics) LoginFailed(string)        {}
+func (nopMetrics) RegistrationSucceeded()    {}
+func (nopMetrics) RegistrationFailed(string) {}
+func (nopMetrics) ObserveNoteSave(int)       {}
+
+type server struct {
+	handler http.Handler
+	users   domainUser.Repository
+}
+
+// newServer wires the handlers and routes the way cmd/api does, on
+// in-memory repositories
+func newServer(t *testing.T) *server {
+	t.Helper()
+	users := memory.NewUserRepository()
+	events := memory.NewAuditRepository()
+	notes := memory.NewNoteRepository()
+	tags := memory.NewTagRepository()
+	notebooks := memory.NewNotebookRepository()
+	attachments := memory.NewAttachmentRepository(notes)
+	txm := memory.NewTxManager()
+	recorder := auditUseCase.NewRecorder(events, discard)
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := attachment.NewImageProcessor(attachments, blobs, txm, attachment.ImageConfig{
+		Workers:       1,
+		ThumbnailSize: 64,
+		DisplayWidth:  400,
+		MaxPixels:     1 << 20,
+	}, discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go images.Run(ctx)
+	attachmentUseCase := attachment.NewUseCase(attachments, notes, notebooks, blobs, images, attachment.Config{
+		MaxBytes:     64 << 10,
+		AllowedTypes: []string{"image/png", "text/plain"},
+		LinkSecret:   secret,
+		LinkTTL:      time.Minute,
+	}, discard)
+
+	userUseCase := user.NewUseCase(users, txm, user.Config{JWTSecret: secret}, discard, nopMetrics{}, recorder)
+	noteUseCase := note.NewUseCase(notes, tags, notebooks, attachmentUseCase, txm, recorder, nopMetrics{}, discard)
+	notebookUseCase := notebook.NewUseCase(notebooks, notes, users, txm, recorder, discard)
+	handlers := httpHandler.Handlers{
+		User:       httpHandler.NewUserHandler(userUseCase, discard),
+		Admin:      httpHandler.NewAdminHandler(admin.NewUseCase(users, txm, recorder, discard), discard),
+		Audit:      httpHandler.NewAuditHandler(auditUseCase.NewUseCase(events, recorder, discard), discard),
+		Note:       httpHandler.NewNoteHandler(noteUseCase, discard),
+		Tag:        httpHandler.NewTagHandler(tag.NewUseCase(tags, notes, txm, discard), discard),
+		Notebook:   httpHandler.NewNotebookHandler(notebookUseCase, discard),
+		Attachment: httpHandler.NewAttachmentHandler(attachmentUseCase, discard),
+		Export:     httpHandler.NewExportHandler(export.NewUseCase(noteUseCase, notebookUseCase, attachmentUseCase, discard), discard),
+		Import: httpHandler.NewImportHandler(importer.NewUseCase(noteUseCase, notebookUseCase, attachmentUseCase, importer.Config{
+			MaxBytes:      1 << 20,
+			MaxNotes:      5,
+			AttachmentURL: httpHandler.AttachmentURL,
+		}, discard), discard),
+	}
+
+	if err := httpHandler.CheckRequests(); err != nil {
+		t.Fatalf("CheckRequests: %v", err)
+	}
+	rt := router.New()
+	httpHandler.RegisterRoutes(rt, handlers, middleware.AuthMiddleware(secret, userUseCase))
+
+	return &server{handler: middleware.RequestIDMiddleware(rt), users: users}
+}
+
+// response is the envelope of every API response
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Meta   map[string]int  `json:"meta"`
+	Errors []struct {
+		Code    string `json:"code"`
+		Target  string `json:"target"`
+		Details []struct {
+			Code    string `json:"code"`
+			Target  string `json:"target"`
+			Message string `json:"message"`
+		} `json:"details"`
+	} `json:"errors"`
+	RequestID string `json:"requestId"`
+}
+
+func (s *server) do(t *testing.T, method, path, token, body string) (int, response) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	var resp response
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s %s: invalid JSON body %q: %v", method, path, rec.Body.String(), err)
+		}
+		if resp.RequestID == "" || resp.RequestID != rec.Header().Get("X-Request-ID") {
+			t.Errorf("requestId %q does not match header %q", resp.RequestID, rec.Header().Get("X-Request-ID"))
+		}
+	}
+	return rec.Code, resp
+}
+
+// upload sends a file as a multipart attachment upload
+func (s *server) upload(t *testing.T, token, filename, content string) (int, response) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", filename)
+	io.WriteString(part, content)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	var resp response
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+// fetch sends a GET request whose response is not necessarily JSON
+func (s *server) fetch(t *testing.T, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// login registers an account, optionally with a role, and returns a token
+func (s *server) login(t *testing.T, email string, role domainUser.Role) string {
+	t.Helper()
+	creds := `{"email":"` + email + `","password":"password123"}`
+	if status, resp := s.do(t, http.MethodPost, "/api/v1/auth/register", "", creds); status != http.StatusCreated {
+		t.Fatalf("register: status %d, errors %+v", status, resp.Errors)
+	}
+	if role != "" {
+		u, _ := s.users.GetByEmail(context.Background(), email)
+		u.Role = role
+		s.users.Update(context.Background(), u)
+	}
+	status, resp := s.do(t, http.MethodPost, "/api/v1/auth/login", "", creds)
+	if status != http.StatusOK {
+		t.Fatalf("login: status %d, errors %+v", status, resp.Errors)
+	}
+	var data struct{ Token string }
+	json.Unmarshal(resp.Data, &data)
+	return data.Token
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/usecase/importer"
+)
+
+// maxFieldBytes bounds the form fields of a multipart body, files aside
+const maxFieldBytes = 1 << 10
+
+// ImportHandler handles HTTP requests importing notes
+type ImportHandler struct {
+	importUseCase importer.UseCase
+	logger        *slog.Logger
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(importUseCase importer.UseCase, logger *slog.Logger) *ImportHandler {
+	return &ImportHandler{
+		importUseCase: importUseCase,
+		logger:        logger,
+	}
+}
+
+// ImportResponse reports an import document by document
+type ImportResponse struct {
+	Imported int                    `json:"imported"`
+	Failed   int                    `json:"failed"`
+	Results  []ImportResultResponse `json:"results"`
+}
+
+// ImportResultResponse reports the import of one document: the note
+// created, or the error it failed with. Warnings list what the note
+// leaves out, their target being the address the document used.
+type ImportResultResponse struct {
+	Name     string              `json:"name"`
+	Status   string              `json:"status"` // imported or failed
+	Note     *NoteResponse       `json:"note,omitempty"`
+	Error    *response.APIError  `json:"error,omitempty"`
+	Warnings []response.APIError `json:"warnings,omitempty"`
+}
+
+func newImportResponse(results []importer.Result) ImportResponse {
+	resp := ImportResponse{Results: make([]ImportResultResponse, 0, len(results))}
+	for _, result := range results {
+		item := ImportResultResponse{Name: result.Name, Status: "imported"}
+		if result.Err != nil {
+			_, apiErr := response.FromError(result.Err)
+			item.Status = "failed"
+			item.Error = &apiErr
+			resp.Failed++
+		} else {
+			note := newNoteResponse(result.Note)
+			item.Note = &note
+			resp.Imported++
+		}
+		for _, warning := range result.Warnings {
+			_, apiErr := response.FromError(warning)
+			item.Warnings = append(item.Warnings, apiErr)
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	return resp
+}
+
+// Import handles importing notes from the files of a multipart body:
+// Markdown, HTML and Evernote (.enex) files, and zip archives of Markdown
+// and HTML files with the images they embed, in "file" parts. An optional
+// "notebookId" field files the notes in a notebook. Documents that fail
+// are reported alongside those imported.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		response.Fail(w, r, errs.ErrUnsupportedMedia.WithParam("expected", "multipart/form-data"))
+		return
+	}
+	var files []importer.File
+	var notebookID string
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.logger.WarnContext(r.Context(), "invalid multipart body", slog.Any("error", err))
+			response.Fail(w, r, uploadError(err))
+			return
+		}
+
+		var data []byte
+		switch part.FormName() {
+		case "file":
+			data, err = io.ReadAll(part)
+			files = append(files, importer.File{Name: part.FileName(), Data: data})
+		case "notebookId":
+			data, err = io.ReadAll(io.LimitReader(part, maxFieldBytes))
+			notebookID = strings.TrimSpace(string(data))
+		}
+		part.Close()
+		if err != nil {
+			h.logger.WarnContext(r.Context(), "reading import failed", slog.Any("error", err))
+			response.Fail(w, r, uploadError(err))
+			return
+		}
+	}
+	if len(files) == 0 {
+		response.Fail(w, r, errFileRequired)
+		return
+	}
+
+	results, err := h.importUseCase.Import(r.Context(), userID, notebookID, files)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "importing notes failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newImportResponse(results))
+}
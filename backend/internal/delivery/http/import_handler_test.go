@@ -0,0 +1,145 @@
+package http_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+)
+
+func TestImportHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+
+	var pixel bytes.Buffer
+	png.Encode(&pixel, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"trip/plan.md":         "# Plan\n\n![Map](../img/map.png) ![Gone](gone.png) [next](day2.html)\n",
+		"trip/day2.html":       "<h1>Day 2</h1><p>Hike</p>",
+		"img/map.png":          pixel.String(),
+		"__MACOSX/._plan.md":   "junk",
+		"trip/.notes/skip.txt": "not a document",
+	} {
+		f, _ := zw.Create(name)
+		io.WriteString(f, content)
+	}
+	zw.Close()
+
+	enex := `<?xml version="1.0" encoding="UTF-8"?>
+<en-export><note><title>From Evernote</title>
+<content><![CDATA[<en-note><div>Buy <b>milk</b></div></en-note>]]></content>
+</note></en-export>`
+
+	imports := func(t *testing.T, files map[string]string, fields map[string]string) (int, response) {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for name, value := range fields {
+			mw.WriteField(name, value)
+		}
+		for name, content := range files {
+			part, _ := mw.CreateFormFile("file", name)
+			io.WriteString(part, content)
+		}
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/notes/import", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, req)
+
+		var resp response
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	t.Run("documents", func(t *testing.T) {
+		status, resp := imports(t, map[string]string{
+			"notes.md":       "# Hello\n\n**Bold** [bad](javascript:alert(1)) and [good](https://example.com)\n",
+			"trip.zip":       archive.String(),
+			"groceries.enex": enex,
+			"slides.pptx":    "binary",
+		}, nil)
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+		var got httpHandler.ImportResponse
+		json.Unmarshal(resp.Data, &got)
+		if got.Imported != 4 || got.Failed != 1 {
+			t.Fatalf("imported %d, failed %d: %+v", got.Imported, got.Failed, got.Results)
+		}
+
+		results := make(map[string]httpHandler.ImportResultResponse)
+		for _, result := range got.Results {
+			results[result.Name] = result
+		}
+		if r := results["slides.pptx"]; r.Status != "failed" || r.Error == nil || r.Error.Code != "IMPORT_UNSUPPORTED_FILE" {
+			t.Errorf("unsupported file: %+v", r)
+		}
+		if r := results["notes.md"]; r.Note == nil || r.Note.Title != "Hello" ||
+			string(r.Note.Content.JSON()) != `{"ops":[{"insert":"Bold","attributes":{"bold":true}},{"insert":" bad and "},{"insert":"good","attributes":{"link":"https://example.com"}},{"insert":"\n"}]}` {
+			t.Errorf("markdown note: %+v", r.Note)
+		}
+		if r := results["groceries.enex/1"]; r.Note == nil || r.Note.Title != "From Evernote" {
+			t.Errorf("evernote note: %+v", r)
+		}
+		if r := results["trip.zip/trip/day2.html"]; r.Note == nil || r.Note.Title != "Day 2" {
+			t.Errorf("html note: %+v", r)
+		}
+
+		plan := results["trip.zip/trip/plan.md"]
+		if plan.Note == nil || len(plan.Warnings) != 1 || plan.Warnings[0].Code != "IMPORT_MISSING_FILE" || plan.Warnings[0].Target != "gone.png" {
+			t.Fatalf("zip note: %+v", plan)
+		}
+		embed, _ := plan.Note.Content.Ops[0].Insert.(map[string]any)
+		url, _ := embed["image"].(string)
+		if !strings.HasPrefix(url, "/api/v1/attachments/") {
+			t.Fatalf("image not attached: %s", plan.Note.Content.JSON())
+		}
+		if rec := s.fetch(t, url, token); rec.Code != http.StatusOK || rec.Body.String() != pixel.String() {
+			t.Errorf("attached image: status %d", rec.Code)
+		}
+		if strings.Contains(string(plan.Note.Content.JSON()), "day2.html") {
+			t.Errorf("link to another document kept: %s", plan.Note.Content.JSON())
+		}
+	})
+
+	t.Run("notebook", func(t *testing.T) {
+		status, resp := imports(t, map[string]string{"a.md": "text"}, map[string]string{"notebookId": "unknown"})
+		if status != http.StatusUnprocessableEntity || len(resp.Errors) != 1 || resp.Errors[0].Code != "VALIDATION_FAILED" {
+			t.Errorf("unknown notebook: status %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		files := make(map[string]string)
+		for i := range 6 {
+			files[string(rune('a'+i))+".md"] = "text"
+		}
+		status, resp := imports(t, files, nil)
+		var got httpHandler.ImportResponse
+		json.Unmarshal(resp.Data, &got)
+		if status != http.StatusOK || got.Imported != 5 || got.Failed != 1 {
+			t.Fatalf("status %d, imported %d, failed %d", status, got.Imported, got.Failed)
+		}
+		if last := got.Results[5]; last.Error == nil || last.Error.Code != "IMPORT_TOO_MANY_NOTES" {
+			t.Errorf("last result: %+v", last)
+		}
+
+		if status, _ := imports(t, nil, map[string]string{"notebookId": ""}); status != http.StatusUnprocessableEntity {
+			t.Errorf("no file: status = %d, want 422", status)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"notes-app/backend/internal/delivery/http/requestid"
+	"notes-app/backend/internal/domain/audit"
+)
+
+// ClientMiddleware stores the caller's address, user agent and request ID
+// in the context for the audit trail. It must run after
+// RequestIDMiddleware. The address is the direct peer; forwarding headers
+// are not trusted.
+func ClientMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		ctx := audit.NewContext(r.Context(), audit.Client{
+			IP:        ip,
+			UserAgent: r.UserAgent(),
+			RequestID: requestid.FromContext(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,302 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/errs"
+)
+
+// CORSPolicy configures CORSMiddleware
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins such as "https://app.example.com",
+	// wildcard subdomain patterns such as "https://*.example.com", or "*"
+	// for any origin when credentials are not allowed
+	AllowedOrigins []string
+	// AllowedMethods are accepted in preflight requests when no route
+	// override or RouteMethods applies
+	AllowedMethods []string
+	// AllowedHeaders are request headers accepted in preflight requests
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by browser scripts
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight result
+	MaxAge time.Duration
+	// RouteMethods, when set, returns the methods served for a request's
+	// path. Preflight requests are then answered with the route's own
+	// methods instead of AllowedMethods.
+	RouteMethods func(r *http.Request) []string
+	// Routes overrides the allowed methods or headers for paths matching
+	// a ServeMux pattern, e.g. "/api/v1/shared/{token}"
+	Routes []CORSRoute
+}
+
+// CORSRoute overrides a CORSPolicy for the paths matching Pattern.
+// Empty fields fall back to the policy.
+type CORSRoute struct {
+	Pattern        string
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+// DefaultCORSPolicy returns the policy used by the API for the given
+// origins: credentials allowed, request IDs and ETags exposed and
+// preflight results cached for ten minutes
+func DefaultCORSPolicy(origins []string) CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost,
+			http.MethodPut, http.MethodPatch, http.MethodDelete,
+		},
+		AllowedHeaders: []string{
+			"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match",
+			"X-Request-ID", "traceparent", "tracestate",
+		},
+		ExposedHeaders:   []string{"X-Request-ID", "ETag", "Location", "X-Export-Warnings"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+}
+
+// ErrCORSAnyOriginWithCredentials reports a policy letting any origin make
+// credentialed requests, which would hand every site the user's session
+var ErrCORSAnyOriginWithCredentials = errors.New(`cors: origin "*" cannot be allowed with credentials`)
+
+// Validate reports origins that cannot be parsed, and "*" in a policy
+// allowing credentials
+func (p CORSPolicy) Validate() error {
+	for _, o := range p.AllowedOrigins {
+		pattern, ok := parseOrigin(strings.TrimSpace(o))
+		if !ok {
+			return fmt.Errorf("cors: invalid origin %q", o)
+		}
+		if pattern.any && p.AllowCredentials {
+			return ErrCORSAnyOriginWithCredentials
+		}
+	}
+	return nil
+}
+
+// originPattern is a parsed entry of CORSPolicy.AllowedOrigins
+type originPattern struct {
+	any      bool
+	scheme   string
+	host     string // exact host, or the parent domain for wildcards
+	port     string
+	wildcard bool
+}
+
+func parseOrigin(origin string) (originPattern, bool) {
+	if origin == "*" {
+		return originPattern{any: true}, true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return originPattern{}, false
+	}
+	p := originPattern{scheme: strings.ToLower(u.Scheme), port: u.Port()}
+	host := strings.ToLower(u.Hostname())
+	if strings.HasPrefix(host, "*.") {
+		p.wildcard = true
+		host = host[1:] // keep the leading dot
+	}
+	p.host = host
+	return p, true
+}
+
+func (p originPattern) matches(o originPattern) bool {
+	if p.any {
+		return true
+	}
+	if p.scheme != o.scheme || p.port != o.port {
+		return false
+	}
+	if p.wildcard {
+		return strings.HasSuffix(o.host, p.host) && len(o.host) > len(p.host)
+	}
+	return p.host == o.host
+}
+
+// corsRoute is a compiled CORSRoute
+type corsRoute struct {
+	methods map[string]bool
+	list    string
+	headers map[string]bool
+}
+
+// CORSMiddleware implements the CORS protocol for policy:
+//   - responses always vary on Origin so caches never mix origins
+//   - actual requests from allowed origins get Allow-Origin, credentials
+//     and exposed headers; other origins get no CORS headers
+//   - preflight requests are answered here without reaching the router;
+//     disallowed origins, methods or headers are rejected with 403
+//   - OPTIONS requests without Access-Control-Request-Method are not
+//     preflights and are passed on
+//
+// Entries Validate rejects are skipped, so "*" allows no origin at all
+// when credentials are allowed.
+func CORSMiddleware(policy CORSPolicy) func(http.Handler) http.Handler {
+	var origins []originPattern
+	for _, o := range policy.AllowedOrigins {
+		if p, ok := parseOrigin(strings.TrimSpace(o)); ok && !(p.any && policy.AllowCredentials) {
+			origins = append(origins, p)
+		}
+	}
+
+	defaults := compileCORSRoute(policy.AllowedMethods, policy.AllowedHeaders)
+	routes := http.NewServeMux()
+	overrides := make(map[string]corsRoute)
+	for _, route := range policy.Routes {
+		methods := route.AllowedMethods
+		if len(methods) == 0 {
+			methods = policy.AllowedMethods
+		}
+		headers := route.AllowedHeaders
+		if len(headers) == 0 {
+			headers = policy.AllowedHeaders
+		}
+		overrides[route.Pattern] = compileCORSRoute(methods, headers)
+		routes.Handle(route.Pattern, http.NotFoundHandler())
+	}
+
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
+	allowOrigin := func(origin string) (string, bool) {
+		o, ok := parseOrigin(origin)
+		if !ok {
+			return "", false
+		}
+		for _, p := range origins {
+			if !p.matches(o) {
+				continue
+			}
+			if p.any {
+				return "*", true
+			}
+			return origin, true
+		}
+		return "", false
+	}
+
+	routeFor := func(r *http.Request) corsRoute {
+		if _, pattern := routes.Handler(r); pattern != "" {
+			return overrides[pattern]
+		}
+		if policy.RouteMethods != nil {
+			if methods := policy.RouteMethods(r); len(methods) > 0 {
+				route := compileCORSRoute(methods, nil)
+				route.headers = defaults.headers
+				return route
+			}
+		}
+		return defaults
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method != http.MethodOptions || requestMethod == "" {
+				// Actual request
+				if allowed, ok := allowOrigin(origin); ok {
+					w.Header().Set("Access-Control-Allow-Origin", allowed)
+					if policy.AllowCredentials {
+						w.Header().Set("Access-Control-Allow-Credentials", "true")
+					}
+					if exposed != "" {
+						w.Header().Set("Access-Control-Expose-Headers", exposed)
+					}
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Preflight request
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			allowed, ok := allowOrigin(origin)
+			if !ok {
+				response.Fail(w, r, errs.ErrCORSOriginNotAllowed)
+				return
+			}
+
+			route := routeFor(r)
+			if !route.methods[strings.ToUpper(requestMethod)] {
+				response.Fail(w, r, errs.ErrCORSMethodNotAllowed.WithParam("method", requestMethod))
+				return
+			}
+
+			requestHeaders := parseHeaderList(r.Header.Values("Access-Control-Request-Headers"))
+			for _, h := range requestHeaders {
+				if !route.headers[strings.ToLower(h)] {
+					response.Fail(w, r, errs.ErrCORSHeaderNotAllowed.WithParam("header", h))
+					return
+				}
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", route.list)
+			if len(requestHeaders) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+			}
+			if policy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func compileCORSRoute(methods, headers []string) corsRoute {
+	route := corsRoute{
+		methods: make(map[string]bool, len(methods)),
+		headers: make(map[string]bool, len(headers)),
+	}
+	upper := make([]string, 0, len(methods))
+	for _, m := range methods {
+		m = strings.ToUpper(m)
+		if !route.methods[m] {
+			route.methods[m] = true
+			upper = append(upper, m)
+		}
+	}
+	route.list = strings.Join(upper, ", ")
+	for _, h := range headers {
+		route.headers[strings.ToLower(h)] = true
+	}
+	return route
+}
+
+// parseHeaderList splits comma-separated header names
+func parseHeaderList(values []string) []string {
+	var headers []string
+	for _, v := range values {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				headers = append(headers, h)
+			}
+		}
+	}
+	return headers
+}
@@ -0,0 +1,432 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type corsCase struct {
+	name    string
+	policy  CORSPolicy
+	method  string
+	path    string
+	headers map[string]string
+
+	wantStatus  int
+	wantNext    bool
+	wantHeaders map[string]string // "" means the header must be absent
+	wantVary    []string
+	wantCode    string
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	base := DefaultCORSPolicy([]string{"https://app.example.com", "https://*.notes.dev", "http://localhost:3000"})
+
+	noCredentials := DefaultCORSPolicy([]string{"*"})
+	noCredentials.AllowCredentials = false
+
+	anyWithCredentials := DefaultCORSPolicy([]string{"*"})
+
+	withRouteMethods := base
+	withRouteMethods.RouteMethods = func(r *http.Request) []string {
+		if strings.HasPrefix(r.URL.Path, "/api/v1/errors") {
+			return []string{http.MethodGet, http.MethodHead}
+		}
+		return nil
+	}
+
+	withOverride := base
+	withOverride.Routes = []CORSRoute{{
+		Pattern:        "/api/v1/shared/{token}",
+		AllowedMethods: []string{http.MethodGet},
+		AllowedHeaders: []string{"Accept"},
+	}}
+
+	cases := []corsCase{
+		{
+			name:        "request without origin is not CORS",
+			policy:      base,
+			method:      http.MethodGet,
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			wantVary:    []string{"Origin"},
+		},
+		{
+			name:       "allowed origin on actual request",
+			policy:     base,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://app.example.com"},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-ID, ETag, Location, X-Export-Warnings",
+				"Access-Control-Allow-Methods":     "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:       "disallowed origin on actual request gets no CORS headers",
+			policy:     base,
+			method:     http.MethodPost,
+			headers:    map[string]string{"Origin": "https://evil.example.org"},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:        "wildcard subdomain matches",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "https://team.notes.dev"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://team.notes.dev"},
+		},
+		{
+			name:        "wildcard subdomain matches nested subdomains",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "https://a.b.notes.dev"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://a.b.notes.dev"},
+		},
+		{
+			name:        "wildcard subdomain does not match the bare domain",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "https://notes.dev"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:        "wildcard subdomain does not match a suffix lookalike",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "https://evilnotes.dev"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:        "scheme must match",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "http://app.example.com"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:        "port must match",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "http://localhost:3001"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:        "null origin is never allowed implicitly",
+			policy:      base,
+			method:      http.MethodGet,
+			headers:     map[string]string{"Origin": "null"},
+			wantStatus:  http.StatusOK,
+			wantNext:    true,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:       "any origin without credentials uses a literal wildcard",
+			policy:     noCredentials,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://whoever.test"},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:       "any origin with credentials allows no origin",
+			policy:     anyWithCredentials,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://whoever.test"},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:   "allowed preflight is answered without calling the handler",
+			policy: base,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type, Authorization",
+			},
+			wantStatus: http.StatusNoContent,
+			wantNext:   false,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, HEAD, POST, PUT, PATCH, DELETE",
+				"Access-Control-Allow-Headers":     "content-type, Authorization",
+				"Access-Control-Max-Age":           "600",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "preflight without requested headers omits Allow-Headers",
+			policy: base,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Headers": ""},
+		},
+		{
+			name:   "preflight from disallowed origin is rejected",
+			policy: base,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.org",
+				"Access-Control-Request-Method": "POST",
+			},
+			wantStatus:  http.StatusForbidden,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			wantVary:    []string{"Origin"},
+			wantCode:    "CORS_ORIGIN_NOT_ALLOWED",
+		},
+		{
+			name:   "preflight for a disallowed method is rejected",
+			policy: base,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "TRACE",
+			},
+			wantStatus:  http.StatusForbidden,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			wantCode:    "CORS_METHOD_NOT_ALLOWED",
+		},
+		{
+			name:   "preflight for a disallowed header is rejected",
+			policy: base,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "Content-Type, X-Custom-Secret",
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   "CORS_HEADER_NOT_ALLOWED",
+		},
+		{
+			name:       "OPTIONS without a requested method is not a preflight",
+			policy:     base,
+			method:     http.MethodOptions,
+			headers:    map[string]string{"Origin": "https://app.example.com"},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:   "preflight uses the methods of the requested route",
+			policy: withRouteMethods,
+			method: http.MethodOptions,
+			path:   "/api/v1/errors",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": "GET, HEAD"},
+		},
+		{
+			name:   "preflight for a method the route does not serve is rejected",
+			policy: withRouteMethods,
+			method: http.MethodOptions,
+			path:   "/api/v1/errors",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   "CORS_METHOD_NOT_ALLOWED",
+		},
+		{
+			name:   "route override restricts methods",
+			policy: withOverride,
+			method: http.MethodOptions,
+			path:   "/api/v1/shared/abc",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   "CORS_METHOD_NOT_ALLOWED",
+		},
+		{
+			name:   "route override restricts headers",
+			policy: withOverride,
+			method: http.MethodOptions,
+			path:   "/api/v1/shared/abc",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "Authorization",
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   "CORS_HEADER_NOT_ALLOWED",
+		},
+		{
+			name:   "route override allows its own methods and headers",
+			policy: withOverride,
+			method: http.MethodOptions,
+			path:   "/api/v1/shared/abc",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "accept",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "GET",
+				"Access-Control-Allow-Headers": "accept",
+			},
+		},
+		{
+			name: "max age is omitted when zero",
+			policy: func() CORSPolicy {
+				p := base
+				p.MaxAge = 0
+				return p
+			}(),
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Max-Age": ""},
+		},
+		{
+			name: "max age is reported in seconds",
+			policy: func() CORSPolicy {
+				p := base
+				p.MaxAge = 2 * time.Hour
+				return p
+			}(),
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: map[string]string{"Access-Control-Max-Age": "7200"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			path := tc.path
+			if path == "" {
+				path = "/api/v1/notes"
+			}
+			req := httptest.NewRequest(tc.method, path, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			CORSMiddleware(tc.policy)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if called != tc.wantNext {
+				t.Errorf("next called = %v, want %v", called, tc.wantNext)
+			}
+			for header, want := range tc.wantHeaders {
+				if got := rec.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+			vary := rec.Header().Values("Vary")
+			for _, want := range tc.wantVary {
+				if !containsFold(vary, want) {
+					t.Errorf("Vary = %v, missing %q", vary, want)
+				}
+			}
+			if tc.wantCode != "" {
+				var body struct {
+					Errors []struct {
+						Code string `json:"code"`
+					} `json:"errors"`
+				}
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+					t.Fatalf("decoding error body: %v", err)
+				}
+				if len(body.Errors) == 0 || body.Errors[0].Code != tc.wantCode {
+					t.Errorf("error code = %+v, want %s", body.Errors, tc.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestCORSPolicyValidate(t *testing.T) {
+	if err := DefaultCORSPolicy([]string{"https://app.example.com", "https://*.notes.dev"}).Validate(); err != nil {
+		t.Errorf("valid origins: %v", err)
+	}
+	if err := DefaultCORSPolicy([]string{"https://app.example.com", " *"}).Validate(); !errors.Is(err, ErrCORSAnyOriginWithCredentials) {
+		t.Errorf("any origin with credentials: error %v", err)
+	}
+	noCredentials := DefaultCORSPolicy([]string{"*"})
+	noCredentials.AllowCredentials = false
+	if err := noCredentials.Validate(); err != nil {
+		t.Errorf("any origin without credentials: %v", err)
+	}
+	if err := DefaultCORSPolicy([]string{"app.example.com"}).Validate(); err == nil {
+		t.Error("origin without scheme accepted")
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/router"
+	"notes-app/backend/internal/infrastructure/metrics"
+)
+
+// MetricsMiddleware records request counts and latency labelled by the
+// matched route pattern. Register it on the router with Use.
+func MetricsMiddleware(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			route, _ := router.RouteFromContext(r.Context())
+			m.ObserveHTTP(r.Method, route.Path, status, time.Since(start))
+		})
+	}
+}
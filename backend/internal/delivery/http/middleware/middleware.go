@@ -2,77 +2,129 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
+	"math"
 	"net/http"
 	"strings"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/infrastructure/logger"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
-func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-			
-			// Check if origin is allowed
-			for _, allowed := range allowedOrigins {
-				if origin == allowed {
-					w.Header().Set("Access-Control-Allow-Origin", origin)
-					break
-				}
-			}
-			
-			// Handle preflight requests
-			if r.Method == "OPTIONS" {
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			next.ServeHTTP(w, r)
-		})
-	}
+// AccountChecker confirms that the account behind a verified token may
+// still use it, typically that it is not disabled and the session was not
+// revoked, and fills in the principal's current role
+type AccountChecker interface {
+	CheckAccount(ctx context.Context, principal *auth.Principal) error
 }
 
-// AuthMiddleware handles JWT authentication
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// AuthMiddleware handles JWT authentication. Requests without a valid
+// bearer token are rejected; the principal of accepted requests is
+// available through auth.FromContext. When accounts is not nil every
+// token is also checked against the current state of its account.
+func AuthMiddleware(jwtSecret string, accounts AccountChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				unauthorized(w, r, errs.ErrUnauthorized)
 				return
 			}
 
 			// Remove "Bearer " prefix
-			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-			// Parse and validate token
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				return []byte(jwtSecret), nil
-			})
-
-			if err != nil || !token.Valid {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+			if !ok || tokenString == "" {
+				unauthorized(w, r, errs.ErrInvalidToken)
 				return
 			}
 
-			// Extract claims
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
-				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+			principal, err := parseToken(tokenString, jwtSecret)
+			if err != nil {
+				unauthorized(w, r, errs.ErrInvalidToken)
 				return
 			}
 
-			// Add user information to request context
-			ctx := context.WithValue(r.Context(), "user_id", claims["user_id"])
-			ctx = context.WithValue(ctx, "email", claims["email"])
+			if accounts != nil {
+				if err := accounts.CheckAccount(r.Context(), principal); err != nil {
+					if status, _ := response.FromError(err); status == http.StatusUnauthorized {
+						unauthorized(w, r, err)
+						return
+					}
+					response.Fail(w, r, err)
+					return
+				}
+			}
+
+			// Add the principal to the request context
+			ctx := auth.NewContext(r.Context(), principal)
+			ctx = logger.WithAttrs(ctx, slog.String("user_id", principal.UserID))
 
 			// Call next handler with updated context
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
-} 
\ No newline at end of file
+}
+
+// parseToken validates a signed token and builds its principal
+func parseToken(tokenString, jwtSecret string) (*auth.Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := claims[auth.ClaimUserID].(string)
+	if userID == "" {
+		return nil, errs.ErrInvalidToken
+	}
+	email, _ := claims[auth.ClaimEmail].(string)
+	sessionID, _ := claims[auth.ClaimSessionID].(string)
+	scope, _ := claims[auth.ClaimScope].(string)
+	// Read iat directly: jwt.NumericDate truncates to whole seconds
+	var issuedAt time.Time
+	if iat, ok := claims[auth.ClaimIssuedAt].(float64); ok {
+		issuedAt = time.UnixMilli(int64(math.Round(iat * 1000)))
+	}
+
+	return &auth.Principal{
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
+		Scopes:    strings.Fields(scope),
+		Method:    auth.MethodBearer,
+		IssuedAt:  issuedAt,
+	}, nil
+}
+
+// unauthorized sends a 401 with the bearer challenge
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="notes-app"`)
+	response.Fail(w, r, err)
+}
+
+// RequirePermission rejects requests whose principal's role does not
+// grant perm. It must run after AuthMiddleware.
+func RequirePermission(perm auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, errs.ErrUnauthorized)
+				return
+			}
+			if !principal.Can(perm) {
+				response.Fail(w, r, errs.ErrForbidden.WithParam("permission", string(perm)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"notes-app/backend/internal/delivery/http/response"
+)
+
+// RecoveryMiddleware turns a panic in any later handler into a logged
+// stack trace and a standard INTERNAL_ERROR response. If the handler had
+// already started writing, the connection is left as is since a second
+// status line cannot be sent.
+func RecoveryMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					// Deliberate abort, let net/http close the connection
+					panic(p)
+				}
+
+				log.ErrorContext(r.Context(), "panic recovered",
+					slog.Any("panic", p),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if rec.status == 0 {
+					response.Fail(w, r, fmt.Errorf("panic: %v", p))
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"notes-app/backend/internal/delivery/http/requestid"
+	"notes-app/backend/internal/infrastructure/logger"
+)
+
+// RequestIDMiddleware assigns every request an ID. A valid incoming
+// X-Request-ID is reused, otherwise a UUIDv7 is generated. The ID is stored
+// in the request context, added to the logging context and echoed in the
+// response header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.Generate()
+		}
+
+		ctx := requestid.NewContext(r.Context(), id)
+		ctx = logger.WithAttrs(ctx, slog.String("request_id", id))
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,489 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	"notes-app/backend/internal/usecase/note"
+
+	"github.com/google/uuid"
+)
+
+// NoteHandler handles HTTP requests for notes
+type NoteHandler struct {
+	noteUseCase note.UseCase
+	logger      *slog.Logger
+}
+
+// NewNoteHandler creates a new note handler
+func NewNoteHandler(noteUseCase note.UseCase, logger *slog.Logger) *NoteHandler {
+	return &NoteHandler{
+		noteUseCase: noteUseCase,
+		logger:      logger,
+	}
+}
+
+// NoteRequest represents the body of note creation and updates. Content
+// is a Quill delta; a missing content is an empty note. Omitting tagIds
+// on update leaves the tags unchanged. NotebookID only applies on
+// creation.
+type NoteRequest struct {
+	Title      string          `json:"title" validate:"max=200"`
+	Content    json.RawMessage `json:"content"`
+	TagIDs     []string        `json:"tagIds" validate:"max=50"`
+	NotebookID string          `json:"notebookId"`
+}
+
+// NoteTagsRequest represents the body of a note tagging request
+type NoteTagsRequest struct {
+	TagIDs []string `json:"tagIds" validate:"max=50"`
+}
+
+// NoteNotebookRequest represents the body of a note move. An empty
+// notebookId takes the note out of its notebook.
+type NoteNotebookRequest struct {
+	NotebookID string `json:"notebookId"`
+}
+
+// NoteStateRequest represents the body of a note filing request. Omitted
+// flags are left unchanged.
+type NoteStateRequest struct {
+	Pinned    *bool `json:"pinned"`
+	Archived  *bool `json:"archived"`
+	Favourite *bool `json:"favourite"`
+}
+
+// NotePositionRequest represents the body of a manual reordering request:
+// the note goes after previousId and before nextId, either of which may
+// be empty
+type NotePositionRequest struct {
+	PreviousID string `json:"previousId"`
+	NextID     string `json:"nextId"`
+}
+
+// NoteResponse describes a note. The pinned, archived and favourite flags
+// and the position are the caller's own. Role is the caller's access to
+// the note and is only set when a single note is loaded.
+type NoteResponse struct {
+	ID         string           `json:"id"`
+	NotebookID string           `json:"notebookId,omitempty"`
+	Title      string           `json:"title"`
+	Content    domainNote.Delta `json:"content"`
+	TagIDs     []string         `json:"tagIds"`
+	Role       string           `json:"role,omitempty"`
+	Pinned     bool             `json:"pinned"`
+	Archived   bool             `json:"archived"`
+	Favourite  bool             `json:"favourite"`
+	Position   string           `json:"position,omitempty"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	UpdatedAt  time.Time        `json:"updatedAt"`
+	DeletedAt  *time.Time       `json:"deletedAt,omitempty"`
+}
+
+func newNoteResponse(n *domainNote.Note) NoteResponse {
+	tagIDs := n.TagIDs
+	if tagIDs == nil {
+		tagIDs = []string{}
+	}
+	return NoteResponse{
+		ID:         n.ID,
+		NotebookID: n.NotebookID,
+		Title:      n.Title,
+		Content:    n.Content,
+		TagIDs:     tagIDs,
+		Pinned:     n.State.Pinned,
+		Archived:   n.State.Archived,
+		Favourite:  n.State.Favourite,
+		Position:   n.State.Position,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+		DeletedAt:  n.DeletedAt,
+	}
+}
+
+// Create handles note creation
+func (h *NoteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	input, ok := h.decodeNote(w, r)
+	if !ok {
+		return
+	}
+	if input.TagIDs == nil {
+		input.TagIDs = []string{}
+	}
+
+	n, err := h.noteUseCase.Create(r.Context(), userID, input)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "creating note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, newNoteResponse(n))
+}
+
+// Get handles note retrieval
+func (h *NoteHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	n, role, err := h.noteUseCase.Get(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "loading note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	resp := newNoteResponse(n)
+	resp.Role = string(role)
+	response.JSON(w, r, http.StatusOK, resp)
+}
+
+// Update handles note edits
+func (h *NoteHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	input, ok := h.decodeNote(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := h.noteUseCase.Update(r.Context(), userID, r.PathValue("id"), input)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "updating note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNoteResponse(n))
+}
+
+// Delete handles moving a note to the trash
+func (h *NoteHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.noteUseCase.Delete(r.Context(), userID, r.PathValue("id")); err != nil {
+		h.logger.WarnContext(r.Context(), "deleting note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Trash handles paginated listing of the caller's trashed notes, most
+// recently trashed first
+func (h *NoteHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := request.ParsePage(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	notes, total, err := h.noteUseCase.Trash(r.Context(), userID, page.Offset(), page.PerPage)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "listing trash failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	data := make([]NoteResponse, 0, len(notes))
+	for _, n := range notes {
+		data = append(data, newNoteResponse(n))
+	}
+	response.JSONWithMeta(w, r, http.StatusOK, data, &response.Meta{
+		Total:   total,
+		Page:    page.Number,
+		PerPage: page.PerPage,
+	})
+}
+
+// Restore handles taking a note out of the trash
+func (h *NoteHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := h.noteUseCase.Restore(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "restoring note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNoteResponse(n))
+}
+
+// DeletePermanently handles deleting a trashed note for good
+func (h *NoteHandler) DeletePermanently(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.noteUseCase.DeletePermanently(r.Context(), userID, r.PathValue("id")); err != nil {
+		h.logger.WarnContext(r.Context(), "deleting note permanently failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List handles paginated note listing, pinned notes first. Query
+// parameters: q searches the title and text, tags is a comma-separated
+// list of tag IDs, tagMode (all or any, default all) decides whether
+// notes need every tag or one of them, archived (exclude, include or only,
+// default exclude) and favourites (true or false) filter by the caller's
+// flags, order (updated or manual, default updated) sorts the rest, page
+// and perPage paginate.
+func (h *NoteHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := request.ParsePage(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domainNote.ListFilter{
+		Query:    strings.TrimSpace(query.Get("q")),
+		TagMode:  domainNote.TagModeAll,
+		Archived: domainNote.ArchivedExclude,
+		Order:    domainNote.OrderUpdated,
+		Offset:   page.Offset(),
+		Limit:    page.PerPage,
+	}
+
+	var details []*errs.Error
+	if v := query.Get("tags"); v != "" {
+		for _, id := range strings.Split(v, ",") {
+			id = strings.TrimSpace(id)
+			if uuid.Validate(id) != nil {
+				details = append(details, request.InvalidQueryParam("tags"))
+				break
+			}
+			filter.TagIDs = append(filter.TagIDs, id)
+		}
+	}
+	if v := query.Get("tagMode"); v != "" {
+		filter.TagMode = domainNote.TagMode(v)
+		if filter.TagMode != domainNote.TagModeAll && filter.TagMode != domainNote.TagModeAny {
+			details = append(details, request.InvalidQueryParam("tagMode"))
+		}
+	}
+	if v := query.Get("archived"); v != "" {
+		filter.Archived = domainNote.ArchivedFilter(v)
+		switch filter.Archived {
+		case domainNote.ArchivedExclude, domainNote.ArchivedInclude, domainNote.ArchivedOnly:
+		default:
+			details = append(details, request.InvalidQueryParam("archived"))
+		}
+	}
+	if v := query.Get("favourites"); v != "" {
+		favourites, err := strconv.ParseBool(v)
+		if err != nil {
+			details = append(details, request.InvalidQueryParam("favourites"))
+		}
+		filter.Favourites = favourites
+	}
+	if v := query.Get("order"); v != "" {
+		filter.Order = domainNote.Order(v)
+		if filter.Order != domainNote.OrderUpdated && filter.Order != domainNote.OrderManual {
+			details = append(details, request.InvalidQueryParam("order"))
+		}
+	}
+	if len(details) > 0 {
+		response.Fail(w, r, errs.Validation(details...))
+		return
+	}
+
+	notes, total, err := h.noteUseCase.List(r.Context(), userID, filter)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "listing notes failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	data := make([]NoteResponse, 0, len(notes))
+	for _, n := range notes {
+		data = append(data, newNoteResponse(n))
+	}
+	response.JSONWithMeta(w, r, http.StatusOK, data, &response.Meta{
+		Total:   total,
+		Page:    page.Number,
+		PerPage: page.PerPage,
+	})
+}
+
+// SetTags handles replacing the tags of a note
+func (h *NoteHandler) SetTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req NoteTagsRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+	if req.TagIDs == nil {
+		req.TagIDs = []string{}
+	}
+
+	n, err := h.noteUseCase.SetTags(r.Context(), userID, r.PathValue("id"), req.TagIDs)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "tagging note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNoteResponse(n))
+}
+
+// Move handles moving a note between notebooks
+func (h *NoteHandler) Move(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req NoteNotebookRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	n, err := h.noteUseCase.Move(r.Context(), userID, r.PathValue("id"), strings.TrimSpace(req.NotebookID))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "moving note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNoteResponse(n))
+}
+
+// UpdateState handles pinning, archiving and favouriting a note for the
+// caller
+func (h *NoteHandler) UpdateState(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req NoteStateRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	n, err := h.noteUseCase.UpdateState(r.Context(), userID, r.PathValue("id"), note.StateInput{
+		Pinned:    req.Pinned,
+		Archived:  req.Archived,
+		Favourite: req.Favourite,
+	})
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "updating note state failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNoteResponse(n))
+}
+
+// Reorder handles placing a note in the caller's manual order
+func (h *NoteHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req NotePositionRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	n, err := h.noteUseCase.Reorder(r.Context(), userID, r.PathValue("id"), strings.TrimSpace(req.PreviousID), strings.TrimSpace(req.NextID))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "reordering note failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNoteResponse(n))
+}
+
+// decodeNote reads a NoteRequest and parses its content
+func (h *NoteHandler) decodeNote(w http.ResponseWriter, r *http.Request) (note.Input, bool) {
+	var req NoteRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return note.Input{}, false
+	}
+
+	input := note.Input{
+		Title:      strings.TrimSpace(req.Title),
+		Content:    domainNote.EmptyDelta(),
+		TagIDs:     req.TagIDs,
+		NotebookID: strings.TrimSpace(req.NotebookID),
+	}
+	if len(req.Content) > 0 && !bytes.Equal(req.Content, []byte("null")) {
+		content, err := domainNote.ParseDelta(req.Content)
+		if err != nil {
+			response.Fail(w, r, domainNote.ErrInvalidContent)
+			return note.Input{}, false
+		}
+		input.Content = content
+	}
+	return input, true
+}
+
+// callerID returns the ID of the authenticated user
+func callerID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.Fail(w, r, errs.ErrUnauthorized)
+		return "", false
+	}
+	return principal.UserID, true
+}
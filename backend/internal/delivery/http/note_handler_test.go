@@ -0,0 +1,368 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+)
+
+func TestNoteAndTagHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+	otherToken := s.login(t, "john@example.com", "")
+
+	createTag := func(t *testing.T, body string) httpHandler.TagResponse {
+		t.Helper()
+		status, resp := s.do(t, http.MethodPost, "/api/v1/tags", token, body)
+		if status != http.StatusCreated {
+			t.Fatalf("create tag: status %d, errors %+v", status, resp.Errors)
+		}
+		var tag httpHandler.TagResponse
+		json.Unmarshal(resp.Data, &tag)
+		return tag
+	}
+	createNote := func(t *testing.T, title string, tagIDs ...string) httpHandler.NoteResponse {
+		t.Helper()
+		ids, _ := json.Marshal(append([]string{}, tagIDs...))
+		body := `{"title":"` + title + `","content":{"ops":[{"insert":"` + title + `\n"}]},"tagIds":` + string(ids) + `}`
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, body)
+		if status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		return n
+	}
+	listNotes := func(t *testing.T, query string) []string {
+		t.Helper()
+		status, resp := s.do(t, http.MethodGet, "/api/v1/notes"+query, token, "")
+		if status != http.StatusOK {
+			t.Fatalf("list notes: status %d, errors %+v", status, resp.Errors)
+		}
+		var notes []httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &notes)
+		titles := make([]string, len(notes))
+		for i, n := range notes {
+			titles[i] = n.Title
+		}
+		return titles
+	}
+
+	work := createTag(t, `{"name":"Work","color":"#FF0000"}`)
+	urgent := createTag(t, `{"name":"urgent"}`)
+	home := createTag(t, `{"name":"home"}`)
+	if work.Color != "#ff0000" {
+		t.Errorf("color = %q, want it lower-cased", work.Color)
+	}
+	createNote(t, "report", work.ID, urgent.ID)
+	createNote(t, "slides", work.ID)
+	groceries := createNote(t, "groceries", home.ID)
+
+	t.Run("tag validation", func(t *testing.T) {
+		tests := []struct {
+			body       string
+			wantStatus int
+			wantCode   string
+		}{
+			{`{"name":"WORK"}`, http.StatusConflict, "TAG_EXISTS"},
+			{`{"name":"   "}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+			{`{"name":"x","color":"red"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		}
+		for _, tt := range tests {
+			status, resp := s.do(t, http.MethodPost, "/api/v1/tags", token, tt.body)
+			if status != tt.wantStatus || resp.Errors[0].Code != tt.wantCode {
+				t.Errorf("%s: status = %d, errors %+v", tt.body, status, resp.Errors)
+			}
+		}
+	})
+
+	t.Run("filter by tags", func(t *testing.T) {
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"?tags=" + work.ID + "," + urgent.ID, []string{"report"}},
+			{"?tags=" + urgent.ID + "," + home.ID + "&tagMode=any", []string{"groceries", "report"}},
+			{"?tags=" + work.ID + "&q=slides", []string{"slides"}},
+		}
+		for _, tt := range tests {
+			got := listNotes(t, tt.query)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: notes = %v, want %v", tt.query, got, tt.want)
+			}
+		}
+
+		status, resp := s.do(t, http.MethodGet, "/api/v1/notes?tags=nope&tagMode=some", token, "")
+		if status != http.StatusUnprocessableEntity || len(resp.Errors[0].Details) != 2 {
+			t.Errorf("invalid filters: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("autocomplete orders by usage", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodGet, "/api/v1/tags/autocomplete?prefix=&limit=2", token, "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+		var tags []httpHandler.TagResponse
+		json.Unmarshal(resp.Data, &tags)
+		if len(tags) != 2 || tags[0].Name != "Work" || tags[0].NoteCount != 2 || tags[1].Name != "home" {
+			t.Errorf("suggestions = %+v", tags)
+		}
+	})
+
+	t.Run("other users cannot use the tags", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPut, "/api/v1/notes/"+groceries.ID+"/tags", otherToken, `{"tagIds":[]}`)
+		if status != http.StatusNotFound || resp.Errors[0].Code != "NOTE_NOT_FOUND" {
+			t.Errorf("tag other's note: status = %d, errors %+v", status, resp.Errors)
+		}
+		status, resp = s.do(t, http.MethodPost, "/api/v1/notes", otherToken, `{"title":"x","tagIds":["`+work.ID+`"]}`)
+		if status != http.StatusUnprocessableEntity || resp.Errors[0].Details[0].Target != "/tagIds/0" {
+			t.Errorf("apply other's tag: status = %d, errors %+v", status, resp.Errors)
+		}
+		status, resp = s.do(t, http.MethodDelete, "/api/v1/tags/"+work.ID, otherToken, "")
+		if status != http.StatusNotFound || resp.Errors[0].Code != "TAG_NOT_FOUND" {
+			t.Errorf("delete other's tag: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("merge moves notes to the target", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/tags/"+work.ID+"/merge", token, `{"sourceIds":["`+home.ID+`"]}`)
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, errors %+v", status, resp.Errors)
+		}
+		var merged httpHandler.TagResponse
+		json.Unmarshal(resp.Data, &merged)
+		if merged.NoteCount != 3 {
+			t.Errorf("noteCount = %d, want 3", merged.NoteCount)
+		}
+
+		status, resp = s.do(t, http.MethodGet, "/api/v1/tags", token, "")
+		var tags []httpHandler.TagResponse
+		json.Unmarshal(resp.Data, &tags)
+		if status != http.StatusOK || len(tags) != 2 {
+			t.Errorf("tags after merge = %+v", tags)
+		}
+	})
+
+	t.Run("rename and delete", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPatch, "/api/v1/tags/"+urgent.ID, token, `{"name":" Very  urgent "}`)
+		var renamed httpHandler.TagResponse
+		json.Unmarshal(resp.Data, &renamed)
+		if status != http.StatusOK || renamed.Name != "Very urgent" || renamed.NoteCount != 1 {
+			t.Errorf("rename: status = %d, tag %+v", status, renamed)
+		}
+
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/tags/"+urgent.ID, token, ""); status != http.StatusNoContent {
+			t.Fatalf("delete: status = %d, errors %+v", status, resp.Errors)
+		}
+		if got := listNotes(t, "?tags="+urgent.ID); len(got) != 0 {
+			t.Errorf("notes still tagged with a deleted tag: %v", got)
+		}
+		if got := listNotes(t, ""); len(got) != 3 {
+			t.Errorf("deleting a tag removed notes: %v", got)
+		}
+	})
+}
+
+func TestNoteTrashHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+	otherToken := s.login(t, "john@example.com", "")
+
+	var ids []string
+	for _, title := range []string{"first", "second", "third"} {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, `{"title":"`+title+`"}`)
+		if status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		ids = append(ids, n.ID)
+	}
+	listTitles := func(t *testing.T, path string) ([]string, int) {
+		t.Helper()
+		status, resp := s.do(t, http.MethodGet, path, token, "")
+		if status != http.StatusOK {
+			t.Fatalf("GET %s: status %d, errors %+v", path, status, resp.Errors)
+		}
+		var notes []httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &notes)
+		var titles []string
+		for _, n := range notes {
+			if (n.DeletedAt != nil) != strings.HasSuffix(path, "/trash") {
+				t.Errorf("%s: deletedAt = %v", n.Title, n.DeletedAt)
+			}
+			titles = append(titles, n.Title)
+		}
+		return titles, resp.Meta["total"]
+	}
+
+	for _, id := range ids[:2] {
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/notes/"+id, token, ""); status != http.StatusNoContent {
+			t.Fatalf("delete: status %d, errors %+v", status, resp.Errors)
+		}
+	}
+	if status, _ := s.do(t, http.MethodDelete, "/api/v1/notes/"+ids[2], otherToken, ""); status != http.StatusNotFound {
+		t.Errorf("delete other's note: status = %d", status)
+	}
+
+	if titles, total := listTitles(t, "/api/v1/notes"); strings.Join(titles, ",") != "third" || total != 1 {
+		t.Errorf("live notes = %v (total %d), want [third]", titles, total)
+	}
+	if titles, total := listTitles(t, "/api/v1/notes/trash"); len(titles) != 2 || total != 2 {
+		t.Errorf("trash = %v (total %d), want 2 notes", titles, total)
+	}
+	if status, _ := s.do(t, http.MethodGet, "/api/v1/notes/"+ids[0], token, ""); status != http.StatusNotFound {
+		t.Errorf("get trashed note: status = %d, want 404", status)
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		if status, _ := s.do(t, http.MethodPost, "/api/v1/notes/"+ids[2]+"/restore", token, ""); status != http.StatusNotFound {
+			t.Errorf("restore live note: status = %d, want 404", status)
+		}
+		if status, _ := s.do(t, http.MethodPost, "/api/v1/notes/"+ids[0]+"/restore", otherToken, ""); status != http.StatusNotFound {
+			t.Errorf("restore other's note: status = %d, want 404", status)
+		}
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notes/"+ids[0]+"/restore", token, "")
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		if status != http.StatusOK || n.DeletedAt != nil {
+			t.Fatalf("restore: status = %d, note %+v", status, n)
+		}
+		if status, _ := s.do(t, http.MethodGet, "/api/v1/notes/"+ids[0], token, ""); status != http.StatusOK {
+			t.Errorf("get restored note: status = %d", status)
+		}
+	})
+
+	t.Run("permanent delete", func(t *testing.T) {
+		if status, _ := s.do(t, http.MethodDelete, "/api/v1/notes/"+ids[2]+"/permanent", token, ""); status != http.StatusNotFound {
+			t.Errorf("permanently delete live note: status = %d, want 404", status)
+		}
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/notes/"+ids[1]+"/permanent", token, ""); status != http.StatusNoContent {
+			t.Fatalf("permanent delete: status = %d, errors %+v", status, resp.Errors)
+		}
+		if status, _ := s.do(t, http.MethodPost, "/api/v1/notes/"+ids[1]+"/restore", token, ""); status != http.StatusNotFound {
+			t.Errorf("restore deleted note: status = %d, want 404", status)
+		}
+		if titles, total := listTitles(t, "/api/v1/notes/trash"); len(titles) != 0 || total != 0 {
+			t.Errorf("trash = %v (total %d), want empty", titles, total)
+		}
+	})
+}
+
+func TestNoteStateHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+	otherToken := s.login(t, "john@example.com", "")
+
+	status, resp := s.do(t, http.MethodPost, "/api/v1/notebooks", token, `{"name":"Shared"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("create notebook: status %d, errors %+v", status, resp.Errors)
+	}
+	var nb httpHandler.NotebookResponse
+	json.Unmarshal(resp.Data, &nb)
+	s.do(t, http.MethodPut, "/api/v1/notebooks/"+nb.ID+"/collaborators", token, `{"email":"john@example.com","role":"viewer"}`)
+
+	ids := map[string]string{}
+	for _, title := range []string{"a", "b", "c", "d"} {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, `{"title":"`+title+`","notebookId":"`+nb.ID+`"}`)
+		if status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		ids[title] = n.ID
+	}
+	list := func(t *testing.T, token, query string) string {
+		t.Helper()
+		status, resp := s.do(t, http.MethodGet, "/api/v1/notes"+query, token, "")
+		if status != http.StatusOK {
+			t.Fatalf("list: status %d, errors %+v", status, resp.Errors)
+		}
+		var notes []httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &notes)
+		var titles []string
+		for _, n := range notes {
+			titles = append(titles, n.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+	setState := func(t *testing.T, token, title, body string) httpHandler.NoteResponse {
+		t.Helper()
+		status, resp := s.do(t, http.MethodPatch, "/api/v1/notes/"+ids[title]+"/state", token, body)
+		if status != http.StatusOK {
+			t.Fatalf("state: status %d, errors %+v", status, resp.Errors)
+		}
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		return n
+	}
+
+	t.Run("flags are per user", func(t *testing.T) {
+		if n := setState(t, otherToken, "a", `{"pinned":true}`); !n.Pinned {
+			t.Errorf("collaborator pin: note %+v", n)
+		}
+		if got := list(t, token, ""); got != "d,c,b,a" {
+			t.Errorf("owner's notes = %s, want d,c,b,a", got)
+		}
+
+		n := setState(t, token, "b", `{"pinned":true,"favourite":true}`)
+		n = setState(t, token, "b", `{"pinned":false}`)
+		if n.Pinned || !n.Favourite {
+			t.Errorf("partial update: note %+v", n)
+		}
+		setState(t, token, "a", `{"pinned":true}`)
+		setState(t, token, "c", `{"archived":true}`)
+
+		tests := []struct {
+			query string
+			want  string
+		}{
+			{"", "a,d,b"},
+			{"?archived=include", "a,d,c,b"},
+			{"?archived=only", "c"},
+			{"?favourites=true", "b"},
+		}
+		for _, tt := range tests {
+			if got := list(t, token, tt.query); got != tt.want {
+				t.Errorf("%q: notes = %s, want %s", tt.query, got, tt.want)
+			}
+		}
+
+		status, resp := s.do(t, http.MethodGet, "/api/v1/notes?archived=all&favourites=maybe&order=random", token, "")
+		if status != http.StatusUnprocessableEntity || len(resp.Errors[0].Details) != 3 {
+			t.Errorf("invalid filters: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+
+	t.Run("manual order", func(t *testing.T) {
+		reorder := func(t *testing.T, title, body string) (int, response) {
+			t.Helper()
+			return s.do(t, http.MethodPut, "/api/v1/notes/"+ids[title]+"/position", token, body)
+		}
+		for _, step := range []struct{ title, body string }{
+			{"b", `{}`},
+			{"d", `{"previousId":"` + ids["b"] + `"}`},
+			{"c", `{"previousId":"` + ids["b"] + `","nextId":"` + ids["d"] + `"}`},
+		} {
+			if status, resp := reorder(t, step.title, step.body); status != http.StatusOK {
+				t.Fatalf("reorder %s: status %d, errors %+v", step.title, status, resp.Errors)
+			}
+		}
+		if got := list(t, token, "?order=manual&archived=include"); got != "a,b,c,d" {
+			t.Errorf("manual order = %s, want a,b,c,d", got)
+		}
+
+		status, resp := reorder(t, "a", `{"previousId":"`+ids["d"]+`","nextId":"`+ids["b"]+`"}`)
+		if status != http.StatusUnprocessableEntity || resp.Errors[0].Details[0].Target != "/nextId" {
+			t.Errorf("out of order: status = %d, errors %+v", status, resp.Errors)
+		}
+		status, resp = reorder(t, "b", `{"previousId":"`+ids["a"]+`"}`)
+		if status != http.StatusUnprocessableEntity || resp.Errors[0].Details[0].Target != "/previousId" {
+			t.Errorf("unplaced previous note: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+}
@@ -0,0 +1,345 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/usecase/notebook"
+)
+
+// NotebookHandler handles HTTP requests for notebooks
+type NotebookHandler struct {
+	notebookUseCase notebook.UseCase
+	logger          *slog.Logger
+}
+
+// NewNotebookHandler creates a new notebook handler
+func NewNotebookHandler(notebookUseCase notebook.UseCase, logger *slog.Logger) *NotebookHandler {
+	return &NotebookHandler{
+		notebookUseCase: notebookUseCase,
+		logger:          logger,
+	}
+}
+
+// CreateNotebookRequest represents the notebook creation request body. A
+// missing parentId creates a top-level notebook.
+type CreateNotebookRequest struct {
+	Name     string `json:"name" validate:"required,max=200"`
+	ParentID string `json:"parentId"`
+}
+
+// RenameNotebookRequest represents the notebook rename request body
+type RenameNotebookRequest struct {
+	Name string `json:"name" validate:"required,max=200"`
+}
+
+// MoveNotebookRequest represents the notebook move request body. An empty
+// parentId moves the notebook to the top level.
+type MoveNotebookRequest struct {
+	ParentID string `json:"parentId"`
+}
+
+// ShareNotebookRequest represents the notebook sharing request body
+type ShareNotebookRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor"`
+}
+
+// NotebookResponse describes a notebook. Role is the caller's access and
+// is only set when a single notebook is loaded.
+type NotebookResponse struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"ownerId"`
+	ParentID  string    `json:"parentId,omitempty"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NotebookItemResponse is one entry of a notebook's contents; exactly one
+// of Notebook and Note is set, as told by Type
+type NotebookItemResponse struct {
+	Type     string            `json:"type"`
+	Notebook *NotebookResponse `json:"notebook,omitempty"`
+	Note     *NoteResponse     `json:"note,omitempty"`
+}
+
+// CollaboratorResponse describes a user a notebook is shared with
+type CollaboratorResponse struct {
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newNotebookResponse(nb *domainNotebook.Notebook) NotebookResponse {
+	return NotebookResponse{
+		ID:        nb.ID,
+		OwnerID:   nb.OwnerID,
+		ParentID:  nb.ParentID,
+		Name:      nb.Name,
+		CreatedAt: nb.CreatedAt,
+		UpdatedAt: nb.UpdatedAt,
+	}
+}
+
+func newNotebookResponses(notebooks []*domainNotebook.Notebook) []NotebookResponse {
+	data := make([]NotebookResponse, 0, len(notebooks))
+	for _, nb := range notebooks {
+		data = append(data, newNotebookResponse(nb))
+	}
+	return data
+}
+
+func newCollaboratorResponse(c *domainNotebook.Collaborator) CollaboratorResponse {
+	return CollaboratorResponse{
+		UserID:    c.UserID,
+		Email:     c.Email,
+		Role:      string(c.Role),
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+// List handles listing the caller's notebooks
+func (h *NotebookHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	notebooks, err := h.notebookUseCase.List(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "listing notebooks failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNotebookResponses(notebooks))
+}
+
+// Shared handles listing the notebooks shared with the caller
+func (h *NotebookHandler) Shared(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	notebooks, err := h.notebookUseCase.SharedWithMe(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "listing shared notebooks failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNotebookResponses(notebooks))
+}
+
+// Create handles notebook creation
+func (h *NotebookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateNotebookRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	nb, err := h.notebookUseCase.Create(r.Context(), userID, req.Name, strings.TrimSpace(req.ParentID))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "creating notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, newNotebookResponse(nb))
+}
+
+// Get handles notebook retrieval
+func (h *NotebookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	nb, role, err := h.notebookUseCase.Get(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "loading notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	resp := newNotebookResponse(nb)
+	resp.Role = string(role)
+	response.JSON(w, r, http.StatusOK, resp)
+}
+
+// Rename handles renaming a notebook
+func (h *NotebookHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req RenameNotebookRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	nb, err := h.notebookUseCase.Rename(r.Context(), userID, r.PathValue("id"), req.Name)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "renaming notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNotebookResponse(nb))
+}
+
+// Move handles moving a notebook under another parent
+func (h *NotebookHandler) Move(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req MoveNotebookRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	nb, err := h.notebookUseCase.Move(r.Context(), userID, r.PathValue("id"), strings.TrimSpace(req.ParentID))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "moving notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newNotebookResponse(nb))
+}
+
+// Delete handles notebook deletion. Only empty notebooks can be deleted.
+func (h *NotebookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.notebookUseCase.Delete(r.Context(), userID, r.PathValue("id")); err != nil {
+		h.logger.WarnContext(r.Context(), "deleting notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Contents handles paginated listing of a notebook's notebooks and notes
+func (h *NotebookHandler) Contents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := request.ParsePage(r)
+	if err != nil {
+		response.Fail(w, r, err)
+		return
+	}
+
+	contents, err := h.notebookUseCase.Contents(r.Context(), userID, r.PathValue("id"), page.Offset(), page.PerPage)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "listing notebook contents failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	data := make([]NotebookItemResponse, 0, len(contents.Notebooks)+len(contents.Notes))
+	for _, nb := range contents.Notebooks {
+		resp := newNotebookResponse(nb)
+		data = append(data, NotebookItemResponse{Type: "notebook", Notebook: &resp})
+	}
+	for _, n := range contents.Notes {
+		resp := newNoteResponse(n)
+		data = append(data, NotebookItemResponse{Type: "note", Note: &resp})
+	}
+	response.JSONWithMeta(w, r, http.StatusOK, data, &response.Meta{
+		Total:   contents.Total,
+		Page:    page.Number,
+		PerPage: page.PerPage,
+	})
+}
+
+// Collaborators handles listing the users a notebook is shared with
+func (h *NotebookHandler) Collaborators(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	collaborators, err := h.notebookUseCase.Collaborators(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "listing collaborators failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	data := make([]CollaboratorResponse, 0, len(collaborators))
+	for _, c := range collaborators {
+		data = append(data, newCollaboratorResponse(c))
+	}
+	response.JSON(w, r, http.StatusOK, data)
+}
+
+// Share handles adding a collaborator or changing their role
+func (h *NotebookHandler) Share(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShareNotebookRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	c, err := h.notebookUseCase.Share(r.Context(), userID, r.PathValue("id"), strings.TrimSpace(req.Email), domainNotebook.Role(req.Role))
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "sharing notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newCollaboratorResponse(c))
+}
+
+// Unshare handles removing a collaborator
+func (h *NotebookHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.notebookUseCase.Unshare(r.Context(), userID, r.PathValue("id"), r.PathValue("userId")); err != nil {
+		h.logger.WarnContext(r.Context(), "unsharing notebook failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
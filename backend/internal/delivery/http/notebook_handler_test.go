@@ -0,0 +1,179 @@
+package http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+)
+
+func TestNotebookHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+	otherToken := s.login(t, "john@example.com", "")
+
+	createNotebook := func(t *testing.T, name, parentID string) httpHandler.NotebookResponse {
+		t.Helper()
+		body := `{"name":"` + name + `","parentId":"` + parentID + `"}`
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notebooks", token, body)
+		if status != http.StatusCreated {
+			t.Fatalf("create notebook: status %d, errors %+v", status, resp.Errors)
+		}
+		var nb httpHandler.NotebookResponse
+		json.Unmarshal(resp.Data, &nb)
+		return nb
+	}
+	createNote := func(t *testing.T, title, notebookID string) httpHandler.NoteResponse {
+		t.Helper()
+		body := `{"title":"` + title + `","notebookId":"` + notebookID + `"}`
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notes", token, body)
+		if status != http.StatusCreated {
+			t.Fatalf("create note: status %d, errors %+v", status, resp.Errors)
+		}
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		return n
+	}
+
+	work := createNotebook(t, "Work", "")
+	projects := createNotebook(t, "Projects", work.ID)
+	archive := createNotebook(t, "Archive", work.ID)
+	report := createNote(t, "report", projects.ID)
+	createNote(t, "plan", work.ID)
+	createNote(t, "budget", work.ID)
+
+	t.Run("moves cannot create cycles", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPost, "/api/v1/notebooks/"+work.ID+"/move", token, `{"parentId":"`+projects.ID+`"}`)
+		if status != http.StatusConflict || resp.Errors[0].Code != "NOTEBOOK_CYCLE" {
+			t.Errorf("move under descendant: status = %d, errors %+v", status, resp.Errors)
+		}
+		status, resp = s.do(t, http.MethodPost, "/api/v1/notebooks/"+archive.ID+"/move", token, `{"parentId":"`+projects.ID+`"}`)
+		var moved httpHandler.NotebookResponse
+		json.Unmarshal(resp.Data, &moved)
+		if status != http.StatusOK || moved.ParentID != projects.ID {
+			t.Errorf("move: status = %d, notebook %+v", status, moved)
+		}
+	})
+
+	t.Run("notebooks are listed and renamed", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodPatch, "/api/v1/notebooks/"+archive.ID, token, `{"name":"Old"}`)
+		if status != http.StatusOK {
+			t.Fatalf("rename: status = %d, errors %+v", status, resp.Errors)
+		}
+		status, resp = s.do(t, http.MethodGet, "/api/v1/notebooks", token, "")
+		var notebooks []httpHandler.NotebookResponse
+		json.Unmarshal(resp.Data, &notebooks)
+		var names []string
+		for _, nb := range notebooks {
+			names = append(names, nb.Name)
+		}
+		if status != http.StatusOK || !slices.Contains(names, "Old") || slices.Contains(names, "Archive") {
+			t.Errorf("list: status = %d, names %q", status, names)
+		}
+	})
+
+	t.Run("contents are paginated", func(t *testing.T) {
+		var types []string
+		for page := 1; page <= 2; page++ {
+			status, resp := s.do(t, http.MethodGet, "/api/v1/notebooks/"+work.ID+"/contents?perPage=2&page="+string(rune('0'+page)), token, "")
+			if status != http.StatusOK || resp.Meta["total"] != 3 {
+				t.Fatalf("page %d: status = %d, meta %v, errors %+v", page, status, resp.Meta, resp.Errors)
+			}
+			var items []httpHandler.NotebookItemResponse
+			json.Unmarshal(resp.Data, &items)
+			for _, item := range items {
+				types = append(types, item.Type)
+			}
+		}
+		if got := strings.Join(types, ","); got != "notebook,note,note" {
+			t.Errorf("items = %s, want notebook,note,note", got)
+		}
+	})
+
+	t.Run("unknown and own emails are rejected alike", func(t *testing.T) {
+		var errors []string
+		for _, email := range []string{"nobody@example.com", "jane@example.com"} {
+			status, resp := s.do(t, http.MethodPut, "/api/v1/notebooks/"+work.ID+"/collaborators", token, `{"email":"`+email+`","role":"viewer"}`)
+			if status != http.StatusUnprocessableEntity || len(resp.Errors) != 1 {
+				t.Fatalf("share with %s: status = %d, errors %+v", email, status, resp.Errors)
+			}
+			errors = append(errors, fmt.Sprintf("%+v", resp.Errors))
+		}
+		if errors[0] != errors[1] {
+			t.Errorf("unknown and owner emails get different errors: %q", errors)
+		}
+	})
+
+	t.Run("sharing is inherited", func(t *testing.T) {
+		if status, resp := s.do(t, http.MethodGet, "/api/v1/notes/"+report.ID, otherToken, ""); status != http.StatusNotFound {
+			t.Fatalf("before sharing: status = %d, errors %+v", status, resp.Errors)
+		}
+
+		status, resp := s.do(t, http.MethodPut, "/api/v1/notebooks/"+work.ID+"/collaborators", token, `{"email":"john@example.com","role":"viewer"}`)
+		if status != http.StatusOK {
+			t.Fatalf("share: status = %d, errors %+v", status, resp.Errors)
+		}
+		var collaborator httpHandler.CollaboratorResponse
+		json.Unmarshal(resp.Data, &collaborator)
+
+		status, resp = s.do(t, http.MethodGet, "/api/v1/notebooks/"+work.ID+"/collaborators", token, "")
+		var collaborators []httpHandler.CollaboratorResponse
+		json.Unmarshal(resp.Data, &collaborators)
+		if status != http.StatusOK || len(collaborators) != 1 || collaborators[0].Email != "john@example.com" {
+			t.Errorf("collaborators: status = %d, %+v", status, collaborators)
+		}
+
+		status, resp = s.do(t, http.MethodGet, "/api/v1/notes/"+report.ID, otherToken, "")
+		var n httpHandler.NoteResponse
+		json.Unmarshal(resp.Data, &n)
+		if status != http.StatusOK || n.Role != "viewer" {
+			t.Errorf("read shared note: status = %d, note %+v", status, n)
+		}
+		status, resp = s.do(t, http.MethodPut, "/api/v1/notes/"+report.ID, otherToken, `{"title":"edited"}`)
+		if status != http.StatusForbidden {
+			t.Errorf("viewer edit: status = %d, errors %+v", status, resp.Errors)
+		}
+
+		s.do(t, http.MethodPut, "/api/v1/notebooks/"+work.ID+"/collaborators", token, `{"email":"john@example.com","role":"editor"}`)
+		if status, resp := s.do(t, http.MethodPut, "/api/v1/notes/"+report.ID, otherToken, `{"title":"edited"}`); status != http.StatusOK {
+			t.Errorf("editor edit: status = %d, errors %+v", status, resp.Errors)
+		}
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/notes/"+report.ID, otherToken, ""); status != http.StatusForbidden {
+			t.Errorf("editor delete: status = %d, errors %+v", status, resp.Errors)
+		}
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/notebooks/"+work.ID, otherToken, ""); status != http.StatusForbidden {
+			t.Errorf("collaborator deletes notebook: status = %d, errors %+v", status, resp.Errors)
+		}
+
+		status, resp = s.do(t, http.MethodGet, "/api/v1/notebooks/shared", otherToken, "")
+		var shared []httpHandler.NotebookResponse
+		json.Unmarshal(resp.Data, &shared)
+		if status != http.StatusOK || len(shared) != 1 || shared[0].ID != work.ID {
+			t.Errorf("shared notebooks = %+v", shared)
+		}
+
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/notebooks/"+work.ID+"/collaborators/"+collaborator.UserID, token, ""); status != http.StatusNoContent {
+			t.Fatalf("unshare: status = %d, errors %+v", status, resp.Errors)
+		}
+		if status, _ := s.do(t, http.MethodGet, "/api/v1/notebooks/"+projects.ID, otherToken, ""); status != http.StatusNotFound {
+			t.Errorf("after unsharing: status = %d", status)
+		}
+	})
+
+	t.Run("only empty notebooks can be deleted", func(t *testing.T) {
+		status, resp := s.do(t, http.MethodDelete, "/api/v1/notebooks/"+projects.ID, token, "")
+		if status != http.StatusConflict || resp.Errors[0].Code != "NOTEBOOK_NOT_EMPTY" {
+			t.Errorf("delete non-empty: status = %d, errors %+v", status, resp.Errors)
+		}
+
+		s.do(t, http.MethodPut, "/api/v1/notes/"+report.ID+"/notebook", token, `{"notebookId":""}`)
+		s.do(t, http.MethodDelete, "/api/v1/notebooks/"+archive.ID, token, "")
+		if status, resp := s.do(t, http.MethodDelete, "/api/v1/notebooks/"+projects.ID, token, ""); status != http.StatusNoContent {
+			t.Errorf("delete emptied notebook: status = %d, errors %+v", status, resp.Errors)
+		}
+	})
+}
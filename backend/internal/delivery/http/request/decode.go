@@ -0,0 +1,154 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/delivery/http/request")
+
+// DefaultMaxBodyBytes is the body size limit used when none is given
+const DefaultMaxBodyBytes = 1 << 20 // 1 MiB
+
+// Options tunes how a request body is decoded
+type Options struct {
+	// MaxBodyBytes limits the body size, DefaultMaxBodyBytes if zero
+	MaxBodyBytes int64
+}
+
+// Decode reads a JSON request body into dst and validates it.
+// The body must be sent as application/json, must not exceed the size
+// limit, must hold exactly one JSON value and must not contain fields dst
+// does not declare. dst is then checked against its validate struct tags.
+// The returned error is an *errs.Error ready for response.Fail.
+func Decode(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	return DecodeWithOptions(w, r, dst, Options{})
+}
+
+// DecodeWithOptions is Decode with a custom configuration
+func DecodeWithOptions(w http.ResponseWriter, r *http.Request, dst interface{}, opts Options) (err error) {
+	_, span := tracer.Start(r.Context(), "request.Decode")
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkContentType(r); err != nil {
+		return err
+	}
+
+	limit := opts.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return decodeError(err)
+		}
+		// Trailing data after the first JSON value
+		return errs.ErrMalformedJSON
+	}
+
+	return Validate(dst)
+}
+
+// checkContentType requires an application/json body
+func checkContentType(r *http.Request) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errs.ErrUnsupportedMedia.WithParam("expected", "application/json")
+	}
+	return nil
+}
+
+// decodeError converts a json.Decoder error into a coded error
+func decodeError(err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		maxErr    *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &maxErr):
+		return errs.ErrPayloadTooLarge.WithParam("limit", strconv.FormatInt(maxErr.Limit, 10))
+	case errors.Is(err, io.EOF):
+		// Empty body
+		return errs.ErrInvalidRequest
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return errs.ErrMalformedJSON
+	case errors.As(err, &typeErr):
+		path := strings.Split(typeErr.Field, ".")
+		return errs.Validation(
+			errs.New(errs.CodeFieldInvalidType, "invalid type").
+				WithTarget(Pointer(path...)).
+				WithParam("field", fieldLabel(path)).
+				WithParam("type", jsonType(typeErr.Type.Kind().String())),
+		)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return errs.Validation(
+			errs.New(errs.CodeFieldUnknown, "unknown field").
+				WithTarget(Pointer(name)).
+				WithParam("field", name),
+		)
+	default:
+		return errs.ErrMalformedJSON
+	}
+}
+
+// Pointer builds an RFC 6901 JSON pointer from path segments
+func Pointer(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s == "" {
+			continue
+		}
+		b.WriteByte('/')
+		s = strings.ReplaceAll(s, "~", "~0")
+		b.WriteString(strings.ReplaceAll(s, "/", "~1"))
+	}
+	return b.String()
+}
+
+// fieldLabel names a field for messages, using its last path segment
+func fieldLabel(path []string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] != "" {
+			return path[i]
+		}
+	}
+	return "value"
+}
+
+// jsonType names a Go kind the way JSON documents describe it
+func jsonType(kind string) string {
+	switch {
+	case kind == "string":
+		return "string"
+	case kind == "bool":
+		return "boolean"
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "slice", kind == "array":
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,65 @@
+package request
+
+import (
+	"net/http"
+	"strconv"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+// Pagination defaults and limits
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Page is a 1-based page of a listing
+type Page struct {
+	Number  int
+	PerPage int
+}
+
+// Offset is the number of items before the page
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.PerPage
+}
+
+// ParsePage reads the page and perPage query parameters. Missing values
+// default to the first page of DefaultPerPage items; perPage may not
+// exceed MaxPerPage.
+func ParsePage(r *http.Request) (Page, error) {
+	page := Page{Number: 1, PerPage: DefaultPerPage}
+	var details []*errs.Error
+
+	query := r.URL.Query()
+	if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			details = append(details, InvalidQueryParam("page"))
+		} else {
+			page.Number = n
+		}
+	}
+	if v := query.Get("perPage"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxPerPage {
+			details = append(details, InvalidQueryParam("perPage"))
+		} else {
+			page.PerPage = n
+		}
+	}
+
+	if len(details) > 0 {
+		return page, errs.Validation(details...)
+	}
+	return page, nil
+}
+
+// InvalidQueryParam reports a query parameter with an unsupported value.
+// The target names the parameter since query strings have no JSON
+// pointer.
+func InvalidQueryParam(name string) *errs.Error {
+	return errs.New(errs.CodeFieldInvalid, "invalid query parameter").
+		WithTarget(name).
+		WithParam("field", name)
+}
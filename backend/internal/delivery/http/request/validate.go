@@ -0,0 +1,286 @@
+package request
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+// Validate checks a struct against its validate tags and reports every
+// invalid field at once. Supported rules, separated by commas:
+//
+//	required   the value must not be the zero value
+//	email      the string must be a bare email address
+//	min=N      strings need N characters, slices N items, numbers N
+//	max=N      strings allow N characters, slices N items, numbers N
+//	oneof=a b  the string must be one of the space-separated values
+//	hexcolor   the string must be a #rrggbb color
+//
+// Empty optional values skip every rule but required. Nested structs and
+// pointers to structs are validated recursively. Targets are JSON
+// pointers built from the json tags, e.g. "/email". A malformed tag fails
+// with an error that is not an *errs.Error; Register reports it up front.
+func Validate(v interface{}) error {
+	var details []*errs.Error
+	if err := validateValue(reflect.ValueOf(v), nil, &details); err != nil {
+		return err
+	}
+	if len(details) > 0 {
+		return errs.Validation(details...)
+	}
+	return nil
+}
+
+// Register parses the validate tags of the types of values and of the
+// structs they nest, so a malformed tag is reported when the server
+// starts rather than by the first request validated against it
+func Register(values ...interface{}) error {
+	seen := make(map[reflect.Type]bool)
+	var walk func(t reflect.Type) error
+	walk = func(t reflect.Type) error {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return nil
+		}
+		seen[t] = true
+		fields, err := rulesOf(t)
+		if err != nil {
+			return err
+		}
+		for _, f := range fields {
+			if err := walk(t.Field(f.index).Type); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, v := range values {
+		if err := walk(reflect.TypeOf(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rule is a parsed validation rule
+type rule struct {
+	name string
+	arg  string
+	// limit is the bound of min and max rules
+	limit int
+	// values are the values a oneof rule accepts
+	values []string
+}
+
+// fieldRules are the rules of an exported struct field
+type fieldRules struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// parsedRules caches the fields of struct types by type
+var parsedRules sync.Map
+
+// rulesOf returns the rules of the fields of a struct type, parsing its
+// validate tags on first use
+func rulesOf(t reflect.Type) ([]fieldRules, error) {
+	if cached, ok := parsedRules.Load(t); ok {
+		return cached.([]fieldRules), nil
+	}
+	var fields []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		rules, err := parseRules(field.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("request: %s.%s: %w", t, field.Name, err)
+		}
+		fields = append(fields, fieldRules{index: i, name: name, rules: rules})
+	}
+	parsedRules.Store(t, fields)
+	return fields, nil
+}
+
+// parseRules parses a validate tag
+func parseRules(tag string) ([]rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	var rules []rule
+	for _, text := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(text), "=")
+		r := rule{name: name, arg: arg}
+		switch name {
+		case "required", "email", "hexcolor":
+			if arg != "" {
+				return nil, fmt.Errorf("validation rule %q takes no argument", text)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("validation rule %q needs a count", text)
+			}
+			r.limit = n
+		case "oneof":
+			if r.values = strings.Fields(arg); len(r.values) == 0 {
+				return nil, fmt.Errorf("validation rule %q needs values", text)
+			}
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", text)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func validateValue(v reflect.Value, path []string, details *[]*errs.Error) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields, err := rulesOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fieldPath := append(append([]string(nil), path...), field.name)
+		value := v.Field(field.index)
+
+		if err := checkRules(value, field.rules); err != nil {
+			*details = append(*details, err.
+				WithTarget(Pointer(fieldPath...)).
+				WithParam("field", field.name))
+			continue
+		}
+
+		if err := validateValue(value, fieldPath, details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRules returns the first rule value violates
+func checkRules(value reflect.Value, rules []rule) *errs.Error {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if slices.ContainsFunc(rules, func(r rule) bool { return r.name == "required" }) {
+				return errs.New(errs.CodeFieldRequired, "required")
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	empty := value.IsZero()
+	for _, r := range rules {
+		if r.name == "required" {
+			if empty {
+				return errs.New(errs.CodeFieldRequired, "required")
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+
+		switch r.name {
+		case "email":
+			if !isEmail(value.String()) {
+				return errs.New(errs.CodeInvalidEmail, "invalid email")
+			}
+		case "min":
+			if n, unit := measure(value); n < r.limit {
+				return errs.New(errs.CodeFieldTooShort, "too short").WithParam("min", withUnit(r.arg, unit))
+			}
+		case "max":
+			if n, unit := measure(value); n > r.limit {
+				return errs.New(errs.CodeFieldTooLong, "too long").WithParam("max", withUnit(r.arg, unit))
+			}
+		case "oneof":
+			if !slices.Contains(r.values, fmt.Sprint(value.Interface())) {
+				return errs.New(errs.CodeFieldInvalid, "not one of "+r.arg)
+			}
+		case "hexcolor":
+			if !isHexColor(value.String()) {
+				return errs.New(errs.CodeFieldInvalid, "not a #rrggbb color")
+			}
+		}
+	}
+	return nil
+}
+
+// measure returns the size a min/max rule compares against
+func measure(v reflect.Value) (int, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return int(v.Float()), ""
+	default:
+		return 0, ""
+	}
+}
+
+func withUnit(n, unit string) string {
+	if unit == "" {
+		return n
+	}
+	return n + " " + unit
+}
+
+// isEmail accepts a bare address such as "jane@example.com"
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && strings.Contains(s[strings.LastIndex(s, "@"):], ".")
+}
+
+// isHexColor accepts a color such as "#1a2b3c"
+func isHexColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonName returns the name a field is encoded under
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
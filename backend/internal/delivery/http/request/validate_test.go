@@ -0,0 +1,88 @@
+package request
+
+import (
+	"errors"
+	"testing"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+func TestValidate(t *testing.T) {
+	type tag struct {
+		Name  string `json:"name" validate:"required,max=5"`
+		Color string `json:"color" validate:"hexcolor"`
+	}
+	type body struct {
+		Email string   `json:"email" validate:"required,email"`
+		Kind  string   `json:"kind" validate:"oneof=note notebook"`
+		IDs   []string `json:"ids" validate:"min=1"`
+		Tag   *tag     `json:"tag"`
+	}
+
+	err := Validate(&body{Email: "jane", Kind: "tag", Tag: &tag{Name: "too long", Color: "red"}})
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Validate error = %v, want a validation error", err)
+	}
+	got := map[string]errs.Code{}
+	for _, d := range e.Details {
+		got[d.Target] = d.Code
+	}
+	want := map[string]errs.Code{
+		"/email":     errs.CodeInvalidEmail,
+		"/kind":      errs.CodeFieldInvalid,
+		"/tag/name":  errs.CodeFieldTooLong,
+		"/tag/color": errs.CodeFieldInvalid,
+	}
+	if len(got) != len(want) {
+		t.Errorf("details = %v, want %v", got, want)
+	}
+	for target, code := range want {
+		if got[target] != code {
+			t.Errorf("%s: code %q, want %q", target, got[target], code)
+		}
+	}
+
+	if err := Validate(&body{Email: "jane@example.com", IDs: []string{"1"}}); err != nil {
+		t.Errorf("Validate(valid) = %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	type nested struct {
+		Count int `validate:"max=ten"`
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+		ok   bool
+	}{
+		{"valid", struct {
+			Name string `validate:"required,max=10"`
+		}{}, true},
+		{"unknown rule", struct {
+			Name string `validate:"required,uuid"`
+		}{}, false},
+		{"malformed count", struct {
+			Name string `validate:"min="`
+		}{}, false},
+		{"oneof without values", struct {
+			Kind string `validate:"oneof="`
+		}{}, false},
+		{"nested", struct{ Items []nested }{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Register(tt.v); (err == nil) != tt.ok {
+				t.Errorf("Register error = %v", err)
+			}
+		})
+	}
+
+	// Validate fails with the same error instead of panicking
+	if err := Validate(struct {
+		Name string `validate:"required,uuid"`
+	}{Name: "x"}); err == nil || errors.As(err, new(*errs.Error)) {
+		t.Errorf("Validate with an unknown rule = %v", err)
+	}
+}
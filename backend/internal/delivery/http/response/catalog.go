@@ -0,0 +1,129 @@
+package response
+
+//go:generate go run notes-app/backend/cmd/errcodes -out ../../../../../docs
+
+import (
+	"net/http"
+	"strings"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+// DefaultDocsBaseURL is the page documenting every error code. Each
+// code links to its own anchor on that page.
+const DefaultDocsBaseURL = "https://github.com/OrhanOzkercin/notes-app/blob/main/docs/error-codes.md"
+
+var docsBaseURL = DefaultDocsBaseURL
+
+// CodeInfo describes how an error code is presented to API clients
+type CodeInfo struct {
+	Code      errs.Code `json:"code"`
+	Status    int       `json:"status"`
+	Message   string    `json:"message"` // Template, placeholders look like {name}
+	DocURL    string    `json:"docUrl"`
+	Retryable bool      `json:"retryable"`
+}
+
+// catalog is the registry of every error code the API can return
+var catalog = []CodeInfo{
+	{Code: errs.CodeInternal, Status: http.StatusInternalServerError, Message: "Internal server error"},
+	{Code: errs.CodeInvalidRequest, Status: http.StatusBadRequest, Message: "Invalid request body"},
+	{Code: errs.CodeMalformedJSON, Status: http.StatusBadRequest, Message: "Request body is not valid JSON"},
+	{Code: errs.CodeUnsupportedMedia, Status: http.StatusUnsupportedMediaType, Message: "Content-Type must be {expected}"},
+	{Code: errs.CodePayloadTooLarge, Status: http.StatusRequestEntityTooLarge, Message: "Request body must not exceed {limit} bytes"},
+	{Code: errs.CodeValidationFailed, Status: http.StatusUnprocessableEntity, Message: "One or more fields are invalid"},
+	{Code: errs.CodeFieldRequired, Status: http.StatusUnprocessableEntity, Message: "{field} is required"},
+	{Code: errs.CodeFieldInvalid, Status: http.StatusUnprocessableEntity, Message: "{field} is invalid"},
+	{Code: errs.CodeFieldInvalidType, Status: http.StatusUnprocessableEntity, Message: "{field} must be of type {type}"},
+	{Code: errs.CodeFieldTooShort, Status: http.StatusUnprocessableEntity, Message: "{field} must be at least {min} long"},
+	{Code: errs.CodeFieldTooLong, Status: http.StatusUnprocessableEntity, Message: "{field} must be at most {max} long"},
+	{Code: errs.CodeFieldUnknown, Status: http.StatusUnprocessableEntity, Message: "{field} is not a recognised field"},
+	{Code: errs.CodeMethodNotAllowed, Status: http.StatusMethodNotAllowed, Message: "Method {method} is not allowed"},
+	{Code: errs.CodeRouteNotFound, Status: http.StatusNotFound, Message: "No route matches {path}"},
+	{Code: errs.CodeUnauthorized, Status: http.StatusUnauthorized, Message: "Authentication required"},
+	{Code: errs.CodeInvalidToken, Status: http.StatusUnauthorized, Message: "Access token is invalid or expired"},
+	{Code: errs.CodeSessionRevoked, Status: http.StatusUnauthorized, Message: "Session has been revoked, log in again"},
+	{Code: errs.CodeForbidden, Status: http.StatusForbidden, Message: "Missing permission {permission}"},
+	{Code: errs.CodeCORSOriginDenied, Status: http.StatusForbidden, Message: "Origin is not allowed to access this API"},
+	{Code: errs.CodeCORSMethodDenied, Status: http.StatusForbidden, Message: "Method {method} is not allowed for cross-origin requests"},
+	{Code: errs.CodeCORSHeaderDenied, Status: http.StatusForbidden, Message: "Header {header} is not allowed for cross-origin requests"},
+	{Code: errs.CodeNotFound, Status: http.StatusNotFound, Message: "Resource not found"},
+	{Code: errs.CodeConflict, Status: http.StatusConflict, Message: "Resource conflicts with an existing one"},
+	{Code: errs.CodeServiceUnavailable, Status: http.StatusServiceUnavailable, Message: "Service temporarily unavailable", Retryable: true},
+	{Code: errs.CodeUserExists, Status: http.StatusConflict, Message: "User already exists"},
+	{Code: errs.CodeInvalidCredentials, Status: http.StatusUnauthorized, Message: "Invalid email or password"},
+	{Code: errs.CodeInvalidEmail, Status: http.StatusBadRequest, Message: "Invalid email address"},
+	{Code: errs.CodeInvalidPassword, Status: http.StatusBadRequest, Message: "Invalid password"},
+	{Code: errs.CodeUserNotFound, Status: http.StatusNotFound, Message: "User not found"},
+	{Code: errs.CodeAccountDisabled, Status: http.StatusForbidden, Message: "Account is disabled"},
+	{Code: errs.CodeCannotDisableSelf, Status: http.StatusConflict, Message: "You cannot disable your own account"},
+	{Code: errs.CodeNoteNotFound, Status: http.StatusNotFound, Message: "Note not found"},
+	{Code: errs.CodeNotebookNotFound, Status: http.StatusNotFound, Message: "Notebook not found"},
+	{Code: errs.CodeNotebookCycle, Status: http.StatusConflict, Message: "A notebook cannot be moved into itself or one of its notebooks"},
+	{Code: errs.CodeNotebookNotEmpty, Status: http.StatusConflict, Message: "Move or delete the notes and notebooks inside first"},
+	{Code: errs.CodeCollaboratorNotFound, Status: http.StatusNotFound, Message: "The notebook is not shared with this user"},
+	{Code: errs.CodeAttachmentNotFound, Status: http.StatusNotFound, Message: "Attachment not found"},
+	{Code: errs.CodeAttachmentTypeDenied, Status: http.StatusUnsupportedMediaType, Message: "Files of type {type} cannot be attached"},
+	{Code: errs.CodeInvalidDownloadLink, Status: http.StatusForbidden, Message: "Download link is invalid or has expired"},
+	{Code: errs.CodeInvalidImage, Status: http.StatusUnprocessableEntity, Message: "The image is damaged or cannot be read"},
+	{Code: errs.CodeImportUnsupported, Status: http.StatusUnsupportedMediaType, Message: "{name} is not a Markdown, HTML, Evernote or zip file"},
+	{Code: errs.CodeImportInvalid, Status: http.StatusUnprocessableEntity, Message: "{name} is damaged or cannot be read"},
+	{Code: errs.CodeImportTooManyNotes, Status: http.StatusUnprocessableEntity, Message: "An import creates at most {limit} notes"},
+	{Code: errs.CodeImportMissingFile, Status: http.StatusUnprocessableEntity, Message: "{ref} is not part of the import"},
+	{Code: errs.CodeExportMissingCharacters, Status: http.StatusUnprocessableEntity, Message: "{characters} cannot be printed and show as boxes"},
+	{Code: errs.CodeExportTooLarge, Status: http.StatusUnprocessableEntity, Message: "A PDF document holds at most {limit} notes and {size} MB of them"},
+	{Code: errs.CodeTagNotFound, Status: http.StatusNotFound, Message: "Tag not found"},
+	{Code: errs.CodeTagExists, Status: http.StatusConflict, Message: "A tag named {name} already exists"},
+}
+
+var catalogIndex = func() map[errs.Code]int {
+	index := make(map[errs.Code]int, len(catalog))
+	for i, info := range catalog {
+		if _, dup := index[info.Code]; dup {
+			panic("response: duplicate error code " + string(info.Code))
+		}
+		index[info.Code] = i
+	}
+	return index
+}()
+
+// SetDocsBaseURL changes the page error documentation links point to
+func SetDocsBaseURL(url string) {
+	if url != "" {
+		docsBaseURL = url
+	}
+}
+
+// Lookup returns the catalog entry for code. Unknown codes resolve to
+// the INTERNAL_ERROR entry and ok is false.
+func Lookup(code errs.Code) (info CodeInfo, ok bool) {
+	i, ok := catalogIndex[code]
+	if !ok {
+		i = catalogIndex[errs.CodeInternal]
+	}
+	info = catalog[i]
+	info.DocURL = getErrorDocURL(info.Code)
+	return info, ok
+}
+
+// Catalog returns every registered error code in declaration order
+func Catalog() []CodeInfo {
+	infos := make([]CodeInfo, len(catalog))
+	for i, info := range catalog {
+		info.DocURL = getErrorDocURL(info.Code)
+		infos[i] = info
+	}
+	return infos
+}
+
+// renderMessage fills the {name} placeholders of a message template
+func renderMessage(template string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
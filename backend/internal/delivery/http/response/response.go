@@ -3,7 +3,11 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
+
+	"notes-app/backend/internal/delivery/http/requestid"
+	"notes-app/backend/internal/domain/errs"
 )
 
 // SuccessResponse represents a successful API response
@@ -38,10 +42,17 @@ type Meta struct {
 }
 
 // JSON sends a successful JSON response
-func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
+func JSON(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	JSONWithMeta(w, r, statusCode, data, nil)
+}
+
+// JSONWithMeta sends a successful JSON response with metadata such as
+// pagination
+func JSONWithMeta(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}, meta *Meta) {
 	response := SuccessResponse{
 		Data:      data,
-		RequestID: generateRequestID(), // You'll need to implement this
+		Meta:      meta,
+		RequestID: requestID(w, r),
 		Timestamp: time.Now().UTC(),
 	}
 
@@ -51,37 +62,34 @@ func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
 }
 
 // Error sends a JSON error response
-func Error(w http.ResponseWriter, statusCode int, code string, message string, target string) {
-	response := ErrorResponse{
-		Errors: []APIError{
-			{
-				Code:    code,
-				Message: message,
-				Target:  target,
-				DocURL:  getErrorDocURL(code), // You'll need to implement this
-			},
+func Error(w http.ResponseWriter, r *http.Request, statusCode int, code string, message string, target string) {
+	writeErrors(w, r, statusCode, []APIError{
+		{
+			Code:    code,
+			Message: message,
+			Target:  target,
+			DocURL:  getErrorDocURL(errs.Code(code)),
 		},
-		RequestID: generateRequestID(), // You'll need to implement this
-		Timestamp: time.Now().UTC(),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // ErrorWithDetails sends a JSON error response with nested errors
-func ErrorWithDetails(w http.ResponseWriter, statusCode int, code string, message string, details []APIError) {
-	response := ErrorResponse{
-		Errors: []APIError{
-			{
-				Code:    code,
-				Message: message,
-				Details: details,
-				DocURL:  getErrorDocURL(code), // You'll need to implement this
-			},
+func ErrorWithDetails(w http.ResponseWriter, r *http.Request, statusCode int, code string, message string, details []APIError) {
+	writeErrors(w, r, statusCode, []APIError{
+		{
+			Code:    code,
+			Message: message,
+			Details: details,
+			DocURL:  getErrorDocURL(errs.Code(code)),
 		},
-		RequestID: generateRequestID(), // You'll need to implement this
+	})
+}
+
+// writeErrors sends an ErrorResponse holding errors
+func writeErrors(w http.ResponseWriter, r *http.Request, statusCode int, errors []APIError) {
+	response := ErrorResponse{
+		Errors:    errors,
+		RequestID: requestID(w, r),
 		Timestamp: time.Now().UTC(),
 	}
 
@@ -90,14 +98,24 @@ func ErrorWithDetails(w http.ResponseWriter, statusCode int, code string, messag
 	json.NewEncoder(w).Encode(response)
 }
 
-// Helper functions to implement
-func generateRequestID() string {
-	// TODO: Implement request ID generation
-	// Use UUID v4 or similar
-	return "temp-id"
+// requestID returns the ID assigned by the request ID middleware so the
+// body matches the X-Request-ID header and the logs
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		return id
+	}
+	if id := w.Header().Get(requestid.Header); id != "" {
+		return id
+	}
+	id := requestid.Generate()
+	w.Header().Set(requestid.Header, id)
+	return id
 }
 
-func getErrorDocURL(code string) string {
-	// TODO: Implement mapping error codes to documentation URLs
-	return ""
+// getErrorDocURL links to the documentation anchor of a registered code
+func getErrorDocURL(code errs.Code) string {
+	if _, ok := catalogIndex[code]; !ok {
+		return ""
+	}
+	return docsBaseURL + "#" + strings.ToLower(string(code))
 }
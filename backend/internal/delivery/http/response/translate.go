@@ -0,0 +1,42 @@
+package response
+
+import (
+	"net/http"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+// FromError translates an error returned by a use case into the HTTP
+// status and APIError sent to the client. Errors without a registered
+// code become INTERNAL_ERROR so internal details never leak.
+func FromError(err error) (int, APIError) {
+	info, _ := Lookup(errs.CodeOf(err))
+	apiErr := APIError{
+		Code:    string(info.Code),
+		Message: renderMessage(info.Message, errs.ParamsOf(err)),
+		Target:  errs.TargetOf(err),
+		DocURL:  info.DocURL,
+	}
+	for _, detail := range errs.DetailsOf(err) {
+		_, d := FromError(detail)
+		apiErr.Details = append(apiErr.Details, d)
+	}
+	return info.Status, apiErr
+}
+
+// Fail sends the error response for err. Errors with field-level details
+// are reported through ErrorWithDetails.
+func Fail(w http.ResponseWriter, r *http.Request, err error) {
+	status, apiErr := FromError(err)
+	if len(apiErr.Details) > 0 {
+		ErrorWithDetails(w, r, status, apiErr.Code, apiErr.Message, apiErr.Details)
+		return
+	}
+	writeErrors(w, r, status, []APIError{apiErr})
+}
+
+// IsServerError reports whether err translates to a 5xx response
+func IsServerError(err error) bool {
+	status, _ := FromError(err)
+	return status >= http.StatusInternalServerError
+}
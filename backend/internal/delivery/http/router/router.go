@@ -0,0 +1,218 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/errs"
+)
+
+// Middleware wraps a handler with additional behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Route describes a registered route
+type Route struct {
+	Method string
+	Path   string // Full path pattern, e.g. /api/v1/notes/{id}
+}
+
+// Pattern returns the route in ServeMux pattern syntax, e.g. "GET /notes/{id}".
+// Requests that matched no route report "unmatched".
+func (r Route) Pattern() string {
+	if r.Method == "" {
+		return r.Path
+	}
+	return r.Method + " " + r.Path
+}
+
+// unmatched labels requests that did not match any route
+var unmatched = Route{Path: "unmatched"}
+
+// probeMethods are tried to build the Allow header of a 405 response
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// Router is a method-aware router built on the Go 1.22 ServeMux.
+// It supports path parameters (read with r.PathValue), route groups that
+// share a prefix and a middleware stack, per-route middleware, JSON 404
+// and 405 responses with an Allow header, and a route table dump.
+//
+// Middleware must be added with Use before any route or group is
+// registered on the same router.
+type Router struct {
+	*table
+	prefix     string
+	middleware []Middleware
+	sealed     bool
+}
+
+// table is the state shared by a router and all of its groups
+type table struct {
+	mux      *http.ServeMux
+	routes   []Route
+	root     *Router
+	fallback http.Handler
+	once     sync.Once
+}
+
+// New creates an empty router
+func New() *Router {
+	rt := &Router{table: &table{mux: http.NewServeMux()}}
+	rt.root = rt
+	return rt
+}
+
+// Use appends middleware to the stack of every route registered on rt
+// afterwards. It panics once rt has routes or groups.
+func (rt *Router) Use(mw ...Middleware) {
+	if rt.sealed {
+		panic("router: Use must be called before routes or groups are registered")
+	}
+	rt.middleware = append(rt.middleware, mw...)
+}
+
+// Group creates a sub-router whose routes share the prefix and run the
+// parent's middleware followed by mw
+func (rt *Router) Group(prefix string, mw ...Middleware) *Router {
+	rt.sealed = true
+	stack := make([]Middleware, 0, len(rt.middleware)+len(mw))
+	stack = append(stack, rt.middleware...)
+	stack = append(stack, mw...)
+	return &Router{
+		table:      rt.table,
+		prefix:     rt.prefix + prefix,
+		middleware: stack,
+	}
+}
+
+// Handle registers h for method and path. Route middleware runs after
+// the group's stack.
+func (rt *Router) Handle(method, path string, h http.Handler, mw ...Middleware) {
+	rt.sealed = true
+	rt.root.sealed = true
+
+	route := Route{Method: method, Path: rt.prefix + path}
+	h = chain(h, mw)
+	h = chain(h, rt.middleware)
+	rt.mux.Handle(route.Pattern(), withRoute(route, h))
+	rt.routes = append(rt.routes, route)
+}
+
+// Get registers a GET route, which also answers HEAD requests
+func (rt *Router) Get(path string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodGet, path, h, mw...)
+}
+
+// Post registers a POST route
+func (rt *Router) Post(path string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodPost, path, h, mw...)
+}
+
+// Put registers a PUT route
+func (rt *Router) Put(path string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodPut, path, h, mw...)
+}
+
+// Patch registers a PATCH route
+func (rt *Router) Patch(path string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodPatch, path, h, mw...)
+}
+
+// Delete registers a DELETE route
+func (rt *Router) Delete(path string, h http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(http.MethodDelete, path, h, mw...)
+}
+
+// Routes returns the route table sorted by path and method
+func (rt *Router) Routes() []Route {
+	routes := append([]Route(nil), rt.routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// ServeHTTP dispatches the request to the matching route. Requests that
+// match no route get a JSON 404, or a 405 with an Allow header when the
+// path exists for other methods. OPTIONS requests for a known path are
+// answered with the Allow header.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+
+	rt.once.Do(func() {
+		rt.fallback = withRoute(unmatched, chain(http.HandlerFunc(rt.serveUnmatched), rt.root.middleware))
+	})
+	rt.fallback.ServeHTTP(w, r)
+}
+
+// serveUnmatched answers requests that matched no route
+func (rt *Router) serveUnmatched(w http.ResponseWriter, r *http.Request) {
+	allowed := rt.AllowedMethods(r)
+	if len(allowed) == 0 {
+		response.Fail(w, r, errs.ErrRouteNotFound.WithParam("path", r.URL.Path))
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	response.Fail(w, r, errs.ErrMethodNotAllowed.WithParam("method", r.Method))
+}
+
+// AllowedMethods lists the methods that have a route for r's path,
+// whatever r's own method is. CORS preflight handling uses it to answer
+// with the methods of the route being requested.
+func (rt *Router) AllowedMethods(r *http.Request) []string {
+	var allowed []string
+	for _, method := range probeMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := rt.mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// chain wraps h so that mw[0] runs first
+func chain(h http.Handler, mw []Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+type ctxKey struct{}
+
+// withRoute stores the matched route in the request context
+func withRoute(route Route, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, route)))
+	})
+}
+
+// RouteFromContext returns the route the request matched. Middleware
+// registered on the router can use it to label logs and metrics with the
+// route pattern instead of the raw path.
+func RouteFromContext(ctx context.Context) (Route, bool) {
+	route, ok := ctx.Value(ctxKey{}).(Route)
+	return route, ok
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"notes-app/backend/internal/delivery/http/middleware"
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/router"
+	"notes-app/backend/internal/domain/auth"
+)
+
+// Handlers holds the handlers the API routes lead to
+type Handlers struct {
+	User       *UserHandler
+	Admin      *AdminHandler
+	Audit      *AuditHandler
+	Note       *NoteHandler
+	Tag        *TagHandler
+	Notebook   *NotebookHandler
+	Attachment *AttachmentHandler
+	Export     *ExportHandler
+	Import     *ImportHandler
+}
+
+// requestBodies are the request bodies the handlers decode
+var requestBodies = []interface{}{
+	RegisterRequest{}, LoginRequest{}, ChangePasswordRequest{},
+	NoteRequest{}, NoteTagsRequest{}, NoteNotebookRequest{}, NoteStateRequest{}, NotePositionRequest{},
+	CreateNotebookRequest{}, RenameNotebookRequest{}, MoveNotebookRequest{}, ShareNotebookRequest{},
+	CreateTagRequest{}, UpdateTagRequest{}, MergeTagsRequest{},
+	NoteExportRequest{},
+}
+
+// CheckRequests parses the validate tags of every request body the
+// handlers decode, reporting a malformed one before any request does
+func CheckRequests() error {
+	return request.Register(requestBodies...)
+}
+
+// RegisterRoutes registers the API routes under /api/v1. Routes for
+// signed-in users sit behind requireAuth, and admin routes also check
+// the caller's permissions.
+func RegisterRoutes(rt *router.Router, h Handlers, requireAuth router.Middleware) {
+	canReadUsers := middleware.RequirePermission(auth.PermUsersRead)
+	canManageUsers := middleware.RequirePermission(auth.PermUsersManage)
+	canReadAudit := middleware.RequirePermission(auth.PermAuditRead)
+
+	api := rt.Group("/api/v1")
+	api.Get("/errors", ErrorCodes)
+
+	authGroup := api.Group("/auth")
+	authGroup.Post("/register", h.User.Register)
+	authGroup.Post("/login", h.User.Login)
+	authGroup.Get("/me", h.User.Me, requireAuth)
+	authGroup.Get("/activity", h.Audit.Activity, requireAuth)
+	authGroup.Post("/password", h.User.ChangePassword, requireAuth)
+	authGroup.Post("/logout", h.User.Logout, requireAuth)
+
+	notesGroup := api.Group("/notes", requireAuth)
+	notesGroup.Get("", h.Note.List)
+	notesGroup.Post("", h.Note.Create)
+	notesGroup.Get("/trash", h.Note.Trash)
+	notesGroup.Post("/export", h.Export.Archive)
+	notesGroup.Post("/import", h.Import.Import)
+	notesGroup.Get("/{id}", h.Note.Get)
+	notesGroup.Put("/{id}", h.Note.Update)
+	notesGroup.Delete("/{id}", h.Note.Delete)
+	notesGroup.Post("/{id}/restore", h.Note.Restore)
+	notesGroup.Delete("/{id}/permanent", h.Note.DeletePermanently)
+	notesGroup.Put("/{id}/tags", h.Note.SetTags)
+	notesGroup.Put("/{id}/notebook", h.Note.Move)
+	notesGroup.Patch("/{id}/state", h.Note.UpdateState)
+	notesGroup.Put("/{id}/position", h.Note.Reorder)
+	notesGroup.Get("/{id}/export", h.Export.Note)
+
+	notebooksGroup := api.Group("/notebooks", requireAuth)
+	notebooksGroup.Get("", h.Notebook.List)
+	notebooksGroup.Post("", h.Notebook.Create)
+	notebooksGroup.Get("/shared", h.Notebook.Shared)
+	notebooksGroup.Get("/{id}", h.Notebook.Get)
+	notebooksGroup.Patch("/{id}", h.Notebook.Rename)
+	notebooksGroup.Delete("/{id}", h.Notebook.Delete)
+	notebooksGroup.Post("/{id}/move", h.Notebook.Move)
+	notebooksGroup.Get("/{id}/contents", h.Notebook.Contents)
+	notebooksGroup.Get("/{id}/export", h.Export.Notebook)
+	notebooksGroup.Get("/{id}/collaborators", h.Notebook.Collaborators)
+	notebooksGroup.Put("/{id}/collaborators", h.Notebook.Share)
+	notebooksGroup.Delete("/{id}/collaborators/{userId}", h.Notebook.Unshare)
+
+	tagsGroup := api.Group("/tags", requireAuth)
+	tagsGroup.Get("", h.Tag.List)
+	tagsGroup.Post("", h.Tag.Create)
+	tagsGroup.Get("/autocomplete", h.Tag.Autocomplete)
+	tagsGroup.Patch("/{id}", h.Tag.Update)
+	tagsGroup.Delete("/{id}", h.Tag.Delete)
+	tagsGroup.Post("/{id}/merge", h.Tag.Merge)
+
+	attachmentsGroup := api.Group("/attachments", requireAuth)
+	attachmentsGroup.Post("", h.Attachment.Upload)
+	attachmentsGroup.Get("/{id}", h.Attachment.Get)
+	attachmentsGroup.Get("/{id}/content", h.Attachment.Content)
+	// Signed download links carry their own authorization
+	api.Get("/attachments/{id}/download", h.Attachment.Download)
+
+	adminGroup := api.Group("/admin", requireAuth)
+	adminGroup.Get("/users", h.Admin.ListUsers, canReadUsers)
+	adminGroup.Get("/users/{id}/storage", h.Admin.StorageUsage, canReadUsers)
+	adminGroup.Post("/users/{id}/disable", h.Admin.DisableUser, canManageUsers)
+	adminGroup.Post("/users/{id}/enable", h.Admin.EnableUser, canManageUsers)
+	adminGroup.Post("/users/{id}/logout", h.Admin.ForceLogout, canManageUsers)
+	adminGroup.Get("/audit", h.Audit.Query, canReadAudit)
+}
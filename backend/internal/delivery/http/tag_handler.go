@@ -0,0 +1,214 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notes-app/backend/internal/delivery/http/request"
+	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/errs"
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/usecase/tag"
+)
+
+// Autocomplete limits
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 50
+)
+
+// TagHandler handles HTTP requests for tags
+type TagHandler struct {
+	tagUseCase tag.UseCase
+	logger     *slog.Logger
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(tagUseCase tag.UseCase, logger *slog.Logger) *TagHandler {
+	return &TagHandler{
+		tagUseCase: tagUseCase,
+		logger:     logger,
+	}
+}
+
+// CreateTagRequest represents the tag creation request body
+type CreateTagRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color" validate:"hexcolor"`
+}
+
+// UpdateTagRequest represents the tag update request body. Omitted
+// fields are left unchanged; an empty color removes it.
+type UpdateTagRequest struct {
+	Name  *string `json:"name" validate:"max=50"`
+	Color *string `json:"color" validate:"hexcolor"`
+}
+
+// MergeTagsRequest represents the tag merge request body
+type MergeTagsRequest struct {
+	SourceIDs []string `json:"sourceIds" validate:"required,min=1,max=100"`
+}
+
+// TagResponse describes a tag
+type TagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color,omitempty"`
+	NoteCount int       `json:"noteCount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newTagResponse(t *domainTag.Tag) TagResponse {
+	return TagResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Color:     t.Color,
+		NoteCount: t.NoteCount,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+func newTagResponses(tags []*domainTag.Tag) []TagResponse {
+	data := make([]TagResponse, 0, len(tags))
+	for _, t := range tags {
+		data = append(data, newTagResponse(t))
+	}
+	return data
+}
+
+// List handles listing the caller's tags
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := h.tagUseCase.List(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "listing tags failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newTagResponses(tags))
+}
+
+// Create handles tag creation
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateTagRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	t, err := h.tagUseCase.Create(r.Context(), userID, req.Name, req.Color)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "creating tag failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusCreated, newTagResponse(t))
+}
+
+// Update handles renaming and recoloring a tag
+func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateTagRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	t, err := h.tagUseCase.Update(r.Context(), userID, r.PathValue("id"), req.Name, req.Color)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "updating tag failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newTagResponse(t))
+}
+
+// Delete handles tag deletion. Notes keep existing without the tag.
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.tagUseCase.Delete(r.Context(), userID, r.PathValue("id")); err != nil {
+		h.logger.WarnContext(r.Context(), "deleting tag failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Merge handles merging other tags into the {id} tag
+func (h *TagHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req MergeTagsRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	t, err := h.tagUseCase.Merge(r.Context(), userID, r.PathValue("id"), req.SourceIDs)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "merging tags failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newTagResponse(t))
+}
+
+// Autocomplete handles tag suggestions. Query parameters: prefix matches
+// the start of the name ignoring case, limit caps the number of results.
+// The most used tags come first.
+func (h *TagHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit := defaultSuggestions
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSuggestions {
+			response.Fail(w, r, errs.Validation(request.InvalidQueryParam("limit")))
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.tagUseCase.Autocomplete(r.Context(), userID, query.Get("prefix"), limit)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "tag autocomplete failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, newTagResponses(tags))
+}
@@ -1,35 +1,45 @@
 package http
 
 import (
-	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"notes-app/backend/internal/delivery/http/request"
 	"notes-app/backend/internal/delivery/http/response"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
 	"notes-app/backend/internal/usecase/user"
 )
 
 // UserHandler handles HTTP requests for user operations
 type UserHandler struct {
 	userUseCase user.UseCase
+	logger      *slog.Logger
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userUseCase user.UseCase) *UserHandler {
+func NewUserHandler(userUseCase user.UseCase, logger *slog.Logger) *UserHandler {
 	return &UserHandler{
 		userUseCase: userUseCase,
+		logger:      logger,
 	}
 }
 
 // RegisterRequest represents the registration request body
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
 // LoginRequest represents the login request body
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+// ChangePasswordRequest represents the password change request body
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=72"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=72"`
 }
 
 // LoginResponse represents the login response
@@ -37,66 +47,112 @@ type LoginResponse struct {
 	Token string `json:"token"`
 }
 
+// MeResponse describes the authenticated caller
+type MeResponse struct {
+	ID     string   `json:"id"`
+	Email  string   `json:"email"`
+	Role   string   `json:"role"`
+	Scopes []string `json:"scopes"`
+}
+
 // Register handles user registration
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		log.Printf("Method not allowed: %s", r.Method)
-		response.Error(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed", "")
-		return
-	}
-
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Failed to decode request body: %v", err)
-		response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
 		return
 	}
 
-	log.Printf("Processing registration request for email: %s", req.Email)
+	h.logger.InfoContext(r.Context(), "processing registration", slog.String("email", req.Email))
 	err := h.userUseCase.Register(r.Context(), req.Email, req.Password)
 	if err != nil {
-		log.Printf("Registration failed: %v", err)
-		switch err {
-		case user.ErrUserAlreadyExists:
-			response.Error(w, http.StatusConflict, "USER_EXISTS", "User already exists", "")
-		default:
-			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", "")
-		}
+		h.logger.WarnContext(r.Context(), "registration failed", slog.Any("error", err))
+		response.Fail(w, r, err)
 		return
 	}
 
-	log.Printf("Registration successful for email: %s", req.Email)
-	response.JSON(w, http.StatusCreated, map[string]string{"message": "User registered successfully"})
+	h.logger.InfoContext(r.Context(), "registration successful", slog.String("email", req.Email))
+	response.JSON(w, r, http.StatusCreated, map[string]string{"message": "User registered successfully"})
 }
 
 // Login handles user login
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		log.Printf("Method not allowed: %s", r.Method)
-		response.Error(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed", "")
+	var req LoginRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
 		return
 	}
 
-	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Failed to decode request body: %v", err)
-		response.Error(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", err.Error())
+	h.logger.InfoContext(r.Context(), "processing login", slog.String("email", req.Email))
+	token, err := h.userUseCase.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "login failed", slog.Any("error", err))
+		response.Fail(w, r, err)
 		return
 	}
 
-	log.Printf("Processing login request for email: %s", req.Email)
-	token, err := h.userUseCase.Login(r.Context(), req.Email, req.Password)
+	h.logger.InfoContext(r.Context(), "login successful", slog.String("email", req.Email))
+	response.JSON(w, r, http.StatusOK, LoginResponse{Token: token})
+}
+
+// Me returns the authenticated caller
+func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.Fail(w, r, errs.ErrUnauthorized)
+		return
+	}
+
+	response.JSON(w, r, http.StatusOK, MeResponse{
+		ID:     principal.UserID,
+		Email:  principal.Email,
+		Role:   string(principal.Role),
+		Scopes: principal.Scopes,
+	})
+}
+
+// ChangePassword handles password changes. Every existing session is
+// revoked, so the response carries a new token.
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.Fail(w, r, errs.ErrUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := request.Decode(w, r, &req); err != nil {
+		h.logger.WarnContext(r.Context(), "invalid request body", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	token, err := h.userUseCase.ChangePassword(r.Context(), principal.UserID, req.CurrentPassword, req.NewPassword)
 	if err != nil {
-		log.Printf("Login failed: %v", err)
-		switch err {
-		case user.ErrInvalidCredentials:
-			response.Error(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password", "")
-		default:
-			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", "")
-		}
+		h.logger.WarnContext(r.Context(), "password change failed", slog.Any("error", err))
+		response.Fail(w, r, err)
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "password changed")
+	response.JSON(w, r, http.StatusOK, LoginResponse{Token: token})
+}
+
+// Logout revokes every session of the caller, on every device
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.Fail(w, r, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := h.userUseCase.Logout(r.Context(), principal.UserID); err != nil {
+		h.logger.WarnContext(r.Context(), "logout failed", slog.Any("error", err))
+		response.Fail(w, r, err)
 		return
 	}
 
-	log.Printf("Login successful for email: %s", req.Email)
-	response.JSON(w, http.StatusOK, LoginResponse{Token: token})
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,137 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	httpHandler "notes-app/backend/internal/delivery/http"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantTarget string
+	}{
+		{"created", `{"email":"jane@example.com","password":"password123"}`, http.StatusCreated, "", ""},
+		{"duplicate", `{"email":"taken@example.com","password":"password123"}`, http.StatusConflict, "USER_EXISTS", ""},
+		{"malformed JSON", `{"email":`, http.StatusBadRequest, "MALFORMED_JSON", ""},
+		{"unknown field", `{"email":"jane@example.com","password":"password123","admin":true}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED", "/admin"},
+		{"invalid email", `{"email":"jane","password":"password123"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED", "/email"},
+		{"short password", `{"email":"jane@example.com","password":"short"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED", "/password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			s.login(t, "taken@example.com", "")
+
+			status, resp := s.do(t, http.MethodPost, "/api/v1/auth/register", "", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (errors %+v)", status, tt.wantStatus, resp.Errors)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Code != tt.wantCode {
+				t.Fatalf("errors = %+v, want code %s", resp.Errors, tt.wantCode)
+			}
+			if tt.wantTarget != "" {
+				details := resp.Errors[0].Details
+				if len(details) != 1 || details[0].Target != tt.wantTarget {
+					t.Errorf("details = %+v, want target %s", details, tt.wantTarget)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"valid", `{"email":"jane@example.com","password":"password123"}`, http.StatusOK, ""},
+		{"wrong password", `{"email":"jane@example.com","password":"wrong-password"}`, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+		{"unknown email", `{"email":"john@example.com","password":"password123"}`, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+		{"missing password", `{"email":"jane@example.com"}`, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			s.login(t, "jane@example.com", "")
+
+			status, resp := s.do(t, http.MethodPost, "/api/v1/auth/login", "", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (errors %+v)", status, tt.wantStatus, resp.Errors)
+			}
+			if tt.wantCode != "" && (len(resp.Errors) != 1 || resp.Errors[0].Code != tt.wantCode) {
+				t.Fatalf("errors = %+v, want code %s", resp.Errors, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMeHandler(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"authenticated", token, http.StatusOK, ""},
+		{"anonymous", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"tampered token", token + "x", http.StatusUnauthorized, "INVALID_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := s.do(t, http.MethodGet, "/api/v1/auth/me", tt.token, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if len(resp.Errors) != 1 || resp.Errors[0].Code != tt.wantCode {
+					t.Fatalf("errors = %+v, want code %s", resp.Errors, tt.wantCode)
+				}
+				return
+			}
+			var me httpHandler.MeResponse
+			json.Unmarshal(resp.Data, &me)
+			if me.Email != "jane@example.com" || me.Role != "user" {
+				t.Errorf("me = %+v", me)
+			}
+		})
+	}
+}
+
+func TestSessionHandlers(t *testing.T) {
+	s := newServer(t)
+	token := s.login(t, "jane@example.com", "")
+
+	status, resp := s.do(t, http.MethodPost, "/api/v1/auth/password", token, `{"currentPassword":"password123","newPassword":"password456"}`)
+	if status != http.StatusOK {
+		t.Fatalf("change password: status = %d, errors %+v", status, resp.Errors)
+	}
+	var data httpHandler.LoginResponse
+	json.Unmarshal(resp.Data, &data)
+	if status, _ := s.do(t, http.MethodGet, "/api/v1/auth/me", token, ""); status != http.StatusUnauthorized {
+		t.Errorf("token before the change: status = %d, want 401", status)
+	}
+
+	// Revocation spares tokens issued in its millisecond
+	time.Sleep(2 * time.Millisecond)
+	if status, resp := s.do(t, http.MethodPost, "/api/v1/auth/logout", data.Token, ""); status != http.StatusNoContent {
+		t.Fatalf("logout: status = %d, errors %+v", status, resp.Errors)
+	}
+	if status, _ := s.do(t, http.MethodGet, "/api/v1/auth/me", data.Token, ""); status != http.StatusUnauthorized {
+		t.Errorf("token after logout: status = %d, want 401", status)
+	}
+}
@@ -0,0 +1,112 @@
+package attachment
+
+import (
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+var (
+	ErrAttachmentNotFound = errs.New(errs.CodeAttachmentNotFound, "attachment not found")
+	ErrTypeNotAllowed     = errs.New(errs.CodeAttachmentTypeDenied, "attachment type not allowed")
+	ErrInvalidLink        = errs.New(errs.CodeInvalidDownloadLink, "invalid or expired download link")
+	ErrInvalidImage       = errs.New(errs.CodeInvalidImage, "image cannot be read")
+)
+
+// Processing is how far the background processing of an image got
+type Processing string
+
+const (
+	// ProcessingNone is the state of attachments that are not processed,
+	// such as documents
+	ProcessingNone Processing = ""
+	// ProcessingPending images wait for a worker
+	ProcessingPending Processing = "pending"
+	// ProcessingDone images have their dimensions and variants recorded
+	ProcessingDone Processing = "done"
+	// ProcessingFailed images could not be decoded and are served as
+	// uploaded
+	ProcessingFailed Processing = "failed"
+)
+
+// Variant names. The display variant bounds the width an editor shows an
+// image at; the thumbnail fits in a small square.
+const (
+	VariantDisplay   = "display"
+	VariantThumbnail = "thumbnail"
+)
+
+// Variants lists every variant name
+var Variants = []string{VariantDisplay, VariantThumbnail}
+
+// Variant is a smaller rendition of an image attachment. Variants are only
+// generated where they are smaller than the image itself.
+type Variant struct {
+	Name        string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
+}
+
+// Attachment is a file a user uploaded to embed in notes. Its content
+// lives in a BlobStore under Key.
+type Attachment struct {
+	ID      string
+	OwnerID string
+	Key     string
+	// Filename is the name the file was uploaded with, without any
+	// directory
+	Filename string
+	// ContentType is sniffed from the content, never taken from the client
+	ContentType string
+	Size        int64
+	// Checksum is the hex SHA-256 of the content
+	Checksum string
+	// Width and Height are the upright dimensions of an image, once
+	// processed
+	Width      int
+	Height     int
+	Processing Processing
+	Variants   []Variant
+	CreatedAt  time.Time
+}
+
+// NewAttachment creates an attachment owned by ownerID. Its content is
+// stored under a key derived from the owner and the attachment ID.
+func NewAttachment(id, ownerID, filename, contentType string, size int64, checksum string, now time.Time) *Attachment {
+	return &Attachment{
+		ID:          id,
+		OwnerID:     ownerID,
+		Key:         ownerID + "/" + id,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+		CreatedAt:   now,
+	}
+}
+
+// IsImage reports whether the attachment can be shown inline as an image
+func (a *Attachment) IsImage() bool {
+	switch a.ContentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// VariantKey is the key the content of a variant is stored under
+func (a *Attachment) VariantKey(name string) string {
+	return a.Key + "-" + name
+}
+
+// Variant returns the named variant, or nil when the image has none
+func (a *Attachment) Variant(name string) *Variant {
+	for i := range a.Variants {
+		if a.Variants[i].Name == name {
+			return &a.Variants[i]
+		}
+	}
+	return nil
+}
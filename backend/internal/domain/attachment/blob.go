@@ -0,0 +1,22 @@
+package attachment
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps the content of attachments. Keys are made of letters,
+// digits, dashes and slashes.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any content
+	// stored there before
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the content stored under key. Missing content is reported
+	// as errs.ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the content stored under key. Missing content is not
+	// an error, so deletes can be retried.
+	Delete(ctx context.Context, key string) error
+}
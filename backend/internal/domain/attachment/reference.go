@@ -0,0 +1,51 @@
+package attachment
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// referencePattern matches the attachment URLs the API hands out, such as
+// /api/v1/attachments/<id>/content, whatever host or prefix they carry
+var referencePattern = regexp.MustCompile(`/attachments/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})(?:[/?#]|$)`)
+
+// References returns the IDs of the attachments a note's content embeds,
+// as image or other embeds, or links to. IDs come sorted, lower-case and
+// without duplicates.
+func References(content domainNote.Delta) []string {
+	var ids []string
+	add := func(value any) {
+		s, ok := value.(string)
+		if !ok {
+			return
+		}
+		for _, m := range referencePattern.FindAllStringSubmatch(s, -1) {
+			ids = append(ids, strings.ToLower(m[1]))
+		}
+	}
+
+	for _, op := range content.Ops {
+		if embed, ok := op.Insert.(map[string]any); ok {
+			for _, value := range embed {
+				add(value)
+			}
+		}
+		add(op.Attributes["link"])
+	}
+
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// ReferenceID returns the ID of the attachment an address points to, if
+// it is one the API hands out
+func ReferenceID(url string) (string, bool) {
+	m := referencePattern.FindStringSubmatch(url)
+	if m == nil {
+		return "", false
+	}
+	return strings.ToLower(m[1]), true
+}
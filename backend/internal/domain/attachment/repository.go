@@ -0,0 +1,49 @@
+package attachment
+
+import (
+	"context"
+	"time"
+
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// Repository defines the interface for attachment persistence operations
+type Repository interface {
+	// Create stores a new attachment
+	Create(ctx context.Context, attachment *Attachment) error
+
+	// GetByID retrieves an attachment by its ID, with its variants
+	GetByID(ctx context.Context, id string) (*Attachment, error)
+
+	// UpdateImage records the outcome of processing an image: its size,
+	// checksum, dimensions, processing state and variants, which replace
+	// the ones recorded before
+	UpdateImage(ctx context.Context, attachment *Attachment) error
+
+	// ListPending returns up to limit images waiting to be processed,
+	// oldest first
+	ListPending(ctx context.Context, limit int) ([]*Attachment, error)
+
+	// Delete removes an attachment that no note references. A missing or
+	// referenced attachment is reported as errs.ErrNotFound.
+	Delete(ctx context.Context, id string) error
+
+	// SetReferences replaces the attachments a note references. IDs of
+	// attachments that do not exist are ignored.
+	SetReferences(ctx context.Context, noteID string, attachmentIDs []string) error
+
+	// References returns the IDs of the attachments a note references,
+	// sorted
+	References(ctx context.Context, noteID string) ([]string, error)
+
+	// ReferencingNotes returns the IDs of the notes, live or trashed,
+	// that reference an attachment, sorted
+	ReferencingNotes(ctx context.Context, attachmentID string) ([]string, error)
+
+	// ListOrphans returns up to limit attachments created before the given
+	// time that no note references, oldest first
+	ListOrphans(ctx context.Context, before time.Time, limit int) ([]*Attachment, error)
+
+	// StorageUsage reports the attachments a user uploaded and their size
+	StorageUsage(ctx context.Context, userID string) (domainUser.StorageUsage, error)
+}
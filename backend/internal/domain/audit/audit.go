@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Action names an audited operation
+type Action string
+
+// Account and authentication actions
+const (
+	ActionLoginSucceeded  Action = "auth.login.success"
+	ActionLoginFailed     Action = "auth.login.failure"
+	ActionRegistered      Action = "auth.register"
+	ActionPasswordChanged Action = "auth.password.change"
+	ActionSessionsRevoked Action = "auth.token.revoke"
+)
+
+// Content actions
+const (
+	ActionNoteCreated  Action = "note.create"
+	ActionNoteUpdated  Action = "note.update"
+	ActionNoteDeleted  Action = "note.delete"
+	ActionNoteTrashed  Action = "note.trash"
+	ActionNoteRestored Action = "note.restore"
+
+	ActionNotebookShared   Action = "notebook.share"
+	ActionNotebookUnshared Action = "notebook.unshare"
+)
+
+// Admin actions
+const (
+	ActionAdminUsersListed   Action = "admin.users.list"
+	ActionAdminUserDisabled  Action = "admin.user.disable"
+	ActionAdminUserEnabled   Action = "admin.user.enable"
+	ActionAdminUserLoggedOut Action = "admin.user.force_logout"
+	ActionAdminUsageViewed   Action = "admin.user.storage_usage"
+	ActionAdminAuditQueried  Action = "admin.audit.query"
+)
+
+// Target types
+const (
+	TargetUser     = "user"
+	TargetNote     = "note"
+	TargetNotebook = "notebook"
+)
+
+// Event records who did what, to what, and from where
+type Event struct {
+	ID         string            `json:"id"`
+	Action     Action            `json:"action"`
+	ActorID    string            `json:"actorId,omitempty"`
+	TargetType string            `json:"targetType,omitempty"`
+	TargetID   string            `json:"targetId,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"userAgent,omitempty"`
+	RequestID  string            `json:"requestId,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+// Repository stores audit events. The log is append-only: events are
+// never updated or deleted.
+type Repository interface {
+	// Append stores a new event
+	Append(ctx context.Context, event *Event) error
+
+	// List returns one page of the events matching filter, newest first,
+	// along with the total number of matches
+	List(ctx context.Context, filter Filter) ([]*Event, int, error)
+}
+
+// Filter selects the events returned by Repository.List. Zero fields do
+// not filter; a zero Limit returns every match.
+type Filter struct {
+	ActorID    string
+	Action     Action
+	TargetType string
+	TargetID   string
+	// UserID matches events the user performed or that targeted the
+	// user's account
+	UserID string
+	// Since and Until bound CreatedAt, inclusive and exclusive
+	Since  time.Time
+	Until  time.Time
+	Offset int
+	Limit  int
+}
+
+// Client describes where a request came from
+type Client struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the request's client
+func NewContext(ctx context.Context, c Client) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// ClientFromContext returns the client stored by NewContext, or the zero
+// Client outside of a request
+func ClientFromContext(ctx context.Context) Client {
+	c, _ := ctx.Value(ctxKey{}).(Client)
+	return c
+}
@@ -0,0 +1,32 @@
+package auth
+
+import domainUser "notes-app/backend/internal/domain/user"
+
+// Permission is an action guarded by the role policy
+type Permission string
+
+const (
+	// PermUsersRead allows listing users and viewing their storage usage
+	PermUsersRead Permission = "users:read"
+	// PermUsersManage allows disabling, enabling and logging out users
+	PermUsersManage Permission = "users:manage"
+	// PermAuditRead allows querying the whole audit log
+	PermAuditRead Permission = "audit:read"
+)
+
+// policy lists the permissions granted to each role
+var policy = map[domainUser.Role][]Permission{
+	domainUser.RoleUser:    nil,
+	domainUser.RoleSupport: {PermUsersRead},
+	domainUser.RoleAdmin:   {PermUsersRead, PermUsersManage, PermAuditRead},
+}
+
+// Can reports whether the principal's role grants perm
+func (p *Principal) Can(perm Permission) bool {
+	for _, granted := range policy[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
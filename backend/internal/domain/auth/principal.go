@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// Method identifies how a principal authenticated
+type Method string
+
+const (
+	// MethodBearer is a JWT sent in the Authorization header
+	MethodBearer Method = "bearer"
+)
+
+// Scopes granted to access tokens
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+)
+
+// Token claim names shared by token issuing and verification
+const (
+	ClaimUserID    = "user_id"
+	ClaimEmail     = "email"
+	ClaimSessionID = "jti"
+	ClaimScope     = "scope"
+	ClaimIssuedAt  = "iat"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID    string
+	Email     string
+	SessionID string
+	Scopes    []string
+	Method    Method
+	IssuedAt  time.Time
+	// Role is loaded from the account on every request rather than read
+	// from the token, so role changes apply immediately
+	Role domainUser.Role
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the principal of an authenticated request. ok is
+// false for anonymous requests.
+func FromContext(ctx context.Context) (p *Principal, ok bool) {
+	p, ok = ctx.Value(ctxKey{}).(*Principal)
+	return p, ok && p != nil
+}
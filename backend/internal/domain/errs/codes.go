@@ -0,0 +1,110 @@
+package errs
+
+// Generic error codes shared by every part of the API
+const (
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeInvalidRequest     Code = "INVALID_REQUEST"
+	CodeMalformedJSON      Code = "MALFORMED_JSON"
+	CodeUnsupportedMedia   Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodePayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeMethodNotAllowed   Code = "METHOD_NOT_ALLOWED"
+	CodeRouteNotFound      Code = "ROUTE_NOT_FOUND"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeInvalidToken       Code = "INVALID_TOKEN"
+	CodeSessionRevoked     Code = "SESSION_REVOKED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeCORSOriginDenied   Code = "CORS_ORIGIN_NOT_ALLOWED"
+	CodeCORSMethodDenied   Code = "CORS_METHOD_NOT_ALLOWED"
+	CodeCORSHeaderDenied   Code = "CORS_HEADER_NOT_ALLOWED"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeConflict           Code = "CONFLICT"
+)
+
+// Field-level validation error codes, reported as details of a
+// VALIDATION_FAILED error with the field's JSON pointer as target
+const (
+	CodeFieldRequired    Code = "FIELD_REQUIRED"
+	CodeFieldInvalid     Code = "FIELD_INVALID"
+	CodeFieldInvalidType Code = "FIELD_INVALID_TYPE"
+	CodeFieldTooShort    Code = "FIELD_TOO_SHORT"
+	CodeFieldTooLong     Code = "FIELD_TOO_LONG"
+	CodeFieldUnknown     Code = "FIELD_UNKNOWN"
+)
+
+// User and authentication error codes
+const (
+	CodeUserExists         Code = "USER_EXISTS"
+	CodeInvalidCredentials Code = "INVALID_CREDENTIALS"
+	CodeInvalidEmail       Code = "INVALID_EMAIL"
+	CodeInvalidPassword    Code = "INVALID_PASSWORD"
+	CodeUserNotFound       Code = "USER_NOT_FOUND"
+	CodeAccountDisabled    Code = "ACCOUNT_DISABLED"
+	CodeCannotDisableSelf  Code = "CANNOT_DISABLE_SELF"
+)
+
+// Note error codes
+const (
+	CodeNoteNotFound Code = "NOTE_NOT_FOUND"
+)
+
+// Notebook error codes
+const (
+	CodeNotebookNotFound     Code = "NOTEBOOK_NOT_FOUND"
+	CodeNotebookCycle        Code = "NOTEBOOK_CYCLE"
+	CodeNotebookNotEmpty     Code = "NOTEBOOK_NOT_EMPTY"
+	CodeCollaboratorNotFound Code = "COLLABORATOR_NOT_FOUND"
+)
+
+// Attachment error codes
+const (
+	CodeAttachmentNotFound   Code = "ATTACHMENT_NOT_FOUND"
+	CodeAttachmentTypeDenied Code = "ATTACHMENT_TYPE_NOT_ALLOWED"
+	CodeInvalidDownloadLink  Code = "INVALID_DOWNLOAD_LINK"
+	CodeInvalidImage         Code = "INVALID_IMAGE"
+)
+
+// Import error codes
+const (
+	CodeImportUnsupported  Code = "IMPORT_UNSUPPORTED_FILE"
+	CodeImportInvalid      Code = "IMPORT_INVALID_FILE"
+	CodeImportTooManyNotes Code = "IMPORT_TOO_MANY_NOTES"
+	CodeImportMissingFile  Code = "IMPORT_MISSING_FILE"
+)
+
+// Export error codes
+const (
+	CodeExportMissingCharacters Code = "EXPORT_MISSING_CHARACTERS"
+	CodeExportTooLarge          Code = "EXPORT_TOO_LARGE"
+)
+
+// Tag error codes
+const (
+	CodeTagNotFound Code = "TAG_NOT_FOUND"
+	CodeTagExists   Code = "TAG_EXISTS"
+)
+
+// Generic sentinel errors
+var (
+	ErrInvalidRequest   = New(CodeInvalidRequest, "invalid request body")
+	ErrMalformedJSON    = New(CodeMalformedJSON, "malformed JSON")
+	ErrUnsupportedMedia = New(CodeUnsupportedMedia, "unsupported media type")
+	ErrPayloadTooLarge  = New(CodePayloadTooLarge, "payload too large")
+	ErrMethodNotAllowed = New(CodeMethodNotAllowed, "method not allowed")
+	ErrRouteNotFound    = New(CodeRouteNotFound, "route not found")
+	ErrUnauthorized     = New(CodeUnauthorized, "authentication required")
+	ErrInvalidToken     = New(CodeInvalidToken, "invalid or expired token")
+	ErrSessionRevoked   = New(CodeSessionRevoked, "session revoked")
+	ErrForbidden        = New(CodeForbidden, "forbidden")
+
+	// ErrNotFound and ErrConflict are returned by repositories when a row
+	// does not exist or would violate a uniqueness constraint. Use cases
+	// usually translate them into a resource-specific error.
+	ErrNotFound = New(CodeNotFound, "not found")
+	ErrConflict = New(CodeConflict, "conflict")
+
+	ErrCORSOriginNotAllowed = New(CodeCORSOriginDenied, "origin not allowed")
+	ErrCORSMethodNotAllowed = New(CodeCORSMethodDenied, "method not allowed by CORS policy")
+	ErrCORSHeaderNotAllowed = New(CodeCORSHeaderDenied, "header not allowed by CORS policy")
+)
@@ -0,0 +1,175 @@
+package errs
+
+import (
+	"errors"
+	"strings"
+)
+
+// Code identifies a class of error. Codes are stable, documented and
+// returned to API clients, so never rename one once it has shipped.
+type Code string
+
+// Error is a domain error. Sentinels created with New carry a code and a
+// message; Wrap adds the operation and context while keeping the sentinel
+// reachable through errors.Is.
+type Error struct {
+	// Code is the machine-readable error code
+	Code Code
+	// Op is the operation that failed, e.g. "user.Register"
+	Op string
+	// Message is a human-readable description
+	Message string
+	// Target is the field or resource that caused the error
+	Target string
+	// Params fill the placeholders of the code's message template
+	Params map[string]string
+	// Details lists nested errors, e.g. one per invalid field
+	Details []*Error
+	// Err is the wrapped error
+	Err error
+	// origin is the error made by New or Validation that e copies, which
+	// errors.Is matches
+	origin *Error
+}
+
+// New creates a sentinel error with the given code and message
+func New(code Code, message string) *Error {
+	e := &Error{Code: code, Message: message}
+	e.origin = e
+	return e
+}
+
+// Wrap annotates err with the operation that failed. The code of the
+// innermost coded error is kept; errors without one become CodeInternal.
+func Wrap(err error, op string) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: CodeOf(err), Op: op, Err: err}
+}
+
+// Validation creates a VALIDATION_FAILED error reporting every invalid
+// field in details
+func Validation(details ...*Error) *Error {
+	e := &Error{Code: CodeValidationFailed, Message: "validation failed", Details: details}
+	e.origin = e
+	return e
+}
+
+// WithTarget returns a copy of e referring to the given field or resource
+func (e *Error) WithTarget(target string) *Error {
+	c := *e
+	c.Target = target
+	return &c
+}
+
+// WithParam returns a copy of e with a message template parameter set
+func (e *Error) WithParam(key, value string) *Error {
+	c := *e
+	c.Params = make(map[string]string, len(e.Params)+1)
+	for k, v := range e.Params {
+		c.Params[k] = v
+	}
+	c.Params[key] = value
+	return &c
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Op != "" {
+		b.WriteString(e.Op)
+		b.WriteString(": ")
+	}
+	switch {
+	case e.Message != "" && e.Err != nil:
+		b.WriteString(e.Message)
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	case e.Message != "":
+		b.WriteString(e.Message)
+	case e.Err != nil:
+		b.WriteString(e.Err.Error())
+	default:
+		b.WriteString(string(e.Code))
+	}
+	for i, d := range e.Details {
+		if i == 0 {
+			b.WriteString(" (")
+		} else {
+			b.WriteString("; ")
+		}
+		if d.Target != "" {
+			b.WriteString(d.Target)
+			b.WriteString(": ")
+		}
+		b.WriteString(string(d.Code))
+		if i == len(e.Details)-1 {
+			b.WriteString(")")
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e and target were made by the same call to New or
+// Validation, so copies made by WithTarget or WithParam still match their
+// sentinel with errors.Is. Distinct sentinels sharing a code do not match.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && e.origin != nil && e.origin == t.origin
+}
+
+// CodeOf returns the code of the outermost coded error in err's chain,
+// or CodeInternal if there is none
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// DetailsOf returns the nested errors of the first error in err's chain
+// that has any
+func DetailsOf(err error) []*Error {
+	for err != nil {
+		if e, ok := err.(*Error); ok && len(e.Details) > 0 {
+			return e.Details
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
+// TargetOf returns the first target set in err's chain
+func TargetOf(err error) string {
+	for err != nil {
+		if e, ok := err.(*Error); ok && e.Target != "" {
+			return e.Target
+		}
+		err = errors.Unwrap(err)
+	}
+	return ""
+}
+
+// ParamsOf merges the template parameters set in err's chain, with outer
+// errors taking precedence
+func ParamsOf(err error) map[string]string {
+	params := make(map[string]string)
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			for k, v := range e.Params {
+				if _, set := params[k]; !set {
+					params[k] = v
+				}
+			}
+		}
+		err = errors.Unwrap(err)
+	}
+	return params
+}
@@ -0,0 +1,36 @@
+package errs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIs(t *testing.T) {
+	notFound := New(CodeNotFound, "not found")
+	invalidNotebook := Validation(New(CodeFieldInvalid, "unknown notebook").WithTarget("/notebookId"))
+	invalidPrevious := Validation(New(CodeFieldInvalid, "no position").WithTarget("/previousId"))
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"same sentinel", notFound, notFound, true},
+		{"copy with target", notFound.WithTarget("id"), notFound, true},
+		{"copy with param", notFound.WithParam("id", "1"), notFound, true},
+		{"wrapped", Wrap(Wrap(notFound, "repo.Get"), "uc.Get"), notFound, true},
+		{"other sentinel with the code", New(CodeNotFound, "not found"), notFound, false},
+		{"validation sentinels", invalidPrevious, invalidNotebook, false},
+		{"other validation error", Wrap(Validation(), "note.Create"), invalidNotebook, false},
+		{"validation sentinel wrapped", Wrap(invalidNotebook, "note.Create"), invalidNotebook, true},
+		{"wrapped error", Wrap(errors.New("boom"), "op"), notFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Delta is a Quill document: a sequence of insert operations. Documents
+// never contain retain or delete operations; those only appear in the
+// changes an editor sends while typing.
+type Delta struct {
+	Ops []Op `json:"ops"`
+}
+
+// Op inserts text or an embed, with optional formatting attributes
+type Op struct {
+	// Insert is a string of text or an embed object such as
+	// {"image": "https://..."}
+	Insert     any            `json:"insert"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// EmptyDelta is the document of a note without content. Quill documents
+// always end with a newline.
+func EmptyDelta() Delta {
+	return Delta{Ops: []Op{{Insert: "\n"}}}
+}
+
+var errInvalidDelta = errors.New("invalid delta")
+
+// ParseDelta decodes and checks a Quill document. Operations other than
+// inserts, empty inserts and embeds that are not a single-key object are
+// rejected.
+func ParseDelta(data []byte) (Delta, error) {
+	var d Delta
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(&d); err != nil {
+		return Delta{}, errInvalidDelta
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return Delta{}, errInvalidDelta
+	}
+	if d.Ops == nil {
+		return Delta{}, errInvalidDelta
+	}
+
+	for _, op := range d.Ops {
+		switch insert := op.Insert.(type) {
+		case string:
+			if insert == "" {
+				return Delta{}, errInvalidDelta
+			}
+		case map[string]any:
+			if len(insert) != 1 {
+				return Delta{}, errInvalidDelta
+			}
+		default:
+			return Delta{}, errInvalidDelta
+		}
+	}
+	return d, nil
+}
+
+// PlainText returns the text of the document without formatting or
+// embeds, as used for search and previews
+func (d Delta) PlainText() string {
+	var b strings.Builder
+	for _, op := range d.Ops {
+		if s, ok := op.Insert.(string); ok {
+			b.WriteString(s)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// JSON encodes the document for storage
+func (d Delta) JSON() []byte {
+	if d.Ops == nil {
+		d = EmptyDelta()
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		// Deltas only hold values decoded from JSON
+		panic("note: cannot encode delta: " + err.Error())
+	}
+	return data
+}
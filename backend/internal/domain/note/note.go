@@ -0,0 +1,78 @@
+package note
+
+import (
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+var (
+	ErrNoteNotFound   = errs.New(errs.CodeNoteNotFound, "note not found")
+	ErrInvalidContent = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "content is not a Quill document").
+			WithTarget("/content").
+			WithParam("field", "content"),
+	)
+)
+
+// Note is a rich-text document owned by a user
+type Note struct {
+	ID      string
+	OwnerID string
+	// NotebookID is the notebook holding the note, if any
+	NotebookID string
+	Title      string
+	Content    Delta
+	// PlainText is the text of Content, kept for search and previews
+	PlainText string
+	// TagIDs lists the tags applied to the note, sorted
+	TagIDs    []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// DeletedAt is when the note was moved to the trash, nil for live notes
+	DeletedAt *time.Time
+	// State is how the user the note was loaded for files it
+	State State
+}
+
+// State is how one user files a note. Everyone who can open a note keeps
+// their own, so a collaborator's pin does not affect the owner.
+type State struct {
+	Pinned    bool
+	Archived  bool
+	Favourite bool
+	// Position orders notes by hand, see KeyBetween. It is empty for notes
+	// the user never moved.
+	Position string
+}
+
+// NewNote creates a note owned by ownerID
+func NewNote(ownerID, title string, content Delta, now time.Time) *Note {
+	n := &Note{
+		OwnerID:   ownerID,
+		CreatedAt: now,
+	}
+	n.Edit(title, content, now)
+	return n
+}
+
+// Edit replaces the title and content
+func (n *Note) Edit(title string, content Delta, now time.Time) {
+	if content.Ops == nil {
+		content = EmptyDelta()
+	}
+	n.Title = title
+	n.Content = content
+	n.PlainText = content.PlainText()
+	n.UpdatedAt = now
+}
+
+// Trashed reports whether the note is in the trash
+func (n *Note) Trashed() bool {
+	return n.DeletedAt != nil
+}
+
+// Size is the number of bytes the note's content takes
+func (n *Note) Size() int {
+	return len(n.Title) + len(n.Content.JSON())
+}
@@ -0,0 +1,126 @@
+package note
+
+import "strings"
+
+// positionDigits are the digits of position keys, in byte order
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// KeyBetween returns a position key that sorts strictly between a and b,
+// comparing bytes. An empty a stands for the start and an empty b for the
+// end, so moving a note never renumbers the others. ok is false when a
+// does not sort before b.
+//
+// Keys are base-62 fractions without trailing zeros, which guarantees
+// that there is always room between two of them. Keys at either end grow
+// with the logarithm of the notes placed there rather than their number:
+// see after and before.
+func KeyBetween(a, b string) (key string, ok bool) {
+	switch {
+	case b != "" && a >= b:
+		return "", false
+	case a != "" && b == "":
+		return after(a), true
+	case a == "" && b != "":
+		return before(b), true
+	}
+	return midpoint(a, b), true
+}
+
+// after returns a short key sorting after a. Keys opening with n high
+// digits are followed by a counter of n+1 digits, which after increments,
+// so the notes appended to a list lengthen its keys by two digits each
+// time their count is multiplied by 62.
+func after(a string) string {
+	high := positionDigits[len(positionDigits)-1]
+	n := len(a) - len(strings.TrimLeft(a, string(high)))
+	if counter, ok := step(a[n:], n+1, +1); ok && counter[0] != high {
+		return a[:n] + counter
+	}
+	// The counter is full: open a longer one
+	return strings.Repeat(string(high), n+1) + positionDigits[1:2]
+}
+
+// before returns a short key sorting before b, the counterpart of after
+// for notes prepended to a list, whose keys open with zeros
+func before(b string) string {
+	low := positionDigits[0]
+	n := len(b) - len(strings.TrimLeft(b, string(low)))
+	if counter, ok := step(b[n:], n+1, -1); ok && counter[0] != low {
+		return b[:n] + counter
+	}
+	// The counter is empty: open a longer one
+	return strings.Repeat(string(low), n+1) + positionDigits[len(positionDigits)-1:]
+}
+
+// step reads the first size digits of key, padded with zeros, as a
+// counter and returns it moved by delta, without trailing zeros. Moving
+// down a key longer than size needs no step, as the counter alone sorts
+// before it. ok is false when the counter overflows.
+func step(key string, size, delta int) (counter string, ok bool) {
+	digits := make([]byte, size)
+	for i := range digits {
+		digits[i] = digitAt(key, i)
+	}
+	if delta < 0 && len(key) > size {
+		return strings.TrimRight(string(digits), positionDigits[:1]), true
+	}
+	base := len(positionDigits)
+	for i := size - 1; i >= 0; i-- {
+		d := strings.IndexByte(positionDigits, digits[i]) + delta
+		carry := d < 0 || d >= base
+		digits[i] = positionDigits[(d+base)%base]
+		if !carry {
+			counter = strings.TrimRight(string(digits), positionDigits[:1])
+			return counter, counter != ""
+		}
+	}
+	return "", false
+}
+
+// midpoint returns the shortest key between a and b, b empty being the end
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the common prefix, reading a as padded with zeros
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	lo := 0
+	if a != "" {
+		lo = strings.IndexByte(positionDigits, a[0])
+	}
+	hi := len(positionDigits)
+	if b != "" {
+		hi = strings.IndexByte(positionDigits, b[0])
+	}
+	if hi-lo > 1 {
+		return string(positionDigits[(lo+hi+1)/2])
+	}
+
+	// The first digits are consecutive
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[lo]) + midpoint(rest, "")
+}
+
+// digitAt returns the nth digit of key, zero past its end
+func digitAt(key string, n int) byte {
+	if n < len(key) {
+		return key[n]
+	}
+	return positionDigits[0]
+}
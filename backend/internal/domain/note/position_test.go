@@ -0,0 +1,50 @@
+package note
+
+import "testing"
+
+func TestKeyBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		ok   bool
+	}{
+		{"", "", true},
+		{"V", "W", true},
+		{"V", "V1", true},
+		{"0z", "1", true},
+		{"zz", "", true},
+		{"", "01", true},
+		{"W", "V", false},
+		{"V", "V", false},
+	}
+	for _, tt := range tests {
+		key, ok := KeyBetween(tt.a, tt.b)
+		if ok != tt.ok {
+			t.Errorf("KeyBetween(%q, %q) ok = %v", tt.a, tt.b, ok)
+			continue
+		}
+		if ok && (key <= tt.a || (tt.b != "" && key >= tt.b) || key[len(key)-1] == '0') {
+			t.Errorf("KeyBetween(%q, %q) = %q", tt.a, tt.b, key)
+		}
+	}
+}
+
+func TestKeyBetweenEnds(t *testing.T) {
+	const n = 10000
+	// Appending and prepending n notes keeps keys short
+	first, last := "", ""
+	for i := range n {
+		key, ok := KeyBetween(last, "")
+		if !ok || key <= last || len(key) > 5 {
+			t.Fatalf("append %d after %q: %q", i, last, key)
+		}
+		last = key
+
+		if key, ok = KeyBetween("", first); !ok || (first != "" && key >= first) || len(key) > 5 {
+			t.Fatalf("prepend %d before %q: %q", i, first, key)
+		}
+		first = key
+	}
+	if first >= last {
+		t.Errorf("first key %q does not sort before last key %q", first, last)
+	}
+}
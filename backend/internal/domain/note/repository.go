@@ -0,0 +1,135 @@
+package note
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// Repository defines the interface for note persistence operations
+type Repository interface {
+	// Create stores a new note along with its tags
+	Create(ctx context.Context, note *Note) error
+
+	// GetByID retrieves a note with its tags, whether or not it is in the
+	// trash
+	GetByID(ctx context.Context, id string) (*Note, error)
+
+	// Update modifies the title and content of a note
+	Update(ctx context.Context, note *Note) error
+
+	// Delete removes a note for good
+	Delete(ctx context.Context, id string) error
+
+	// Trash moves a note to the trash
+	Trash(ctx context.Context, id string, at time.Time) error
+
+	// Restore takes a note out of the trash
+	Restore(ctx context.Context, id string) error
+
+	// PurgeTrashed deletes for good up to limit notes moved to the trash
+	// before the given time and returns how many it deleted. Everything
+	// stored with a note goes with it.
+	PurgeTrashed(ctx context.Context, before time.Time, limit int) (int, error)
+
+	// List returns one page of the notes matching filter, along with the
+	// total number of matches. Notes come in the order set by filter.Order,
+	// after the viewer's pinned notes.
+	List(ctx context.Context, filter ListFilter) ([]*Note, int, error)
+
+	// SetNotebook moves a note into a notebook, or out of any notebook
+	// when notebookID is empty
+	SetNotebook(ctx context.Context, noteID, notebookID string) error
+
+	// GetState returns how a user files a note, the zero State if they
+	// never changed it
+	GetState(ctx context.Context, noteID, userID string) (State, error)
+
+	// SetState stores how a user files a note
+	SetState(ctx context.Context, noteID, userID string, state State) error
+
+	// SetTags replaces the tags applied to a note
+	SetTags(ctx context.Context, noteID string, tagIDs []string) error
+
+	// ReplaceTags moves every note tagged with one of from to the tag to,
+	// or only removes the from tags when to is empty
+	ReplaceTags(ctx context.Context, from []string, to string) error
+
+	// CountByTag returns how many live notes carry each of tagIDs. Unused
+	// tags are missing from the result.
+	CountByTag(ctx context.Context, tagIDs []string) (map[string]int, error)
+
+	// StorageUsage reports the notes a user owns and their size, counting
+	// notes in the trash
+	StorageUsage(ctx context.Context, userID string) (domainUser.StorageUsage, error)
+}
+
+// TagMode decides how a list filter combines several tags
+type TagMode string
+
+const (
+	// TagModeAll matches notes carrying every tag
+	TagModeAll TagMode = "all"
+	// TagModeAny matches notes carrying at least one of the tags
+	TagModeAny TagMode = "any"
+)
+
+// ArchivedFilter decides whether a listing shows archived notes
+type ArchivedFilter string
+
+const (
+	// ArchivedExclude hides archived notes, the default
+	ArchivedExclude ArchivedFilter = "exclude"
+	// ArchivedInclude shows archived notes among the others
+	ArchivedInclude ArchivedFilter = "include"
+	// ArchivedOnly shows archived notes alone
+	ArchivedOnly ArchivedFilter = "only"
+)
+
+// Order sorts the notes of a listing
+type Order string
+
+const (
+	// OrderUpdated lists the most recently updated notes first, the
+	// default
+	OrderUpdated Order = "updated"
+	// OrderManual follows the viewer's positions. Notes they never moved
+	// come last, most recently updated first.
+	OrderManual Order = "manual"
+)
+
+// ListFilter selects the notes returned by Repository.List. Zero fields
+// do not filter; a zero Limit returns every match.
+type ListFilter struct {
+	OwnerID    string
+	NotebookID string
+	// Trashed lists the notes in the trash, most recently trashed first,
+	// instead of the live notes
+	Trashed bool
+	// ViewerID is the user whose State the notes are loaded with. Their
+	// pinned notes come first, and Archived and Favourites apply to their
+	// flags; without a viewer no note is pinned, archived or a favourite.
+	ViewerID   string
+	Archived   ArchivedFilter
+	Favourites bool
+	Order      Order
+	// Query is a full-text search over the title and text: every word of
+	// it, as split by SearchTerms, must appear as a word of the note
+	Query   string
+	TagIDs  []string
+	TagMode TagMode
+	Offset  int
+	Limit   int
+}
+
+// SearchTerms splits a search query into lower-case words. Everything but
+// letters and digits separates words, so search syntax such as quotes or
+// operators is treated as plain text.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
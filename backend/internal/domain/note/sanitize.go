@@ -0,0 +1,246 @@
+package note
+
+import (
+	"encoding/json"
+	"maps"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxIndent is the deepest list and paragraph indentation the editor
+// offers
+const maxIndent = 8
+
+var (
+	// colorPattern matches the colors the editor writes, which are safe to
+	// put in a style attribute
+	colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|rgba?\([0-9., %]+\))$`)
+	// languagePattern matches the languages code blocks are highlighted as
+	languagePattern = regexp.MustCompile(`^[a-zA-Z0-9+#-]{1,32}$`)
+	// rowPattern matches the row IDs of table cells
+	rowPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+)
+
+// Sanitize returns a copy of a document holding only what the editor
+// produces. Unknown formats and embeds are dropped, and so are links and
+// images with an address that could run code when followed. Control
+// characters other than tabs and newlines are removed, line endings are
+// normalised and the document is given its final newline.
+func Sanitize(d Delta) Delta {
+	var out []Op
+	// Text inserts sharing their attributes are merged as they are
+	// written
+	var text strings.Builder
+	var textAttrs map[string]any
+	flush := func() {
+		if text.Len() > 0 {
+			out = append(out, Op{Insert: text.String(), Attributes: textAttrs})
+			text.Reset()
+		}
+	}
+	add := func(insert any, attrs map[string]any) {
+		if len(attrs) == 0 {
+			attrs = nil
+		}
+		s, isText := insert.(string)
+		if text.Len() > 0 && (!isText || !maps.Equal(textAttrs, attrs)) {
+			flush()
+		}
+		if !isText {
+			out = append(out, Op{Insert: insert, Attributes: attrs})
+			return
+		}
+		if text.Len() == 0 {
+			textAttrs = attrs
+		}
+		text.WriteString(s)
+	}
+
+	for _, op := range d.Ops {
+		switch insert := op.Insert.(type) {
+		case string:
+			s := cleanText(insert)
+			for s != "" {
+				i := strings.IndexByte(s, '\n')
+				if i < 0 {
+					add(s, inlineAttributes(op.Attributes))
+					break
+				}
+				if i > 0 {
+					add(s[:i], inlineAttributes(op.Attributes))
+				}
+				add("\n", blockAttributes(op.Attributes))
+				s = s[i+1:]
+			}
+		case map[string]any:
+			if embed := sanitizeEmbed(insert); embed != nil {
+				add(embed, embedAttributes(op.Attributes))
+			}
+		}
+	}
+
+	if text.Len() == 0 || !strings.HasSuffix(text.String(), "\n") {
+		add("\n", nil)
+	}
+	flush()
+	return Delta{Ops: out}
+}
+
+// cleanText removes control characters but tabs and newlines, turning
+// carriage returns into newlines
+func cleanText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\r':
+			return '\n'
+		case r == '\n' || r == '\t':
+			return r
+		case r < ' ' || (r >= 0x7f && r < 0xa0) || r == '\ufeff':
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(s, ""))
+}
+
+// inlineAttributes returns the text formats of attrs the editor knows
+func inlineAttributes(attrs map[string]any) map[string]any {
+	out := make(map[string]any)
+	for _, name := range []string{"bold", "italic", "underline", "strike", "code"} {
+		if attrs[name] == true {
+			out[name] = true
+		}
+	}
+	if link, ok := attrs["link"].(string); ok && SafeURL(link) {
+		out["link"] = strings.TrimSpace(link)
+	}
+	if script := attrs["script"]; script == "sub" || script == "super" {
+		out["script"] = script
+	}
+	for _, name := range []string{"color", "background"} {
+		if color, ok := attrs[name].(string); ok && colorPattern.MatchString(color) {
+			out[name] = color
+		}
+	}
+	return out
+}
+
+// blockAttributes returns the line formats of attrs the editor knows
+func blockAttributes(attrs map[string]any) map[string]any {
+	out := make(map[string]any)
+	if level, ok := number(attrs["header"]); ok && level >= 1 && level <= 6 {
+		out["header"] = level
+	}
+	switch list := attrs["list"]; list {
+	case "ordered", "bullet", "checked", "unchecked":
+		out["list"] = list
+	}
+	if indent, ok := number(attrs["indent"]); ok && indent >= 1 && indent <= maxIndent {
+		out["indent"] = indent
+	}
+	if attrs["blockquote"] == true {
+		out["blockquote"] = true
+	}
+	switch lang := attrs["code-block"].(type) {
+	case bool:
+		if lang {
+			out["code-block"] = true
+		}
+	case string:
+		if languagePattern.MatchString(lang) {
+			out["code-block"] = lang
+		}
+	}
+	switch align := attrs["align"]; align {
+	case "center", "right", "justify":
+		out["align"] = align
+	}
+	if row, ok := attrs["table"].(string); ok && rowPattern.MatchString(row) {
+		out["table"] = row
+	}
+	return out
+}
+
+// sanitizeEmbed returns the embed if the editor knows it and its address
+// is safe, or nil
+func sanitizeEmbed(embed map[string]any) map[string]any {
+	if len(embed) != 1 {
+		return nil
+	}
+	if src, ok := embed["image"].(string); ok && SafeURL(src) {
+		return map[string]any{"image": strings.TrimSpace(src)}
+	}
+	if src, ok := embed["video"].(string); ok && webURL(src) {
+		return map[string]any{"video": strings.TrimSpace(src)}
+	}
+	if formula, ok := embed["formula"].(string); ok {
+		return map[string]any{"formula": cleanText(formula)}
+	}
+	return nil
+}
+
+// embedAttributes returns the attributes of an embed the editor knows
+func embedAttributes(attrs map[string]any) map[string]any {
+	out := make(map[string]any)
+	if alt, ok := attrs["alt"].(string); ok {
+		out["alt"] = strings.ReplaceAll(cleanText(alt), "\n", " ")
+	}
+	for _, name := range []string{"width", "height"} {
+		if n, ok := number(attrs[name]); ok && n > 0 {
+			out[name] = n
+		}
+	}
+	if link, ok := attrs["link"].(string); ok && SafeURL(link) {
+		out["link"] = strings.TrimSpace(link)
+	}
+	return out
+}
+
+// SafeURL reports whether u is a relative address or uses a scheme that
+// cannot run code when followed: http, https or mailto
+func SafeURL(u string) bool {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// webURL reports whether u is an absolute http or https address
+func webURL(u string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// number returns an integer attribute. Numbers come as float64 or
+// json.Number depending on how the delta was decoded, and the editor
+// writes sizes as strings.
+func number(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), n == float64(int(n))
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSuffix(n, "px"))
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,107 @@
+package notebook
+
+import (
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+var (
+	ErrNotebookNotFound     = errs.New(errs.CodeNotebookNotFound, "notebook not found")
+	ErrNotebookCycle        = errs.New(errs.CodeNotebookCycle, "notebook cannot be moved into itself or a descendant")
+	ErrNotebookNotEmpty     = errs.New(errs.CodeNotebookNotEmpty, "notebook is not empty")
+	ErrCollaboratorNotFound = errs.New(errs.CodeCollaboratorNotFound, "collaborator not found")
+)
+
+// Notebook groups notes and other notebooks. Notebooks form a tree per
+// owner: ParentID is empty for top-level notebooks.
+type Notebook struct {
+	ID        string
+	OwnerID   string
+	ParentID  string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewNotebook creates a notebook owned by ownerID
+func NewNotebook(ownerID, name, parentID string, now time.Time) *Notebook {
+	return &Notebook{
+		OwnerID:   ownerID,
+		ParentID:  parentID,
+		Name:      NormalizeName(name),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Rename changes the name of the notebook
+func (n *Notebook) Rename(name string, now time.Time) {
+	n.Name = NormalizeName(name)
+	n.UpdatedAt = now
+}
+
+// NormalizeName trims a notebook name and collapses inner whitespace
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Role is the access a user has to a notebook and everything in it
+type Role string
+
+const (
+	// RoleViewer can read notebooks and notes
+	RoleViewer Role = "viewer"
+	// RoleEditor can also edit notes
+	RoleEditor Role = "editor"
+	// RoleOwner can do anything. It is never granted to collaborators.
+	RoleOwner Role = "owner"
+)
+
+// Valid reports whether r can be granted to a collaborator
+func (r Role) Valid() bool {
+	return r == RoleViewer || r == RoleEditor
+}
+
+// CanEdit reports whether r allows editing notes
+func (r Role) CanEdit() bool {
+	return r == RoleEditor || r == RoleOwner
+}
+
+// rank orders roles from no access to full access
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Strongest returns the role granting the most access, or "" if roles is
+// empty
+func Strongest(roles ...Role) Role {
+	var best Role
+	for _, r := range roles {
+		if r.rank() > best.rank() {
+			best = r
+		}
+	}
+	return best
+}
+
+// Collaborator is a user a notebook is shared with. Access extends to
+// every notebook and note nested in it.
+type Collaborator struct {
+	NotebookID string
+	UserID     string
+	Role       Role
+	CreatedAt  time.Time
+	// Email identifies the user in listings. It is never stored.
+	Email string
+}
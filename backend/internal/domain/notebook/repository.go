@@ -0,0 +1,53 @@
+package notebook
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for notebook persistence operations
+type Repository interface {
+	// Create stores a new notebook
+	Create(ctx context.Context, notebook *Notebook) error
+
+	// GetByID retrieves a notebook by its ID
+	GetByID(ctx context.Context, id string) (*Notebook, error)
+
+	// Update renames a notebook
+	Update(ctx context.Context, notebook *Notebook) error
+
+	// Delete removes a notebook and its collaborators
+	Delete(ctx context.Context, id string) error
+
+	// Move places a notebook under parentID, or at the top level when
+	// parentID is empty. A parent that is the notebook itself or one of
+	// its descendants is reported as errs.ErrConflict; the check and the
+	// move are atomic.
+	Move(ctx context.Context, id, parentID string, now time.Time) error
+
+	// ListByOwner returns every notebook of a user ordered by name
+	ListByOwner(ctx context.Context, ownerID string) ([]*Notebook, error)
+
+	// Children returns the notebooks directly under parentID ordered by
+	// name
+	Children(ctx context.Context, parentID string) ([]*Notebook, error)
+
+	// AddCollaborator shares a notebook with a user, or changes the role
+	// of an existing collaborator
+	AddCollaborator(ctx context.Context, collaborator *Collaborator) error
+
+	// RemoveCollaborator stops sharing a notebook with a user
+	RemoveCollaborator(ctx context.Context, notebookID, userID string) error
+
+	// Collaborators returns the users a notebook is shared with directly,
+	// oldest first
+	Collaborators(ctx context.Context, notebookID string) ([]*Collaborator, error)
+
+	// Access returns the strongest role userID was granted on the notebook
+	// or any notebook it is nested in, or "" without access
+	Access(ctx context.Context, notebookID, userID string) (Role, error)
+
+	// SharedWith returns the notebooks shared directly with a user ordered
+	// by name
+	SharedWith(ctx context.Context, userID string) ([]*Notebook, error)
+}
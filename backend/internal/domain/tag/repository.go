@@ -0,0 +1,23 @@
+package tag
+
+import "context"
+
+// Repository defines the interface for tag persistence operations
+type Repository interface {
+	// Create stores a new tag. A name the owner already uses, in any
+	// case, is reported as errs.ErrConflict.
+	Create(ctx context.Context, tag *Tag) error
+
+	// GetByID retrieves a tag by its ID
+	GetByID(ctx context.Context, id string) (*Tag, error)
+
+	// Update renames or recolors a tag
+	Update(ctx context.Context, tag *Tag) error
+
+	// Delete removes a tag
+	Delete(ctx context.Context, id string) error
+
+	// List returns the tags of a user whose name starts with prefix,
+	// ignoring case, ordered by name
+	List(ctx context.Context, ownerID, prefix string) ([]*Tag, error)
+}
@@ -0,0 +1,42 @@
+package tag
+
+import (
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+)
+
+var (
+	ErrTagNotFound = errs.New(errs.CodeTagNotFound, "tag not found")
+	ErrTagExists   = errs.New(errs.CodeTagExists, "tag already exists")
+)
+
+// Tag labels notes. Tags belong to one user, and names are unique per
+// user regardless of case.
+type Tag struct {
+	ID      string
+	OwnerID string
+	Name    string
+	// Color is an optional #rrggbb hex color
+	Color     string
+	CreatedAt time.Time
+	// NoteCount is the number of notes carrying the tag. It is filled in
+	// by listings and never stored.
+	NoteCount int
+}
+
+// NewTag creates a tag owned by ownerID
+func NewTag(ownerID, name, color string, now time.Time) *Tag {
+	return &Tag{
+		OwnerID:   ownerID,
+		Name:      NormalizeName(name),
+		Color:     strings.ToLower(color),
+		CreatedAt: now,
+	}
+}
+
+// NormalizeName trims a tag name and collapses inner whitespace
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
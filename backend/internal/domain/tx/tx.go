@@ -0,0 +1,15 @@
+package tx
+
+import "context"
+
+// Manager runs several repository calls as one unit of work
+type Manager interface {
+	// WithinTx calls fn with a context carrying a transaction. Repositories
+	// called with that context take part in it. The transaction commits
+	// when fn returns nil and rolls back otherwise. A call made inside
+	// another joins the outer transaction.
+	//
+	// fn may run more than once when the database asks for a retry, so it
+	// must not have side effects outside the repositories.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -2,20 +2,45 @@ package user
 
 import "context"
 
-// Repository defines the interface for user data operations
+// Repository defines the interface for user data operations. Missing
+// users are reported as errs.ErrNotFound and duplicate emails as
+// errs.ErrConflict.
 type Repository interface {
 	// Create stores a new user
 	Create(ctx context.Context, user *User) error
-	
+
 	// GetByID retrieves a user by their ID
 	GetByID(ctx context.Context, id string) (*User, error)
-	
+
 	// GetByEmail retrieves a user by their email
 	GetByEmail(ctx context.Context, email string) (*User, error)
-	
+
 	// Update modifies an existing user
 	Update(ctx context.Context, user *User) error
-	
+
 	// Delete removes a user
 	Delete(ctx context.Context, id string) error
-} 
\ No newline at end of file
+
+	// List returns one page of the users matching filter, newest first,
+	// along with the total number of matches
+	List(ctx context.Context, filter ListFilter) ([]*User, int, error)
+}
+
+// Status filters users by whether their account is disabled
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusDisabled Status = "disabled"
+)
+
+// ListFilter selects the users returned by Repository.List. Zero fields
+// do not filter; a zero Limit returns every match.
+type ListFilter struct {
+	// Query matches part of the email address, case-insensitively
+	Query  string
+	Role   Role
+	Status Status
+	Offset int
+	Limit  int
+}
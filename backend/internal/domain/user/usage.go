@@ -0,0 +1,24 @@
+package user
+
+// StorageUsage is the content a user stores and the bytes it takes
+type StorageUsage struct {
+	Notes           int   `json:"notes"`
+	NoteBytes       int64 `json:"noteBytes"`
+	Attachments     int   `json:"attachments"`
+	AttachmentBytes int64 `json:"attachmentBytes"`
+}
+
+// TotalBytes is the storage used by every kind of content
+func (u StorageUsage) TotalBytes() int64 {
+	return u.NoteBytes + u.AttachmentBytes
+}
+
+// Add returns the sum of u and other
+func (u StorageUsage) Add(other StorageUsage) StorageUsage {
+	return StorageUsage{
+		Notes:           u.Notes + other.Notes,
+		NoteBytes:       u.NoteBytes + other.NoteBytes,
+		Attachments:     u.Attachments + other.Attachments,
+		AttachmentBytes: u.AttachmentBytes + other.AttachmentBytes,
+	}
+}
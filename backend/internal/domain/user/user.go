@@ -1,22 +1,53 @@
 package user
 
 import (
+	"log/slog"
 	"time"
-	"errors"
+
 	"golang.org/x/crypto/bcrypt"
+
+	"notes-app/backend/internal/domain/errs"
 )
 
 var (
-	ErrInvalidEmail    = errors.New("invalid email")
-	ErrInvalidPassword = errors.New("invalid password")
+	ErrInvalidEmail    = errs.New(errs.CodeInvalidEmail, "invalid email").WithTarget("/email")
+	ErrInvalidPassword = errs.New(errs.CodeInvalidPassword, "invalid password").WithTarget("/password")
+	ErrUserNotFound    = errs.New(errs.CodeUserNotFound, "user not found")
+	ErrAccountDisabled = errs.New(errs.CodeAccountDisabled, "account disabled")
+)
+
+// Role decides what a user is allowed to do
+type Role string
+
+const (
+	// RoleUser is the default role, limited to the user's own content
+	RoleUser Role = "user"
+	// RoleSupport can look up accounts but not change them
+	RoleSupport Role = "support"
+	// RoleAdmin can look up and manage every account
+	RoleAdmin Role = "admin"
 )
 
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleSupport, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 // User represents the user entity in the domain
 type User struct {
 	ID        string    `json:"id"`
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // "-" ensures password is never serialized to JSON
+	Role      Role      `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
+	// DisabledAt is set while the account is disabled
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	// SessionsRevokedAt invalidates every token issued before it
+	SessionsRevokedAt *time.Time `json:"-"`
 }
 
 // NewUser creates a new user instance with validation
@@ -37,6 +68,7 @@ func NewUser(email, password string) (*User, error) {
 	return &User{
 		Email:     email,
 		Password:  string(hashedPassword),
+		Role:      RoleUser,
 		CreatedAt: time.Now(),
 	}, nil
 }
@@ -60,4 +92,51 @@ func (u *User) UpdatePassword(newPassword string) error {
 
 	u.Password = string(hashedPassword)
 	return nil
-} 
\ No newline at end of file
+}
+
+// Disabled reports whether the account is disabled
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Disable blocks logins and revokes every session of the account
+func (u *User) Disable(now time.Time) {
+	u.DisabledAt = &now
+	u.RevokeSessions(now)
+}
+
+// Enable lets a disabled account log in again. Sessions revoked while
+// it was disabled stay revoked.
+func (u *User) Enable() {
+	u.DisabledAt = nil
+}
+
+// RevokeSessions invalidates every token issued before now
+func (u *User) RevokeSessions(now time.Time) {
+	u.SessionsRevokedAt = &now
+}
+
+// SessionValid reports whether a token issued at issuedAt is still
+// accepted. Tokens carry millisecond issue times, so a token issued in the
+// same millisecond as a revocation is accepted; that is what lets a
+// password change hand out a fresh token.
+func (u *User) SessionValid(issuedAt time.Time) bool {
+	if u.Disabled() {
+		return false
+	}
+	if u.SessionsRevokedAt == nil {
+		return true
+	}
+	return !issuedAt.Before(u.SessionsRevokedAt.Truncate(time.Millisecond))
+}
+
+// LogValue implements slog.LogValuer so that logging a user never
+// writes the password hash
+func (u *User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.ID),
+		slog.String("email", u.Email),
+		slog.String("role", string(u.Role)),
+		slog.Time("created_at", u.CreatedAt),
+	)
+}
@@ -0,0 +1,39 @@
+// Package blob implements domainAttachment.BlobStore on the local file
+// system and on S3-compatible object stores such as MinIO
+package blob
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/infrastructure/blob")
+
+// keyPattern is the shape of the keys a store accepts: slash-separated
+// segments of letters, digits and dashes. It keeps keys from escaping
+// the directory or bucket they are stored in.
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9-]+(/[A-Za-z0-9-]+)*$`)
+
+// checkKey rejects keys that do not match keyPattern
+func checkKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("blob: invalid key %q", key)
+	}
+	return nil
+}
+
+// startSpan starts a client span for an operation of the named store
+func startSpan(ctx context.Context, name, store, key string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("blob.store", store),
+			attribute.String("blob.key", key),
+		),
+	)
+}
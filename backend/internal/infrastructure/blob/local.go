@@ -0,0 +1,101 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// localStore implements domainAttachment.BlobStore in a directory, one
+// file per key
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store keeping blobs under dir, creating it if
+// needed
+func NewLocalStore(dir string) (domainAttachment.BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("blob: create %s: %w", dir, err)
+	}
+	return &localStore{dir: dir}, nil
+}
+
+// Put writes the content to a temporary file and renames it into place,
+// so readers never see a partial blob
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (err error) {
+	_, span := startSpan(ctx, "blobs.Put", "local", key)
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("blob: wrote %d bytes, want %d", n, size)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Get opens the file holding key
+func (s *localStore) Get(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	_, span := startSpan(ctx, "blobs.Get", "local", key)
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errs.Wrap(errs.ErrNotFound, "blobs.Get")
+	}
+	return f, err
+}
+
+// Delete removes the file holding key
+func (s *localStore) Delete(ctx context.Context, key string) (err error) {
+	_, span := startSpan(ctx, "blobs.Delete", "local", key)
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
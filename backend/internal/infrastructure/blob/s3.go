@@ -0,0 +1,181 @@
+package blob
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// S3Config locates a bucket of an S3-compatible object store
+type S3Config struct {
+	// Endpoint is the base URL of the store, such as
+	// https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle puts the bucket in the path instead of the host name, as
+	// MinIO and most self-hosted stores expect
+	PathStyle bool
+	// Timeout bounds each request, transferring the content included,
+	// when Client is nil; defaultS3Timeout if zero
+	Timeout time.Duration
+	// Client sends the requests, a client with Timeout if nil
+	Client *http.Client
+}
+
+// defaultS3Timeout bounds requests when S3Config.Timeout is not set
+const defaultS3Timeout = 5 * time.Minute
+
+// s3Store implements domainAttachment.BlobStore with the S3 REST API
+type s3Store struct {
+	base   *url.URL
+	client *http.Client
+	signer signer
+	now    func() time.Time
+}
+
+// NewS3Store creates a store keeping blobs as objects of a bucket
+func NewS3Store(cfg S3Config) (domainAttachment.BlobStore, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("blob: invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("blob: S3 bucket and region are required")
+	}
+	if cfg.PathStyle {
+		base.Path += "/" + cfg.Bucket
+	} else {
+		base.Host = cfg.Bucket + "." + base.Host
+	}
+
+	client := cfg.Client
+	if client == nil {
+		timeout := cfg.Timeout
+		if timeout == 0 {
+			timeout = defaultS3Timeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	return &s3Store{
+		base:   base,
+		client: client,
+		signer: signer{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			region:          cfg.Region,
+			service:         "s3",
+		},
+		now: time.Now,
+	}, nil
+}
+
+// Put uploads the content with a single PUT. The payload is not hashed
+// into the signature, so it is streamed without being buffered.
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (err error) {
+	ctx, span := startSpan(ctx, "blobs.Put", "s3", key)
+	defer func() { tracing.End(span, err) }()
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads an object
+func (s *s3Store) Get(ctx context.Context, key string) (_ io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "blobs.Get", "s3", key)
+	defer func() { tracing.End(span, err) }()
+
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, errs.Wrap(err, "blobs.Get")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object. S3 reports success for missing objects, and
+// stores that answer 404 instead are treated the same.
+func (s *s3Store) Delete(ctx context.Context, key string) (err error) {
+	ctx, span := startSpan(ctx, "blobs.Delete", "s3", key)
+	defer func() { tracing.End(span, err) }()
+
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// newRequest builds a request for the object holding key
+func (s *s3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	u := *s.base
+	u.Path += "/" + key
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// do signs and sends a request. Answers other than 2xx are returned as
+// errors, 404 as errs.ErrNotFound; the response body is then closed.
+func (s *s3Store) do(req *http.Request) (*http.Response, error) {
+	s.signer.sign(req, unsignedPayload, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errs.ErrNotFound
+	}
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err)
+	return nil, fmt.Errorf("blob: S3 %s %s: %s %s: %s", req.Method, req.URL.Path, resp.Status, s3Err.Code, s3Err.Message)
+}
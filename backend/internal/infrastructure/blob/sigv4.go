@@ -0,0 +1,142 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 constants
+const (
+	sigAlgorithm    = "AWS4-HMAC-SHA256"
+	amzDateLayout   = "20060102T150405Z"
+	amzDayLayout    = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// signer signs requests with AWS Signature Version 4, as S3 and every
+// S3-compatible store expect
+type signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	service         string
+}
+
+// sign adds the X-Amz-Date, X-Amz-Content-Sha256 and Authorization
+// headers. Every header already set on req is signed along with the host,
+// so set them all before signing.
+func (s signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateLayout))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host"}
+	for name := range req.Header {
+		signedHeaders = append(signedHeaders, strings.ToLower(name))
+	}
+	sort.Strings(signedHeaders)
+
+	scope := now.Format(amzDayLayout) + "/" + s.region + "/" + s.service + "/aws4_request"
+	req.Header.Set("Authorization", sigAlgorithm+
+		" Credential="+s.accessKeyID+"/"+scope+
+		", SignedHeaders="+strings.Join(signedHeaders, ";")+
+		", Signature="+s.signature(req, signedHeaders, payloadHash, now))
+}
+
+// signature computes the signature of req over signedHeaders
+func (s signer) signature(req *http.Request, signedHeaders []string, payloadHash string, now time.Time) string {
+	day := now.Format(amzDayLayout)
+	scope := day + "/" + s.region + "/" + s.service + "/aws4_request"
+	stringToSign := sigAlgorithm + "\n" +
+		now.Format(amzDateLayout) + "\n" +
+		scope + "\n" +
+		hashHex([]byte(canonicalRequest(req, signedHeaders, payloadHash)))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalRequest builds the canonical form of req that gets signed
+func canonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		value := host
+		if name != "host" {
+			value = strings.Join(req.Header.Values(name), ",")
+		}
+		headers.WriteString(name + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// canonicalQuery sorts and encodes query parameters
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and
+// slashes unless encodeSlash is set. Already escaped paths are decoded
+// first so they are not encoded twice.
+func uriEncode(s string, encodeSlash bool) string {
+	if unescaped, err := url.PathUnescape(s); err == nil && !encodeSlash {
+		s = unescaped
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
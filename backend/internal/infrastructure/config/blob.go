@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/infrastructure/blob"
+)
+
+// Supported blob store drivers
+const (
+	BlobDriverLocal = "local"
+	BlobDriverS3    = "s3"
+)
+
+// BlobStoreConfig holds where attachment content is stored
+type BlobStoreConfig struct {
+	// Driver is either local or s3
+	Driver string
+	// LocalDir is the directory blobs are written to by the local driver
+	LocalDir string
+	// The S3 settings are used by the s3 driver only, see blob.S3Config
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+	// S3TimeoutSeconds bounds each request to the store, uploads and
+	// downloads included
+	S3TimeoutSeconds int
+}
+
+// NewBlobStore creates the blob store for the configured driver
+func NewBlobStore(config BlobStoreConfig) (domainAttachment.BlobStore, error) {
+	switch config.Driver {
+	case BlobDriverLocal:
+		return blob.NewLocalStore(config.LocalDir)
+	case BlobDriverS3:
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:        config.S3Endpoint,
+			Region:          config.S3Region,
+			Bucket:          config.S3Bucket,
+			AccessKeyID:     config.S3AccessKeyID,
+			SecretAccessKey: config.S3SecretAccessKey,
+			PathStyle:       config.S3PathStyle,
+			Timeout:         time.Duration(config.S3TimeoutSeconds) * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store driver %q", config.Driver)
+	}
+}
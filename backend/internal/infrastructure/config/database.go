@@ -3,11 +3,24 @@ package config
 import (
 	"database/sql"
 	"fmt"
+
 	_ "github.com/lib/pq"
+
+	"notes-app/backend/internal/infrastructure/repository/sqlite"
+)
+
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
 // DatabaseConfig holds all database configuration
 type DatabaseConfig struct {
+	// Driver is either postgres or sqlite
+	Driver string
+	// Path is the SQLite database file, used by the sqlite driver only
+	Path     string
 	Host     string
 	Port     int
 	User     string
@@ -15,21 +28,41 @@ type DatabaseConfig struct {
 	DBName   string
 }
 
-// NewDatabase creates a new database connection
+// NewDatabase creates a new database connection for the configured driver
 func NewDatabase(config DatabaseConfig) (*sql.DB, error) {
+	switch config.Driver {
+	case DriverPostgres:
+		return newPostgres(config)
+	case DriverSQLite:
+		return newSQLite(config)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", config.Driver)
+	}
+}
+
+// newSQLite opens the SQLite database file
+func newSQLite(config DatabaseConfig) (*sql.DB, error) {
+	db, err := sqlite.Open(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	}
+
+	return db, nil
+}
+
+// newPostgres connects to PostgreSQL
+func newPostgres(config DatabaseConfig) (*sql.DB, error) {
 	// Create connection string with all necessary parameters
 	dsn := fmt.Sprintf(
 		"postgresql://%s:%s@%s:%d/%s?sslmode=disable",
 		config.User, config.Password, config.Host, config.Port, config.DBName,
 	)
 
-	// Debug connection string (without password)
-	debugDsn := fmt.Sprintf(
-		"postgresql://%s:****@%s:%d/%s?sslmode=disable",
-		config.User, config.Host, config.Port, config.DBName,
-	)
-	fmt.Printf("Attempting to connect with: %s\n", debugDsn)
-
 	// Open connection
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
@@ -46,4 +79,4 @@ func NewDatabase(config DatabaseConfig) (*sql.DB, error) {
 	}
 
 	return db, nil
-} 
\ No newline at end of file
+}
@@ -4,20 +4,43 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // AppConfig holds all application configuration
 type AppConfig struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
+	Database    DatabaseConfig
+	Server      ServerConfig
+	JWT         JWTConfig
+	Log         LogConfig
+	Tracing     TracingConfig
+	Trash       TrashConfig
+	Attachments AttachmentConfig
+	Import      ImportConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
 	Port int
-	// Add allowed origins for CORS (your Next.js frontend URL)
+	// Add allowed origins for CORS (your Next.js frontend URL).
+	// Entries may be exact origins or wildcard subdomains like https://*.example.com
 	AllowedOrigins []string
+	// CORSMaxAge is how long browsers may cache preflight results, in seconds
+	CORSMaxAge int
+	// ErrorDocsURL is the page that error docUrl links point to
+	ErrorDocsURL string
+	// MaxBodyBytes is the largest request body accepted, in bytes
+	MaxBodyBytes int
+	Security     SecurityConfig
+}
+
+// SecurityConfig holds the security header policy
+type SecurityConfig struct {
+	// HSTSMaxAge is the Strict-Transport-Security max-age in seconds, 0 disables it
+	HSTSMaxAge            int
+	ContentSecurityPolicy string
+	FrameAncestors        string
+	ReferrerPolicy        string
 }
 
 // JWTConfig holds JWT-related configuration
@@ -25,6 +48,75 @@ type JWTConfig struct {
 	Secret string
 }
 
+// LogConfig holds logging configuration
+type LogConfig struct {
+	// Level is one of debug, info, warn, error
+	Level string
+	// Format is either json or text
+	Format string
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	// Exporter is one of none, stdout or otlp
+	Exporter    string
+	ServiceName string
+	SampleRatio float64
+}
+
+// TrashConfig holds the retention of deleted notes
+type TrashConfig struct {
+	// RetentionDays is how long notes stay in the trash, 0 keeps them forever
+	RetentionDays int
+	// PurgeIntervalMinutes is how often expired notes are purged
+	PurgeIntervalMinutes int
+}
+
+// AttachmentConfig holds attachment limits, download links and storage
+type AttachmentConfig struct {
+	// MaxBytes is the largest file accepted. Uploads also count against
+	// Server.MaxBodyBytes, which must leave room for the multipart framing.
+	MaxBytes int
+	// AllowedTypes lists the accepted media types, as sniffed from the
+	// content
+	AllowedTypes []string
+	// LinkSecret signs download links; the JWT secret is used if empty
+	LinkSecret string
+	// LinkTTLMinutes is how long download links stay valid
+	LinkTTLMinutes int
+	// GCGraceHours is how long an attachment no note embeds is kept
+	GCGraceHours int
+	// GCIntervalMinutes is how often orphaned attachments are collected,
+	// 0 disables collection
+	GCIntervalMinutes int
+	Images            ImageConfig
+	Store             BlobStoreConfig
+}
+
+// ImageConfig holds how uploaded images are processed
+type ImageConfig struct {
+	// Workers is how many images are processed at once
+	Workers int
+	// ThumbnailSize is the side of the square thumbnails fit in, in pixels
+	ThumbnailSize int
+	// DisplayWidth is the widest the display variant gets, in pixels
+	DisplayWidth int
+	// MaxPixels is the largest image processed. Decoding, converting and
+	// turning an image keep up to three copies of it at four bytes per
+	// pixel, so each worker takes up to 12 bytes per pixel
+	MaxPixels int
+}
+
+// ImportConfig holds the limits of note imports. Uploaded files also
+// count against Server.MaxBodyBytes.
+type ImportConfig struct {
+	// MaxBytes is the most the files of a zip archive may hold once
+	// uncompressed
+	MaxBytes int
+	// MaxNotes is the most notes an import creates
+	MaxNotes int
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() AppConfig {
 	// Debug: Print all environment variables
@@ -36,6 +128,8 @@ func LoadConfig() AppConfig {
 
 	return AppConfig{
 		Database: DatabaseConfig{
+			Driver:   getEnvOrDefault("DB_DRIVER", DriverPostgres),
+			Path:     getEnvOrDefault("DB_PATH", "notes.db"),
 			Host:     getEnvOrDefault("DB_HOST", "localhost"),
 			Port:     getEnvAsIntOrDefault("DB_PORT", 5432),
 			User:     getEnvOrDefault("DB_USER", "postgres"),
@@ -43,14 +137,65 @@ func LoadConfig() AppConfig {
 			DBName:   getEnvOrDefault("DB_NAME", "notes_app"),
 		},
 		Server: ServerConfig{
-			Port: getEnvAsIntOrDefault("SERVER_PORT", 8080),
-			AllowedOrigins: []string{
-				getEnvOrDefault("FRONTEND_URL", "http://localhost:3000"),
+			Port:           getEnvAsIntOrDefault("SERVER_PORT", 8080),
+			AllowedOrigins: getEnvAsListOrDefault("CORS_ALLOWED_ORIGINS", []string{getEnvOrDefault("FRONTEND_URL", "http://localhost:3000")}),
+			CORSMaxAge:     getEnvAsIntOrDefault("CORS_MAX_AGE", 600),
+			ErrorDocsURL:   os.Getenv("ERROR_DOCS_URL"),
+			MaxBodyBytes:   getEnvAsIntOrDefault("MAX_BODY_BYTES", 10<<20),
+			Security: SecurityConfig{
+				HSTSMaxAge:            getEnvAsIntOrDefault("SECURITY_HSTS_MAX_AGE", 31536000),
+				ContentSecurityPolicy: getEnvOrDefault("SECURITY_CSP", "default-src 'none'"),
+				FrameAncestors:        getEnvOrDefault("SECURITY_FRAME_ANCESTORS", "'none'"),
+				ReferrerPolicy:        getEnvOrDefault("SECURITY_REFERRER_POLICY", "no-referrer"),
 			},
 		},
 		JWT: JWTConfig{
 			Secret: getEnvOrDefault("JWT_SECRET", "your-secret-key"),
 		},
+		Log: LogConfig{
+			Level:  getEnvOrDefault("LOG_LEVEL", "info"),
+			Format: getEnvOrDefault("LOG_FORMAT", "json"),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnvOrDefault("TRACING_EXPORTER", "none"),
+			ServiceName: getEnvOrDefault("OTEL_SERVICE_NAME", "notes-app-backend"),
+			SampleRatio: getEnvAsFloatOrDefault("TRACING_SAMPLE_RATIO", 1),
+		},
+		Trash: TrashConfig{
+			RetentionDays:        getEnvAsIntOrDefault("TRASH_RETENTION_DAYS", 30),
+			PurgeIntervalMinutes: getEnvAsIntOrDefault("TRASH_PURGE_INTERVAL_MINUTES", 60),
+		},
+		Attachments: AttachmentConfig{
+			MaxBytes: getEnvAsIntOrDefault("ATTACHMENT_MAX_BYTES", 8<<20),
+			AllowedTypes: getEnvAsListOrDefault("ATTACHMENT_ALLOWED_TYPES", []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain",
+			}),
+			LinkSecret:        os.Getenv("ATTACHMENT_LINK_SECRET"),
+			LinkTTLMinutes:    getEnvAsIntOrDefault("ATTACHMENT_LINK_TTL_MINUTES", 15),
+			GCGraceHours:      getEnvAsIntOrDefault("ATTACHMENT_GC_GRACE_HOURS", 24),
+			GCIntervalMinutes: getEnvAsIntOrDefault("ATTACHMENT_GC_INTERVAL_MINUTES", 60),
+			Images: ImageConfig{
+				Workers:       getEnvAsIntOrDefault("IMAGE_WORKERS", 2),
+				ThumbnailSize: getEnvAsIntOrDefault("IMAGE_THUMBNAIL_SIZE", 256),
+				DisplayWidth:  getEnvAsIntOrDefault("IMAGE_DISPLAY_WIDTH", 1280),
+				MaxPixels:     getEnvAsIntOrDefault("IMAGE_MAX_PIXELS", 25_000_000),
+			},
+			Store: BlobStoreConfig{
+				Driver:            getEnvOrDefault("BLOB_STORE", BlobDriverLocal),
+				LocalDir:          getEnvOrDefault("BLOB_LOCAL_DIR", "data/attachments"),
+				S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+				S3Region:          getEnvOrDefault("S3_REGION", "us-east-1"),
+				S3Bucket:          os.Getenv("S3_BUCKET"),
+				S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+				S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+				S3PathStyle:       getEnvAsBoolOrDefault("S3_PATH_STYLE", true),
+				S3TimeoutSeconds:  getEnvAsIntOrDefault("S3_TIMEOUT_SECONDS", 300),
+			},
+		},
+		Import: ImportConfig{
+			MaxBytes: getEnvAsIntOrDefault("IMPORT_MAX_BYTES", 100<<20),
+			MaxNotes: getEnvAsIntOrDefault("IMPORT_MAX_NOTES", 500),
+		},
 	}
 }
 
@@ -72,4 +217,39 @@ func getEnvAsIntOrDefault(key string, defaultValue int) int {
 	}
 	fmt.Printf("Warning: Using default value for %s: %d\n", key, defaultValue)
 	return defaultValue
-} 
\ No newline at end of file
+}
+
+func getEnvAsFloatOrDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		fmt.Printf("Warning: Could not parse %s value: %s\n", key, value)
+	}
+	fmt.Printf("Warning: Using default value for %s: %g\n", key, defaultValue)
+	return defaultValue
+}
+
+func getEnvAsBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		fmt.Printf("Warning: Could not parse %s value: %s\n", key, value)
+	}
+	fmt.Printf("Warning: Using default value for %s: %t\n", key, defaultValue)
+	return defaultValue
+}
+
+func getEnvAsListOrDefault(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultValue
+}
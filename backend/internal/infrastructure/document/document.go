@@ -0,0 +1,240 @@
+// Package document converts notes, stored as Quill deltas, to other
+// document formats.
+//
+// A delta is read line by line: the attributes of each newline are the
+// block formats of the line before it (header, list, blockquote,
+// code-block, table), the attributes of the other inserts are inline
+// formats (bold, italic, link...). Consecutive lines of a list, code
+// block, quote or table are rendered as one block.
+package document
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// Options tunes the conversion of a note
+type Options struct {
+	// RewriteURL maps the address of links and embedded images, for
+	// instance to attachments bundled next to the document. Nil keeps
+	// addresses unchanged.
+	RewriteURL func(url string) string
+	// LoadImage returns the content of an image the note embeds, for the
+	// formats that include images rather than link to them (PDF). Nil,
+	// or a nil result, shows its alternative text instead.
+	LoadImage func(url string) []byte
+}
+
+// url returns the address to render for u, or "" when it cannot be
+// linked to safely
+func (o Options) url(u string) string {
+	u = strings.TrimSpace(u)
+	if !domainNote.SafeURL(u) {
+		return ""
+	}
+	if o.RewriteURL != nil {
+		return o.RewriteURL(u)
+	}
+	return u
+}
+
+// run is a piece of text, or an embed, sharing inline attributes
+type run struct {
+	text  string
+	embed map[string]any
+	attrs map[string]any
+}
+
+// line is one line of a document and the block attributes of its
+// newline
+type line struct {
+	runs  []run
+	attrs map[string]any
+}
+
+// text returns the text of the line, without embeds
+func (l line) text() string {
+	var b strings.Builder
+	for _, r := range l.runs {
+		b.WriteString(r.text)
+	}
+	return b.String()
+}
+
+// blank reports whether the line holds nothing but whitespace
+func (l line) blank() bool {
+	for _, r := range l.runs {
+		if r.embed != nil || strings.TrimSpace(r.text) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLines cuts a document into lines. Text after the last newline,
+// which Quill never produces, makes a line of its own.
+func splitLines(content domainNote.Delta) []line {
+	var lines []line
+	var current line
+	for _, op := range content.Ops {
+		switch insert := op.Insert.(type) {
+		case string:
+			for {
+				i := strings.IndexByte(insert, '\n')
+				if i < 0 {
+					break
+				}
+				if i > 0 {
+					current.runs = append(current.runs, run{text: insert[:i], attrs: op.Attributes})
+				}
+				current.attrs = op.Attributes
+				lines = append(lines, current)
+				current = line{}
+				insert = insert[i+1:]
+			}
+			if insert != "" {
+				current.runs = append(current.runs, run{text: insert, attrs: op.Attributes})
+			}
+		case map[string]any:
+			current.runs = append(current.runs, run{embed: insert, attrs: op.Attributes})
+		}
+	}
+	if len(current.runs) > 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// blockKind is the kind of a block of lines
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockQuote
+	blockCode
+	blockList
+	blockTable
+)
+
+// block is a run of lines rendered together. Paragraphs and headings
+// always hold one line.
+type block struct {
+	kind  blockKind
+	lines []line
+}
+
+// kindOf returns the kind of block a line belongs to
+func kindOf(l line) blockKind {
+	switch {
+	case stringAttr(l.attrs, "table") != "":
+		return blockTable
+	case boolAttr(l.attrs, "code-block"):
+		return blockCode
+	case stringAttr(l.attrs, "list") != "":
+		return blockList
+	case intAttr(l.attrs, "header") > 0:
+		return blockHeading
+	case boolAttr(l.attrs, "blockquote"):
+		return blockQuote
+	}
+	return blockParagraph
+}
+
+// splitBlocks groups the lines of a document into blocks
+func splitBlocks(content domainNote.Delta) []block {
+	var blocks []block
+	for _, l := range splitLines(content) {
+		kind := kindOf(l)
+		grouped := kind != blockParagraph && kind != blockHeading
+		if n := len(blocks); grouped && n > 0 && blocks[n-1].kind == kind {
+			blocks[n-1].lines = append(blocks[n-1].lines, l)
+			continue
+		}
+		blocks = append(blocks, block{kind: kind, lines: []line{l}})
+	}
+	return blocks
+}
+
+// tableRows groups the cells of a table block by row
+func tableRows(lines []line) [][]line {
+	var rows [][]line
+	previous := ""
+	for _, l := range lines {
+		row := stringAttr(l.attrs, "table")
+		if len(rows) == 0 || row != previous {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], l)
+		previous = row
+	}
+	return rows
+}
+
+// headingLevel returns the level of a heading, between 1 and 6
+func headingLevel(l line) int {
+	return min(max(intAttr(l.attrs, "header"), 1), 6)
+}
+
+// codeLanguage returns the language of a code block, if it names one
+func codeLanguage(l line) string {
+	switch lang := stringAttr(l.attrs, "code-block"); lang {
+	case "", "true", "plain":
+		return ""
+	default:
+		return lang
+	}
+}
+
+// listItem describes a list line: its type (bullet, ordered, checked or
+// unchecked) and its nesting level
+func listItem(l line) (kind string, level int) {
+	return stringAttr(l.attrs, "list"), max(intAttr(l.attrs, "indent"), 0)
+}
+
+// embedURL returns the address of an image or video embed, and whether
+// the embed is one
+func embedURL(embed map[string]any, kind string) (string, bool) {
+	s, ok := embed[kind].(string)
+	return s, ok
+}
+
+// stringAttr returns a string attribute, or "" when it is missing or not
+// a string
+func stringAttr(attrs map[string]any, name string) string {
+	s, _ := attrs[name].(string)
+	return s
+}
+
+// boolAttr reports whether a flag attribute is set. Some flags, such as
+// code-block, may carry a string value instead of true.
+func boolAttr(attrs map[string]any, name string) bool {
+	switch v := attrs[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	return false
+}
+
+// intAttr returns a numeric attribute, or 0. Numbers come as float64 or
+// json.Number depending on how the delta was decoded.
+func intAttr(attrs map[string]any, name string) int {
+	switch v := attrs[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,279 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// testDelta exercises every block and the common inline formats
+const testDelta = `{"ops":[
+	{"insert":"Intro "},
+	{"insert":"bold ","attributes":{"bold":true}},
+	{"insert":"and both","attributes":{"bold":true,"italic":true}},
+	{"insert":", "},
+	{"insert":"a link","attributes":{"link":"https://example.com/a b"}},
+	{"insert":", "},
+	{"insert":"a script","attributes":{"link":"javascript:alert(1)"}},
+	{"insert":" and 1*2 <x>\n"},
+	{"insert":"Plan"},{"insert":"\n","attributes":{"header":2}},
+	{"insert":"1. not a list\n"},
+	{"insert":"one"},{"insert":"\n","attributes":{"list":"ordered"}},
+	{"insert":"nested"},{"insert":"\n","attributes":{"list":"bullet","indent":1}},
+	{"insert":"two"},{"insert":"\n","attributes":{"list":"ordered"}},
+	{"insert":"done"},{"insert":"\n","attributes":{"list":"checked"}},
+	{"insert":"todo"},{"insert":"\n","attributes":{"list":"unchecked"}},
+	{"insert":"x := 1"},{"insert":"\n","attributes":{"code-block":"go"}},
+	{"insert":"quoted"},{"insert":"\n","attributes":{"blockquote":true}},
+	{"insert":"a"},{"insert":"\n","attributes":{"table":"row-1"}},
+	{"insert":"b|c"},{"insert":"\n","attributes":{"table":"row-1"}},
+	{"insert":"d"},{"insert":"\n","attributes":{"table":"row-2"}},
+	{"insert":"e"},{"insert":"\n","attributes":{"table":"row-2"}},
+	{"insert":{"image":"/api/v1/attachments/3f0c2d4e-1111-4222-8333-444455556666/content"},"attributes":{"alt":"A cat"}},
+	{"insert":"\n\n"}
+]}`
+
+func parse(t *testing.T, data string) domainNote.Delta {
+	t.Helper()
+	d, err := domainNote.ParseDelta([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseDelta: %v", err)
+	}
+	return d
+}
+
+// rewrite points attachments at a bundled copy
+func rewrite(u string) string {
+	return strings.Replace(u, "/api/v1/attachments/3f0c2d4e-1111-4222-8333-444455556666/content", "attachments/cat.png", 1)
+}
+
+func TestMarkdown(t *testing.T) {
+	got := string(Markdown("My *note*", parse(t, testDelta), Options{RewriteURL: rewrite}))
+	want := "# My \\*note\\*\n" +
+		"\n" +
+		"Intro **bold *and both***, [a link](https://example.com/a%20b), a script and 1\\*2 \\<x\\>\n" +
+		"\n" +
+		"## Plan\n" +
+		"\n" +
+		"1\\. not a list\n" +
+		"\n" +
+		"1. one\n" +
+		"   - nested\n" +
+		"2. two\n" +
+		"- [x] done\n" +
+		"- [ ] todo\n" +
+		"\n" +
+		"```go\n" +
+		"x := 1\n" +
+		"```\n" +
+		"\n" +
+		"> quoted\n" +
+		"\n" +
+		"| a | b\\|c |\n" +
+		"| --- | --- |\n" +
+		"| d | e |\n" +
+		"\n" +
+		"![A cat](attachments/cat.png)\n"
+	if got != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdownEscaping(t *testing.T) {
+	tests := []struct {
+		name  string
+		delta string
+		want  string
+	}{
+		{
+			name:  "code with backticks",
+			delta: `{"ops":[{"insert":"a ` + "`" + `b","attributes":{"code":true}},{"insert":"\n"}]}`,
+			want:  "``a `b``\n",
+		},
+		{
+			name:  "fence longer than the code",
+			delta: `{"ops":[{"insert":"` + "```" + `"},{"insert":"\n","attributes":{"code-block":true}}]}`,
+			want:  "````\n```\n````\n",
+		},
+		{
+			name:  "spaces outside delimiters",
+			delta: `{"ops":[{"insert":"a"},{"insert":" b ","attributes":{"italic":true}},{"insert":"c\n"}]}`,
+			want:  "a *b* c\n",
+		},
+		{
+			name:  "thematic break",
+			delta: `{"ops":[{"insert":"---\n"}]}`,
+			want:  "\\---\n",
+		},
+		{
+			name:  "entity",
+			delta: `{"ops":[{"insert":"Tom & Jerry &amp;\n"}]}`,
+			want:  "Tom & Jerry \\&amp;\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Markdown("", parse(t, tt.delta), Options{})); got != tt.want {
+				t.Errorf("Markdown = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := string(HTML("My <note>", parse(t, testDelta), Options{RewriteURL: rewrite}))
+	for _, want := range []string{
+		"<title>My &lt;note&gt;</title>",
+		"<h1>My &lt;note&gt;</h1>",
+		`<p>Intro <strong>bold <em>and both</em></strong>, <a href="https://example.com/a b">a link</a>, a script and 1*2 &lt;x&gt;</p>`,
+		"<h2>Plan</h2>",
+		"<ol>\n<li>one\n<ul>\n<li>nested</li>\n</ul>\n</li>\n<li>two</li>\n</ol>",
+		`<ul class="checklist">` + "\n" + `<li><input type="checkbox" disabled checked> done</li>`,
+		`<pre><code class="language-go">x := 1</code></pre>`,
+		"<blockquote>\n<p>quoted</p>\n</blockquote>",
+		"<tr><td>a</td><td>b|c</td></tr>",
+		`<img src="attachments/cat.png" alt="A cat">`,
+		"<p><br></p>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "javascript") {
+		t.Errorf("HTML links to a script:\n%s", got)
+	}
+}
+
+func TestText(t *testing.T) {
+	got := string(Text("My note", parse(t, testDelta)))
+	want := "My note\n" +
+		"\n" +
+		"Intro bold and both, a link, a script and 1*2 <x>\n" +
+		"Plan\n" +
+		"1. not a list\n" +
+		"1. one\n" +
+		"  - nested\n" +
+		"2. two\n" +
+		"[x] done\n" +
+		"[ ] todo\n" +
+		"x := 1\n" +
+		"> quoted\n" +
+		"a\tb|c\n" +
+		"d\te\n" +
+		"A cat\n"
+	if got != want {
+		t.Errorf("Text =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPDF(t *testing.T) {
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 40, 30)))
+	var loaded []string
+	opts := Options{LoadImage: func(u string) []byte {
+		loaded = append(loaded, u)
+		return img.Bytes()
+	}}
+
+	long := `{"ops":[{"insert":"` + strings.Repeat("A long paragraph of words wrapping across lines.\\n", 120) + `"}]}`
+	out, missing := PDF("My notes", []Section{
+		{Title: "My note", Content: parse(t, testDelta)},
+		{Title: "", Content: parse(t, long)},
+	}, opts)
+	if len(missing) != 0 {
+		t.Errorf("PDF misses %q", string(missing))
+	}
+
+	s := string(out)
+	if !strings.HasPrefix(s, "%PDF-") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Fatalf("PDF is not a PDF document:\n%.200s", s)
+	}
+	if !strings.Contains(s, "/Count 4 >>") {
+		t.Errorf("PDF lacks its 4 pages:\n%s", s)
+	}
+	for _, want := range []string{"/Subtype /Image", "/URI (https://example.com/a%20b)", "/Title <FEFF004D0079"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+	if strings.Contains(s, "javascript") {
+		t.Error("PDF links to a script")
+	}
+	if len(loaded) != 1 || loaded[0] != "/api/v1/attachments/3f0c2d4e-1111-4222-8333-444455556666/content" {
+		t.Errorf("LoadImage called with %q", loaded)
+	}
+
+	texts := pdfTexts(out)
+	for _, want := range []string{"My note", "Untitled", "Plan", "2.", "x := 1", "My notes", "4 / 4"} {
+		if !texts[want] {
+			t.Errorf("PDF does not show %q", want)
+		}
+	}
+}
+
+func TestPDFCharacters(t *testing.T) {
+	content := parse(t, `{"ops":[{"insert":"Dağ yolu, Şişli, İzmir\nПривет, Ελλάδα\n"},`+
+		`{"insert":"東京 ok","attributes":{"code":true}},{"insert":"\n"}]}`)
+	out, missing := PDF("Notlar", []Section{{Title: "Gezi ığdır", Content: content}}, Options{})
+
+	texts := pdfTexts(out)
+	// The boxes printed for 東京 map back to no text
+	for _, want := range []string{"Gezi ığdır", "Dağ yolu, Şişli, İzmir", "Привет, Ελλάδα", " ok"} {
+		if !texts[want] {
+			t.Errorf("PDF does not show %q", want)
+		}
+	}
+	if string(missing) != "京東" {
+		t.Errorf("PDF misses %q, want the characters its fonts lack", string(missing))
+	}
+}
+
+// pdfTexts returns the strings a PDF document shows, read back through the
+// ToUnicode maps of its fonts
+func pdfTexts(doc []byte) map[string]bool {
+	var streams []string
+	for _, part := range bytes.Split(doc, []byte("stream\n"))[1:] {
+		if zr, err := zlib.NewReader(bytes.NewReader(part)); err == nil {
+			data, _ := io.ReadAll(zr)
+			streams = append(streams, string(data))
+		}
+	}
+	var maps []map[string]string
+	entry := regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`)
+	for _, s := range streams {
+		if _, chars, ok := strings.Cut(s, "endcodespacerange"); ok {
+			m := make(map[string]string)
+			for _, e := range entry.FindAllStringSubmatch(chars, -1) {
+				utf16, _ := hex.DecodeString(e[2])
+				var r []rune
+				for i := 0; i+1 < len(utf16); i += 2 {
+					r = append(r, rune(utf16[i])<<8|rune(utf16[i+1]))
+				}
+				m[e[1]] = string(r)
+			}
+			maps = append(maps, m)
+		}
+	}
+	texts := make(map[string]bool)
+	shown := regexp.MustCompile(`<([0-9A-F]*)> Tj`)
+	for _, s := range streams {
+		for _, tj := range shown.FindAllStringSubmatch(s, -1) {
+			for _, m := range maps {
+				var b strings.Builder
+				for i := 0; i+4 <= len(tj[1]); i += 4 {
+					b.WriteString(m[tj[1][i:i+4]])
+				}
+				texts[b.String()] = true
+			}
+		}
+	}
+	return texts
+}
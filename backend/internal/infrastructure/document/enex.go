@@ -0,0 +1,135 @@
+package document
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotENEX reports a file that is not an Evernote export
+var ErrNotENEX = errors.New("not an Evernote export")
+
+// ENEXNote is a note of an Evernote export
+type ENEXNote struct {
+	Title string
+	// Content is the ENML of the note, a dialect of XHTML
+	Content   string
+	Resources []Resource
+}
+
+// Resource is a file an Evernote note embeds. Notes refer to it by the
+// MD5 hash of its content.
+type Resource struct {
+	Hash      string
+	Name      string
+	MediaType string
+	Data      []byte
+}
+
+// IsImage reports whether the resource is an image
+func (r Resource) IsImage() bool {
+	return strings.HasPrefix(r.MediaType, "image/")
+}
+
+// enexExport is the XML of an Evernote export
+type enexExport struct {
+	XMLName xml.Name `xml:"en-export"`
+	Notes   []struct {
+		Title     string `xml:"title"`
+		Content   string `xml:"content"`
+		Resources []struct {
+			Data     string `xml:"data"`
+			Mime     string `xml:"mime"`
+			FileName string `xml:"resource-attributes>file-name"`
+		} `xml:"resource"`
+	} `xml:"note"`
+}
+
+// ParseENEX reads the notes of an Evernote export (.enex)
+func ParseENEX(r io.Reader) ([]ENEXNote, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	var export enexExport
+	if err := dec.Decode(&export); err != nil {
+		var syntaxErr *xml.SyntaxError
+		var tagErr xml.UnmarshalError
+		if errors.As(err, &syntaxErr) || errors.As(err, &tagErr) || errors.Is(err, io.EOF) {
+			return nil, ErrNotENEX
+		}
+		return nil, err
+	}
+
+	notes := make([]ENEXNote, 0, len(export.Notes))
+	for _, n := range export.Notes {
+		note := ENEXNote{Title: strings.TrimSpace(n.Title), Content: n.Content}
+		for _, res := range n.Resources {
+			data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(res.Data), ""))
+			if err != nil {
+				return nil, ErrNotENEX
+			}
+			sum := md5.Sum(data)
+			note.Resources = append(note.Resources, Resource{
+				Hash:      hex.EncodeToString(sum[:]),
+				Name:      strings.TrimSpace(res.FileName),
+				MediaType: strings.ToLower(strings.TrimSpace(res.Mime)),
+				Data:      data,
+			})
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// Document converts the note. attach stores a resource the note embeds
+// and returns the address to refer to it with, or "" to leave it out; it
+// is called once per resource. Images are embedded, other files linked to
+// under their name, which is all that is left of them without address.
+func (n ENEXNote) Document(opts ParseOptions, attach func(Resource) string) Document {
+	resources := make(map[string]Resource, len(n.Resources))
+	for _, r := range n.Resources {
+		resources[r.Hash] = r
+	}
+	addresses := make(map[string]string)
+
+	p := newHTMLParser(opts)
+	p.titled = true
+	p.media = func(attrs map[string]string) []run {
+		hash := strings.ToLower(attrs["hash"])
+		r, ok := resources[hash]
+		if !ok {
+			return nil
+		}
+		address, attached := addresses[hash]
+		if !attached {
+			address = attach(r)
+			addresses[hash] = address
+		}
+		name := r.Name
+		if name == "" {
+			name = "attachment"
+		}
+		switch {
+		case address == "" && r.IsImage():
+			return nil
+		case address == "":
+			return []run{{text: name}}
+		case r.IsImage():
+			attrs := map[string]any{}
+			if r.Name != "" {
+				attrs["alt"] = r.Name
+			}
+			return []run{{embed: map[string]any{"image": address}, attrs: attrs}}
+		default:
+			return []run{{text: name, attrs: map[string]any{"link": address}}}
+		}
+	}
+	p.parse(n.Content)
+	doc := p.document()
+	doc.Title = n.Title
+	return doc
+}
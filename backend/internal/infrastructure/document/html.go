@@ -0,0 +1,243 @@
+package document
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// htmlStyle keeps exported pages readable without any other file
+const htmlStyle = `body { max-width: 48em; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.5; }
+pre { background: #f5f5f5; padding: 0.75em; overflow-x: auto; }
+blockquote { border-left: 4px solid #ccc; margin-left: 0; padding-left: 1em; color: #555; }
+table { border-collapse: collapse; }
+td { border: 1px solid #ccc; padding: 0.25em 0.5em; }
+ul.checklist { list-style: none; padding-left: 1.5em; }
+img { max-width: 100%; }`
+
+// cssColor matches the colors Quill writes, which are safe to put in a
+// style attribute
+var cssColor = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|rgba?\([0-9., %]+\))$`)
+
+// HTML renders a note as a standalone HTML5 page, titled with a level 1
+// heading. Links and images with an address that could run code are
+// dropped.
+func HTML(title string, content domainNote.Delta, opts Options) []byte {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>")
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</title>\n<style>\n" + htmlStyle + "\n</style>\n</head>\n<body>\n")
+	if title != "" {
+		b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	}
+	for _, blk := range splitBlocks(content) {
+		writeHTMLBlock(&b, blk, opts)
+	}
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String())
+}
+
+// writeHTMLBlock renders a block
+func writeHTMLBlock(b *strings.Builder, blk block, opts Options) {
+	switch blk.kind {
+	case blockHeading:
+		l := blk.lines[0]
+		tag := "h" + strconv.Itoa(headingLevel(l))
+		b.WriteString("<" + tag + alignAttr(l) + ">" + htmlLine(l, opts) + "</" + tag + ">\n")
+
+	case blockQuote:
+		b.WriteString("<blockquote>\n")
+		for _, l := range blk.lines {
+			b.WriteString("<p" + alignAttr(l) + ">" + htmlLine(l, opts) + "</p>\n")
+		}
+		b.WriteString("</blockquote>\n")
+
+	case blockCode:
+		class := ""
+		if lang := codeLanguage(blk.lines[0]); lang != "" {
+			class = ` class="language-` + html.EscapeString(lang) + `"`
+		}
+		var code []string
+		for _, l := range blk.lines {
+			code = append(code, html.EscapeString(l.text()))
+		}
+		b.WriteString("<pre><code" + class + ">" + strings.Join(code, "\n") + "</code></pre>\n")
+
+	case blockList:
+		writeHTMLList(b, blk.lines, opts)
+
+	case blockTable:
+		b.WriteString("<table>\n")
+		for _, row := range tableRows(blk.lines) {
+			b.WriteString("<tr>")
+			for _, cell := range row {
+				b.WriteString("<td>" + htmlLine(cell, opts) + "</td>")
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</table>\n")
+
+	default:
+		l := blk.lines[0]
+		text := htmlLine(l, opts)
+		if text == "" {
+			// Quill keeps empty lines as paragraphs holding a break
+			text = "<br>"
+		}
+		b.WriteString("<p" + alignAttr(l) + ">" + text + "</p>\n")
+	}
+}
+
+// writeHTMLList renders the items of a list, nesting lists in the item
+// before them as they are indented
+func writeHTMLList(b *strings.Builder, lines []line, opts Options) {
+	type list struct {
+		tag   string
+		class string
+	}
+	// open holds the lists open, each with an open item
+	var open []list
+	closeList := func() {
+		b.WriteString("</li>\n</" + open[len(open)-1].tag + ">\n")
+		open = open[:len(open)-1]
+	}
+
+	for _, l := range lines {
+		kind, depth := listItem(l)
+		depth = min(depth, len(open))
+		want := list{tag: "ul"}
+		switch kind {
+		case "ordered":
+			want.tag = "ol"
+		case "checked", "unchecked":
+			want.class = "checklist"
+		}
+
+		for len(open) > depth+1 {
+			closeList()
+		}
+		if len(open) == depth+1 {
+			if open[depth] == want {
+				b.WriteString("</li>\n")
+			} else {
+				closeList()
+			}
+		}
+		if len(open) == depth {
+			if depth > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("<" + want.tag)
+			if want.class != "" {
+				b.WriteString(` class="` + want.class + `"`)
+			}
+			b.WriteString(">\n")
+			open = append(open, want)
+		}
+
+		b.WriteString("<li" + alignAttr(l) + ">")
+		switch kind {
+		case "checked":
+			b.WriteString(`<input type="checkbox" disabled checked> `)
+		case "unchecked":
+			b.WriteString(`<input type="checkbox" disabled> `)
+		}
+		b.WriteString(htmlLine(l, opts))
+	}
+	for len(open) > 0 {
+		closeList()
+	}
+}
+
+// alignAttr returns the style attribute of an aligned line, or ""
+func alignAttr(l line) string {
+	switch align := stringAttr(l.attrs, "align"); align {
+	case "center", "right", "justify":
+		return ` style="text-align: ` + align + `"`
+	}
+	return ""
+}
+
+// htmlLine renders the inline content of a line
+func htmlLine(l line, opts Options) string {
+	var b strings.Builder
+	writeInline(&b, l.runs, func(r run) []mark {
+		return htmlMarks(r, opts)
+	}, func(r run) string {
+		return htmlRun(r, opts)
+	}, false)
+	return b.String()
+}
+
+// htmlMarks returns the marks of a run, outermost first
+func htmlMarks(r run, opts Options) []mark {
+	var marks []mark
+	if href := opts.url(stringAttr(r.attrs, "link")); href != "" {
+		marks = append(marks, mark{key: "link " + href, open: `<a href="` + html.EscapeString(href) + `">`, close: "</a>"})
+	}
+	for _, format := range []struct{ attr, tag string }{
+		{"bold", "strong"},
+		{"italic", "em"},
+		{"strike", "s"},
+		{"underline", "u"},
+	} {
+		if boolAttr(r.attrs, format.attr) {
+			marks = append(marks, mark{key: format.attr, open: "<" + format.tag + ">", close: "</" + format.tag + ">"})
+		}
+	}
+	switch stringAttr(r.attrs, "script") {
+	case "sub":
+		marks = append(marks, mark{key: "sub", open: "<sub>", close: "</sub>"})
+	case "super":
+		marks = append(marks, mark{key: "super", open: "<sup>", close: "</sup>"})
+	}
+
+	var style []string
+	if color := stringAttr(r.attrs, "color"); cssColor.MatchString(color) {
+		style = append(style, "color: "+color)
+	}
+	if background := stringAttr(r.attrs, "background"); cssColor.MatchString(background) {
+		style = append(style, "background-color: "+background)
+	}
+	if len(style) > 0 {
+		value := strings.Join(style, "; ")
+		marks = append(marks, mark{key: "style " + value, open: `<span style="` + value + `">`, close: "</span>"})
+	}
+	return marks
+}
+
+// htmlRun renders the content of a run
+func htmlRun(r run, opts Options) string {
+	if r.embed != nil {
+		if src, ok := embedURL(r.embed, "image"); ok {
+			alt := html.EscapeString(stringAttr(r.attrs, "alt"))
+			if src = opts.url(src); src == "" {
+				return alt
+			}
+			size := ""
+			for _, dimension := range []string{"width", "height"} {
+				if n := intAttr(r.attrs, dimension); n > 0 {
+					size += " " + dimension + `="` + strconv.Itoa(n) + `"`
+				}
+			}
+			return `<img src="` + html.EscapeString(src) + `" alt="` + alt + `"` + size + ">"
+		}
+		if src, ok := embedURL(r.embed, "video"); ok {
+			if src = opts.url(src); src == "" {
+				return ""
+			}
+			return `<a href="` + html.EscapeString(src) + `">` + html.EscapeString(src) + "</a>"
+		}
+		if formula, ok := r.embed["formula"].(string); ok {
+			return "<code>" + html.EscapeString(formula) + "</code>"
+		}
+		return ""
+	}
+	if boolAttr(r.attrs, "code") {
+		return "<code>" + html.EscapeString(r.text) + "</code>"
+	}
+	return html.EscapeString(r.text)
+}
@@ -0,0 +1,640 @@
+package document
+
+import (
+	"html"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// whitespace matches the whitespace HTML collapses
+var whitespace = regexp.MustCompile(`[ \t\n\r\f]+`)
+
+// htmlBlocks lists the elements that hold lines of their own
+var htmlBlocks = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"center": true, "dd": true, "div": true, "dl": true, "dt": true, "en-note": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"html": true, "li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "tbody": true, "td": true,
+	"tfoot": true, "th": true, "thead": true, "tr": true, "ul": true,
+}
+
+// htmlVoid lists the elements that have no content nor end tag
+var htmlVoid = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "en-media": true,
+	"en-todo": true, "hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// htmlSkipped lists the elements whose content is not part of the
+// document. Scripts, styles, text areas and the title hold raw text and
+// are skipped as they are read.
+var htmlSkipped = map[string]bool{
+	"button": true, "iframe": true, "math": true, "noscript": true, "object": true,
+	"select": true, "svg": true, "template": true,
+}
+
+// ParseHTML reads an HTML document, such as a page saved from a browser
+// or another note-taking app. The title is the one of the title element
+// or of a level 1 heading opening the body, which is then left out of
+// the content when it repeats the title.
+//
+// Scripts, styles and forms are dropped, along with every attribute but
+// links, image addresses and sizes, alignment and the inline styles that
+// make text bold, italic, underlined, struck or colored.
+func ParseHTML(data []byte, opts ParseOptions) Document {
+	p := newHTMLParser(opts)
+	p.parse(strings.ToValidUTF8(string(data), "\uFFFD"))
+	return p.document()
+}
+
+// htmlElement is an element open while the document is read
+type htmlElement struct {
+	name  string
+	attrs map[string]string
+	// link is the address an a element links to, once resolved
+	link string
+}
+
+// htmlParser converts HTML to a delta as it reads it
+type htmlParser struct {
+	opts  ParseOptions
+	b     builder
+	title string
+	// titled is set once the title can no longer come from a heading
+	titled bool
+	// runs are the runs of the line being read
+	runs  []run
+	stack []htmlElement
+	// skipping is the element whose content is dropped, and depth how
+	// many elements of that name are open
+	skipping string
+	depth    int
+	// row is the ID of the table row being read
+	row string
+	// task is the state of a checkbox opening the line, if any
+	task string
+	// media returns the runs an en-media element of an Evernote note
+	// stands for
+	media func(attrs map[string]string) []run
+}
+
+func newHTMLParser(opts ParseOptions) *htmlParser {
+	return &htmlParser{opts: opts}
+}
+
+// document ends the document and returns it
+func (p *htmlParser) document() Document {
+	p.flush(false)
+	return Document{Title: strings.TrimSpace(whitespace.ReplaceAllString(p.title, " ")), Content: p.b.delta()}
+}
+
+// parse reads the tags and text of s
+func (p *htmlParser) parse(s string) {
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			j := strings.IndexByte(s[i:], '<')
+			if j < 0 {
+				j = len(s) - i
+			}
+			p.text(html.UnescapeString(s[i : i+j]))
+			i += j
+			continue
+		}
+
+		rest := s[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			i = skipPast(s, i+4, "-->")
+		case strings.HasPrefix(rest, "<![CDATA["):
+			end := strings.Index(rest, "]]>")
+			if end < 0 {
+				end = len(rest)
+			}
+			p.text(rest[len("<![CDATA["):end])
+			i = skipPast(s, i, "]]>")
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			i = skipPast(s, i, ">")
+		case len(rest) > 2 && rest[1] == '/' && isLetter(rest[2]):
+			n := 2 + tagNameLength(rest[2:])
+			p.end(strings.ToLower(rest[2:n]))
+			i = skipPast(s, i+n, ">")
+		case len(rest) > 1 && isLetter(rest[1]):
+			name, attrs, n, selfClosing := parseStartTag(rest)
+			i += n
+			if name == "title" || name == "script" || name == "style" || name == "textarea" {
+				// Raw text runs to the end tag, whatever it holds
+				end := rawTextEnd(s, i, name)
+				if name == "title" && p.title == "" {
+					p.title = html.UnescapeString(s[i:end])
+				}
+				i = skipPast(s, end, ">")
+				continue
+			}
+			p.start(name, attrs)
+			if selfClosing && !htmlVoid[name] {
+				p.end(name)
+			}
+		default:
+			p.text("<")
+			i++
+		}
+	}
+}
+
+// parseStartTag reads the start tag opening s and returns its lower-case
+// name, its attributes, its length and whether it closes itself. A tag
+// left open runs to the end of s.
+func parseStartTag(s string) (name string, attrs map[string]string, n int, selfClosing bool) {
+	i := 1 + tagNameLength(s[1:])
+	name = strings.ToLower(s[1:i])
+	attrs = make(map[string]string)
+	for i < len(s) {
+		for i < len(s) && (isHTMLSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		if i >= len(s) || s[i] == '>' {
+			break
+		}
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '/' && s[i] != '>' && (s[i] != '=' || i == start) {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			for i++; i < len(s) && isHTMLSpace(s[i]); i++ {
+			}
+			switch {
+			case i < len(s) && (s[i] == '"' || s[i] == '\''):
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					end = len(s) - i - 1
+				}
+				value = s[i+1 : i+1+end]
+				i = min(i+end+2, len(s))
+			default:
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if _, seen := attrs[key]; !seen {
+			attrs[key] = html.UnescapeString(value)
+		}
+	}
+	selfClosing = i < len(s) && i > 0 && s[i-1] == '/'
+	return name, attrs, min(i+1, len(s)), selfClosing
+}
+
+// tagNameLength returns the length of the tag name opening s
+func tagNameLength(s string) int {
+	n := 0
+	for n < len(s) && (isLetter(s[n]) || (s[n] >= '0' && s[n] <= '9') || s[n] == ':' || s[n] == '-') {
+		n++
+	}
+	return n
+}
+
+// rawTextEnd returns where the raw text of an element named name, such
+// as a script, ends in s from i: at its end tag, or at the end of s
+func rawTextEnd(s string, i int, name string) int {
+	for {
+		k := strings.Index(s[i:], "</")
+		if k < 0 {
+			return len(s)
+		}
+		i += k
+		if end := i + 2 + len(name); end <= len(s) && strings.EqualFold(s[i+2:end], name) {
+			return i
+		}
+		i += 2
+	}
+}
+
+// isHTMLSpace reports whether c is whitespace between attributes
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// skipPast returns the index after the first sep in s from i, or the end
+// of s
+func skipPast(s string, i int, sep string) int {
+	if end := strings.Index(s[i:], sep); end >= 0 {
+		return i + end + len(sep)
+	}
+	return len(s)
+}
+
+// start handles a start tag
+func (p *htmlParser) start(name string, attrs map[string]string) {
+	if p.skipping != "" {
+		if name == p.skipping {
+			p.depth++
+		}
+		return
+	}
+	if htmlSkipped[name] {
+		p.skipping, p.depth = name, 1
+		return
+	}
+
+	switch name {
+	case "br":
+		p.flush(true)
+	case "hr":
+		p.flush(false)
+	case "img":
+		p.image(attrs["src"], attrs)
+	case "input":
+		if strings.EqualFold(attrs["type"], "checkbox") {
+			p.task = "unchecked"
+			if _, checked := attrs["checked"]; checked {
+				p.task = "checked"
+			}
+		}
+	case "en-todo":
+		p.task = "unchecked"
+		if attrs["checked"] == "true" {
+			p.task = "checked"
+		}
+	case "en-media":
+		if p.media != nil {
+			for _, r := range p.media(attrs) {
+				r.attrs = mergeAttrs(p.inlineAttrs(), r.attrs)
+				p.runs = append(p.runs, r)
+			}
+		}
+	}
+	if htmlVoid[name] {
+		return
+	}
+
+	if htmlBlocks[name] {
+		// Elements whose end tag may be left out end when a sibling opens
+		switch name {
+		case "li":
+			p.closeOpen("li", "ul", "ol")
+		case "tr":
+			p.closeOpen("tr", "table")
+		case "td", "th":
+			p.closeOpen("td", "tr", "table")
+			p.closeOpen("th", "tr", "table")
+		case "p":
+			p.closeOpen("p", "div", "li", "td", "th", "blockquote")
+		}
+		p.flush(false)
+	}
+	e := htmlElement{name: name, attrs: attrs}
+	if name == "a" {
+		if href, ok := attrs["href"]; ok && !strings.HasPrefix(href, "#") {
+			e.link = p.opts.resolve(href)
+		}
+	}
+	if name == "tr" {
+		p.row = p.b.row()
+	}
+	if len(p.stack) < maxNesting {
+		p.stack = append(p.stack, e)
+	}
+}
+
+// closeOpen closes the innermost element named name, unless one of the
+// elements named in scope is open inside it
+func (p *htmlParser) closeOpen(name string, scope ...string) {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		switch {
+		case p.stack[i].name == name:
+			p.end(name)
+			return
+		case slices.Contains(scope, p.stack[i].name):
+			return
+		}
+	}
+}
+
+// end handles an end tag, closing the elements left open inside the one
+// it ends
+func (p *htmlParser) end(name string) {
+	if p.skipping != "" {
+		if name == p.skipping {
+			if p.depth--; p.depth == 0 {
+				p.skipping = ""
+			}
+		}
+		return
+	}
+	i := len(p.stack) - 1
+	for i >= 0 && p.stack[i].name != name {
+		i--
+	}
+	if i < 0 {
+		return
+	}
+	if htmlBlocks[name] {
+		// Table cells are lines even when empty
+		p.flush(name == "td" || name == "th")
+	}
+	p.stack = p.stack[:i]
+}
+
+// text adds text to the line being read
+func (p *htmlParser) text(s string) {
+	if p.skipping != "" || s == "" {
+		return
+	}
+	if p.inside("pre") {
+		for i, part := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+			if i > 0 {
+				p.flush(true)
+			}
+			if part != "" {
+				p.runs = append(p.runs, run{text: part, attrs: p.inlineAttrs()})
+			}
+		}
+		return
+	}
+	s = whitespace.ReplaceAllString(s, " ")
+	if p.lineStart() {
+		s = strings.TrimLeft(s, " ")
+	}
+	if s != "" {
+		p.runs = append(p.runs, run{text: s, attrs: p.inlineAttrs()})
+	}
+}
+
+// image adds an image to the line being read, or its alternative text
+// when it cannot be embedded
+func (p *htmlParser) image(src string, attrs map[string]string) {
+	if p.skipping != "" {
+		return
+	}
+	inline := p.inlineAttrs()
+	src = p.opts.resolve(src)
+	if src == "" {
+		if alt := strings.TrimSpace(attrs["alt"]); alt != "" {
+			p.runs = append(p.runs, run{text: alt, attrs: inline})
+		}
+		return
+	}
+	embedAttrs := make(map[string]any)
+	if alt := attrs["alt"]; alt != "" {
+		embedAttrs["alt"] = alt
+	}
+	for _, dimension := range []string{"width", "height"} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(attrs[dimension], "px")); err == nil && n > 0 {
+			embedAttrs[dimension] = n
+		}
+	}
+	if link, ok := inline["link"]; ok {
+		embedAttrs["link"] = link
+	}
+	p.runs = append(p.runs, run{embed: map[string]any{"image": src}, attrs: embedAttrs})
+}
+
+// lineStart reports whether the line being read holds no text yet, or
+// ends with a space
+func (p *htmlParser) lineStart() bool {
+	if len(p.runs) == 0 {
+		return true
+	}
+	last := p.runs[len(p.runs)-1]
+	return last.embed == nil && strings.HasSuffix(last.text, " ")
+}
+
+// inside reports whether an element named name is open
+func (p *htmlParser) inside(name string) bool {
+	return slices.ContainsFunc(p.stack, func(e htmlElement) bool { return e.name == name })
+}
+
+// flush ends the line being read, if it holds anything or force is set
+func (p *htmlParser) flush(force bool) {
+	if n := len(p.runs); n > 0 && p.runs[n-1].embed == nil && !p.inside("pre") {
+		p.runs[n-1].text = strings.TrimRight(p.runs[n-1].text, " ")
+	}
+	empty := !slices.ContainsFunc(p.runs, func(r run) bool { return r.text != "" || r.embed != nil })
+	if empty && !force && p.task == "" {
+		p.runs = nil
+		return
+	}
+
+	attrs := p.blockAttrs()
+	if !p.titled && p.b.empty() && intAttr(attrs, "header") == 1 {
+		text := strings.TrimSpace(line{runs: p.runs}.text())
+		if p.title == "" || strings.EqualFold(text, strings.TrimSpace(p.title)) {
+			p.title = text
+			p.titled = true
+			p.runs, p.task = nil, ""
+			return
+		}
+	}
+	p.titled = p.titled || !empty
+
+	for _, r := range p.runs {
+		if r.embed != nil {
+			p.b.embed(r.embed, r.attrs)
+			continue
+		}
+		p.b.text(r.text, r.attrs)
+	}
+	p.b.newline(attrs)
+	p.runs, p.task = nil, ""
+}
+
+// blockAttrs returns the block attributes of the line being read. Notes
+// hold a single block format per line, so a table cell wins over code,
+// code over a list item, an item over a heading and a heading over a
+// quote.
+func (p *htmlParser) blockAttrs() map[string]any {
+	attrs := make(map[string]any)
+	lists := 0
+	var list, item *htmlElement
+	var lang any
+	cell := false
+	for i := range p.stack {
+		e := &p.stack[i]
+		switch e.name {
+		case "ul", "ol":
+			lists++
+			list = e
+		case "li":
+			item = e
+		case "blockquote":
+			attrs["blockquote"] = true
+		case "pre":
+			lang = codeClass(e.attrs)
+		case "code":
+			if lang == true {
+				lang = codeClass(e.attrs)
+			}
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			attrs["header"] = int(e.name[1] - '0')
+		case "td", "th":
+			cell = true
+		}
+		if align := alignment(e.attrs); align != "" {
+			attrs["align"] = align
+		}
+	}
+
+	switch {
+	case cell && p.row != "":
+		return map[string]any{"table": p.row}
+	case lang != nil:
+		return map[string]any{"code-block": lang}
+	case item != nil || p.task != "":
+		kind := "bullet"
+		if list != nil && list.name == "ol" {
+			kind = "ordered"
+		}
+		if list != nil && (strings.Contains(list.attrs["class"], "checklist") || list.attrs["data-checked"] != "") {
+			kind = "unchecked"
+			if list.attrs["data-checked"] == "true" {
+				kind = "checked"
+			}
+		}
+		if item != nil {
+			switch dataList := item.attrs["data-list"]; dataList {
+			case "bullet", "ordered", "checked", "unchecked":
+				kind = dataList
+			}
+		}
+		if p.task != "" {
+			kind = p.task
+		}
+		delete(attrs, "header")
+		delete(attrs, "blockquote")
+		attrs["list"] = kind
+		if lists > 1 {
+			attrs["indent"] = min(lists-1, maxIndent)
+		}
+	case attrs["header"] != nil:
+		delete(attrs, "blockquote")
+	}
+	return attrs
+}
+
+// codeClass returns the language a code element names with a
+// language-* class, or true
+func codeClass(attrs map[string]string) any {
+	for _, class := range strings.Fields(attrs["class"]) {
+		if lang, ok := strings.CutPrefix(class, "language-"); ok && lang != "" {
+			return lang
+		}
+	}
+	return true
+}
+
+// alignment returns how an element aligns its text, if it does
+func alignment(attrs map[string]string) string {
+	align := strings.ToLower(attrs["align"])
+	if value, ok := styleOf(attrs)["text-align"]; ok {
+		align = value
+	}
+	for _, class := range strings.Fields(attrs["class"]) {
+		if value, ok := strings.CutPrefix(class, "ql-align-"); ok {
+			align = value
+		}
+	}
+	switch align {
+	case "center", "right", "justify":
+		return align
+	}
+	return ""
+}
+
+// inlineAttrs returns the inline attributes of text added now
+func (p *htmlParser) inlineAttrs() map[string]any {
+	attrs := make(map[string]any)
+	pre := false
+	for _, e := range p.stack {
+		switch e.name {
+		case "b", "strong":
+			attrs["bold"] = true
+		case "i", "em", "cite", "var":
+			attrs["italic"] = true
+		case "u", "ins":
+			attrs["underline"] = true
+		case "s", "strike", "del":
+			attrs["strike"] = true
+		case "code", "kbd", "samp", "tt":
+			if !pre {
+				attrs["code"] = true
+			}
+		case "sub":
+			attrs["script"] = "sub"
+		case "sup":
+			attrs["script"] = "super"
+		case "pre":
+			pre = true
+		case "a":
+			if e.link != "" {
+				attrs["link"] = e.link
+			}
+		}
+
+		style := styleOf(e.attrs)
+		switch weight := style["font-weight"]; weight {
+		case "bold", "bolder", "600", "700", "800", "900":
+			attrs["bold"] = true
+		case "normal", "400":
+			delete(attrs, "bold")
+		}
+		if style["font-style"] == "italic" {
+			attrs["italic"] = true
+		}
+		for _, property := range []string{"text-decoration", "text-decoration-line"} {
+			if strings.Contains(style[property], "underline") {
+				attrs["underline"] = true
+			}
+			if strings.Contains(style[property], "line-through") {
+				attrs["strike"] = true
+			}
+		}
+		if color := style["color"]; color != "" {
+			attrs["color"] = color
+		}
+		if background := style["background-color"]; background != "" {
+			attrs["background"] = background
+		}
+	}
+	return attrs
+}
+
+// styleOf returns the declarations of the style attribute, with
+// lower-case properties
+func styleOf(attrs map[string]string) map[string]string {
+	style, ok := attrs["style"]
+	if !ok {
+		return nil
+	}
+	declarations := make(map[string]string)
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		declarations[strings.ToLower(strings.TrimSpace(property))] = strings.ToLower(value)
+	}
+	return declarations
+}
+
+// mergeAttrs returns the attributes of both maps, those of b winning
+func mergeAttrs(a, b map[string]any) map[string]any {
+	out := maps.Clone(a)
+	if out == nil {
+		out = make(map[string]any, len(b))
+	}
+	maps.Copy(out, b)
+	return out
+}
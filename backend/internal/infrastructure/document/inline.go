@@ -0,0 +1,60 @@
+package document
+
+import "strings"
+
+// mark is an inline format open around text: a key telling marks apart
+// and what opens and closes it
+type mark struct {
+	key   string
+	open  string
+	close string
+}
+
+// writeInline renders the runs of a line. marksOf returns the marks of a
+// run in a fixed order, render its content. Marks shared with the
+// previous run stay open, so formats that overlap across runs nest
+// properly. With hoistSpaces, whitespace at the edges of a run is moved
+// outside its marks, as Markdown delimiters cannot touch it.
+func writeInline(b *strings.Builder, runs []run, marksOf func(run) []mark, render func(run) string, hoistSpaces bool) {
+	var open []mark
+	pending := ""
+	for _, r := range runs {
+		text := render(r)
+		if text == "" {
+			continue
+		}
+		lead, trail := "", ""
+		if hoistSpaces {
+			core := strings.TrimLeft(text, " \t")
+			if core == "" {
+				pending += text
+				continue
+			}
+			lead = text[:len(text)-len(core)]
+			text = strings.TrimRight(core, " \t")
+			trail = core[len(text):]
+		}
+
+		want := marksOf(r)
+		common := 0
+		for common < len(open) && common < len(want) && open[common].key == want[common].key {
+			common++
+		}
+		for len(open) > common {
+			b.WriteString(open[len(open)-1].close)
+			open = open[:len(open)-1]
+		}
+		b.WriteString(pending)
+		b.WriteString(lead)
+		for _, m := range want[common:] {
+			b.WriteString(m.open)
+			open = append(open, m)
+		}
+		b.WriteString(text)
+		pending = trail
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString(open[i].close)
+	}
+	b.WriteString(pending)
+}
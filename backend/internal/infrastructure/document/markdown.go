@@ -0,0 +1,288 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// Markdown renders a note as CommonMark, titled with a level 1 heading.
+// Tables, task lists and strikethrough use their GitHub Flavored Markdown
+// syntax; formats Markdown lacks, such as underline, are written as
+// inline HTML. Every line of the note makes a paragraph of its own.
+func Markdown(title string, content domainNote.Delta, opts Options) []byte {
+	var parts []string
+	if title != "" {
+		parts = append(parts, "# "+escapeMarkdown(title, false))
+	}
+	for _, blk := range splitBlocks(content) {
+		if s := markdownBlock(blk, opts); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return []byte(strings.Join(parts, "\n\n") + "\n")
+}
+
+// markdownBlock renders a block, or returns "" for an empty one
+func markdownBlock(blk block, opts Options) string {
+	switch blk.kind {
+	case blockHeading:
+		l := blk.lines[0]
+		text := markdownLine(l, opts, false)
+		if text == "" {
+			return ""
+		}
+		if strings.HasSuffix(text, "#") {
+			// A closing sequence would be dropped
+			text = text[:len(text)-1] + `\#`
+		}
+		return strings.Repeat("#", headingLevel(l)) + " " + text
+
+	case blockQuote:
+		var quoted []string
+		for _, l := range blk.lines {
+			if text := markdownLine(l, opts, false); text != "" {
+				quoted = append(quoted, "> "+text)
+			}
+		}
+		return strings.Join(quoted, "\n>\n")
+
+	case blockCode:
+		var code []string
+		for _, l := range blk.lines {
+			code = append(code, l.text())
+		}
+		text := strings.Join(code, "\n")
+		fence := strings.Repeat("`", max(3, longestRun(text, '`')+1))
+		return fence + codeLanguage(blk.lines[0]) + "\n" + text + "\n" + fence
+
+	case blockList:
+		return markdownList(blk.lines, opts)
+
+	case blockTable:
+		return markdownTable(blk.lines, opts)
+	}
+	return markdownLine(blk.lines[0], opts, false)
+}
+
+// markdownList renders the items of a list. Nested items are indented to
+// the content of their parent, and ordered items numbered per level.
+func markdownList(lines []line, opts Options) string {
+	type level struct {
+		kind   string
+		number int
+		// column is where the content of the item starts
+		column int
+	}
+	var levels []level
+	var items []string
+	for _, l := range lines {
+		kind, depth := listItem(l)
+		depth = min(depth, len(levels))
+		if depth < len(levels) && levels[depth].kind != kind && !(isTask(kind) && isTask(levels[depth].kind)) {
+			levels = levels[:depth]
+		}
+		indent := 0
+		if depth > 0 {
+			indent = levels[depth-1].column
+		}
+
+		number := 1
+		if depth < len(levels) {
+			number = levels[depth].number + 1
+		}
+		marker := "- "
+		if kind == "ordered" {
+			marker = strconv.Itoa(number) + ". "
+		}
+		levels = append(levels[:depth], level{kind: kind, number: number, column: indent + len(marker)})
+
+		switch kind {
+		case "checked":
+			marker += "[x] "
+		case "unchecked":
+			marker += "[ ] "
+		}
+		items = append(items, strings.TrimRight(strings.Repeat(" ", indent)+marker+markdownLine(l, opts, false), " "))
+	}
+	return strings.Join(items, "\n")
+}
+
+// isTask reports whether a list type is an item of a checklist
+func isTask(kind string) bool {
+	return kind == "checked" || kind == "unchecked"
+}
+
+// markdownTable renders a table. GitHub Flavored Markdown tables need a
+// header, which the first row provides.
+func markdownTable(lines []line, opts Options) string {
+	rows := tableRows(lines)
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	var out []string
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j, cell := range row {
+			cells[j] = markdownLine(cell, opts, true)
+		}
+		out = append(out, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			out = append(out, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// markdownLine renders the inline content of a line, escaping what would
+// otherwise start another block
+func markdownLine(l line, opts Options, inTable bool) string {
+	var b strings.Builder
+	writeInline(&b, l.runs, func(r run) []mark {
+		return markdownMarks(r, opts)
+	}, func(r run) string {
+		return markdownRun(r, opts, inTable)
+	}, true)
+	text := strings.TrimSpace(b.String())
+	if inTable {
+		return text
+	}
+	return escapeLineStart(text)
+}
+
+// markdownMarks returns the marks of a run, outermost first
+func markdownMarks(r run, opts Options) []mark {
+	var marks []mark
+	if dest := opts.url(stringAttr(r.attrs, "link")); dest != "" {
+		marks = append(marks, mark{key: "link " + dest, open: "[", close: "](" + markdownDestination(dest) + ")"})
+	}
+	if boolAttr(r.attrs, "bold") {
+		marks = append(marks, mark{key: "bold", open: "**", close: "**"})
+	}
+	if boolAttr(r.attrs, "italic") {
+		marks = append(marks, mark{key: "italic", open: "*", close: "*"})
+	}
+	if boolAttr(r.attrs, "strike") {
+		marks = append(marks, mark{key: "strike", open: "~~", close: "~~"})
+	}
+	if boolAttr(r.attrs, "underline") {
+		marks = append(marks, mark{key: "underline", open: "<u>", close: "</u>"})
+	}
+	switch stringAttr(r.attrs, "script") {
+	case "sub":
+		marks = append(marks, mark{key: "sub", open: "<sub>", close: "</sub>"})
+	case "super":
+		marks = append(marks, mark{key: "super", open: "<sup>", close: "</sup>"})
+	}
+	return marks
+}
+
+// markdownRun renders the content of a run
+func markdownRun(r run, opts Options, inTable bool) string {
+	if r.embed != nil {
+		if src, ok := embedURL(r.embed, "image"); ok {
+			alt := escapeMarkdown(stringAttr(r.attrs, "alt"), inTable)
+			if src = opts.url(src); src == "" {
+				return alt
+			}
+			return "![" + alt + "](" + markdownDestination(src) + ")"
+		}
+		if src, ok := embedURL(r.embed, "video"); ok {
+			if src = opts.url(src); src == "" {
+				return ""
+			}
+			return "[" + escapeMarkdown(src, inTable) + "](" + markdownDestination(src) + ")"
+		}
+		if formula, ok := r.embed["formula"].(string); ok {
+			return markdownCode(formula, inTable)
+		}
+		return ""
+	}
+	if boolAttr(r.attrs, "code") {
+		return markdownCode(r.text, inTable)
+	}
+	return escapeMarkdown(r.text, inTable)
+}
+
+// markdownCode renders a code span. Pipes end table cells even within
+// code spans, so they are escaped there too.
+func markdownCode(text string, inTable bool) string {
+	span := codeSpan(text)
+	if inTable {
+		span = strings.ReplaceAll(span, "|", `\|`)
+	}
+	return span
+}
+
+// codeSpan wraps text in enough backticks to hold the backticks it has
+func codeSpan(text string) string {
+	if text == "" {
+		return ""
+	}
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if text[0] == '`' || text[len(text)-1] == '`' || (text[0] == ' ' && text[len(text)-1] == ' ') {
+		// Code spans lose one space at each end
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// markdownDestination percent-encodes what would end a link destination
+var markdownDestination = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace
+
+// escapeMarkdown backslash-escapes the characters that would format text.
+// Pipes only matter in tables.
+func escapeMarkdown(text string, inTable bool) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case strings.IndexByte("\\`*_[]<>~", c) >= 0,
+			c == '|' && inTable,
+			c == '&' && i+1 < len(text) && (isLetter(text[i+1]) || text[i+1] == '#'):
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// escapeLineStart escapes what would make a line a heading, quote, list
+// item or thematic break
+func escapeLineStart(text string) string {
+	if text == "" {
+		return text
+	}
+	if strings.IndexByte("#>-+=", text[0]) >= 0 {
+		return `\` + text
+	}
+	digits := 0
+	for digits < len(text) && digits < 10 && text[digits] >= '0' && text[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits < len(text) && (text[digits] == '.' || text[digits] == ')') {
+		return text[:digits] + `\` + text[digits:]
+	}
+	return text
+}
+
+// longestRun returns the length of the longest run of c in text
+func longestRun(text string, c byte) int {
+	longest, current := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != c {
+			current = 0
+			continue
+		}
+		current++
+		longest = max(longest, current)
+	}
+	return longest
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,1086 @@
+package document
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	atxHeadingPattern = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematicPattern   = regexp.MustCompile(`^(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern      = regexp.MustCompile("^(`{3,}|~{3,})[ \t]*([^`]*)$")
+	listMarkerPattern = regexp.MustCompile(`^( {0,3})([-+*]|[0-9]{1,9}[.)])( +|$)`)
+	setextPattern     = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	delimiterPattern  = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	taskPattern       = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+|$)`)
+	definitionPattern = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*(<[^>]*>|\S+)(?:[ \t]+("[^"]*"|'[^']*'|\([^)]*\)))?[ \t]*$`)
+	frontMatterTitle  = regexp.MustCompile(`^title:[ \t]*(.*?)[ \t]*$`)
+
+	autolinkPattern  = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*|[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*)>`)
+	bareURLPattern   = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]*[^\s<?!.,:;*_~'")\]]`)
+	inlineTagPattern = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[a-zA-Z_:][-a-zA-Z0-9_.:]*(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+))?)*)\s*/?>`)
+	tagAttrPattern   = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_.:]*)\s*=\s*("[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+)`)
+	entityPattern    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+)
+
+// ParseMarkdown reads a CommonMark document, along with the tables, task
+// lists, strikethrough and autolinks of GitHub Flavored Markdown. The
+// title is the one of a YAML front matter, or else a level 1 heading
+// opening the document, which is then left out of the content.
+//
+// Formats notes cannot hold are simplified: thematic breaks are dropped,
+// a list item keeps its first paragraph on the list line and indents the
+// others, and raw HTML keeps its text and inline formats.
+func ParseMarkdown(data []byte, opts ParseOptions) Document {
+	p := &mdParser{opts: opts, refs: make(map[string]string)}
+	lines := strings.Split(strings.ReplaceAll(strings.ToValidUTF8(string(data), "\uFFFD"), "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = expandTabs(strings.TrimSuffix(l, "\r"))
+	}
+
+	var doc Document
+	doc.Title, lines = frontMatter(lines)
+	blocks := p.blocks(lines)
+	if doc.Title == "" && len(blocks) > 0 && blocks[0].kind == mdHeading && blocks[0].level == 1 {
+		doc.Title = plainText(p.inline(blocks[0].text))
+		blocks = blocks[1:]
+	}
+
+	var b builder
+	p.write(&b, blocks, mdContext{})
+	doc.Content = b.delta()
+	return doc
+}
+
+// maxLinkText bounds the text of links, in bytes, so that unclosed
+// brackets are not looked at again and again
+const maxLinkText = 1000
+
+// mdKind is the kind of a Markdown block
+type mdKind int
+
+const (
+	mdParagraph mdKind = iota
+	mdHeading
+	mdCode
+	mdQuote
+	mdList
+	mdTable
+	mdBreak
+)
+
+// mdBlock is a block of a Markdown document
+type mdBlock struct {
+	kind mdKind
+	// text is the inline source of paragraphs and headings
+	text  string
+	level int
+	// lang and lines hold code blocks
+	lang  string
+	lines []string
+	// children are the blocks of a quote
+	children []mdBlock
+	ordered  bool
+	items    []mdItem
+	// rows are the cells of a table, header first
+	rows [][]string
+}
+
+// mdItem is a list item: its task list state, if any, and its blocks
+type mdItem struct {
+	task   string
+	blocks []mdBlock
+}
+
+// mdParser reads a Markdown document
+type mdParser struct {
+	opts ParseOptions
+	// refs maps the labels of link reference definitions to their
+	// destination
+	refs map[string]string
+	// nesting is how many quotes and lists hold the blocks being read
+	nesting int
+}
+
+// blocks parses lines into blocks
+func (p *mdParser) blocks(lines []string) []mdBlock {
+	var out []mdBlock
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		if isBlank(l) {
+			i++
+			continue
+		}
+		indent := indentOf(l)
+		rest := l[indent:]
+
+		if indent >= 4 {
+			var code []string
+			for i < len(lines) && (isBlank(lines[i]) || indentOf(lines[i]) >= 4) {
+				code = append(code, stripIndent(lines[i], 4))
+				i++
+			}
+			out = append(out, mdBlock{kind: mdCode, lines: trimBlankLines(code)})
+			continue
+		}
+		if m := fencePattern.FindStringSubmatch(rest); m != nil {
+			fence := m[1]
+			i++
+			var code []string
+			for i < len(lines) {
+				if closesFence(lines[i], fence) {
+					i++
+					break
+				}
+				code = append(code, stripIndent(lines[i], indent))
+				i++
+			}
+			lang := ""
+			if fields := strings.Fields(html.UnescapeString(m[2])); len(fields) > 0 {
+				lang = strings.Trim(fields[0], "{}.")
+			}
+			out = append(out, mdBlock{kind: mdCode, lang: lang, lines: code})
+			continue
+		}
+		if m := atxHeadingPattern.FindStringSubmatch(rest); m != nil {
+			out = append(out, mdBlock{kind: mdHeading, level: len(m[1]), text: m[2]})
+			i++
+			continue
+		}
+		if thematicPattern.MatchString(rest) {
+			out = append(out, mdBlock{kind: mdBreak})
+			i++
+			continue
+		}
+		container := p.nesting < maxNesting
+		if container && strings.HasPrefix(rest, ">") {
+			var inner []string
+			for i < len(lines) {
+				l := lines[i]
+				if r := strings.TrimLeft(l, " "); indentOf(l) < 4 && strings.HasPrefix(r, ">") {
+					inner = append(inner, strings.TrimPrefix(r[1:], " "))
+					i++
+					continue
+				}
+				// Lazy continuation of a quoted paragraph
+				if !isBlank(l) && len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !p.interrupts(l) {
+					inner = append(inner, l)
+					i++
+					continue
+				}
+				break
+			}
+			p.nesting++
+			out = append(out, mdBlock{kind: mdQuote, children: p.blocks(inner)})
+			p.nesting--
+			continue
+		}
+		if _, ok := parseListMarker(l); ok && container {
+			var list mdBlock
+			list, i = p.list(lines, i)
+			out = append(out, list)
+			continue
+		}
+		if i+1 < len(lines) && strings.Contains(l, "|") && strings.Contains(lines[i+1], "|") && delimiterPattern.MatchString(lines[i+1]) {
+			rows := [][]string{splitRow(l)}
+			for i += 2; i < len(lines) && !isBlank(lines[i]) && !p.interrupts(lines[i]); i++ {
+				rows = append(rows, splitRow(lines[i]))
+			}
+			out = append(out, mdBlock{kind: mdTable, rows: rows})
+			continue
+		}
+
+		var para []string
+		heading := 0
+		for i < len(lines) {
+			l := lines[i]
+			if isBlank(l) {
+				break
+			}
+			if len(para) > 0 {
+				if m := setextPattern.FindStringSubmatch(l); m != nil {
+					heading = 1
+					if m[1][0] == '-' {
+						heading = 2
+					}
+					i++
+					break
+				}
+				if p.interrupts(l) {
+					break
+				}
+			}
+			para = append(para, strings.TrimLeft(l, " "))
+			i++
+		}
+		para = p.definitions(para)
+		if n := len(para); n > 0 {
+			para[n-1] = strings.TrimRight(para[n-1], " ")
+		}
+		switch {
+		case len(para) == 0:
+		case heading > 0:
+			out = append(out, mdBlock{kind: mdHeading, level: heading, text: strings.Join(para, "\n")})
+		default:
+			out = append(out, mdBlock{kind: mdParagraph, text: strings.Join(para, "\n")})
+		}
+	}
+	return out
+}
+
+// interrupts reports whether a line starts a block that ends the
+// paragraph before it
+func (p *mdParser) interrupts(l string) bool {
+	if indentOf(l) >= 4 {
+		return false
+	}
+	rest := strings.TrimLeft(l, " ")
+	if fencePattern.MatchString(rest) || atxHeadingPattern.MatchString(rest) ||
+		thematicPattern.MatchString(rest) || strings.HasPrefix(rest, ">") {
+		return true
+	}
+	// Only lists of something, and ordered lists starting at 1, interrupt
+	m, ok := parseListMarker(l)
+	return ok && m.offset < len(l) && !isBlank(l[m.offset:]) && (!m.ordered || m.start == "1")
+}
+
+// listMarker describes the marker opening a list item
+type listMarker struct {
+	ordered bool
+	// delimiter is the bullet, or the character after the number
+	delimiter byte
+	start     string
+	// offset is where the content of the item starts
+	offset int
+}
+
+// parseListMarker reads the marker of a list item opening l
+func parseListMarker(l string) (listMarker, bool) {
+	m := listMarkerPattern.FindStringSubmatch(l)
+	if m == nil || thematicPattern.MatchString(strings.TrimLeft(l, " ")) {
+		return listMarker{}, false
+	}
+	marker := listMarker{delimiter: m[2][len(m[2])-1]}
+	if m[2][0] >= '0' && m[2][0] <= '9' {
+		marker.ordered = true
+		marker.start = strings.TrimLeft(m[2][:len(m[2])-1], "0")
+		if marker.start == "" {
+			marker.start = "0"
+		}
+	}
+	marker.offset = len(m[1]) + len(m[2]) + len(m[3])
+	if len(m[3]) == 0 || len(m[3]) > 4 {
+		// Blank items, and items opening with indented code, start one
+		// space after the marker
+		marker.offset = len(m[1]) + len(m[2]) + 1
+	}
+	return marker, true
+}
+
+// list parses the list starting at lines[i] and returns it with the
+// index of the line after it
+func (p *mdParser) list(lines []string, i int) (mdBlock, int) {
+	first, _ := parseListMarker(lines[i])
+	list := mdBlock{kind: mdList, ordered: first.ordered}
+	for i < len(lines) {
+		m, ok := parseListMarker(lines[i])
+		if !ok || m.ordered != first.ordered || m.delimiter != first.delimiter {
+			break
+		}
+		l := lines[i]
+		content := []string{""}
+		if m.offset < len(l) {
+			content[0] = l[m.offset:]
+		}
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			switch {
+			case isBlank(l):
+				content = append(content, "")
+				continue
+			case indentOf(l) >= m.offset:
+				content = append(content, l[m.offset:])
+				continue
+			case !isBlank(content[len(content)-1]) && !p.interrupts(l):
+				if _, sibling := parseListMarker(l); !sibling {
+					content = append(content, l)
+					continue
+				}
+			}
+			break
+		}
+
+		item := mdItem{}
+		if t := taskPattern.FindStringSubmatch(content[0]); t != nil {
+			item.task = "unchecked"
+			if t[1] != " " {
+				item.task = "checked"
+			}
+			content[0] = content[0][len(t[0]):]
+		}
+		p.nesting++
+		item.blocks = p.blocks(trimBlankLines(content))
+		p.nesting--
+		list.items = append(list.items, item)
+	}
+	return list, i
+}
+
+// definitions records the link reference definitions opening a paragraph
+// and returns the lines left
+func (p *mdParser) definitions(para []string) []string {
+	for len(para) > 0 {
+		m := definitionPattern.FindStringSubmatch(para[0])
+		if m == nil {
+			break
+		}
+		label := normalizeLabel(m[1])
+		if _, defined := p.refs[label]; !defined {
+			p.refs[label] = unescapeMarkdown(strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">"))
+		}
+		para = para[1:]
+	}
+	return para
+}
+
+// mdContext is where blocks are written: inside a quote, or at some
+// depth of a list
+type mdContext struct {
+	quote bool
+	depth int
+}
+
+// attrs returns the block attributes of a paragraph in the context
+func (c mdContext) attrs() map[string]any {
+	attrs := make(map[string]any)
+	if c.quote {
+		attrs["blockquote"] = true
+	}
+	if c.depth > 0 {
+		attrs["indent"] = min(c.depth, maxIndent)
+	}
+	return attrs
+}
+
+// maxIndent is the deepest indentation notes hold
+const maxIndent = 8
+
+// write converts blocks to the delta
+func (p *mdParser) write(b *builder, blocks []mdBlock, ctx mdContext) {
+	for _, blk := range blocks {
+		switch blk.kind {
+		case mdParagraph:
+			p.writeInline(b, blk.text, ctx.attrs())
+
+		case mdHeading:
+			p.writeInline(b, blk.text, map[string]any{"header": blk.level})
+
+		case mdCode:
+			var lang any = true
+			if blk.lang != "" {
+				lang = blk.lang
+			}
+			for _, l := range blk.lines {
+				b.text(l, nil)
+				b.newline(map[string]any{"code-block": lang})
+			}
+
+		case mdQuote:
+			inner := ctx
+			inner.quote = true
+			p.write(b, blk.children, inner)
+
+		case mdList:
+			p.writeList(b, blk, ctx)
+
+		case mdTable:
+			columns := len(blk.rows[0])
+			for _, row := range blk.rows {
+				attrs := map[string]any{"table": b.row()}
+				for i := range columns {
+					cell := ""
+					if i < len(row) {
+						cell = row[i]
+					}
+					p.writeInline(b, cell, attrs)
+				}
+			}
+		}
+	}
+}
+
+// writeList converts the items of a list. Nested lists are indented;
+// the paragraphs of an item after the first are indented paragraphs.
+func (p *mdParser) writeList(b *builder, list mdBlock, ctx mdContext) {
+	for _, item := range list.items {
+		kind := "bullet"
+		switch {
+		case item.task != "":
+			kind = item.task
+		case list.ordered:
+			kind = "ordered"
+		}
+		attrs := map[string]any{"list": kind}
+		if ctx.depth > 0 {
+			attrs["indent"] = min(ctx.depth, maxIndent)
+		}
+
+		blocks := item.blocks
+		if len(blocks) > 0 && blocks[0].kind == mdParagraph {
+			p.writeInline(b, blocks[0].text, attrs)
+			blocks = blocks[1:]
+		} else {
+			b.newline(attrs)
+		}
+		inner := mdContext{quote: ctx.quote, depth: ctx.depth + 1}
+		for _, blk := range blocks {
+			if blk.kind == mdList {
+				p.writeList(b, blk, inner)
+				continue
+			}
+			p.write(b, []mdBlock{blk}, inner)
+		}
+	}
+}
+
+// writeInline converts the inline source of a line, ending it with
+// attrs. Hard line breaks start lines with the same attributes.
+func (p *mdParser) writeInline(b *builder, text string, attrs map[string]any) {
+	for _, n := range p.inline(text) {
+		switch {
+		case n.br:
+			b.newline(attrs)
+		case n.image != "":
+			if src := p.opts.resolve(n.image); src != "" {
+				embedAttrs := map[string]any{}
+				if n.text != "" {
+					embedAttrs["alt"] = n.text
+				}
+				if link, ok := n.attrs["link"]; ok {
+					embedAttrs["link"] = link
+				}
+				b.embed(map[string]any{"image": src}, embedAttrs)
+			} else {
+				b.text(n.text, n.attrs)
+			}
+		default:
+			b.text(n.text, n.attrs)
+		}
+	}
+	b.newline(attrs)
+}
+
+// mdNode is a piece of inline content: text, an image, a line break or,
+// until emphasis is resolved, a run of delimiters
+type mdNode struct {
+	text  string
+	attrs map[string]any
+	// image is the address of an image, whose text is the alternative
+	// text
+	image string
+	br    bool
+	// delim is the character of a delimiter run: *, _ or ~. count is how
+	// many are left, length how many the run had.
+	delim      byte
+	count      int
+	length     int
+	open, shut bool
+	// tag is an inline HTML tag, such as "b" or "/b"
+	tag string
+}
+
+// inline parses the inline source of a block
+func (p *mdParser) inline(s string) []mdNode {
+	var nodes []mdNode
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			nodes = append(nodes, mdNode{text: buf.String()})
+			buf.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			buf.WriteByte(s[i+1])
+			i += 2
+
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			flush()
+			nodes = append(nodes, mdNode{br: true})
+			i += 2
+
+		case c == '\n':
+			text := buf.String()
+			trimmed := strings.TrimRight(text, " ")
+			buf.Reset()
+			buf.WriteString(trimmed)
+			if len(text)-len(trimmed) >= 2 {
+				flush()
+				nodes = append(nodes, mdNode{br: true})
+			} else {
+				buf.WriteByte(' ')
+			}
+			for i++; i < len(s) && s[i] == ' '; i++ {
+			}
+
+		case c == '`':
+			n := runLength(s, i, '`')
+			end := closingBackticks(s, i+n, n)
+			if end < 0 {
+				buf.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+			if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			flush()
+			nodes = append(nodes, mdNode{text: code, attrs: map[string]any{"code": true}})
+			i = end + n
+
+		case c == '*' || c == '_' || c == '~':
+			n := runLength(s, i, c)
+			if c == '~' && n > 2 {
+				buf.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			before, _ := utf8.DecodeLastRuneInString(s[:i])
+			after, _ := utf8.DecodeRuneInString(s[i+n:])
+			if i == 0 {
+				before = ' '
+			}
+			if i+n == len(s) {
+				after = ' '
+			}
+			left := !unicode.IsSpace(after) && (!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+			right := !unicode.IsSpace(before) && (!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+			node := mdNode{delim: c, count: n, length: n, open: left, shut: right}
+			if c == '_' {
+				node.open = left && (!right || isPunct(before))
+				node.shut = right && (!left || isPunct(after))
+			}
+			flush()
+			nodes = append(nodes, node)
+			i += n
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			text, dest, end, ok := p.link(s, i+1)
+			if !ok {
+				buf.WriteByte('!')
+				i++
+				continue
+			}
+			flush()
+			nodes = append(nodes, mdNode{text: plainText(p.inline(text)), image: dest})
+			i = end
+
+		case c == '[':
+			text, dest, end, ok := p.link(s, i)
+			if !ok {
+				buf.WriteByte('[')
+				i++
+				continue
+			}
+			flush()
+			nodes = append(nodes, linked(p.inline(text), p.opts.resolve(dest))...)
+			i = end
+
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				href := m[1]
+				if !strings.Contains(href, ":") {
+					href = "mailto:" + href
+				}
+				flush()
+				nodes = append(nodes, linked([]mdNode{{text: m[1]}}, p.opts.resolve(href))...)
+				i += len(m[0])
+				continue
+			}
+			if m := inlineTagPattern.FindStringSubmatch(s[i:]); m != nil {
+				flush()
+				nodes = append(nodes, inlineTag(m))
+				i += len(m[0])
+				continue
+			}
+			buf.WriteByte(c)
+			i++
+
+		case c == '&':
+			if m := entityPattern.FindString(s[i:]); m != "" {
+				buf.WriteString(html.UnescapeString(m))
+				i += len(m)
+				continue
+			}
+			buf.WriteByte(c)
+			i++
+
+		case (c == 'h' || c == 'w') && (i == 0 || !isWordByte(s[i-1])):
+			m := bareURLPattern.FindString(s[i:])
+			if m == "" {
+				buf.WriteByte(c)
+				i++
+				continue
+			}
+			href := m
+			if strings.HasPrefix(href, "www.") {
+				href = "http://" + href
+			}
+			flush()
+			nodes = append(nodes, linked([]mdNode{{text: m}}, p.opts.resolve(href))...)
+			i += len(m)
+
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return applyTags(emphasis(nodes))
+}
+
+// link parses the link opening with the bracket at s[i], inline or by
+// reference. It returns the link text, the destination and the index
+// after the link.
+func (p *mdParser) link(s string, i int) (text, dest string, end int, ok bool) {
+	closing := -1
+	depth := 0
+	for j := i; j < min(len(s), i+maxLinkText) && closing < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			n := runLength(s, j, '`')
+			if k := closingBackticks(s, j+n, n); k >= 0 {
+				j = k + n - 1
+			} else {
+				j += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = j
+			}
+		}
+	}
+	if closing < 0 {
+		return "", "", 0, false
+	}
+	text = s[i+1 : closing]
+	j := closing + 1
+
+	if j < len(s) && s[j] == '(' {
+		if dest, end, ok := inlineDestination(s, j+1); ok {
+			return text, dest, end, true
+		}
+	}
+	label := text
+	if j+1 < len(s) && s[j] == '[' {
+		if k := strings.IndexByte(s[j+1:], ']'); k >= 0 {
+			if k > 0 {
+				label = s[j+1 : j+1+k]
+			}
+			j += k + 2
+		}
+	}
+	dest, ok = p.refs[normalizeLabel(label)]
+	if !ok {
+		return "", "", 0, false
+	}
+	return text, dest, j, true
+}
+
+// inlineDestination parses the destination and optional title of an
+// inline link, after its opening parenthesis at s[i-1]
+func inlineDestination(s string, i int) (dest string, end int, ok bool) {
+	skip := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n' || s[i] == '\t') {
+			i++
+		}
+	}
+	skip()
+	start := i
+	if i < len(s) && s[i] == '<' {
+		k := strings.IndexAny(s[i+1:], ">\n")
+		if k < 0 || s[i+1+k] != '>' {
+			return "", 0, false
+		}
+		dest = s[i+1 : i+1+k]
+		i += k + 2
+	} else {
+		depth := 0
+	loop:
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '(':
+				if depth++; depth > maxNesting {
+					return "", 0, false
+				}
+			case ')':
+				if depth == 0 {
+					break loop
+				}
+				depth--
+			case ' ', '\n', '\t':
+				break loop
+			}
+		}
+		dest = s[start:min(i, len(s))]
+	}
+	skip()
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		k := strings.IndexByte(s[i+1:], closer)
+		if k < 0 {
+			return "", 0, false
+		}
+		i += k + 2
+		skip()
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", 0, false
+	}
+	return unescapeMarkdown(dest), i + 1, true
+}
+
+// linked returns nodes linking to href, or unchanged when href is empty
+func linked(nodes []mdNode, href string) []mdNode {
+	if href == "" {
+		return nodes
+	}
+	for i := range nodes {
+		if _, ok := nodes[i].attrs["link"]; !ok && !nodes[i].br {
+			nodes[i].attrs = with(nodes[i].attrs, "link", href)
+		}
+	}
+	return nodes
+}
+
+// emphasis pairs delimiter runs into bold, italic and strikethrough
+// formats, following the CommonMark rules, and turns the delimiters left
+// into text
+func emphasis(nodes []mdNode) []mdNode {
+	// bottom is, for each delimiter, the index below which no opener is
+	// left to look for
+	bottom := map[byte]int{}
+	for c := range nodes {
+		closer := &nodes[c]
+		if closer.delim == 0 || !closer.shut {
+			continue
+		}
+		for closer.count > 0 {
+			o := c - 1
+			for ; o >= bottom[closer.delim]; o-- {
+				opener := &nodes[o]
+				if opener.delim != closer.delim || !opener.open || opener.count == 0 {
+					continue
+				}
+				if closer.delim == '~' {
+					if opener.count == closer.count {
+						break
+					}
+					continue
+				}
+				// The rule of 3 keeps *a**b* from pairing the wrong runs
+				if (opener.shut || closer.open) && (opener.length+closer.length)%3 == 0 &&
+					(opener.length%3 != 0 || closer.length%3 != 0) {
+					continue
+				}
+				break
+			}
+			if o < bottom[closer.delim] {
+				bottom[closer.delim] = c
+				break
+			}
+
+			opener := &nodes[o]
+			n, format := 1, "italic"
+			switch {
+			case closer.delim == '~':
+				n, format = closer.count, "strike"
+			case opener.count >= 2 && closer.count >= 2:
+				n, format = 2, "bold"
+			}
+			for k := o + 1; k < c; k++ {
+				if !nodes[k].br {
+					nodes[k].attrs = with(nodes[k].attrs, format, true)
+				}
+				nodes[k].open, nodes[k].shut = false, false
+			}
+			opener.count -= n
+			closer.count -= n
+		}
+	}
+
+	for i := range nodes {
+		if nodes[i].delim != 0 {
+			nodes[i].text = strings.Repeat(string(nodes[i].delim), nodes[i].count)
+			nodes[i].delim = 0
+		}
+	}
+	return nodes
+}
+
+// inlineTag returns the node of an inline HTML tag, matched by
+// inlineTagPattern. Images become image nodes.
+func inlineTag(m []string) mdNode {
+	name := strings.ToLower(m[2])
+	switch {
+	case name == "br":
+		return mdNode{br: true}
+	case name == "img" && m[1] == "":
+		n := mdNode{}
+		for _, attr := range tagAttrPattern.FindAllStringSubmatch(m[3], -1) {
+			value := html.UnescapeString(strings.Trim(attr[2], `"'`))
+			switch strings.ToLower(attr[1]) {
+			case "src":
+				n.image = value
+			case "alt":
+				n.text = value
+			}
+		}
+		if n.image == "" {
+			return mdNode{}
+		}
+		return n
+	}
+	return mdNode{tag: m[1] + name}
+}
+
+// inlineFormats maps inline HTML tags to the format they apply
+var inlineFormats = map[string][2]string{
+	"b":      {"bold", ""},
+	"strong": {"bold", ""},
+	"i":      {"italic", ""},
+	"em":     {"italic", ""},
+	"u":      {"underline", ""},
+	"ins":    {"underline", ""},
+	"s":      {"strike", ""},
+	"del":    {"strike", ""},
+	"strike": {"strike", ""},
+	"code":   {"code", ""},
+	"kbd":    {"code", ""},
+	"sub":    {"script", "sub"},
+	"sup":    {"script", "super"},
+}
+
+// applyTags applies the formats of inline HTML tags to the nodes they
+// surround and drops the tags. Unknown tags are dropped, keeping their
+// content.
+func applyTags(nodes []mdNode) []mdNode {
+	open := make(map[string]int)
+	out := nodes[:0]
+	for _, n := range nodes {
+		if n.tag != "" {
+			name := strings.TrimPrefix(n.tag, "/")
+			if _, ok := inlineFormats[name]; ok {
+				if n.tag[0] == '/' {
+					open[name] = max(open[name]-1, 0)
+				} else {
+					open[name]++
+				}
+			}
+			continue
+		}
+		for name, count := range open {
+			if count == 0 || n.br {
+				continue
+			}
+			format := inlineFormats[name]
+			var value any = true
+			if format[1] != "" {
+				value = format[1]
+			}
+			n.attrs = with(n.attrs, format[0], value)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// plainText returns the text of inline nodes
+func plainText(nodes []mdNode) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		if n.br {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteString(n.text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// frontMatter returns the title of a YAML front matter opening lines,
+// and the lines after it
+func frontMatter(lines []string) (string, []string) {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return "", lines
+	}
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimSpace(lines[i]); l == "---" || l == "..." {
+			title := ""
+			for _, l := range lines[1:i] {
+				if m := frontMatterTitle.FindStringSubmatch(l); m != nil {
+					title = strings.Trim(m[1], `"'`)
+				}
+			}
+			return title, lines[i+1:]
+		}
+	}
+	return "", lines
+}
+
+// splitRow returns the cells of a table row
+func splitRow(l string) []string {
+	l = strings.TrimSpace(l)
+	l = strings.TrimPrefix(l, "|")
+	if strings.HasSuffix(l, "|") && !strings.HasSuffix(l, `\|`) {
+		l = l[:len(l)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(l); i++ {
+		switch {
+		case l[i] == '\\' && i+1 < len(l) && l[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case l[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(l[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// closesFence reports whether l closes a code block opened by fence
+func closesFence(l, fence string) bool {
+	if indentOf(l) >= 4 {
+		return false
+	}
+	rest := strings.TrimSpace(l)
+	n := runLength(rest, 0, fence[0])
+	return n >= len(fence) && n == len(rest)
+}
+
+// closingBackticks returns the index of the first run of exactly n
+// backticks in s from i, or -1
+func closingBackticks(s string, i, n int) int {
+	for i < len(s) {
+		k := strings.IndexByte(s[i:], '`')
+		if k < 0 {
+			return -1
+		}
+		i += k
+		m := runLength(s, i, '`')
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+// runLength returns how many times c repeats in s from i
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// expandTabs turns the tabs indenting a line into spaces, up to the next
+// multiple of 4 columns
+func expandTabs(l string) string {
+	if !strings.Contains(l, "\t") {
+		return l
+	}
+	var b strings.Builder
+	for i := 0; i < len(l); i++ {
+		switch l[i] {
+		case '\t':
+			b.WriteString(strings.Repeat(" ", 4-b.Len()%4))
+		case ' ':
+			b.WriteByte(' ')
+		default:
+			b.WriteString(l[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+// indentOf returns how many spaces indent l
+func indentOf(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " "))
+}
+
+// stripIndent removes up to n spaces of indentation
+func stripIndent(l string, n int) string {
+	return l[min(indentOf(l), n):]
+}
+
+// isBlank reports whether l holds nothing but whitespace
+func isBlank(l string) bool {
+	return strings.TrimSpace(l) == ""
+}
+
+// trimBlankLines removes the blank lines ending lines
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// normalizeLabel returns the form link labels are matched by
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// unescapeMarkdown resolves the backslash escapes and entities of a link
+// destination
+func unescapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return html.UnescapeString(b.String())
+}
+
+// isASCIIPunct reports whether c is an ASCII punctuation character, which
+// a backslash escapes
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// isPunct reports whether r is punctuation or a symbol, for delimiter
+// runs
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// isWordByte reports whether c can be part of a word
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
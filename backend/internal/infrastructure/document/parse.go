@@ -0,0 +1,138 @@
+package document
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// maxNesting bounds how deep lists, quotes and elements nest in the
+// documents read. Deeper ones are read as if they were not open, which
+// keeps reading them linear.
+const maxNesting = 64
+
+// Document is a note read from another format
+type Document struct {
+	// Title is the title the document gives itself, if any
+	Title   string
+	Content domainNote.Delta
+}
+
+// ParseOptions tunes the reading of documents
+type ParseOptions struct {
+	// Resolve maps the address of a link or an image to the one the note
+	// keeps, for instance an attachment holding a file the document
+	// refers to. It returns "" to drop the link, or the image in favour of
+	// its alternative text. Nil keeps addresses unchanged.
+	Resolve func(ref string) string
+}
+
+// resolve returns the address to keep for ref, or ""
+func (o ParseOptions) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || o.Resolve == nil {
+		return ref
+	}
+	return o.Resolve(ref)
+}
+
+// builder writes a delta op by op, merging inserts that share their
+// attributes
+type builder struct {
+	ops []domainNote.Op
+	// pending is the text insert being written, with pendingAttrs, not
+	// in ops yet
+	pending      strings.Builder
+	pendingAttrs map[string]any
+	// rows numbers the table rows written so far
+	rows int
+}
+
+// text inserts text with inline attributes. Newlines must go through
+// newline.
+func (b *builder) text(s string, attrs map[string]any) {
+	if s == "" {
+		return
+	}
+	b.insert(s, attrs)
+}
+
+// embed inserts an embed such as {"image": url}
+func (b *builder) embed(embed map[string]any, attrs map[string]any) {
+	b.flush()
+	b.ops = append(b.ops, domainNote.Op{Insert: embed, Attributes: withoutEmpty(attrs)})
+}
+
+// newline ends a line with block attributes
+func (b *builder) newline(attrs map[string]any) {
+	b.insert("\n", attrs)
+}
+
+func (b *builder) insert(s string, attrs map[string]any) {
+	attrs = withoutEmpty(attrs)
+	if b.pending.Len() > 0 && !maps.Equal(b.pendingAttrs, attrs) {
+		b.flush()
+	}
+	if b.pending.Len() == 0 {
+		b.pendingAttrs = maps.Clone(attrs)
+	}
+	b.pending.WriteString(s)
+}
+
+// flush moves the text insert being written to ops
+func (b *builder) flush() {
+	if b.pending.Len() == 0 {
+		return
+	}
+	b.ops = append(b.ops, domainNote.Op{Insert: b.pending.String(), Attributes: b.pendingAttrs})
+	b.pending.Reset()
+	b.pendingAttrs = nil
+}
+
+// lineStart reports whether nothing was written since the last newline
+func (b *builder) lineStart() bool {
+	if b.pending.Len() > 0 {
+		return strings.HasSuffix(b.pending.String(), "\n")
+	}
+	return len(b.ops) == 0
+}
+
+// empty reports whether nothing was written
+func (b *builder) empty() bool {
+	return len(b.ops) == 0 && b.pending.Len() == 0
+}
+
+// row returns the ID of a new table row
+func (b *builder) row() string {
+	b.rows++
+	return "row-" + strconv.Itoa(b.rows)
+}
+
+// delta returns the document written, ended by a newline
+func (b *builder) delta() domainNote.Delta {
+	if b.empty() || !b.lineStart() {
+		b.newline(nil)
+	}
+	b.flush()
+	return domainNote.Delta{Ops: b.ops}
+}
+
+// withoutEmpty returns nil for attributes without any entry
+func withoutEmpty(attrs map[string]any) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
+// with returns a copy of attrs with name set to value
+func with(attrs map[string]any, name string, value any) map[string]any {
+	out := maps.Clone(attrs)
+	if out == nil {
+		out = make(map[string]any, 1)
+	}
+	out[name] = value
+	return out
+}
@@ -0,0 +1,206 @@
+package document
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// markdownOf renders parsed content back to Markdown, which shows every
+// format a note holds in a readable way
+func markdownOf(doc Document) string {
+	return string(Markdown("", doc.Content, Options{}))
+}
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		title string
+		want  string
+	}{
+		{
+			name:  "title and inline formats",
+			input: "# My note\n\nSome **bold *and both*** text, `code`, ~~gone~~ and [a link](https://example.com \"Title\").\nSame line  \nnext line\n",
+			title: "My note",
+			want:  "Some **bold *and both*** text, `code`, ~~gone~~ and [a link](https://example.com). Same line\n\nnext line\n",
+		},
+		{
+			name:  "front matter",
+			input: "---\ntitle: \"From front matter\"\ntags: [a]\n---\n# Heading\n\nSetext\n---\n",
+			title: "From front matter",
+			want:  "# Heading\n\n## Setext\n",
+		},
+		{
+			name:  "lists",
+			input: "- one\n  - nested\n    continued\n- [x] done\n\n1. first\n2) other list\n",
+			want:  "- one\n  - nested continued\n- [x] done\n1. first\n2. other list\n",
+		},
+		{
+			name:  "code and quote",
+			input: "```go title\nx := 1\n\ny := 2\n```\ntext\n\n    indented\n\n> quoted\nlazy\n> > nested\n",
+			want:  "```go\nx := 1\n\ny := 2\n```\n\ntext\n\n```\nindented\n```\n\n> quoted lazy\n>\n> nested\n",
+		},
+		{
+			name:  "table",
+			input: "| a | b \\| c |\n|---|:-:|\n| d |\n",
+			want:  "| a | b \\| c |\n| --- | --- |\n| d |  |\n",
+		},
+		{
+			name:  "references, autolinks and html",
+			input: "[ref][x], <https://a.example>, www.b.example and <b>tag</b><br>next\n\n[x]: https://x.example\n",
+			want:  "[ref](https://x.example), [https://a.example](https://a.example), [www.b.example](http://www.b.example) and **tag**\n\nnext\n",
+		},
+		{
+			name:  "literal delimiters",
+			input: "2 * 3 * 4, snake_case_name and \\*escaped\\*\n",
+			want:  "2 \\* 3 \\* 4, snake\\_case\\_name and \\*escaped\\*\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := ParseMarkdown([]byte(tt.input), ParseOptions{})
+			if doc.Title != tt.title {
+				t.Errorf("title = %q, want %q", doc.Title, tt.title)
+			}
+			if got := markdownOf(doc); got != tt.want {
+				t.Errorf("content =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownImages(t *testing.T) {
+	input := "![A cat](cat.png) ![Missing](gone.png)\n"
+	doc := ParseMarkdown([]byte(input), ParseOptions{Resolve: func(ref string) string {
+		if ref == "cat.png" {
+			return "/api/v1/attachments/1/content"
+		}
+		return ""
+	}})
+	if got, want := markdownOf(doc), "![A cat](/api/v1/attachments/1/content) Missing\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
+
+func TestParseMarkdownRoundTrip(t *testing.T) {
+	exported := string(Markdown("My note", parse(t, testDelta), Options{}))
+	doc := ParseMarkdown([]byte(exported), ParseOptions{})
+	if doc.Title != "My note" {
+		t.Errorf("title = %q", doc.Title)
+	}
+	if got := string(Markdown(doc.Title, doc.Content, Options{})); got != exported {
+		t.Errorf("round trip =\n%s\nwant\n%s", got, exported)
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	input := `<!DOCTYPE html>
+<html><head><title>My &amp; note</title><style>p { color: red }</style>
+<script>alert("<p>")</script></head>
+<body>
+<h1>My &amp; note</h1>
+<p>Some <b>bold</b>, <span style="font-style: italic">styled</span> and
+   <a href="https://example.com">linked</a> text<br>on two lines</p>
+<h2 style="text-align: center">Plan</h2>
+<ul>
+  <li>one
+    <ol><li>nested</li></ol>
+  <li><input type="checkbox" checked> done
+</ul>
+<pre><code class="language-go">x := 1
+y := 2</code></pre>
+<blockquote><p>quoted</p></blockquote>
+<table><tr><th>a</th><td></td></tr><tr><td>c<td>d</table>
+<div><img src="cat.png" alt="A cat" width="80"><!-- comment --></div>
+</body></html>`
+	doc := ParseHTML([]byte(input), ParseOptions{})
+	if doc.Title != "My & note" {
+		t.Errorf("title = %q", doc.Title)
+	}
+	want := "Some **bold**, *styled* and [linked](https://example.com) text\n" +
+		"\n" +
+		"on two lines\n" +
+		"\n" +
+		"## Plan\n" +
+		"\n" +
+		"- one\n" +
+		"  1. nested\n" +
+		"- [x] done\n" +
+		"\n" +
+		"```go\n" +
+		"x := 1\n" +
+		"y := 2\n" +
+		"```\n" +
+		"\n" +
+		"> quoted\n" +
+		"\n" +
+		"| a |  |\n" +
+		"| --- | --- |\n" +
+		"| c | d |\n" +
+		"\n" +
+		"![A cat](cat.png)\n"
+	if got := markdownOf(doc); got != want {
+		t.Errorf("content =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(string(doc.Content.JSON()), "alert") {
+		t.Errorf("content keeps the script: %s", doc.Content.JSON())
+	}
+}
+
+func TestParseENEX(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export>
+<note>
+<title>Groceries</title>
+<content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div><span style="font-weight: bold;">Buy</span>&nbsp;soon</div>
+<div><en-todo checked="true"/>milk</div><div><en-todo/>eggs</div>
+<div><en-media hash="` + md5Hex(png) + `" type="image/png"/></div></en-note>]]></content>
+<resource>
+<data encoding="base64">` + base64.StdEncoding.EncodeToString(png) + `</data>
+<mime>image/png</mime>
+<resource-attributes><file-name>list.png</file-name></resource-attributes>
+</resource>
+</note>
+</en-export>`
+	notes, err := ParseENEX(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseENEX: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Title != "Groceries" || len(notes[0].Resources) != 1 {
+		t.Fatalf("notes = %+v", notes)
+	}
+
+	var attached []string
+	doc := notes[0].Document(ParseOptions{}, func(r Resource) string {
+		attached = append(attached, r.Name)
+		return "/api/v1/attachments/1/content"
+	})
+	want := "**Buy** soon\n" +
+		"\n" +
+		"- [x] milk\n" +
+		"- [ ] eggs\n" +
+		"\n" +
+		"![list.png](/api/v1/attachments/1/content)\n"
+	if got := markdownOf(doc); got != want {
+		t.Errorf("content =\n%s\nwant\n%s", got, want)
+	}
+	if len(attached) != 1 || attached[0] != "list.png" {
+		t.Errorf("attached %v", attached)
+	}
+
+	if _, err := ParseENEX(strings.NewReader("<html></html>")); err != ErrNotENEX {
+		t.Errorf("ParseENEX(html) error = %v, want ErrNotENEX", err)
+	}
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
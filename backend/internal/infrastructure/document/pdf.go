@@ -0,0 +1,709 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	domainNote "notes-app/backend/internal/domain/note"
+	"notes-app/backend/internal/infrastructure/pdf"
+)
+
+// Page layout, in points
+const (
+	pdfMargin = 56.0
+	// pdfTop is where the content of a page starts, below its header
+	pdfTop        = 72.0
+	pdfBottom     = pdf.A4Height - pdfMargin
+	pdfWidth      = pdf.A4Width - 2*pdfMargin
+	pdfHeaderLine = 40.0
+	pdfTitleSize  = 20.0
+	pdfBodySize   = 11.0
+	pdfCodeSize   = 9.0
+	pdfSmallSize  = 9.0
+	pdfLeading    = 1.4
+	// pdfIndent is the width of an indent or list level
+	pdfIndent = 18.0
+	// pdfPixel converts the sizes the editor gives images, in CSS pixels
+	pdfPixel = 0.75
+)
+
+// pdfHeadingSizes are the font sizes of headings, by level
+var pdfHeadingSizes = [6]float64{18, 15, 13, 12, 11, 10}
+
+var (
+	pdfInk            = pdf.Color{R: 33, G: 37, B: 41}
+	pdfMuted          = pdf.Color{R: 108, G: 117, B: 125}
+	pdfLinkColor      = pdf.Color{R: 13, G: 110, B: 253}
+	pdfRule           = pdf.Color{R: 206, G: 212, B: 218}
+	pdfCodeBackground = pdf.Color{R: 241, G: 243, B: 245}
+)
+
+// Section is a note of a PDF document
+type Section struct {
+	Title   string
+	Content domainNote.Delta
+}
+
+// PDF renders notes as an A4 document, each starting on a new page under
+// its title. Pages are headed with the title of the document and their
+// number, and the outline lists the notes and their headings. Images
+// are drawn when opts.LoadImage returns them as JPEG, PNG or GIF, links
+// to web and mail addresses can be followed. The fonts cover Latin, Greek
+// and Cyrillic scripts among others; the characters they lack, such as
+// Chinese characters and emoji, print as boxes and are returned.
+func PDF(title string, sections []Section, opts Options) (data []byte, missing []rune) {
+	l := &pdfLayout{doc: pdf.New(title), opts: opts, images: make(map[string]*pdf.Image)}
+	for _, s := range sections {
+		l.section(s)
+	}
+	if len(sections) == 0 {
+		l.newPage()
+	}
+	l.headers(title)
+	return l.doc.Bytes(), l.doc.Missing()
+}
+
+// pdfLayout places the content of notes on pages
+type pdfLayout struct {
+	doc  *pdf.Document
+	opts Options
+	page *pdf.Page
+	// y is the top of the space left on the page
+	y float64
+	// outline is the entry of the note being laid out
+	outline *pdf.OutlineItem
+	// images holds the images loaded so far by address, nil for those
+	// that cannot be drawn
+	images map[string]*pdf.Image
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = l.doc.AddPage(pdf.A4Width, pdf.A4Height)
+	l.y = pdfTop
+}
+
+// ensure starts a new page unless height fits on the current one. A
+// page holding nothing yet is kept whatever the height.
+func (l *pdfLayout) ensure(height float64) {
+	if l.y+height > pdfBottom && l.y > pdfTop {
+		l.newPage()
+	}
+}
+
+// section lays out a note from a new page
+func (l *pdfLayout) section(s Section) {
+	l.newPage()
+	title := strings.TrimSpace(s.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	l.outline = l.doc.Outline(nil, title, l.page, l.y)
+	style := pdfStyle{bold: true, size: pdfTitleSize, color: pdfInk}
+	l.text([]run{{text: title}}, style, pdfMargin, pdfWidth, "", nil)
+	l.y += 4
+	l.page.Line(pdfMargin, l.y, pdfMargin+pdfWidth, l.y, 0.5, pdfRule)
+	l.y += 12
+
+	for _, blk := range splitBlocks(s.Content) {
+		l.block(blk)
+	}
+}
+
+// headers writes the title of the document and the page number at the
+// top of every page
+func (l *pdfLayout) headers(title string) {
+	pages := l.doc.Pages()
+	for i, p := range pages {
+		number := strconv.Itoa(i+1) + " / " + strconv.Itoa(len(pages))
+		numberWidth := pdf.Sans.Width(number, pdfSmallSize)
+		p.Text(pdfMargin+pdfWidth-numberWidth, pdfHeaderLine, pdf.Sans, pdfSmallSize, pdfMuted, number)
+		p.Text(pdfMargin, pdfHeaderLine, pdf.Sans, pdfSmallSize, pdfMuted,
+			ellipsize(title, pdf.Sans, pdfSmallSize, pdfWidth-numberWidth-pdfIndent))
+		p.Line(pdfMargin, pdfHeaderLine+8, pdfMargin+pdfWidth, pdfHeaderLine+8, 0.5, pdfRule)
+	}
+}
+
+// block lays out a block
+func (l *pdfLayout) block(blk block) {
+	body := pdfStyle{size: pdfBodySize, color: pdfInk}
+	switch blk.kind {
+	case blockHeading:
+		ln := blk.lines[0]
+		level := headingLevel(ln)
+		size := pdfHeadingSizes[level-1]
+		if l.y > pdfTop {
+			l.y += size * 0.6
+		}
+		// A heading stays with the line after it
+		l.ensure((size + pdfBodySize) * pdfLeading)
+		if text := strings.TrimSpace(ln.text()); text != "" && level <= 3 {
+			l.doc.Outline(l.outline, text, l.page, l.y)
+		}
+		left, width := indented(ln)
+		l.text(ln.runs, pdfStyle{bold: true, size: size, color: pdfInk}, left, width, lineAlign(ln), nil)
+		l.y += 2
+
+	case blockQuote:
+		for _, ln := range blk.lines {
+			style := body
+			style.color = pdfMuted
+			l.text(ln.runs, style, pdfMargin+pdfIndent, pdfWidth-pdfIndent, lineAlign(ln), func(top, height float64, _ bool) {
+				l.page.Rect(pdfMargin+4, top, 3, height, pdfRule)
+			})
+		}
+		l.y += 6
+
+	case blockCode:
+		l.code(blk.lines)
+
+	case blockList:
+		l.list(blk.lines, body)
+
+	case blockTable:
+		l.table(blk.lines, body)
+
+	default:
+		ln := blk.lines[0]
+		left, width := indented(ln)
+		l.text(ln.runs, body, left, width, lineAlign(ln), nil)
+	}
+}
+
+// indented returns where an indented line starts and how wide it is
+func indented(ln line) (left, width float64) {
+	indent := float64(min(max(intAttr(ln.attrs, "indent"), 0), maxIndent))
+	return pdfMargin + indent*pdfIndent, pdfWidth - indent*pdfIndent
+}
+
+// lineAlign returns how a line is aligned: "", center or right.
+// Justified lines are set flush left.
+func lineAlign(ln line) string {
+	switch align := stringAttr(ln.attrs, "align"); align {
+	case "center", "right":
+		return align
+	}
+	return ""
+}
+
+// code lays out the lines of a code block, cutting them where they are
+// too wide to fit
+func (l *pdfLayout) code(lines []line) {
+	const padding = 6.0
+	height := pdfCodeSize * 1.5
+	columns := max(int((pdfWidth-2*padding)/pdf.Mono.Width("m", pdfCodeSize)), 1)
+
+	l.ensure(padding + height)
+	l.page.Rect(pdfMargin, l.y, pdfWidth, padding, pdfCodeBackground)
+	l.y += padding
+	for _, ln := range lines {
+		for _, chunk := range chunks(strings.ReplaceAll(ln.text(), "\t", "    "), columns) {
+			l.ensure(height)
+			l.page.Rect(pdfMargin, l.y, pdfWidth, height, pdfCodeBackground)
+			l.page.Text(pdfMargin+padding, l.y+height*0.7, pdf.Mono, pdfCodeSize, pdfInk, chunk)
+			l.y += height
+		}
+	}
+	l.page.Rect(pdfMargin, l.y, pdfWidth, padding, pdfCodeBackground)
+	l.y += padding + 6
+}
+
+// chunks cuts s into pieces of at most n characters. An empty s makes a
+// single empty piece.
+func chunks(s string, n int) []string {
+	var out []string
+	for utf8.RuneCountInString(s) > n {
+		i, count := 0, 0
+		for count < n {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+			count++
+		}
+		out = append(out, s[:i])
+		s = s[i:]
+	}
+	return append(out, s)
+}
+
+// list lays out the items of a list, numbering ordered items per level
+func (l *pdfLayout) list(lines []line, body pdfStyle) {
+	type level struct {
+		kind   string
+		number int
+	}
+	var levels []level
+	for _, ln := range lines {
+		kind, depth := listItem(ln)
+		depth = min(depth, len(levels), maxIndent)
+		if depth < len(levels) && levels[depth].kind != kind && !(isTask(kind) && isTask(levels[depth].kind)) {
+			levels = levels[:depth]
+		}
+		number := 1
+		if depth < len(levels) {
+			number = levels[depth].number + 1
+		}
+		levels = append(levels[:depth], level{kind: kind, number: number})
+
+		left := pdfMargin + float64(depth+1)*pdfIndent
+		l.text(ln.runs, body, left, pdfMargin+pdfWidth-left, lineAlign(ln), func(top, height float64, first bool) {
+			if first {
+				l.marker(kind, depth, number, left, top, height)
+			}
+		})
+	}
+	l.y += 6
+}
+
+// marker draws the bullet, number or check box of a list item before
+// left, on the line at top
+func (l *pdfLayout) marker(kind string, depth, number int, left, top, height float64) {
+	baseline := pdfBaseline(top, height, pdfBodySize)
+	switch kind {
+	case "checked", "unchecked":
+		const side = 8.0
+		x, y := left-side-6, baseline-side
+		if kind == "checked" {
+			l.page.Rect(x, y, side, side, pdfMuted)
+			l.page.Line(x+1.8, y+4.2, x+3.4, y+6, 1.2, pdf.Color{R: 255, G: 255, B: 255})
+			l.page.Line(x+3.4, y+6, x+6.4, y+2, 1.2, pdf.Color{R: 255, G: 255, B: 255})
+		} else {
+			l.page.StrokeRect(x, y, side, side, 0.8, pdfMuted)
+		}
+	default:
+		marker := "•"
+		switch {
+		case kind == "ordered":
+			marker = strconv.Itoa(number) + "."
+		case depth%2 == 1:
+			marker = "–"
+		}
+		width := pdf.Sans.Width(marker, pdfBodySize)
+		l.page.Text(left-6-width, baseline, pdf.Sans, pdfBodySize, pdfInk, marker)
+	}
+}
+
+// table lays out a table, its columns sharing the width evenly. Rows are
+// not split across pages.
+func (l *pdfLayout) table(lines []line, body pdfStyle) {
+	const padding = 4.0
+	rows := tableRows(lines)
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	columnWidth := pdfWidth / float64(columns)
+
+	for _, row := range rows {
+		cells := make([][]pdfLine, len(row))
+		height := 0.0
+		for i, cell := range row {
+			cells[i] = wrapSpans(l.spans(cell.runs, body), columnWidth-2*padding)
+			height = max(height, linesHeight(cells[i]))
+		}
+		height += 2 * padding
+		l.ensure(height)
+		for i := range columns {
+			x := pdfMargin + float64(i)*columnWidth
+			l.page.StrokeRect(x, l.y, columnWidth, height, 0.5, pdfRule)
+			if i >= len(cells) {
+				continue
+			}
+			y := l.y + padding
+			for _, pl := range cells[i] {
+				h := pl.height()
+				l.drawLine(pl, x+padding+offset(pl, columnWidth-2*padding, lineAlign(row[i])), y, h)
+				y += h
+			}
+		}
+		l.y += height
+	}
+	l.y += 6
+}
+
+// text lays out the runs of a line as wrapped lines between left and
+// left+width. Images the note embeds are drawn on lines of their own.
+// decorate, if not nil, draws what goes with each line, such as a list
+// marker on the first one, before its text.
+func (l *pdfLayout) text(runs []run, base pdfStyle, left, width float64, align string, decorate func(top, height float64, first bool)) {
+	placed := false
+	var spans []pdfSpan
+	flush := func() {
+		if len(spans) == 0 {
+			return
+		}
+		for _, pl := range wrapSpans(spans, width) {
+			h := pl.height()
+			l.ensure(h)
+			if decorate != nil {
+				decorate(l.y, h, !placed)
+			}
+			l.drawLine(pl, left+offset(pl, width, align), l.y, h)
+			l.y += h
+			placed = true
+		}
+		spans = nil
+	}
+
+	for _, r := range runs {
+		if src, ok := embedURL(r.embed, "image"); ok {
+			if img := l.image(src); img != nil {
+				flush()
+				l.drawImage(img, r.attrs, left, width, align, decorate, !placed)
+				placed = true
+				continue
+			}
+		}
+		spans = append(spans, l.spans([]run{r}, base)...)
+	}
+	flush()
+	if !placed {
+		// An empty line still takes its height
+		h := base.size * pdfLeading
+		l.ensure(h)
+		if decorate != nil {
+			decorate(l.y, h, true)
+		}
+		l.y += h
+	}
+}
+
+// spans returns the text of runs with its style. Embeds turn into text:
+// images into their alternative text, videos into a link to them.
+func (l *pdfLayout) spans(runs []run, base pdfStyle) []pdfSpan {
+	var spans []pdfSpan
+	for _, r := range runs {
+		style := l.style(base, r.attrs)
+		switch {
+		case r.embed == nil:
+			spans = append(spans, pdfSpan{text: r.text, style: style})
+		case r.embed["image"] != nil:
+			if alt := stringAttr(r.attrs, "alt"); alt != "" {
+				style.italic, style.color = true, pdfMuted
+				spans = append(spans, pdfSpan{text: "[" + alt + "]", style: style})
+			}
+		case r.embed["video"] != nil:
+			src, _ := embedURL(r.embed, "video")
+			if src = l.opts.url(src); src != "" {
+				style.link, style.color, style.underline = src, pdfLinkColor, true
+				spans = append(spans, pdfSpan{text: src, style: style})
+			}
+		case r.embed["formula"] != nil:
+			formula, _ := r.embed["formula"].(string)
+			style.mono = true
+			spans = append(spans, pdfSpan{text: formula, style: style})
+		}
+	}
+	return spans
+}
+
+// style returns the style of text with inline attributes
+func (l *pdfLayout) style(base pdfStyle, attrs map[string]any) pdfStyle {
+	s := base
+	s.bold = s.bold || boolAttr(attrs, "bold")
+	s.italic = s.italic || boolAttr(attrs, "italic")
+	s.underline = s.underline || boolAttr(attrs, "underline")
+	s.strike = s.strike || boolAttr(attrs, "strike")
+	if boolAttr(attrs, "code") {
+		s.mono = true
+		s.background, s.highlight = pdfCodeBackground, true
+	}
+	if c, ok := parseColor(stringAttr(attrs, "color")); ok {
+		s.color = c
+	}
+	if c, ok := parseColor(stringAttr(attrs, "background")); ok {
+		s.background, s.highlight = c, true
+	}
+	switch stringAttr(attrs, "script") {
+	case "super":
+		s.rise = s.size * 0.33
+		s.size *= 0.7
+	case "sub":
+		s.rise = -s.size * 0.15
+		s.size *= 0.7
+	}
+	if href := l.opts.url(stringAttr(attrs, "link")); href != "" {
+		s.link, s.color, s.underline = href, pdfLinkColor, true
+	}
+	return s
+}
+
+// image returns an image the note embeds, or nil when it cannot be drawn
+func (l *pdfLayout) image(src string) *pdf.Image {
+	src = strings.TrimSpace(src)
+	if l.opts.LoadImage == nil || src == "" || !domainNote.SafeURL(src) {
+		return nil
+	}
+	if img, ok := l.images[src]; ok {
+		return img
+	}
+	var img *pdf.Image
+	if data := l.opts.LoadImage(src); data != nil {
+		img, _ = l.doc.AddImage(data)
+	}
+	l.images[src] = img
+	return img
+}
+
+// drawImage draws an image on a line of its own, at the width the editor
+// gives it or its own, shrunk to fit the page
+func (l *pdfLayout) drawImage(img *pdf.Image, attrs map[string]any, left, width float64, align string, decorate func(float64, float64, bool), first bool) {
+	pixelsWide, pixelsHigh := img.Size()
+	w, h := float64(pixelsWide)*pdfPixel, float64(pixelsHigh)*pdfPixel
+	if n := intAttr(attrs, "width"); n > 0 {
+		w, h = float64(n)*pdfPixel, h*float64(n)*pdfPixel/w
+	}
+	if w > width {
+		w, h = width, h*width/w
+	}
+	if maxHeight := pdfBottom - pdfTop; h > maxHeight {
+		w, h = w*maxHeight/h, maxHeight
+	}
+
+	l.ensure(h + 4)
+	if decorate != nil {
+		decorate(l.y, h+4, first)
+	}
+	x := left
+	switch align {
+	case "center":
+		x += (width - w) / 2
+	case "right":
+		x += width - w
+	}
+	l.page.Image(img, x, l.y+2, w, h)
+	if href := l.opts.url(stringAttr(attrs, "link")); followable(href) {
+		l.page.Link(x, l.y+2, w, h, href)
+	}
+	l.y += h + 4
+}
+
+// drawLine draws a line of text from x, top being the top of the line
+func (l *pdfLayout) drawLine(pl pdfLine, x, top, height float64) {
+	baseline := pdfBaseline(top, height, pl.size)
+	for _, span := range pl.spans {
+		s := span.style
+		width := s.width(span.text)
+		if s.highlight {
+			l.page.Rect(x, baseline-s.size*0.8, width, s.size*1.05, s.background)
+		}
+		y := baseline - s.rise
+		l.page.Text(x, y, s.font(), s.size, s.color, span.text)
+		if s.underline {
+			l.page.Line(x, y+s.size*0.12, x+width, y+s.size*0.12, s.size*0.06, s.color)
+		}
+		if s.strike {
+			l.page.Line(x, y-s.size*0.28, x+width, y-s.size*0.28, s.size*0.06, s.color)
+		}
+		if followable(s.link) {
+			l.page.Link(x, y-s.size*0.8, width, s.size*1.05, s.link)
+		}
+		x += width
+	}
+}
+
+// pdfBaseline returns the baseline of a line of text of the given size
+func pdfBaseline(top, height, size float64) float64 {
+	return top + (height-size)/2 + size*0.8
+}
+
+// followable reports whether a link leads somewhere out of the document,
+// unlike addresses relative to the app
+func followable(href string) bool {
+	return strings.Contains(href, ":")
+}
+
+// offset returns where a line starts within width as it is aligned
+func offset(pl pdfLine, width float64, align string) float64 {
+	switch align {
+	case "center":
+		return max(width-pl.width, 0) / 2
+	case "right":
+		return max(width-pl.width, 0)
+	}
+	return 0
+}
+
+// ellipsize cuts s to fit width, marking the cut with an ellipsis
+func ellipsize(s string, font pdf.Font, size, width float64) string {
+	if font.Width(s, size) <= width {
+		return s
+	}
+	n, _ := fit(s, pdfStyle{size: size}, width-font.Width("…", size))
+	return strings.TrimSpace(s[:n]) + "…"
+}
+
+// pdfStyle is how a piece of text is set
+type pdfStyle struct {
+	bold, italic, mono bool
+	size               float64
+	color              pdf.Color
+	// background is drawn behind the text when highlight is set
+	background pdf.Color
+	highlight  bool
+	underline  bool
+	strike     bool
+	// rise raises the text above the baseline, or lowers it
+	rise float64
+	link string
+}
+
+func (s pdfStyle) font() pdf.Font {
+	if s.mono {
+		return pdf.Mono.Styled(s.bold, s.italic)
+	}
+	return pdf.Sans.Styled(s.bold, s.italic)
+}
+
+func (s pdfStyle) width(text string) float64 {
+	return s.font().Width(text, s.size)
+}
+
+// pdfSpan is text set in one style
+type pdfSpan struct {
+	text  string
+	style pdfStyle
+}
+
+// pdfLine is a line of wrapped text
+type pdfLine struct {
+	spans []pdfSpan
+	width float64
+	// size is the largest font size of the line
+	size float64
+}
+
+func (pl pdfLine) height() float64 {
+	return pl.size * pdfLeading
+}
+
+// add appends text to the line, merging it with the last span when they
+// share their style
+func (pl *pdfLine) add(span pdfSpan, width float64) {
+	if n := len(pl.spans); n > 0 && pl.spans[n-1].style == span.style {
+		pl.spans[n-1].text += span.text
+	} else {
+		pl.spans = append(pl.spans, span)
+	}
+	pl.width += width
+	pl.size = max(pl.size, span.style.size)
+}
+
+// wrapSpans breaks text into lines no wider than width. Lines break
+// between words, runs of spaces collapsing into one; words wider than a
+// line are broken where they reach its end. Empty text makes one empty
+// line.
+func wrapSpans(spans []pdfSpan, width float64) []pdfLine {
+	var lines []pdfLine
+	var current pdfLine
+	// word is the word being read, which may mix styles, and space the
+	// space before it
+	var word []pdfSpan
+	wordWidth := 0.0
+	var space *pdfSpan
+
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		spaceWidth := 0.0
+		if space != nil && len(current.spans) > 0 {
+			spaceWidth = space.style.width(" ")
+		}
+		if len(current.spans) > 0 && current.width+spaceWidth+wordWidth > width {
+			lines = append(lines, current)
+			current, spaceWidth = pdfLine{}, 0
+		}
+		if spaceWidth > 0 {
+			current.add(pdfSpan{text: " ", style: space.style}, spaceWidth)
+		}
+		for _, span := range word {
+			if wordWidth <= width {
+				current.add(span, span.style.width(span.text))
+				continue
+			}
+			for rest := span.text; rest != ""; {
+				n, w := fit(rest, span.style, width-current.width)
+				if n == 0 && len(current.spans) > 0 {
+					lines = append(lines, current)
+					current = pdfLine{}
+					continue
+				}
+				if n == 0 {
+					_, n = utf8.DecodeRuneInString(rest)
+					w = span.style.width(rest[:n])
+				}
+				current.add(pdfSpan{text: rest[:n], style: span.style}, w)
+				rest = rest[n:]
+			}
+		}
+		word, wordWidth, space = nil, 0, nil
+	}
+
+	for _, span := range spans {
+		text := span.text
+		for text != "" {
+			i := strings.IndexAny(text, " \t\n")
+			if i == 0 {
+				flushWord()
+				if space == nil {
+					space = &pdfSpan{text: " ", style: span.style}
+				}
+				text = text[1:]
+				continue
+			}
+			if i < 0 {
+				i = len(text)
+			}
+			word = append(word, pdfSpan{text: text[:i], style: span.style})
+			wordWidth += span.style.width(text[:i])
+			text = text[i:]
+		}
+	}
+	flushWord()
+	if len(current.spans) > 0 || len(lines) == 0 {
+		if current.size == 0 && len(spans) > 0 {
+			current.size = spans[0].style.size
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// fit returns how many bytes of the start of s fit in width, and their
+// width
+func fit(s string, style pdfStyle, width float64) (int, float64) {
+	total := 0.0
+	for i, r := range s {
+		w := style.width(string(r))
+		if total+w > width {
+			return i, total
+		}
+		total += w
+	}
+	return len(s), total
+}
+
+// linesHeight returns the height of lines of text
+func linesHeight(lines []pdfLine) float64 {
+	total := 0.0
+	for _, pl := range lines {
+		total += pl.height()
+	}
+	return total
+}
+
+// parseColor reads a color written #rgb or #rrggbb
+func parseColor(s string) (pdf.Color, bool) {
+	if !strings.HasPrefix(s, "#") || (len(s) != 4 && len(s) != 7) {
+		return pdf.Color{}, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return pdf.Color{}, false
+	}
+	return pdf.Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, true
+}
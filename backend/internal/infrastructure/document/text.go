@@ -0,0 +1,79 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// Text renders a note as plain text: the title, a blank line and the
+// lines of the note. List items keep a marker and their indentation,
+// table cells are separated by tabs and images are replaced by their
+// alternative text.
+func Text(title string, content domainNote.Delta) []byte {
+	var out []string
+	if title != "" {
+		out = append(out, title, "")
+	}
+	for _, blk := range splitBlocks(content) {
+		switch blk.kind {
+		case blockList:
+			numbers := map[int]int{}
+			for _, l := range blk.lines {
+				kind, depth := listItem(l)
+				for deeper := range numbers {
+					if deeper > depth {
+						delete(numbers, deeper)
+					}
+				}
+				marker := "- "
+				switch kind {
+				case "ordered":
+					numbers[depth]++
+					marker = strconv.Itoa(numbers[depth]) + ". "
+				case "checked":
+					marker = "[x] "
+				case "unchecked":
+					marker = "[ ] "
+				}
+				out = append(out, strings.Repeat("  ", depth)+marker+textLine(l))
+			}
+		case blockQuote:
+			for _, l := range blk.lines {
+				out = append(out, "> "+textLine(l))
+			}
+		case blockTable:
+			for _, row := range tableRows(blk.lines) {
+				cells := make([]string, len(row))
+				for i, cell := range row {
+					cells[i] = textLine(cell)
+				}
+				out = append(out, strings.Join(cells, "\t"))
+			}
+		default:
+			for _, l := range blk.lines {
+				out = append(out, textLine(l))
+			}
+		}
+	}
+	return []byte(strings.TrimRight(strings.Join(out, "\n"), "\n") + "\n")
+}
+
+// textLine returns the text of a line, with images as their alternative
+// text and formulas as written
+func textLine(l line) string {
+	var b strings.Builder
+	for _, r := range l.runs {
+		switch {
+		case r.embed == nil:
+			b.WriteString(r.text)
+		case r.embed["image"] != nil:
+			b.WriteString(stringAttr(r.attrs, "alt"))
+		case r.embed["formula"] != nil:
+			formula, _ := r.embed["formula"].(string)
+			b.WriteString(formula)
+		}
+	}
+	return strings.TrimRight(b.String(), " \t")
+}
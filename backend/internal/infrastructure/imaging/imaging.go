@@ -0,0 +1,156 @@
+// Package imaging prepares uploaded images to be served: it strips their
+// metadata, turns them upright and renders smaller variants. It only uses
+// the standard library, so it decodes JPEG, PNG and GIF; WebP images are
+// stripped and measured but not decoded.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+var (
+	// ErrInvalid reports an image that cannot be read
+	ErrInvalid = errors.New("imaging: invalid image")
+	// ErrTooLarge reports an image with more pixels than Options.MaxPixels
+	ErrTooLarge = errors.New("imaging: image too large")
+)
+
+// jpegQuality is the quality JPEG renditions are encoded at
+const jpegQuality = 85
+
+// Options bound the variants Process renders
+type Options struct {
+	// ThumbnailSize is the side of the square thumbnails fit in
+	ThumbnailSize int
+	// DisplayWidth is the widest the display variant gets
+	DisplayWidth int
+	// MaxPixels is the largest image, in pixels, that is decoded. Decoding
+	// takes four bytes of memory per pixel.
+	MaxPixels int
+}
+
+// Rendition is an encoded image
+type Rendition struct {
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Result is what Process made of an image
+type Result struct {
+	// Width and Height are the upright dimensions of the image
+	Width  int
+	Height int
+	// Upright replaces the image when it had to be turned, nil otherwise
+	Upright *Rendition
+	// Display and Thumbnail are nil when the image is small enough
+	// already. GIFs get no display variant, which would stop animations.
+	Display   *Rendition
+	Thumbnail *Rendition
+}
+
+// Process measures an image stripped by Strip, turns it upright and
+// renders its variants. Renditions have the format of the image, except
+// that GIF variants are PNG.
+func Process(contentType string, data []byte, opts Options) (*Result, error) {
+	if contentType == "image/webp" {
+		width, height, err := webpSize(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Width: width, Height: height}, nil
+	}
+
+	img, err := decode(contentType, data, opts.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+	rgba := toRGBA(img)
+	orientation := orientationOf(contentType, data)
+	rgba = orient(rgba, orientation)
+
+	encodeAs := contentType
+	if contentType == "image/gif" {
+		encodeAs = "image/png"
+	}
+	render := func(img *image.RGBA) (*Rendition, error) {
+		var buf bytes.Buffer
+		var err error
+		if encodeAs == "image/jpeg" {
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = png.Encode(&buf, img)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &Rendition{ContentType: encodeAs, Width: img.Rect.Dx(), Height: img.Rect.Dy(), Data: buf.Bytes()}, nil
+	}
+
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+	result := &Result{Width: w, Height: h}
+	if orientation != 1 {
+		if result.Upright, err = render(rgba); err != nil {
+			return nil, err
+		}
+	}
+	if dw, dh := fit(w, h, opts.DisplayWidth, h); dw < w && contentType != "image/gif" {
+		if result.Display, err = render(resize(rgba, dw, dh)); err != nil {
+			return nil, err
+		}
+	}
+	if tw, th := fit(w, h, opts.ThumbnailSize, opts.ThumbnailSize); tw < w || th < h {
+		if result.Thumbnail, err = render(resize(rgba, tw, th)); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// decode decodes an image, or the first frame of a GIF, after checking its
+// size
+func decode(contentType string, data []byte, maxPixels int) (image.Image, error) {
+	var decodeConfig func([]byte) (image.Config, error)
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+	case "image/png":
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+	case "image/gif":
+		decodeConfig = func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) }
+	default:
+		return nil, fmt.Errorf("%w: cannot decode %s", ErrInvalid, contentType)
+	}
+
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: empty image", ErrInvalid)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, config.Width, config.Height)
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,160 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testImage is w by h pixels, red in its top-left quarter and blue
+// elsewhere, so orientation can be checked
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x < w/2 && y < h/2 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// withSegments inserts raw segments after the start of a JPEG
+func withSegments(jpg []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, jpg[2:]...)
+}
+
+func segment(marker byte, payload []byte) []byte {
+	s := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(s[2:], uint16(len(payload)+2))
+	return append(s, payload...)
+}
+
+func TestStripJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	// EXIF turned right (orientation 6) with a GPS directory, XMP, a
+	// comment and a preview appended after the image
+	exif := append([]byte("Exif\x00\x00"), orientationTIFF(6)...)
+	exif = append(exif, []byte("GPSLatitude 52.37")...)
+	original := withSegments(buf.Bytes(),
+		segment(markerAPP1, exif),
+		segment(markerAPP1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")),
+		segment(markerCOM, []byte("taken at home")),
+	)
+	original = append(original, []byte("\xFF\xD8GPSLatitude")...)
+
+	stripped, err := Strip("image/jpeg", original)
+	if err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	for _, leak := range []string{"GPSLatitude", "xmpmeta", "taken at home"} {
+		if bytes.Contains(stripped, []byte(leak)) {
+			t.Errorf("stripped image still contains %q", leak)
+		}
+	}
+	if got := orientationOf("image/jpeg", stripped); got != 6 {
+		t.Errorf("orientation = %d, want 6", got)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Fatalf("stripped image does not decode: %v", err)
+	}
+
+	result, err := Process("image/jpeg", stripped, Options{ThumbnailSize: 10, DisplayWidth: 16, MaxPixels: 10000})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if result.Width != 20 || result.Height != 40 || result.Upright == nil {
+		t.Fatalf("result = %dx%d, upright %v", result.Width, result.Height, result.Upright != nil)
+	}
+	upright, err := jpeg.Decode(bytes.NewReader(result.Upright.Data))
+	if err != nil {
+		t.Fatalf("upright image does not decode: %v", err)
+	}
+	// Turning right moves the red quarter to the top right
+	if r, _, b, _ := upright.At(15, 5).RGBA(); r < b {
+		t.Errorf("top right of upright image is not red")
+	}
+	if orientationOf("image/jpeg", result.Upright.Data) != 1 {
+		t.Errorf("upright image still carries an orientation")
+	}
+	if d := result.Display; d == nil || d.Width != 16 || d.Height != 32 || d.ContentType != "image/jpeg" {
+		t.Errorf("display = %+v", d)
+	}
+	if th := result.Thumbnail; th == nil || th.Width != 5 || th.Height != 10 {
+		t.Errorf("thumbnail = %+v", th)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	// Text and EXIF chunks go after the header
+	original := append([]byte{}, buf.Bytes()[:33]...)
+	original = append(original, pngChunk("tEXt", []byte("Author\x00Jane"))...)
+	original = append(original, pngChunk("eXIf", orientationTIFF(3))...)
+	original = append(original, buf.Bytes()[33:]...)
+
+	stripped, err := Strip("image/png", original)
+	if err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	if bytes.Contains(stripped, []byte("Jane")) {
+		t.Error("stripped image still contains its text")
+	}
+	if got := orientationOf("image/png", stripped); got != 3 {
+		t.Errorf("orientation = %d, want 3", got)
+	}
+	if _, err := png.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Fatalf("stripped image does not decode: %v", err)
+	}
+
+	result, err := Process("image/png", stripped, Options{ThumbnailSize: 64, DisplayWidth: 64, MaxPixels: 64})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if result.Upright == nil || result.Display != nil || result.Thumbnail != nil {
+		t.Errorf("result = %+v, want only an upright rendition", result)
+	}
+
+	if _, err := Process("image/png", stripped, Options{MaxPixels: 63}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Process over the pixel limit: err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestStripInvalid(t *testing.T) {
+	for _, contentType := range []string{"image/jpeg", "image/png", "image/webp"} {
+		if _, err := Strip(contentType, []byte("not an image")); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Strip(%s): err = %v, want ErrInvalid", contentType, err)
+		}
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	img := resize(testImage(4, 4), 2, 2)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("top left = %v, want red", got)
+	}
+	if got := img.RGBAAt(1, 1); got != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("bottom right = %v, want blue", got)
+	}
+	if got := resize(testImage(2, 2), 1, 1).RGBAAt(0, 0); got != (color.RGBA{R: 64, B: 191, A: 255}) {
+		t.Errorf("average = %v", got)
+	}
+}
@@ -0,0 +1,352 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// Strip removes the metadata of a JPEG, PNG or WebP image without
+// re-encoding it: EXIF (GPS position, camera, timestamps), XMP, IPTC and
+// comments. Only the EXIF orientation is kept, in a minimal EXIF block
+// of its own, so the image still displays upright until Process rotates
+// it. Other content types are returned unchanged.
+func Strip(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// JPEG markers
+const (
+	markerSOI  = 0xD8
+	markerEOI  = 0xD9
+	markerSOS  = 0xDA
+	markerAPP0 = 0xE0
+	markerAPP1 = 0xE1
+	markerAPP2 = 0xE2
+	markerAPPE = 0xEE
+	markerAPPF = 0xEF
+	markerCOM  = 0xFE
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	iccHeader  = []byte("ICC_PROFILE\x00")
+)
+
+// stripJPEG copies the segments of a JPEG, dropping application segments
+// other than JFIF (APP0), ICC profiles (APP2) and Adobe color information
+// (APP14), and comments. Anything after the end of the image, such as the
+// extra pictures some phones append, is dropped as well.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != markerSOI {
+		return nil, fmt.Errorf("%w: missing JPEG start of image", ErrInvalid)
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	exifAt := out.Len()
+	orientation := 1
+
+	i := 2
+	for {
+		if i+1 >= len(data) {
+			return nil, fmt.Errorf("%w: JPEG ends before its image data", ErrInvalid)
+		}
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("%w: JPEG marker expected at offset %d", ErrInvalid, i)
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == markerEOI:
+			out.Write(data[i : i+2])
+			return insertJPEGOrientation(out.Bytes(), exifAt, orientation), nil
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD7:
+			// Markers without a segment
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG segment", ErrInvalid)
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG segment", ErrInvalid)
+		}
+		payload := data[i+4 : end]
+
+		keep := true
+		switch {
+		case marker == markerAPP1:
+			if bytes.HasPrefix(payload, exifHeader) {
+				orientation = exifOrientation(payload[len(exifHeader):])
+			}
+			keep = false
+		case marker == markerAPP2:
+			keep = bytes.HasPrefix(payload, iccHeader)
+		case marker > markerAPP2 && marker <= markerAPPF:
+			keep = marker == markerAPPE
+		case marker == markerCOM:
+			keep = false
+		}
+		if keep {
+			out.Write(data[i:end])
+			// The orientation goes after a leading JFIF segment
+			if marker == markerAPP0 && exifAt == 2 && out.Len() == 2+end-i {
+				exifAt = out.Len()
+			}
+		}
+		i = end
+
+		if marker != markerSOS {
+			continue
+		}
+		// Entropy-coded data runs up to the next marker other than a
+		// restart marker or a stuffed zero byte
+		j := i
+		for ; j+1 < len(data); j++ {
+			if data[j] == 0xFF && data[j+1] != 0 && (data[j+1] < 0xD0 || data[j+1] > 0xD7) {
+				break
+			}
+		}
+		if j+1 >= len(data) {
+			// The image is cut short; keep what there is
+			out.Write(data[i:])
+			out.Write([]byte{0xFF, markerEOI})
+			return insertJPEGOrientation(out.Bytes(), exifAt, orientation), nil
+		}
+		out.Write(data[i:j])
+		i = j
+	}
+}
+
+// insertJPEGOrientation inserts an EXIF segment holding only the
+// orientation at offset at, unless the image is upright already
+func insertJPEGOrientation(jpeg []byte, at, orientation int) []byte {
+	if orientation == 1 {
+		return jpeg
+	}
+	payload := append(append([]byte{}, exifHeader...), orientationTIFF(orientation)...)
+	segment := []byte{0xFF, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+	return append(jpeg[:at:at], append(segment, jpeg[at:]...)...)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG copies the chunks of a PNG, dropping EXIF, text and time chunks
+// and anything after the end of the image
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("%w: missing PNG signature", ErrInvalid)
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	orientation := 1
+	exifAt := 0
+
+	for i := len(pngSignature); ; {
+		if i+12 > len(data) {
+			return nil, fmt.Errorf("%w: PNG ends before IEND", ErrInvalid)
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end < i+12 || end > len(data) {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrInvalid)
+		}
+		switch chunk := string(data[i+4 : i+8]); chunk {
+		case "eXIf":
+			orientation = exifOrientation(data[i+8 : end-4])
+		case "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out.Write(data[i:end])
+			if chunk == "IHDR" {
+				// The orientation goes right after the header, before
+				// any image data
+				exifAt = out.Len()
+			}
+			if chunk == "IEND" {
+				if exifAt == 0 || orientation == 1 {
+					return out.Bytes(), nil
+				}
+				png := out.Bytes()
+				exif := pngChunk("eXIf", orientationTIFF(orientation))
+				return append(png[:exifAt:exifAt], append(exif, png[exifAt:]...)...), nil
+			}
+		}
+		i = end
+	}
+}
+
+// pngChunk encodes a PNG chunk
+func pngChunk(chunk string, data []byte) []byte {
+	b := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(b, uint32(len(data)))
+	copy(b[4:], chunk)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+}
+
+// stripWebP copies the chunks of a WebP image, dropping its EXIF and XMP
+// chunks and clearing the flags that announce them
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("%w: missing WebP header", ErrInvalid)
+	}
+
+	out := append([]byte{}, data[:12]...)
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrInvalid)
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if end < i+8 || end > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrInvalid)
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				// Bit 3 announces EXIF, bit 2 XMP
+				out[start+8] &^= 0x0C
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+// tagOrientation is the EXIF tag of the image orientation
+const tagOrientation = 0x0112
+
+// exifOrientation reads the orientation, 1 to 8, from the first image
+// directory of EXIF data in TIFF layout. Missing or invalid values read
+// as 1, upright.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + 12*n
+		if entry+12 > len(tiff) {
+			break
+		}
+		// A SHORT value sits in the first two bytes of the value field
+		if order.Uint16(tiff[entry:]) == tagOrientation && order.Uint16(tiff[entry+2:]) == 3 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orientationTIFF encodes EXIF data holding nothing but the orientation
+func orientationTIFF(orientation int) []byte {
+	return []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, first directory at 8
+		0, 1, // one entry
+		byte(tagOrientation >> 8), byte(tagOrientation & 0xFF), 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0,
+		0, 0, 0, 0, // no further directory
+	}
+}
+
+// orientationOf reads the EXIF orientation Strip kept in a JPEG or PNG
+func orientationOf(contentType string, data []byte) int {
+	switch contentType {
+	case "image/jpeg":
+		for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+			marker := data[i+1]
+			end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+			if marker == markerSOS || end > len(data) {
+				break
+			}
+			if payload := data[i+4 : end]; marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+				return exifOrientation(payload[len(exifHeader):])
+			}
+			i = end
+		}
+	case "image/png":
+		for i := len(pngSignature); i+12 <= len(data); {
+			end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+			if end > len(data) || string(data[i+4:i+8]) == "IDAT" {
+				break
+			}
+			if string(data[i+4:i+8]) == "eXIf" {
+				return exifOrientation(data[i+8 : end-4])
+			}
+			i = end
+		}
+	}
+	return 1
+}
+
+// webpSize reads the dimensions of a WebP image from its first chunk
+func webpSize(data []byte) (width, height int, err error) {
+	if len(data) < 30 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, fmt.Errorf("%w: missing WebP header", ErrInvalid)
+	}
+	payload := data[20:]
+	switch string(data[12:16]) {
+	case "VP8X":
+		// Canvas width and height minus one, 24 bits each
+		width = (int(payload[4]) | int(payload[5])<<8 | int(payload[6])<<16) + 1
+		height = (int(payload[7]) | int(payload[8])<<8 | int(payload[9])<<16) + 1
+	case "VP8 ":
+		if payload[3] != 0x9D || payload[4] != 0x01 || payload[5] != 0x2A {
+			return 0, 0, fmt.Errorf("%w: invalid VP8 frame", ErrInvalid)
+		}
+		width = int(binary.LittleEndian.Uint16(payload[6:]) & 0x3FFF)
+		height = int(binary.LittleEndian.Uint16(payload[8:]) & 0x3FFF)
+	case "VP8L":
+		if payload[0] != 0x2F {
+			return 0, 0, fmt.Errorf("%w: invalid VP8L frame", ErrInvalid)
+		}
+		bits := binary.LittleEndian.Uint32(payload[1:])
+		width = int(bits&0x3FFF) + 1
+		height = int(bits>>14&0x3FFF) + 1
+	default:
+		return 0, 0, fmt.Errorf("%w: unknown WebP chunk", ErrInvalid)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,113 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// toRGBA converts an image to RGBA with its origin at 0,0
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+// orient turns an image with the given EXIF orientation upright
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// Orientations 5 to 8 turn the image a quarter
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+4*w]
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotate 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirror vertically
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90° counterclockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+4*dx:dy*dst.Stride+4*dx+4], row[4*x:4*x+4])
+		}
+	}
+	return dst
+}
+
+// fit returns the largest size with the aspect ratio of w by h that fits
+// in maxW by maxH, never larger than w by h
+func fit(w, h, maxW, maxH int) (int, int) {
+	if w <= maxW && h <= maxH {
+		return w, h
+	}
+	fw, fh := maxW, h*maxW/w
+	if fh > maxH {
+		fw, fh = w*maxH/h, maxH
+	}
+	return max(fw, 1), max(fh, 1)
+}
+
+// resize scales an image down to w by h, averaging the source pixels
+// each target pixel covers. RGBA is premultiplied, so transparent pixels
+// do not bleed their color into their neighbors.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	// The first source column of each target column; column x covers
+	// xs[x] up to xs[x+1]
+	xs := make([]int, w+1)
+	for x := range xs {
+		xs[x] = x * sw / w
+	}
+	sums := make([]uint64, 4*w)
+
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		y1 = max(y1, y0+1)
+		clear(sums)
+		for sy := y0; sy < y1; sy++ {
+			row := src.Pix[sy*src.Stride:]
+			for x := 0; x < w; x++ {
+				x1 := max(xs[x+1], xs[x]+1)
+				for sx := xs[x]; sx < x1; sx++ {
+					p := row[4*sx : 4*sx+4]
+					sums[4*x] += uint64(p[0])
+					sums[4*x+1] += uint64(p[1])
+					sums[4*x+2] += uint64(p[2])
+					sums[4*x+3] += uint64(p[3])
+				}
+			}
+		}
+
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < w; x++ {
+			n := uint64((max(xs[x+1], xs[x]+1) - xs[x]) * (y1 - y0))
+			for c := 0; c < 4; c++ {
+				out[4*x+c] = uint8((sums[4*x+c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
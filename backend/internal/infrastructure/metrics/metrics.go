@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "notes"
+
+// Metrics holds the Prometheus collectors of the application.
+// It uses its own registry so tests can create independent instances.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	logins        *prometheus.CounterVec
+	registrations *prometheus.CounterVec
+	noteSaveBytes prometheus.Histogram
+}
+
+// New creates and registers all application collectors, along with the
+// Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "logins_total",
+			Help:      "Login attempts by result.",
+		}, []string{"result"}),
+		registrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "registrations_total",
+			Help:      "Registration attempts by result.",
+		}, []string{"result"}),
+		noteSaveBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "notes",
+			Name:      "save_size_bytes",
+			Help:      "Size of saved note content in bytes.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8), // 256B .. 4MiB
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.registrations,
+		m.noteSaveBytes,
+	)
+
+	return m
+}
+
+// RegisterDB exposes the connection pool statistics of db
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTP records a completed HTTP request. route must be the route
+// pattern, never the raw path, to keep label cardinality bounded.
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	method = normalizeMethod(method)
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// LoginSucceeded counts a successful login
+func (m *Metrics) LoginSucceeded() {
+	m.logins.WithLabelValues("success").Inc()
+}
+
+// LoginFailed counts a failed login. reason is a small fixed set such as
+// "unknown_user", "wrong_password" or "error".
+func (m *Metrics) LoginFailed(reason string) {
+	m.logins.WithLabelValues(reason).Inc()
+}
+
+// RegistrationSucceeded counts a new account
+func (m *Metrics) RegistrationSucceeded() {
+	m.registrations.WithLabelValues("success").Inc()
+}
+
+// RegistrationFailed counts a rejected registration
+func (m *Metrics) RegistrationFailed(reason string) {
+	m.registrations.WithLabelValues(reason).Inc()
+}
+
+// ObserveNoteSave records the size of a saved note
+func (m *Metrics) ObserveNoteSave(bytes int) {
+	m.noteSaveBytes.Observe(float64(bytes))
+}
+
+// normalizeMethod folds non-standard methods into one label value
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
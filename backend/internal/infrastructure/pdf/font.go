@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"embed"
+	"sync"
+	"unicode"
+)
+
+// Font is a font documents are set in. The DejaVu font files are
+// embedded in the documents that use them, limited to the glyphs used;
+// oblique fonts slant their upright font.
+type Font int
+
+// Fonts
+const (
+	Sans Font = iota
+	SansBold
+	SansOblique
+	SansBoldOblique
+	Mono
+	MonoBold
+	MonoOblique
+	MonoBoldOblique
+)
+
+// faceCount counts the font files, an upright one per font and its
+// oblique variant
+const faceCount = 4
+
+//go:embed fonts/*.ttf
+var fontFiles embed.FS
+
+// faceNames are the PostScript names of the font files, which are named
+// after them
+var faceNames = [faceCount]string{"DejaVuSans", "DejaVuSans-Bold", "DejaVuSansMono", "DejaVuSansMono-Bold"}
+
+var (
+	loadFaces sync.Once
+	faces     [faceCount]*face
+)
+
+// face returns the font file of the font, read on first use
+func (f Font) face() *face {
+	loadFaces.Do(func() {
+		for i, name := range faceNames {
+			data, err := fontFiles.ReadFile("fonts/" + name + ".ttf")
+			if err == nil {
+				faces[i], err = parseFace(name, data)
+			}
+			if err != nil {
+				panic("pdf: reading font " + name + ": " + err.Error())
+			}
+		}
+	})
+	return faces[f.faceIndex()]
+}
+
+// faceIndex returns the index of the font file of the font
+func (f Font) faceIndex() int {
+	return int(f)/4*2 + int(f)%2
+}
+
+// oblique reports whether the font slants its font file
+func (f Font) oblique() bool {
+	return f%4 >= 2
+}
+
+// Styled returns the font of the family of f with the given weight and
+// slant
+func (f Font) Styled(bold, italic bool) Font {
+	base := Sans
+	if f >= Mono {
+		base = Mono
+	}
+	if bold {
+		base++
+	}
+	if italic {
+		base += 2
+	}
+	return base
+}
+
+// Width returns the width of s set in the font, in points. Characters
+// the font lacks are as wide as the box drawn in their place.
+func (f Font) Width(s string, size float64) float64 {
+	face := f.face()
+	total := 0
+	for _, r := range s {
+		if !unicode.IsControl(r) {
+			g, _ := face.glyph(r)
+			total += face.advance(g)
+		}
+	}
+	return float64(total) * size / float64(face.unitsPerEm)
+}
+
+// Has reports whether the font has a glyph for r
+func (f Font) Has(r rune) bool {
+	_, ok := f.face().glyph(r)
+	return ok
+}
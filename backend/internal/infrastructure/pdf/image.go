@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+
+	// Decoders of the images drawn
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// MaxImagePixels bounds the images drawn, as decoding them takes memory
+// in proportion
+const MaxImagePixels = 25_000_000
+
+// ErrImage reports an image that is not a JPEG, PNG or GIF image, or is
+// too large to draw
+var ErrImage = errors.New("pdf: unsupported image")
+
+// Image is an image of a document, drawn on any of its pages
+type Image struct {
+	// index names the image in the resources of pages
+	index  int
+	id     int
+	maskID int
+	width  int
+	height int
+	// gray tells DeviceGray images from DeviceRGB ones
+	gray bool
+	// jpeg images keep their encoded content, others are compressed
+	// samples
+	jpeg bool
+	data []byte
+	// mask is the compressed alpha channel, nil for opaque images
+	mask []byte
+}
+
+// Size returns the size of the image, in pixels
+func (img *Image) Size() (width, height int) {
+	return img.width, img.height
+}
+
+// AddImage adds a JPEG, PNG or GIF image to the document. JPEG images are
+// embedded as they are, others decoded; only the first frame of a GIF is
+// kept.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxImagePixels {
+		return nil, ErrImage
+	}
+
+	img := &Image{index: len(d.images) + 1, width: cfg.Width, height: cfg.Height}
+	if format == "jpeg" && (cfg.ColorModel == color.YCbCrModel || cfg.ColorModel == color.GrayModel) {
+		img.jpeg = true
+		img.gray = cfg.ColorModel == color.GrayModel
+		img.data = data
+	} else {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrImage
+		}
+		img.data, img.mask = samples(decoded)
+	}
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// samples returns the compressed RGB samples of an image and its alpha
+// channel, nil when the image is opaque. The pixel formats the standard
+// decoders return are read directly, others through their color model.
+func samples(img image.Image) (rgb, alpha []byte) {
+	bounds := img.Bounds()
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	mask := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		switch src := img.(type) {
+		case *image.NRGBA:
+			row := src.Pix[src.PixOffset(bounds.Min.X, y):src.PixOffset(bounds.Max.X, y)]
+			for i := 0; i < len(row); i += 4 {
+				pixels = append(pixels, row[i], row[i+1], row[i+2])
+				mask = append(mask, row[i+3])
+			}
+		case *image.RGBA:
+			row := src.Pix[src.PixOffset(bounds.Min.X, y):src.PixOffset(bounds.Max.X, y)]
+			for i := 0; i < len(row); i += 4 {
+				pixels = append(pixels, unpremultiply(row[i], row[i+3]), unpremultiply(row[i+1], row[i+3]), unpremultiply(row[i+2], row[i+3]))
+				mask = append(mask, row[i+3])
+			}
+		case *image.YCbCr:
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				yi, ci := src.YOffset(x, y), src.COffset(x, y)
+				r, g, b := color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+				pixels = append(pixels, r, g, b)
+				mask = append(mask, 0xff)
+			}
+		default:
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				pixels = append(pixels, c.R, c.G, c.B)
+				mask = append(mask, c.A)
+			}
+		}
+	}
+	rgb = compress(pixels)
+	if slices.ContainsFunc(mask, func(a byte) bool { return a != 0xff }) {
+		alpha = compress(mask)
+	}
+	return rgb, alpha
+}
+
+// unpremultiply returns a color component of an alpha-premultiplied pixel
+// without its alpha, as color.NRGBAModel converts it
+func unpremultiply(c, a byte) byte {
+	switch a {
+	case 0:
+		return 0
+	case 0xff:
+		return c
+	}
+	return byte(uint32(c) * 0x101 * 0xffff / (uint32(a) * 0x101) >> 8)
+}
+
+// compress deflates data in the zlib format of FlateDecode streams
+func compress(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,503 @@
+// Package pdf writes PDF documents. Text is set in the DejaVu fonts,
+// embedded in the documents, which cover Latin, Greek and Cyrillic
+// scripts among others; characters they lack print as boxes, and
+// documents report them.
+//
+// Positions are in points (1/72 inch) from the top left corner of the
+// page, y growing downwards.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Size of A4 pages, in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Color is an RGB color
+type Color struct {
+	R, G, B uint8
+}
+
+// Document is a PDF document being written
+type Document struct {
+	title   string
+	pages   []*Page
+	images  []*Image
+	outline []*OutlineItem
+	// glyphs holds the glyphs drawn from each font file, with the
+	// character each stands for
+	glyphs [faceCount]map[uint16]rune
+	// missing holds the characters drawn that the fonts lack
+	missing map[rune]bool
+}
+
+// New starts a document with a title, shown by readers in place of the
+// file name
+func New(title string) *Document {
+	return &Document{title: title, missing: make(map[rune]bool)}
+}
+
+// Page is a page of a document
+type Page struct {
+	doc     *Document
+	width   float64
+	height  float64
+	content bytes.Buffer
+	fonts   [faceCount]bool
+	images  []*Image
+	links   []link
+}
+
+// link is an area of a page linking to an address
+type link struct {
+	x, y, width, height float64
+	uri                 string
+}
+
+// AddPage adds a page of the given size to the document
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{doc: d, width: width, height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the pages of the document, in order
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Missing returns the characters drawn that the fonts lack, in order
+func (d *Document) Missing() []rune {
+	missing := make([]rune, 0, len(d.missing))
+	for r := range d.missing {
+		missing = append(missing, r)
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// Text draws s with its baseline starting at (x, y). Control characters
+// are left out.
+func (p *Page) Text(x, y float64, font Font, size float64, c Color, s string) {
+	if s == "" {
+		return
+	}
+	index, face := font.faceIndex(), font.face()
+	if p.doc.glyphs[index] == nil {
+		p.doc.glyphs[index] = make(map[uint16]rune)
+	}
+	p.fonts[index] = true
+	shear := 0.0
+	if font.oblique() {
+		shear = 0.2
+	}
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s rg 1 0 %s 1 %s %s Tm <", index+1, num(size), rgb(c), num(shear), num(x), num(p.height-y))
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			continue
+		}
+		g, ok := face.glyph(r)
+		if !ok {
+			p.doc.missing[r] = true
+		} else if _, seen := p.doc.glyphs[index][g]; !seen {
+			p.doc.glyphs[index][g] = r
+		}
+		fmt.Fprintf(&p.content, "%04X", g)
+	}
+	p.content.WriteString("> Tj ET\n")
+}
+
+// Rect fills a rectangle
+func (p *Page) Rect(x, y, width, height float64, c Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", rgb(c), num(x), num(p.height-y-height), num(width), num(height))
+}
+
+// StrokeRect draws the outline of a rectangle
+func (p *Page) StrokeRect(x, y, width, height, lineWidth float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s %s %s re S\n", rgb(c), num(lineWidth), num(x), num(p.height-y-height), num(width), num(height))
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, lineWidth float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n", rgb(c), num(lineWidth), num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+// Image draws an image of the document scaled to a rectangle
+func (p *Page) Image(img *Image, x, y, width, height float64) {
+	if !slices.Contains(p.images, img) {
+		p.images = append(p.images, img)
+	}
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(width), num(height), num(x), num(p.height-y-height), img.index)
+}
+
+// Link makes a rectangle of the page open an address
+func (p *Page) Link(x, y, width, height float64, uri string) {
+	p.links = append(p.links, link{x: x, y: y, width: width, height: height, uri: uri})
+}
+
+// OutlineItem is an entry of the outline readers show next to the
+// document, leading to a place on a page
+type OutlineItem struct {
+	id       int
+	title    string
+	page     *Page
+	y        float64
+	children []*OutlineItem
+}
+
+// Outline adds an entry leading to y on a page to the outline, under
+// parent or at the top level when parent is nil
+func (d *Document) Outline(parent *OutlineItem, title string, page *Page, y float64) *OutlineItem {
+	item := &OutlineItem{title: title, page: page, y: y}
+	if parent == nil {
+		d.outline = append(d.outline, item)
+	} else {
+		parent.children = append(parent.children, item)
+	}
+	return item
+}
+
+// Bytes returns the content of the document
+func (d *Document) Bytes() []byte {
+	w := &writer{offsets: []int{0}}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	catalogID, pagesID, infoID := w.alloc(), w.alloc(), w.alloc()
+	var fontIDs [faceCount]int
+	for _, p := range d.pages {
+		for f, used := range p.fonts {
+			if used && fontIDs[f] == 0 {
+				fontIDs[f] = w.alloc()
+			}
+		}
+	}
+	for _, img := range d.images {
+		img.id = w.alloc()
+		if img.mask != nil {
+			img.maskID = w.alloc()
+		}
+	}
+	pageIDs := make(map[*Page]int, len(d.pages))
+	for _, p := range d.pages {
+		pageIDs[p] = w.alloc()
+	}
+	outlineID := 0
+	if len(d.outline) > 0 {
+		outlineID = w.alloc()
+		allocOutline(w, d.outline)
+	}
+
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		kids[i] = ref(pageIDs[p])
+	}
+	catalog := "<< /Type /Catalog /Pages " + ref(pagesID)
+	if outlineID != 0 {
+		catalog += " /Outlines " + ref(outlineID) + " /PageMode /UseOutlines"
+	}
+	w.object(catalogID, catalog+" >>")
+	w.object(pagesID, "<< /Type /Pages /Kids ["+strings.Join(kids, " ")+"] /Count "+strconv.Itoa(len(d.pages))+" >>")
+	w.object(infoID, "<< /Title "+textString(d.title)+" /Producer (notes-app) >>")
+	for f, id := range fontIDs {
+		if id != 0 {
+			d.writeFont(w, id, faces[f], d.glyphs[f])
+		}
+	}
+	for _, img := range d.images {
+		d.writeImage(w, img)
+	}
+	for _, p := range d.pages {
+		d.writePage(w, p, pageIDs[p], pagesID, fontIDs)
+	}
+	if outlineID != 0 {
+		first, last := d.outline[0], d.outline[len(d.outline)-1]
+		w.object(outlineID, fmt.Sprintf("<< /Type /Outlines /First %s /Last %s /Count %d >>",
+			ref(first.id), ref(last.id), countOutline(d.outline)))
+		writeOutline(w, d.outline, outlineID, pageIDs)
+	}
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets))
+	for _, offset := range w.offsets[1:] {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %s /Info %s >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets), ref(catalogID), ref(infoID), xref)
+	return w.buf.Bytes()
+}
+
+// writeFont writes a font as a composite font whose character codes are
+// the glyph numbers of the font file, embedding the glyphs drawn. Its
+// ToUnicode map lets readers copy and search the text.
+func (d *Document) writeFont(w *writer, id int, f *face, glyphs map[uint16]rune) {
+	cidFontID, descriptorID, fileID, toUnicodeID := w.alloc(), w.alloc(), w.alloc(), w.alloc()
+	used := make([]uint16, 0, len(glyphs))
+	for g := range glyphs {
+		used = append(used, g)
+	}
+	slices.Sort(used)
+
+	// Subsets are named with a tag made of six capital letters, told
+	// apart by the glyphs they hold
+	h := fnv.New32a()
+	for _, g := range used {
+		h.Write([]byte{byte(g >> 8), byte(g)})
+	}
+	tag := make([]byte, 6)
+	for i, sum := 0, h.Sum32(); i < len(tag); i, sum = i+1, sum/26 {
+		tag[i] = 'A' + byte(sum%26)
+	}
+	name := string(tag) + "+" + f.name
+
+	var widths strings.Builder
+	for i := 0; i < len(used); {
+		j := i + 1
+		for j < len(used) && used[j] == used[j-1]+1 {
+			j++
+		}
+		fmt.Fprintf(&widths, "%d [", used[i])
+		for k, g := range used[i:j] {
+			if k > 0 {
+				widths.WriteByte(' ')
+			}
+			widths.WriteString(strconv.Itoa(f.scale(f.advance(g))))
+		}
+		widths.WriteString("] ")
+		i = j
+	}
+
+	w.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%s] /ToUnicode %s >>",
+		name, ref(cidFontID), ref(toUnicodeID)))
+	w.object(cidFontID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %s /DW %d /W [%s] /CIDToGIDMap /Identity >>",
+		name, ref(descriptorID), f.scale(f.advance(0)), strings.TrimSpace(widths.String())))
+
+	flags := 32 // nonsymbolic
+	if strings.Contains(f.name, "Mono") {
+		flags |= 1 // fixed pitch
+	}
+	stem := 80
+	if strings.Contains(f.name, "Bold") {
+		stem = 140
+	}
+	w.object(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV %d /FontFile2 %s >>",
+		name, flags, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), stem, ref(fileID)))
+	program := f.subset(used)
+	w.stream(fileID, fmt.Sprintf("/Length1 %d /Filter /FlateDecode", len(program)), compress(program))
+	w.stream(toUnicodeID, "/Filter /FlateDecode", compress(toUnicode(glyphs, used)))
+}
+
+// toUnicode returns the CMap mapping glyphs to the characters they stand
+// for
+func toUnicode(glyphs map[uint16]rune, used []uint16) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// bfchar sections hold at most 100 entries
+	for start := 0; start < len(used); start += 100 {
+		chunk := used[start:min(start+100, len(used))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, g := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{glyphs[g]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// writeImage writes an image and its mask
+func (d *Document) writeImage(w *writer, img *Image) {
+	colorSpace := "/DeviceRGB"
+	if img.gray {
+		colorSpace = "/DeviceGray"
+	}
+	filter := "/FlateDecode"
+	if img.jpeg {
+		filter = "/DCTDecode"
+	}
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s",
+		img.width, img.height, colorSpace, filter)
+	if img.mask != nil {
+		dict += " /SMask " + ref(img.maskID)
+		w.stream(img.maskID, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+			img.width, img.height), img.mask)
+	}
+	w.stream(img.id, dict, img.data)
+}
+
+// writePage writes a page, its content and its links
+func (d *Document) writePage(w *writer, p *Page, id, pagesID int, fontIDs [faceCount]int) {
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for f, used := range p.fonts {
+		if used {
+			fmt.Fprintf(&resources, " /F%d %s", f+1, ref(fontIDs[f]))
+		}
+	}
+	resources.WriteString(" >>")
+	if len(p.images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for _, img := range p.images {
+			fmt.Fprintf(&resources, " /Im%d %s", img.index, ref(img.id))
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	var annots []string
+	for _, l := range p.links {
+		annots = append(annots, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%s %s %s %s] /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+			num(l.x), num(p.height-l.y-l.height), num(l.x+l.width), num(p.height-l.y), uriString(l.uri)))
+	}
+	contentID := w.alloc()
+	page := fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %s %s] /Resources %s /Contents %s",
+		ref(pagesID), num(p.width), num(p.height), resources.String(), ref(contentID))
+	if len(annots) > 0 {
+		page += " /Annots [" + strings.Join(annots, " ") + "]"
+	}
+	w.object(id, page+" >>")
+	w.stream(contentID, "/Filter /FlateDecode", compress(p.content.Bytes()))
+}
+
+// allocOutline numbers the objects of outline entries
+func allocOutline(w *writer, items []*OutlineItem) {
+	for _, item := range items {
+		item.id = w.alloc()
+		allocOutline(w, item.children)
+	}
+}
+
+// countOutline counts outline entries and their descendants
+func countOutline(items []*OutlineItem) int {
+	n := len(items)
+	for _, item := range items {
+		n += countOutline(item.children)
+	}
+	return n
+}
+
+// writeOutline writes sibling outline entries and their descendants
+func writeOutline(w *writer, items []*OutlineItem, parentID int, pageIDs map[*Page]int) {
+	for i, item := range items {
+		dict := fmt.Sprintf("<< /Title %s /Parent %s /Dest [%s /XYZ 0 %s null]",
+			textString(item.title), ref(parentID), ref(pageIDs[item.page]), num(item.page.height-item.y))
+		if i > 0 {
+			dict += " /Prev " + ref(items[i-1].id)
+		}
+		if i < len(items)-1 {
+			dict += " /Next " + ref(items[i+1].id)
+		}
+		if len(item.children) > 0 {
+			dict += fmt.Sprintf(" /First %s /Last %s /Count %d",
+				ref(item.children[0].id), ref(item.children[len(item.children)-1].id), countOutline(item.children))
+		}
+		w.object(item.id, dict+" >>")
+		writeOutline(w, item.children, item.id, pageIDs)
+	}
+}
+
+// writer writes the objects of a document, recording where each starts
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// alloc returns the number of a new object
+func (w *writer) alloc() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets) - 1
+}
+
+func (w *writer) object(id int, body string) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) stream(id int, dict string, data []byte) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func ref(id int) string {
+	return strconv.Itoa(id) + " 0 R"
+}
+
+// num formats a number with at most two decimals
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// rgb formats a color as the operands of rg and RG
+func rgb(c Color) string {
+	return num(float64(c.R)/255) + " " + num(float64(c.G)/255) + " " + num(float64(c.B)/255)
+}
+
+// writeString writes a literal string, escaping what it must
+func writeString(b *bytes.Buffer, s []byte) {
+	b.WriteByte('(')
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+}
+
+// textString formats s as a text string, in UTF-16 so any character
+// shows in outlines and document properties
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// uriString formats an address as a literal string, percent-encoding
+// the bytes outside ASCII
+func uriString(uri string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(uri); i++ {
+		if c := uri[i]; c <= 0x20 || c >= 0x7f {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		} else {
+			escaped.WriteByte(c)
+		}
+	}
+	var b bytes.Buffer
+	writeString(&b, []byte(escaped.String()))
+	return b.String()
+}
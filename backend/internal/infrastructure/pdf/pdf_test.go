@@ -0,0 +1,166 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	d := New("Notes – 2024")
+	page := d.AddPage(A4Width, A4Height)
+	page.Text(56, 72, SansBold, 12, Color{}, "Café (draft) \\ 1")
+	page.Text(56, 90, SansOblique, 12, Color{}, "Dağ Şişli, Привет, Ελλάδα")
+	page.Text(56, 108, Mono, 12, Color{}, "東京 ok")
+	page.Link(56, 60, 100, 14, "https://example.com/a b")
+	d.Outline(nil, "First", page, 72)
+
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	src.Set(1, 0, color.NRGBA{B: 255, A: 128})
+	var buf bytes.Buffer
+	png.Encode(&buf, src)
+	img, err := d.AddImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("AddImage: %v", err)
+	}
+	page.Image(img, 56, 100, 20, 10)
+	d.AddPage(A4Width, A4Height)
+
+	if _, err := d.AddImage([]byte("not an image")); err != ErrImage {
+		t.Errorf("AddImage(text) error = %v, want ErrImage", err)
+	}
+
+	out := d.Bytes()
+	s := string(out)
+	for _, want := range []string{
+		"%PDF-1.4", "/Count 2", "+DejaVuSans-Bold /Encoding /Identity-H", "+DejaVuSansMono ", "/FontFile2", "/SMask",
+		"/URI (https://example.com/a%20b)", "/Title <FEFF004E006F007400650073",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("document lacks %q", want)
+		}
+	}
+
+	// Every object the cross-reference table lists starts at its offset
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(s)
+	if m == nil {
+		t.Fatal("document lacks startxref")
+	}
+	start, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(s[start:], "xref\n") {
+		t.Fatalf("startxref %d does not point at the table", start)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(s[start:], -1)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(e[1])
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !strings.HasPrefix(s[offset:], want) {
+			t.Errorf("entry %d points at %q", i+1, s[offset:min(offset+10, len(s))])
+		}
+	}
+
+	content := streams(out)
+	if want := "<" + glyphs(SansBold, "Café (draft) \\ 1") + "> Tj"; !strings.Contains(content, want) {
+		t.Errorf("content lacks %q", want)
+	}
+	// Every character of the text maps back to Unicode
+	for _, want := range []string{"<011F>", "<015F>", "<041F>", "<03BB>", "<00E9>"} {
+		if !regexp.MustCompile(`<[0-9A-F]{4}> ` + want).MatchString(content) {
+			t.Errorf("ToUnicode map lacks %s", want)
+		}
+	}
+	if got := string(d.Missing()); got != "京東" {
+		t.Errorf("Missing() = %q", got)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	f := Sans.face()
+	a, _ := f.glyph('a')
+	aring, _ := f.glyph('å')
+	b, _ := f.glyph('b')
+
+	data := f.subset([]uint16{a, aring})
+	if checksum(data) != 0xb1b0afba {
+		t.Errorf("file checksum = %#x", checksum(data))
+	}
+	sub := &face{tables: map[string][]byte{}, longLoca: true}
+	for i := range int(binary.BigEndian.Uint16(data[4:])) {
+		record := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		sub.tables[string(record[:4])] = data[offset : offset+length]
+	}
+	if len(sub.glyphData(a)) == 0 || len(sub.glyphData(aring)) == 0 || len(sub.glyphData(0)) == 0 {
+		t.Error("subset lacks the glyphs drawn")
+	}
+	// å is made of a and a ring, which the subset keeps too
+	for _, g := range components(f.glyphData(aring)) {
+		if len(sub.glyphData(g)) == 0 && len(f.glyphData(g)) > 0 {
+			t.Errorf("subset lacks component %d", g)
+		}
+	}
+	if len(sub.glyphData(b)) != 0 {
+		t.Error("subset keeps a glyph not drawn")
+	}
+}
+
+func TestSamples(t *testing.T) {
+	rect := image.Rect(1, 1, 5, 4)
+	nrgba, rgba := image.NewNRGBA(rect), image.NewRGBA(rect)
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c := color.NRGBA{R: uint8(60 * x), G: uint8(90 * y), B: 200, A: uint8(85 * (x - 1))}
+			nrgba.Set(x, y, c)
+			rgba.Set(x, y, c)
+			ycbcr.Y[ycbcr.YOffset(x, y)] = uint8(40 * x * y)
+			ycbcr.Cb[ycbcr.COffset(x, y)] = uint8(50 * x)
+			ycbcr.Cr[ycbcr.COffset(x, y)] = uint8(70 * y)
+		}
+	}
+	// The fast paths match the pixels read through the color model of
+	// each image
+	for _, img := range []image.Image{nrgba.SubImage(image.Rect(2, 1, 5, 3)), rgba, ycbcr} {
+		rgb, alpha := samples(img)
+		wantRGB, wantAlpha := samples(struct{ image.Image }{img})
+		if !bytes.Equal(rgb, wantRGB) || !bytes.Equal(alpha, wantAlpha) {
+			t.Errorf("samples(%T) differ from the pixels of the image", img)
+		}
+	}
+	if _, alpha := samples(ycbcr); alpha != nil {
+		t.Error("samples(*image.YCbCr) has an alpha channel")
+	}
+}
+
+// glyphs returns the glyph numbers of s in a font, as in content streams
+func glyphs(font Font, s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		g, _ := font.face().glyph(r)
+		fmt.Fprintf(&b, "%04X", g)
+	}
+	return b.String()
+}
+
+// streams returns the streams of a document, inflated and joined
+func streams(doc []byte) string {
+	var out strings.Builder
+	for _, part := range bytes.Split(doc, []byte("stream\n"))[1:] {
+		zr, err := zlib.NewReader(bytes.NewReader(part))
+		if err != nil {
+			continue
+		}
+		data, _ := io.ReadAll(zr)
+		out.Write(data)
+	}
+	return out.String()
+}
@@ -0,0 +1,345 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// errFont reports a font file that cannot be read
+var errFont = errors.New("pdf: invalid TrueType font")
+
+// face is a TrueType font file, read enough to measure text and embed
+// the glyphs a document uses
+type face struct {
+	// name is the PostScript name of the font
+	name   string
+	tables map[string][]byte
+
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	numGlyphs  int
+	longLoca   bool
+	advances   []uint16
+	cmap       map[rune]uint16
+}
+
+// parseFace reads a TrueType font file
+func parseFace(name string, data []byte) (*face, error) {
+	if len(data) < 12 {
+		return nil, errFont
+	}
+	f := &face{name: name, tables: make(map[string][]byte)}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := range n {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errFont
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil, errFont
+		}
+		f.tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 || f.tables["glyf"] == nil || f.tables["loca"] == nil {
+		return nil, errFont
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+	if f.unitsPerEm == 0 || f.numGlyphs == 0 {
+		return nil, errFont
+	}
+
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if metrics == 0 || len(hmtx) < 4*metrics {
+		return nil, errFont
+	}
+	f.advances = make([]uint16, metrics)
+	for i := range f.advances {
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+
+	var err error
+	if f.cmap, err = parseCmap(f.tables["cmap"]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseCmap reads the Unicode character map of a font, from its format 12
+// subtable when it has one, covering every plane, or its format 4 one
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errFont
+	}
+	var bmp, full []byte
+	for i := range int(binary.BigEndian.Uint16(cmap[2:])) {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			return nil, errFont
+		}
+		platform, encoding := binary.BigEndian.Uint16(cmap[record:]), binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+2 > len(cmap) || (platform != 0 && platform != 3) {
+			continue
+		}
+		switch format := binary.BigEndian.Uint16(cmap[offset:]); {
+		case format == 12 && (platform == 0 || encoding == 10):
+			full = cmap[offset:]
+		case format == 4 && (platform == 0 || encoding == 1):
+			bmp = cmap[offset:]
+		}
+	}
+
+	m := make(map[rune]uint16)
+	switch {
+	case full != nil:
+		if len(full) < 16 {
+			return nil, errFont
+		}
+		groups := int(binary.BigEndian.Uint32(full[12:]))
+		if len(full) < 16+12*groups {
+			return nil, errFont
+		}
+		for i := range groups {
+			group := full[16+12*i:]
+			start, end := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:])
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && c <= 0x10ffff; c++ {
+				m[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	case bmp != nil:
+		if len(bmp) < 14 {
+			return nil, errFont
+		}
+		segments := int(binary.BigEndian.Uint16(bmp[6:])) / 2
+		ends, starts := 14, 16+2*segments
+		deltas, ranges := starts+2*segments, starts+4*segments
+		if len(bmp) < ranges+2*segments {
+			return nil, errFont
+		}
+		for i := range segments {
+			start := int(binary.BigEndian.Uint16(bmp[starts+2*i:]))
+			end := int(binary.BigEndian.Uint16(bmp[ends+2*i:]))
+			delta := binary.BigEndian.Uint16(bmp[deltas+2*i:])
+			rangeOffset := int(binary.BigEndian.Uint16(bmp[ranges+2*i:]))
+			for c := start; c <= end && c != 0xffff; c++ {
+				glyph := uint16(c) + delta
+				if rangeOffset != 0 {
+					at := ranges + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(bmp) {
+						return nil, errFont
+					}
+					if glyph = binary.BigEndian.Uint16(bmp[at:]); glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					m[rune(c)] = glyph
+				}
+			}
+		}
+	default:
+		return nil, errFont
+	}
+	return m, nil
+}
+
+// glyph returns the glyph of r, and whether the font has one
+func (f *face) glyph(r rune) (uint16, bool) {
+	g, ok := f.cmap[r]
+	return g, ok && int(g) < f.numGlyphs
+}
+
+// advance returns the width of a glyph, in font units
+func (f *face) advance(g uint16) int {
+	if int(g) < len(f.advances) {
+		return int(f.advances[g])
+	}
+	return int(f.advances[len(f.advances)-1])
+}
+
+// scale converts font units to thousandths of the font size
+func (f *face) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// glyphData returns the outline of a glyph, empty for blank glyphs
+func (f *face) glyphData(g uint16) []byte {
+	loca, glyf := f.tables["loca"], f.tables["glyf"]
+	var start, end int
+	if f.longLoca {
+		if 4*int(g)+8 > len(loca) {
+			return nil
+		}
+		start, end = int(binary.BigEndian.Uint32(loca[4*g:])), int(binary.BigEndian.Uint32(loca[4*g+4:]))
+	} else {
+		if 2*int(g)+4 > len(loca) {
+			return nil
+		}
+		start, end = 2*int(binary.BigEndian.Uint16(loca[2*g:])), 2*int(binary.BigEndian.Uint16(loca[2*g+2:]))
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// Flags of the components of composite glyphs
+const (
+	argsAreWords    = 0x0001
+	haveScale       = 0x0008
+	moreComponents  = 0x0020
+	haveXYScale     = 0x0040
+	haveTwoByTwo    = 0x0080
+	compositeHeader = 10
+)
+
+// components returns the glyphs a composite glyph is made of
+func components(data []byte) []uint16 {
+	if len(data) < compositeHeader || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+	var out []uint16
+	for at := compositeHeader; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		out = append(out, binary.BigEndian.Uint16(data[at+2:]))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// subsetTables are the tables of a font kept in the subsets embedded in
+// documents, which PDF readers need to draw CIDFontType2 glyphs
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// subset returns a font file holding only the given glyphs, the glyphs
+// composite ones are made of and the .notdef glyph. Other glyphs are left
+// blank so glyph numbers stay the same.
+func (f *face) subset(glyphs []uint16) []byte {
+	keep := map[uint16]bool{0: true}
+	queue := slices.Clone(glyphs)
+	for len(queue) > 0 {
+		g := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[g] || int(g) >= f.numGlyphs {
+			continue
+		}
+		keep[g] = true
+		queue = append(queue, components(f.glyphData(g))...)
+	}
+
+	var glyf []byte
+	loca := make([]byte, 4*(f.numGlyphs+1))
+	for g := range f.numGlyphs {
+		if keep[uint16(g)] {
+			glyf = append(glyf, f.glyphData(uint16(g))...)
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+		binary.BigEndian.PutUint32(loca[4*g+4:], uint32(len(glyf)))
+	}
+	head := slices.Clone(f.tables["head"])
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(head[50:], 1) // long loca offsets
+
+	tables := map[string][]byte{"glyf": glyf, "loca": loca, "head": head}
+	var tags []string
+	for _, tag := range subsetTables {
+		if tables[tag] == nil {
+			tables[tag] = f.tables[tag]
+		}
+		if tables[tag] != nil {
+			tags = append(tags, tag)
+		}
+	}
+
+	// Offset table, then table records sorted by tag, then tables padded
+	// to four bytes
+	header := make([]byte, 12+16*len(tags))
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(len(tags)))
+	power := 1
+	for power*2 <= len(tags) {
+		power *= 2
+	}
+	selector := 0
+	for 1<<(selector+1) <= power {
+		selector++
+	}
+	binary.BigEndian.PutUint16(header[6:], uint16(16*power))
+	binary.BigEndian.PutUint16(header[8:], uint16(selector))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*len(tags)-16*power))
+
+	offset, headAt := len(header), 0
+	for i, tag := range tags {
+		data := tables[tag]
+		record := header[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(data))
+		binary.BigEndian.PutUint32(record[8:], uint32(offset))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(data)))
+		if tag == "head" {
+			headAt = offset
+		}
+		offset += (len(data) + 3) &^ 3
+	}
+	out := make([]byte, 0, offset)
+	out = append(out, header...)
+	for _, tag := range tags {
+		out = append(out, tables[tag]...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	binary.BigEndian.PutUint32(out[headAt+8:], 0xb1b0afba-checksum(out))
+	return out
+}
+
+// checksum returns the TrueType checksum of data, the sum of its
+// big-endian 32-bit words
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// attachmentRepository implements domainAttachment.Repository in memory
+type attachmentRepository struct {
+	mu          sync.RWMutex
+	attachments map[string]*domainAttachment.Attachment
+	// references maps note IDs to the attachments they reference
+	references map[string][]string
+	notes      domainNote.Repository
+}
+
+// NewAttachmentRepository creates an empty in-memory attachment
+// repository. References are only kept for notes that exist in notes, the
+// way a database drops them along with the note.
+func NewAttachmentRepository(notes domainNote.Repository) domainAttachment.Repository {
+	return &attachmentRepository{
+		attachments: make(map[string]*domainAttachment.Attachment),
+		references:  make(map[string][]string),
+		notes:       notes,
+	}
+}
+
+// Create stores a new attachment
+func (r *attachmentRepository) Create(ctx context.Context, a *domainAttachment.Attachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attachments[a.ID]; ok {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "attachments_pkey"), "attachments.Create")
+	}
+	r.attachments[a.ID] = copyAttachment(a)
+	return nil
+}
+
+// GetByID retrieves an attachment by its ID
+func (r *attachmentRepository) GetByID(ctx context.Context, id string) (*domainAttachment.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.attachments[id]
+	if !ok {
+		return nil, errs.Wrap(errs.ErrNotFound, "attachments.GetByID")
+	}
+	return copyAttachment(a), nil
+}
+
+// UpdateImage records the outcome of processing an image
+func (r *attachmentRepository) UpdateImage(ctx context.Context, a *domainAttachment.Attachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.attachments[a.ID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "attachments.UpdateImage")
+	}
+	stored.Size = a.Size
+	stored.Checksum = a.Checksum
+	stored.Width = a.Width
+	stored.Height = a.Height
+	stored.Processing = a.Processing
+	stored.Variants = slices.Clone(a.Variants)
+	slices.SortFunc(stored.Variants, func(x, y domainAttachment.Variant) int {
+		return strings.Compare(x.Name, y.Name)
+	})
+	return nil
+}
+
+// ListPending returns up to limit images waiting to be processed, oldest
+// first
+func (r *attachmentRepository) ListPending(ctx context.Context, limit int) ([]*domainAttachment.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pending []*domainAttachment.Attachment
+	for _, a := range r.attachments {
+		if a.Processing == domainAttachment.ProcessingPending {
+			pending = append(pending, copyAttachment(a))
+		}
+	}
+	sortOldestFirst(pending)
+	return paginate(pending, 0, limit), nil
+}
+
+// Delete removes an attachment that no note references
+func (r *attachmentRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	referenced, err := r.referenced(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := r.attachments[id]; !ok || referenced[id] {
+		return errs.Wrap(errs.ErrNotFound, "attachments.Delete")
+	}
+	delete(r.attachments, id)
+	return nil
+}
+
+// SetReferences replaces the attachments a note references
+func (r *attachmentRepository) SetReferences(ctx context.Context, noteID string, attachmentIDs []string) error {
+	if _, err := r.notes.GetByID(ctx, noteID); err != nil {
+		return errs.Wrap(err, "attachments.SetReferences")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := slices.DeleteFunc(uniqueSorted(attachmentIDs), func(id string) bool {
+		_, ok := r.attachments[id]
+		return !ok
+	})
+	if len(ids) == 0 {
+		delete(r.references, noteID)
+		return nil
+	}
+	r.references[noteID] = ids
+	return nil
+}
+
+// References returns the IDs of the attachments a note references
+func (r *attachmentRepository) References(ctx context.Context, noteID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := r.notes.GetByID(ctx, noteID); errors.Is(err, errs.ErrNotFound) {
+		return nil, nil
+	}
+	return slices.Clone(r.references[noteID]), nil
+}
+
+// ReferencingNotes returns the IDs of the notes that reference an
+// attachment
+func (r *attachmentRepository) ReferencingNotes(ctx context.Context, attachmentID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var noteIDs []string
+	for noteID, ids := range r.references {
+		if !slices.Contains(ids, attachmentID) {
+			continue
+		}
+		_, err := r.notes.GetByID(ctx, noteID)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		noteIDs = append(noteIDs, noteID)
+	}
+	slices.Sort(noteIDs)
+	return noteIDs, nil
+}
+
+// ListOrphans returns up to limit unreferenced attachments created before
+// the given time, oldest first
+func (r *attachmentRepository) ListOrphans(ctx context.Context, before time.Time, limit int) ([]*domainAttachment.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	referenced, err := r.referenced(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []*domainAttachment.Attachment
+	for id, a := range r.attachments {
+		if a.CreatedAt.Before(before) && !referenced[id] {
+			orphans = append(orphans, copyAttachment(a))
+		}
+	}
+	sortOldestFirst(orphans)
+	return paginate(orphans, 0, limit), nil
+}
+
+// StorageUsage reports the attachments a user uploaded and their size,
+// variants included
+func (r *attachmentRepository) StorageUsage(ctx context.Context, userID string) (domainUser.StorageUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var usage domainUser.StorageUsage
+	for _, a := range r.attachments {
+		if a.OwnerID == userID {
+			usage.Attachments++
+			usage.AttachmentBytes += a.Size
+			for _, v := range a.Variants {
+				usage.AttachmentBytes += v.Size
+			}
+		}
+	}
+	return usage, nil
+}
+
+// referenced returns the IDs of the attachments referenced by notes that
+// still exist
+func (r *attachmentRepository) referenced(ctx context.Context) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	for noteID, ids := range r.references {
+		_, err := r.notes.GetByID(ctx, noteID)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			referenced[id] = true
+		}
+	}
+	return referenced, nil
+}
+
+// sortOldestFirst sorts attachments by creation time, then ID
+func sortOldestFirst(attachments []*domainAttachment.Attachment) {
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return attachments[i].ID < attachments[j].ID
+	})
+}
+
+func copyAttachment(a *domainAttachment.Attachment) *domainAttachment.Attachment {
+	c := *a
+	c.Variants = slices.Clone(a.Variants)
+	return &c
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/errs"
+)
+
+// auditRepository implements audit.Repository in memory
+type auditRepository struct {
+	mu     sync.RWMutex
+	events []*audit.Event
+	ids    map[string]bool
+}
+
+// NewAuditRepository creates an empty in-memory audit repository
+func NewAuditRepository() audit.Repository {
+	return &auditRepository{ids: make(map[string]bool)}
+}
+
+// Append stores a new event
+func (r *auditRepository) Append(ctx context.Context, event *audit.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ids[event.ID] {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "audit_events_pkey"), "audit_events.Append")
+	}
+	r.ids[event.ID] = true
+	r.events = append(r.events, copyEvent(event))
+	return nil
+}
+
+// List returns one page of the events matching filter, newest first
+func (r *auditRepository) List(ctx context.Context, filter audit.Filter) ([]*audit.Event, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []*audit.Event
+	for _, event := range r.events {
+		if matchesFilter(event, filter) {
+			matches = append(matches, event)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID > matches[j].ID
+	})
+
+	page := paginate(matches, filter.Offset, filter.Limit)
+	events := make([]*audit.Event, len(page))
+	for i, event := range page {
+		events[i] = copyEvent(event)
+	}
+	return events, len(matches), nil
+}
+
+func matchesFilter(event *audit.Event, filter audit.Filter) bool {
+	switch {
+	case filter.ActorID != "" && event.ActorID != filter.ActorID,
+		filter.Action != "" && event.Action != filter.Action,
+		filter.TargetType != "" && event.TargetType != filter.TargetType,
+		filter.TargetID != "" && event.TargetID != filter.TargetID,
+		!filter.Since.IsZero() && event.CreatedAt.Before(filter.Since),
+		!filter.Until.IsZero() && !event.CreatedAt.Before(filter.Until):
+		return false
+	}
+	if filter.UserID != "" {
+		return event.ActorID == filter.UserID ||
+			event.TargetType == audit.TargetUser && event.TargetID == filter.UserID
+	}
+	return true
+}
+
+// copyEvent returns a deep copy of event
+func copyEvent(event *audit.Event) *audit.Event {
+	c := *event
+	if event.Metadata != nil {
+		c.Metadata = make(map[string]string, len(event.Metadata))
+		for k, v := range event.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,375 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// noteRepository implements domainNote.Repository in memory
+type noteRepository struct {
+	mu     sync.RWMutex
+	notes  map[string]*domainNote.Note
+	states map[stateKey]domainNote.State
+}
+
+// stateKey identifies how one user files one note
+type stateKey struct {
+	noteID string
+	userID string
+}
+
+// NewNoteRepository creates an empty in-memory note repository
+func NewNoteRepository() domainNote.Repository {
+	return &noteRepository{
+		notes:  make(map[string]*domainNote.Note),
+		states: make(map[stateKey]domainNote.State),
+	}
+}
+
+// Create stores a new note along with its tags
+func (r *noteRepository) Create(ctx context.Context, note *domainNote.Note) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notes[note.ID]; ok {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "notes_pkey"), "notes.Create")
+	}
+	stored := copyNote(note)
+	stored.TagIDs = uniqueSorted(note.TagIDs)
+	r.notes[note.ID] = stored
+	return nil
+}
+
+// GetByID retrieves a note with its tags
+func (r *noteRepository) GetByID(ctx context.Context, id string) (*domainNote.Note, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	note, ok := r.notes[id]
+	if !ok {
+		return nil, errs.Wrap(errs.ErrNotFound, "notes.GetByID")
+	}
+	return copyNote(note), nil
+}
+
+// Update modifies the title and content of a note
+func (r *noteRepository) Update(ctx context.Context, note *domainNote.Note) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.notes[note.ID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.Update")
+	}
+	existing.Title = note.Title
+	existing.Content = copyDelta(note.Content)
+	existing.PlainText = note.PlainText
+	existing.UpdatedAt = note.UpdatedAt
+	return nil
+}
+
+// Delete removes a note for good
+func (r *noteRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notes[id]; !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.Delete")
+	}
+	r.remove(id)
+	return nil
+}
+
+// Trash moves a note to the trash
+func (r *noteRepository) Trash(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, ok := r.notes[id]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.Trash")
+	}
+	note.DeletedAt = &at
+	return nil
+}
+
+// Restore takes a note out of the trash
+func (r *noteRepository) Restore(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, ok := r.notes[id]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.Restore")
+	}
+	note.DeletedAt = nil
+	return nil
+}
+
+// PurgeTrashed deletes for good up to limit notes trashed before the
+// given time
+func (r *noteRepository) PurgeTrashed(ctx context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []*domainNote.Note
+	for _, note := range r.notes {
+		if note.DeletedAt != nil && note.DeletedAt.Before(before) {
+			expired = append(expired, note)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].DeletedAt.Before(*expired[j].DeletedAt)
+	})
+	expired = paginate(expired, 0, limit)
+	for _, note := range expired {
+		r.remove(note.ID)
+	}
+	return len(expired), nil
+}
+
+// List returns one page of the notes matching filter, the viewer's pinned
+// notes first, or most recently trashed first for the trash
+func (r *noteRepository) List(ctx context.Context, filter domainNote.ListFilter) ([]*domainNote.Note, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	terms := domainNote.SearchTerms(filter.Query)
+	var matches []*domainNote.Note
+	for _, note := range r.notes {
+		state := r.states[stateKey{note.ID, filter.ViewerID}]
+		if note.Trashed() != filter.Trashed {
+			continue
+		}
+		switch filter.Archived {
+		case domainNote.ArchivedInclude:
+		case domainNote.ArchivedOnly:
+			if !state.Archived {
+				continue
+			}
+		default:
+			if state.Archived {
+				continue
+			}
+		}
+		if filter.Favourites && !state.Favourite {
+			continue
+		}
+		if filter.OwnerID != "" && note.OwnerID != filter.OwnerID {
+			continue
+		}
+		if filter.NotebookID != "" && note.NotebookID != filter.NotebookID {
+			continue
+		}
+		if !matchesTags(note.TagIDs, filter.TagIDs, filter.TagMode) {
+			continue
+		}
+		if len(terms) > 0 && !containsTerms(note, terms) {
+			continue
+		}
+		listed := copyNote(note)
+		listed.State = state
+		matches = append(matches, listed)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		x, y := matches[i], matches[j]
+		if filter.Trashed {
+			if !x.DeletedAt.Equal(*y.DeletedAt) {
+				return x.DeletedAt.After(*y.DeletedAt)
+			}
+			return x.ID < y.ID
+		}
+		if x.State.Pinned != y.State.Pinned {
+			return x.State.Pinned
+		}
+		if filter.Order == domainNote.OrderManual && x.State.Position != y.State.Position {
+			// Notes never moved come last
+			if x.State.Position == "" || y.State.Position == "" {
+				return y.State.Position == ""
+			}
+			return x.State.Position < y.State.Position
+		}
+		if !x.UpdatedAt.Equal(y.UpdatedAt) {
+			return x.UpdatedAt.After(y.UpdatedAt)
+		}
+		return x.ID < y.ID
+	})
+
+	notes := paginate(matches, filter.Offset, filter.Limit)
+	return notes, len(matches), nil
+}
+
+// SetNotebook moves a note into a notebook, or out of any notebook
+func (r *noteRepository) SetNotebook(ctx context.Context, noteID, notebookID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, ok := r.notes[noteID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.SetNotebook")
+	}
+	note.NotebookID = notebookID
+	return nil
+}
+
+// GetState returns how a user files a note
+func (r *noteRepository) GetState(ctx context.Context, noteID, userID string) (domainNote.State, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.states[stateKey{noteID, userID}], nil
+}
+
+// SetState stores how a user files a note
+func (r *noteRepository) SetState(ctx context.Context, noteID, userID string, state domainNote.State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notes[noteID]; !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.SetState")
+	}
+	r.states[stateKey{noteID, userID}] = state
+	return nil
+}
+
+// SetTags replaces the tags applied to a note
+func (r *noteRepository) SetTags(ctx context.Context, noteID string, tagIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, ok := r.notes[noteID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notes.SetTags")
+	}
+	note.TagIDs = uniqueSorted(tagIDs)
+	return nil
+}
+
+// ReplaceTags moves every note tagged with one of from to the tag to
+func (r *noteRepository) ReplaceTags(ctx context.Context, from []string, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, note := range r.notes {
+		kept := note.TagIDs[:0:0]
+		replaced := false
+		for _, id := range note.TagIDs {
+			if slices.Contains(from, id) {
+				replaced = true
+				continue
+			}
+			kept = append(kept, id)
+		}
+		if replaced && to != "" {
+			kept = append(kept, to)
+		}
+		note.TagIDs = uniqueSorted(kept)
+	}
+	return nil
+}
+
+// CountByTag returns how many live notes carry each of tagIDs
+func (r *noteRepository) CountByTag(ctx context.Context, tagIDs []string) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, note := range r.notes {
+		if note.Trashed() {
+			continue
+		}
+		for _, id := range note.TagIDs {
+			if slices.Contains(tagIDs, id) {
+				counts[id]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// StorageUsage reports the notes a user owns and their size, trashed
+// notes included
+func (r *noteRepository) StorageUsage(ctx context.Context, userID string) (domainUser.StorageUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var usage domainUser.StorageUsage
+	for _, note := range r.notes {
+		if note.OwnerID == userID {
+			usage.Notes++
+			usage.NoteBytes += int64(note.Size())
+		}
+	}
+	return usage, nil
+}
+
+// remove deletes a note along with how users file it
+func (r *noteRepository) remove(id string) {
+	delete(r.notes, id)
+	for key := range r.states {
+		if key.noteID == id {
+			delete(r.states, key)
+		}
+	}
+}
+
+// matchesTags applies a tag filter to the tags of a note
+func matchesTags(noteTags, filterTags []string, mode domainNote.TagMode) bool {
+	if len(filterTags) == 0 {
+		return true
+	}
+	for _, id := range filterTags {
+		has := slices.Contains(noteTags, id)
+		if mode == domainNote.TagModeAny && has {
+			return true
+		}
+		if mode != domainNote.TagModeAny && !has {
+			return false
+		}
+	}
+	return mode != domainNote.TagModeAny
+}
+
+// containsTerms reports whether every term is a word of the note
+func containsTerms(note *domainNote.Note, terms []string) bool {
+	words := domainNote.SearchTerms(note.Title + " " + note.PlainText)
+	for _, term := range terms {
+		if !slices.Contains(words, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueSorted returns a sorted copy of ids without duplicates
+func uniqueSorted(ids []string) []string {
+	c := slices.Clone(ids)
+	slices.Sort(c)
+	return slices.Compact(c)
+}
+
+// copyNote returns a copy of note that shares no slices with it
+func copyNote(note *domainNote.Note) *domainNote.Note {
+	c := *note
+	c.Content = copyDelta(note.Content)
+	c.TagIDs = slices.Clone(note.TagIDs)
+	if note.DeletedAt != nil {
+		deletedAt := *note.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	return &c
+}
+
+// copyDelta copies the operations of a delta. Inserted values and
+// attributes are never modified in place, so they are shared.
+func copyDelta(d domainNote.Delta) domainNote.Delta {
+	return domainNote.Delta{Ops: slices.Clone(d.Ops)}
+}
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+)
+
+// notebookRepository implements domainNotebook.Repository in memory
+type notebookRepository struct {
+	mu            sync.RWMutex
+	notebooks     map[string]*domainNotebook.Notebook
+	collaborators map[string][]*domainNotebook.Collaborator
+}
+
+// NewNotebookRepository creates an empty in-memory notebook repository
+func NewNotebookRepository() domainNotebook.Repository {
+	return &notebookRepository{
+		notebooks:     make(map[string]*domainNotebook.Notebook),
+		collaborators: make(map[string][]*domainNotebook.Collaborator),
+	}
+}
+
+// Create stores a new notebook
+func (r *notebookRepository) Create(ctx context.Context, notebook *domainNotebook.Notebook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notebooks[notebook.ID]; ok {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "notebooks_pkey"), "notebooks.Create")
+	}
+	if notebook.ParentID != "" && r.notebooks[notebook.ParentID] == nil {
+		return errs.Wrap(errs.ErrNotFound, "notebooks.Create")
+	}
+	c := *notebook
+	r.notebooks[notebook.ID] = &c
+	return nil
+}
+
+// GetByID retrieves a notebook by its ID
+func (r *notebookRepository) GetByID(ctx context.Context, id string) (*domainNotebook.Notebook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notebook, ok := r.notebooks[id]
+	if !ok {
+		return nil, errs.Wrap(errs.ErrNotFound, "notebooks.GetByID")
+	}
+	c := *notebook
+	return &c, nil
+}
+
+// Update renames a notebook
+func (r *notebookRepository) Update(ctx context.Context, notebook *domainNotebook.Notebook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.notebooks[notebook.ID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notebooks.Update")
+	}
+	existing.Name = notebook.Name
+	existing.UpdatedAt = notebook.UpdatedAt
+	return nil
+}
+
+// Delete removes a notebook and its collaborators
+func (r *notebookRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notebooks[id]; !ok {
+		return errs.Wrap(errs.ErrNotFound, "notebooks.Delete")
+	}
+	delete(r.notebooks, id)
+	delete(r.collaborators, id)
+	return nil
+}
+
+// Move places a notebook under parentID. Holding the lock makes the cycle
+// check and the move atomic.
+func (r *notebookRepository) Move(ctx context.Context, id, parentID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notebook, ok := r.notebooks[id]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "notebooks.Move")
+	}
+	for ancestor := parentID; ancestor != ""; ancestor = r.notebooks[ancestor].ParentID {
+		if r.notebooks[ancestor] == nil {
+			return errs.Wrap(errs.ErrNotFound, "notebooks.Move")
+		}
+		if ancestor == id {
+			return errs.Wrap(errs.ErrConflict, "notebooks.Move")
+		}
+	}
+	notebook.ParentID = parentID
+	notebook.UpdatedAt = now
+	return nil
+}
+
+// ListByOwner returns every notebook of a user ordered by name
+func (r *notebookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*domainNotebook.Notebook, error) {
+	return r.filter(func(n *domainNotebook.Notebook) bool { return n.OwnerID == ownerID }), nil
+}
+
+// Children returns the notebooks directly under parentID ordered by name
+func (r *notebookRepository) Children(ctx context.Context, parentID string) ([]*domainNotebook.Notebook, error) {
+	return r.filter(func(n *domainNotebook.Notebook) bool { return parentID != "" && n.ParentID == parentID }), nil
+}
+
+// AddCollaborator shares a notebook with a user, or changes the role of
+// an existing collaborator
+func (r *notebookRepository) AddCollaborator(ctx context.Context, collaborator *domainNotebook.Collaborator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.notebooks[collaborator.NotebookID]; !ok {
+		return errs.Wrap(errs.ErrNotFound, "notebooks.AddCollaborator")
+	}
+	for _, existing := range r.collaborators[collaborator.NotebookID] {
+		if existing.UserID == collaborator.UserID {
+			existing.Role = collaborator.Role
+			return nil
+		}
+	}
+	c := *collaborator
+	c.Email = ""
+	r.collaborators[collaborator.NotebookID] = append(r.collaborators[collaborator.NotebookID], &c)
+	return nil
+}
+
+// RemoveCollaborator stops sharing a notebook with a user
+func (r *notebookRepository) RemoveCollaborator(ctx context.Context, notebookID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	collaborators := r.collaborators[notebookID]
+	for i, c := range collaborators {
+		if c.UserID == userID {
+			r.collaborators[notebookID] = append(collaborators[:i:i], collaborators[i+1:]...)
+			return nil
+		}
+	}
+	return errs.Wrap(errs.ErrNotFound, "notebooks.RemoveCollaborator")
+}
+
+// Collaborators returns the users a notebook is shared with directly,
+// oldest first
+func (r *notebookRepository) Collaborators(ctx context.Context, notebookID string) ([]*domainNotebook.Collaborator, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var collaborators []*domainNotebook.Collaborator
+	for _, c := range r.collaborators[notebookID] {
+		copied := *c
+		collaborators = append(collaborators, &copied)
+	}
+	sort.SliceStable(collaborators, func(i, j int) bool {
+		return collaborators[i].CreatedAt.Before(collaborators[j].CreatedAt)
+	})
+	return collaborators, nil
+}
+
+// Access returns the strongest role userID was granted on the notebook or
+// any notebook it is nested in
+func (r *notebookRepository) Access(ctx context.Context, notebookID, userID string) (domainNotebook.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var roles []domainNotebook.Role
+	for id := notebookID; id != "" && r.notebooks[id] != nil; id = r.notebooks[id].ParentID {
+		for _, c := range r.collaborators[id] {
+			if c.UserID == userID {
+				roles = append(roles, c.Role)
+			}
+		}
+	}
+	return domainNotebook.Strongest(roles...), nil
+}
+
+// SharedWith returns the notebooks shared directly with a user ordered by
+// name
+func (r *notebookRepository) SharedWith(ctx context.Context, userID string) ([]*domainNotebook.Notebook, error) {
+	r.mu.RLock()
+	shared := make(map[string]bool)
+	for notebookID, collaborators := range r.collaborators {
+		for _, c := range collaborators {
+			if c.UserID == userID {
+				shared[notebookID] = true
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	return r.filter(func(n *domainNotebook.Notebook) bool { return shared[n.ID] }), nil
+}
+
+// filter returns copies of the notebooks matching keep ordered by name
+func (r *notebookRepository) filter(keep func(*domainNotebook.Notebook) bool) []*domainNotebook.Notebook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notebooks []*domainNotebook.Notebook
+	for _, n := range r.notebooks {
+		if keep(n) {
+			c := *n
+			notebooks = append(notebooks, &c)
+		}
+	}
+	sort.Slice(notebooks, func(i, j int) bool {
+		a, b := strings.ToLower(notebooks[i].Name), strings.ToLower(notebooks[j].Name)
+		if a != b {
+			return a < b
+		}
+		return notebooks[i].ID < notebooks[j].ID
+	})
+	return notebooks
+}
@@ -0,0 +1,49 @@
+package memory_test
+
+import (
+	"testing"
+
+	"notes-app/backend/internal/domain/audit"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/repository/memory"
+	"notes-app/backend/internal/infrastructure/repository/repotest"
+)
+
+func TestUserRepository(t *testing.T) {
+	repotest.UserRepository(t, func(t *testing.T) domainUser.Repository {
+		return memory.NewUserRepository()
+	})
+}
+
+func TestAuditRepository(t *testing.T) {
+	repotest.AuditRepository(t, func(t *testing.T) audit.Repository {
+		return memory.NewAuditRepository()
+	})
+}
+
+func TestNoteRepository(t *testing.T) {
+	repotest.NoteRepository(t, newRepos)
+}
+
+func TestTagRepository(t *testing.T) {
+	repotest.TagRepository(t, newRepos)
+}
+
+func TestNotebookRepository(t *testing.T) {
+	repotest.NotebookRepository(t, newRepos)
+}
+
+func TestAttachmentRepository(t *testing.T) {
+	repotest.AttachmentRepository(t, newRepos)
+}
+
+func newRepos(t *testing.T) repotest.Repos {
+	notes := memory.NewNoteRepository()
+	return repotest.Repos{
+		Users:       memory.NewUserRepository(),
+		Notes:       notes,
+		Tags:        memory.NewTagRepository(),
+		Notebooks:   memory.NewNotebookRepository(),
+		Attachments: memory.NewAttachmentRepository(notes),
+	}
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"notes-app/backend/internal/domain/errs"
+	domainTag "notes-app/backend/internal/domain/tag"
+)
+
+// tagRepository implements domainTag.Repository in memory. Tags are not
+// removed from notes when deleted; callers use ReplaceTags on the note
+// repository first.
+type tagRepository struct {
+	mu   sync.RWMutex
+	tags map[string]*domainTag.Tag
+}
+
+// NewTagRepository creates an empty in-memory tag repository
+func NewTagRepository() domainTag.Repository {
+	return &tagRepository{tags: make(map[string]*domainTag.Tag)}
+}
+
+// Create stores a new tag
+func (r *tagRepository) Create(ctx context.Context, tag *domainTag.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[tag.ID]; ok {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "tags_pkey"), "tags.Create")
+	}
+	if r.nameTaken(tag) {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "tags_owner_name_key"), "tags.Create")
+	}
+	c := *tag
+	c.NoteCount = 0
+	r.tags[tag.ID] = &c
+	return nil
+}
+
+// GetByID retrieves a tag by its ID
+func (r *tagRepository) GetByID(ctx context.Context, id string) (*domainTag.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, errs.Wrap(errs.ErrNotFound, "tags.GetByID")
+	}
+	c := *tag
+	return &c, nil
+}
+
+// Update renames or recolors a tag
+func (r *tagRepository) Update(ctx context.Context, tag *domainTag.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tags[tag.ID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "tags.Update")
+	}
+	if r.nameTaken(tag) {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "tags_owner_name_key"), "tags.Update")
+	}
+	existing.Name = tag.Name
+	existing.Color = tag.Color
+	return nil
+}
+
+// Delete removes a tag
+func (r *tagRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[id]; !ok {
+		return errs.Wrap(errs.ErrNotFound, "tags.Delete")
+	}
+	delete(r.tags, id)
+	return nil
+}
+
+// List returns the tags of a user whose name starts with prefix
+func (r *tagRepository) List(ctx context.Context, ownerID, prefix string) ([]*domainTag.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	var tags []*domainTag.Tag
+	for _, tag := range r.tags {
+		if tag.OwnerID == ownerID && strings.HasPrefix(strings.ToLower(tag.Name), prefix) {
+			c := *tag
+			tags = append(tags, &c)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+	return tags, nil
+}
+
+// nameTaken reports whether another tag of the owner has the same name
+func (r *tagRepository) nameTaken(tag *domainTag.Tag) bool {
+	for _, other := range r.tags {
+		if other.ID != tag.ID && other.OwnerID == tag.OwnerID && strings.EqualFold(other.Name, tag.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"notes-app/backend/internal/domain/tx"
+)
+
+type txKey struct{}
+
+// txManager implements tx.Manager for the in-memory repositories. Units of
+// work run one at a time, so they are isolated from each other, but
+// changes made before fn fails are not rolled back.
+type txManager struct {
+	mu sync.Mutex
+}
+
+// NewTxManager creates a transaction manager for the in-memory repositories
+func NewTxManager() tx.Manager {
+	return &txManager{}
+}
+
+// WithinTx runs fn, joining the unit of work already carried by ctx
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return fn(context.WithValue(ctx, txKey{}, m))
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"notes-app/backend/internal/domain/errs"
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// userRepository implements domainUser.Repository in memory. It is safe
+// for concurrent use and stores copies, so callers never share a *User
+// with the repository.
+type userRepository struct {
+	mu      sync.RWMutex
+	byID    map[string]*domainUser.User
+	byEmail map[string]string // email -> id
+}
+
+// NewUserRepository creates an empty in-memory user repository
+func NewUserRepository() domainUser.Repository {
+	return &userRepository{
+		byID:    make(map[string]*domainUser.User),
+		byEmail: make(map[string]string),
+	}
+}
+
+// Create stores a new user
+func (r *userRepository) Create(ctx context.Context, user *domainUser.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byID[user.ID]; ok {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "users_pkey"), "users.Create")
+	}
+	if _, ok := r.byEmail[user.Email]; ok {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "users_email_key"), "users.Create")
+	}
+
+	r.byID[user.ID] = copyUser(user)
+	r.byEmail[user.Email] = user.ID
+	return nil
+}
+
+// GetByID retrieves a user by their ID
+func (r *userRepository) GetByID(ctx context.Context, id string) (*domainUser.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, errs.Wrap(errs.ErrNotFound, "users.GetByID")
+	}
+	return copyUser(user), nil
+}
+
+// GetByEmail retrieves a user by their email
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domainUser.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[email]
+	if !ok {
+		return nil, errs.Wrap(errs.ErrNotFound, "users.GetByEmail")
+	}
+	return copyUser(r.byID[id]), nil
+}
+
+// Update modifies an existing user
+func (r *userRepository) Update(ctx context.Context, user *domainUser.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.byID[user.ID]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "users.Update")
+	}
+	if id, taken := r.byEmail[user.Email]; taken && id != user.ID {
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", "users_email_key"), "users.Update")
+	}
+
+	delete(r.byEmail, existing.Email)
+	stored := copyUser(user)
+	stored.CreatedAt = existing.CreatedAt
+	r.byID[user.ID] = stored
+	r.byEmail[user.Email] = user.ID
+	return nil
+}
+
+// Delete removes a user
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return errs.Wrap(errs.ErrNotFound, "users.Delete")
+	}
+	delete(r.byID, id)
+	delete(r.byEmail, user.Email)
+	return nil
+}
+
+// List returns one page of the users matching filter, newest first
+func (r *userRepository) List(ctx context.Context, filter domainUser.ListFilter) ([]*domainUser.User, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query := strings.ToLower(filter.Query)
+	var matches []*domainUser.User
+	for _, user := range r.byID {
+		if query != "" && !strings.Contains(strings.ToLower(user.Email), query) {
+			continue
+		}
+		if filter.Role != "" && user.Role != filter.Role {
+			continue
+		}
+		if filter.Status == domainUser.StatusActive && user.Disabled() ||
+			filter.Status == domainUser.StatusDisabled && !user.Disabled() {
+			continue
+		}
+		matches = append(matches, user)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	page := paginate(matches, filter.Offset, filter.Limit)
+	users := make([]*domainUser.User, len(page))
+	for i, user := range page {
+		users[i] = copyUser(user)
+	}
+	return users, len(matches), nil
+}
+
+// copyUser returns a deep copy of user
+func copyUser(user *domainUser.User) *domainUser.User {
+	c := *user
+	if user.DisabledAt != nil {
+		t := *user.DisabledAt
+		c.DisabledAt = &t
+	}
+	if user.SessionsRevokedAt != nil {
+		t := *user.SessionsRevokedAt
+		c.SessionsRevokedAt = &t
+	}
+	return &c
+}
+
+// paginate returns the items of one page. A limit of zero means no limit.
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
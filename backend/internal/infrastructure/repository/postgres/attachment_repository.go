@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"github.com/lib/pq"
+)
+
+// attachmentRepository implements domainAttachment.Repository for
+// PostgreSQL
+type attachmentRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewAttachmentRepository creates a new PostgreSQL attachment repository
+func NewAttachmentRepository(db *sql.DB, logger *slog.Logger) domainAttachment.Repository {
+	return &attachmentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// attachmentColumns lists the columns read by scanAttachment, in order
+const attachmentColumns = `id, owner_id, storage_key, filename, content_type, size_bytes, checksum, width, height, processing, created_at`
+
+func scanAttachment(row scanner) (*domainAttachment.Attachment, error) {
+	a := &domainAttachment.Attachment{}
+	err := row.Scan(&a.ID, &a.OwnerID, &a.Key, &a.Filename, &a.ContentType, &a.Size, &a.Checksum,
+		&a.Width, &a.Height, &a.Processing, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Create stores a new attachment
+func (r *attachmentRepository) Create(ctx context.Context, a *domainAttachment.Attachment) (err error) {
+	query := `
+		INSERT INTO attachments (id, owner_id, storage_key, filename, content_type, size_bytes, checksum, width, height, processing, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	ctx, span := startSpan(ctx, "attachments.Create", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		a.ID, a.OwnerID, a.Key, a.Filename, a.ContentType, a.Size, a.Checksum, a.Width, a.Height, a.Processing, a.CreatedAt)
+	return translateError(err, "attachments.Create")
+}
+
+// GetByID retrieves an attachment by its ID. A missing attachment is
+// reported as errs.ErrNotFound.
+func (r *attachmentRepository) GetByID(ctx context.Context, id string) (_ *domainAttachment.Attachment, err error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE id = $1
+	`
+
+	ctx, span := startSpan(ctx, "attachments.GetByID", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	a, err := scanAttachment(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, translateError(err, "attachments.GetByID")
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT name, content_type, width, height, size_bytes
+		FROM attachment_variants
+		WHERE attachment_id = $1
+		ORDER BY name
+	`, id)
+	if err != nil {
+		return nil, translateError(err, "attachments.GetByID")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v domainAttachment.Variant
+		if err := rows.Scan(&v.Name, &v.ContentType, &v.Width, &v.Height, &v.Size); err != nil {
+			return nil, err
+		}
+		a.Variants = append(a.Variants, v)
+	}
+	return a, rows.Err()
+}
+
+// UpdateImage records the outcome of processing an image. Call it within a
+// transaction so the variants are replaced as a whole.
+func (r *attachmentRepository) UpdateImage(ctx context.Context, a *domainAttachment.Attachment) (err error) {
+	query := `
+		UPDATE attachments
+		SET size_bytes = $2, checksum = $3, width = $4, height = $5, processing = $6
+		WHERE id = $1
+	`
+
+	ctx, span := startSpan(ctx, "attachments.UpdateImage", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, a.ID, a.Size, a.Checksum, a.Width, a.Height, a.Processing)
+	if err != nil {
+		return translateError(err, "attachments.UpdateImage")
+	}
+	if err = expectRow(result, "attachments.UpdateImage"); err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `DELETE FROM attachment_variants WHERE attachment_id = $1`, a.ID)
+	if err != nil {
+		return translateError(err, "attachments.UpdateImage")
+	}
+	for _, v := range a.Variants {
+		_, err = conn(ctx, r.db).ExecContext(ctx, `
+			INSERT INTO attachment_variants (attachment_id, name, content_type, width, height, size_bytes)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, a.ID, v.Name, v.ContentType, v.Width, v.Height, v.Size)
+		if err != nil {
+			return translateError(err, "attachments.UpdateImage")
+		}
+	}
+	return nil
+}
+
+// ListPending returns up to limit images waiting to be processed, oldest
+// first
+func (r *attachmentRepository) ListPending(ctx context.Context, limit int) (_ []*domainAttachment.Attachment, err error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE processing = 'pending'
+		ORDER BY created_at, id
+		LIMIT $1
+	`
+
+	ctx, span := startSpan(ctx, "attachments.ListPending", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	return r.queryAttachments(ctx, "attachments.ListPending", query, limitArg(limit))
+}
+
+// Delete removes an attachment that no note references
+func (r *attachmentRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM attachments
+		WHERE id = $1
+		AND NOT EXISTS (SELECT 1 FROM note_attachments WHERE attachment_id = $1)
+	`
+
+	ctx, span := startSpan(ctx, "attachments.Delete", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "attachments.Delete")
+	}
+	return expectRow(result, "attachments.Delete")
+}
+
+// SetReferences replaces the attachments a note references. Call it
+// within a transaction so the note never loses its references midway.
+func (r *attachmentRepository) SetReferences(ctx context.Context, noteID string, attachmentIDs []string) (err error) {
+	query := `
+		DELETE FROM note_attachments
+		WHERE note_id = $1
+	`
+
+	ctx, span := startSpan(ctx, "attachments.SetReferences", "note_attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	var id string
+	err = conn(ctx, r.db).QueryRowContext(ctx, `SELECT id FROM notes WHERE id = $1 FOR UPDATE`, noteID).Scan(&id)
+	if err != nil {
+		return translateError(err, "attachments.SetReferences")
+	}
+
+	if _, err = conn(ctx, r.db).ExecContext(ctx, query, noteID); err != nil {
+		return translateError(err, "attachments.SetReferences")
+	}
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO note_attachments (note_id, attachment_id)
+		SELECT $1, id FROM attachments WHERE id = ANY($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, noteID, pq.Array(uniqueIDs(attachmentIDs)))
+	return translateError(err, "attachments.SetReferences")
+}
+
+// References returns the IDs of the attachments a note references
+func (r *attachmentRepository) References(ctx context.Context, noteID string) (_ []string, err error) {
+	query := `
+		SELECT attachment_id
+		FROM note_attachments
+		WHERE note_id = $1
+		ORDER BY attachment_id
+	`
+
+	ctx, span := startSpan(ctx, "attachments.References", "note_attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	return r.queryIDs(ctx, "attachments.References", query, noteID)
+}
+
+// ReferencingNotes returns the IDs of the notes that reference an
+// attachment
+func (r *attachmentRepository) ReferencingNotes(ctx context.Context, attachmentID string) (_ []string, err error) {
+	query := `
+		SELECT note_id
+		FROM note_attachments
+		WHERE attachment_id = $1
+		ORDER BY note_id
+	`
+
+	ctx, span := startSpan(ctx, "attachments.ReferencingNotes", "note_attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	return r.queryIDs(ctx, "attachments.ReferencingNotes", query, attachmentID)
+}
+
+// ListOrphans returns up to limit unreferenced attachments created before
+// the given time, oldest first
+func (r *attachmentRepository) ListOrphans(ctx context.Context, before time.Time, limit int) (_ []*domainAttachment.Attachment, err error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		WHERE created_at < $1
+		AND NOT EXISTS (SELECT 1 FROM note_attachments WHERE attachment_id = a.id)
+		ORDER BY created_at, id
+		LIMIT $2
+	`
+
+	ctx, span := startSpan(ctx, "attachments.ListOrphans", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	return r.queryAttachments(ctx, "attachments.ListOrphans", query, before, limitArg(limit))
+}
+
+// StorageUsage reports the attachments a user uploaded and their size,
+// variants included
+func (r *attachmentRepository) StorageUsage(ctx context.Context, userID string) (_ domainUser.StorageUsage, err error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(a.size_bytes + (
+			SELECT COALESCE(SUM(v.size_bytes), 0)
+			FROM attachment_variants v
+			WHERE v.attachment_id = a.id
+		)), 0)
+		FROM attachments a
+		WHERE a.owner_id = $1
+	`
+
+	ctx, span := startSpan(ctx, "attachments.StorageUsage", "attachments", query)
+	defer func() { tracing.End(span, err) }()
+
+	var usage domainUser.StorageUsage
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&usage.Attachments, &usage.AttachmentBytes)
+	return usage, translateError(err, "attachments.StorageUsage")
+}
+
+// queryAttachments runs a query selecting attachmentColumns
+func (r *attachmentRepository) queryAttachments(ctx context.Context, op, query string, args ...any) ([]*domainAttachment.Attachment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err, op)
+	}
+	defer rows.Close()
+
+	var attachments []*domainAttachment.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// queryIDs runs a query selecting one ID column
+func (r *attachmentRepository) queryIDs(ctx context.Context, op, query string, args ...any) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err, op)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
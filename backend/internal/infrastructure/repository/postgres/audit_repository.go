@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// auditRepository implements audit.Repository for PostgreSQL
+type auditRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewAuditRepository creates a new PostgreSQL audit repository
+func NewAuditRepository(db *sql.DB, logger *slog.Logger) audit.Repository {
+	return &auditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Append stores a new audit event
+func (r *auditRepository) Append(ctx context.Context, event *audit.Event) (err error) {
+	query := `
+		INSERT INTO audit_events (id, action, actor_id, target_type, target_id, ip, user_agent, request_id, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	ctx, span := startSpan(ctx, "audit_events.Append", "audit_events", query)
+	defer func() { tracing.End(span, err) }()
+
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return err
+		}
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		event.ID,
+		event.Action,
+		sql.NullString{String: event.ActorID, Valid: event.ActorID != ""},
+		event.TargetType,
+		event.TargetID,
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		metadata,
+		event.CreatedAt,
+	)
+
+	return translateError(err, "audit_events.Append")
+}
+
+// List returns one page of the events matching filter, newest first
+func (r *auditRepository) List(ctx context.Context, filter audit.Filter) (_ []*audit.Event, total int, err error) {
+	var where []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = $%d", filter.TargetID)
+	}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		where = append(where, fmt.Sprintf(
+			"(actor_id = $%[1]d OR (target_type = '%[2]s' AND target_id = $%[1]d::text))",
+			len(args), audit.TargetUser))
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < $%d", filter.Until)
+	}
+	conditions := ""
+	if len(where) > 0 {
+		conditions = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := `
+		SELECT id, action, actor_id, target_type, target_id, ip, user_agent, request_id, metadata, created_at, COUNT(*) OVER ()
+		FROM audit_events
+		` + conditions + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + fmt.Sprint(len(args)+1) + ` OFFSET $` + fmt.Sprint(len(args)+2)
+	args = append(args, limitArg(filter.Limit), filter.Offset)
+
+	ctx, span := startSpan(ctx, "audit_events.List", "audit_events", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []*audit.Event
+	for rows.Next() {
+		event := &audit.Event{}
+		var actorID sql.NullString
+		var metadata []byte
+		err = rows.Scan(
+			&event.ID,
+			&event.Action,
+			&actorID,
+			&event.TargetType,
+			&event.TargetID,
+			&event.IP,
+			&event.UserAgent,
+			&event.RequestID,
+			&metadata,
+			&event.CreatedAt,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		event.ActorID = actorID.String
+		if err = json.Unmarshal(metadata, &event.Metadata); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// A page past the end has no rows to carry the window count
+	if len(events) == 0 && filter.Offset > 0 {
+		countQuery := `SELECT COUNT(*) FROM audit_events ` + conditions
+		if err = conn(ctx, r.db).QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return events, total, nil
+}
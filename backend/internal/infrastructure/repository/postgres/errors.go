@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"notes-app/backend/internal/domain/errs"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes translated into domain errors
+const (
+	codeUniqueViolation     pq.ErrorCode = "23505"
+	codeForeignKeyViolation pq.ErrorCode = "23503"
+	codeInvalidText         pq.ErrorCode = "22P02"
+)
+
+// translateError maps driver errors to domain errors, annotated with op:
+//   - no rows and malformed keys become errs.ErrNotFound
+//   - unique violations become errs.ErrConflict, naming the constraint
+//   - foreign key violations become errs.ErrNotFound, since the
+//     referenced row is missing
+//
+// Other errors, including serialization failures the transaction manager
+// retries, are returned unchanged.
+func translateError(err error, op string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.Wrap(errs.ErrNotFound, op)
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case codeUniqueViolation:
+		return errs.Wrap(errs.ErrConflict.WithParam("constraint", pqErr.Constraint), op)
+	case codeForeignKeyViolation, codeInvalidText:
+		return errs.Wrap(errs.ErrNotFound, op)
+	}
+	return err
+}
+
+// expectRow reports errs.ErrNotFound when a statement affected no row
+func expectRow(result sql.Result, op string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errs.Wrap(errs.ErrNotFound, op)
+	}
+	return nil
+}
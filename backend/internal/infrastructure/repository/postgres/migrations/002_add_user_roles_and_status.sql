@@ -0,0 +1,9 @@
+-- Roles and account status
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'
+        CHECK (role IN ('user', 'support', 'admin')),
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS sessions_revoked_at TIMESTAMP WITH TIME ZONE;
+
+-- Admin listings are ordered by creation time
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at DESC);
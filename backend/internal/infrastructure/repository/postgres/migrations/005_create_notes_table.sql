@@ -0,0 +1,16 @@
+-- Notes. content holds the Quill delta; plain_text is its text, kept for
+-- search and previews. size_bytes is the storage the note counts for.
+CREATE TABLE IF NOT EXISTS notes (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL DEFAULT '',
+    content JSONB NOT NULL,
+    plain_text TEXT NOT NULL DEFAULT '',
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || plain_text)) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_notes_owner_updated ON notes(owner_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notes_search ON notes USING GIN(search);
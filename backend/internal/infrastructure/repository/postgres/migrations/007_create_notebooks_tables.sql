@@ -0,0 +1,29 @@
+-- Notebooks nest through parent_id. The application keeps the tree free
+-- of cycles; deleting a notebook is only allowed once it is empty.
+CREATE TABLE IF NOT EXISTS notebooks (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES notebooks(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notebooks_owner ON notebooks(owner_id);
+CREATE INDEX IF NOT EXISTS idx_notebooks_parent ON notebooks(parent_id);
+
+-- Collaborators can access a notebook and everything nested in it
+CREATE TABLE IF NOT EXISTS notebook_collaborators (
+    notebook_id UUID NOT NULL REFERENCES notebooks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (notebook_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notebook_collaborators_user ON notebook_collaborators(user_id);
+
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS notebook_id UUID REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notes_notebook_updated ON notes(notebook_id, updated_at DESC);
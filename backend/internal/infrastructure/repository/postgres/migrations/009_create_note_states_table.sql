@@ -0,0 +1,15 @@
+-- How each user files the notes they can open: pins, archive and
+-- favourites are personal, so a collaborator's pin does not affect the
+-- owner. position is a fractional index compared byte by byte, hence the
+-- C collation; it is NULL until the user moves the note by hand.
+CREATE TABLE IF NOT EXISTS note_states (
+    note_id UUID NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    favourite BOOLEAN NOT NULL DEFAULT FALSE,
+    position TEXT COLLATE "C",
+    PRIMARY KEY (note_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_states_user ON note_states(user_id);
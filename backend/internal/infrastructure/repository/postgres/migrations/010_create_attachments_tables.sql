@@ -0,0 +1,27 @@
+-- Uploaded files. The content lives in the blob store under storage_key;
+-- this table holds what is needed to serve it. Attachments outlive the
+-- notes that embed them until the garbage collector finds them orphaned.
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_owner ON attachments(owner_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_created ON attachments(created_at);
+
+-- The attachments each note's content embeds, rewritten on every save.
+-- Deleting a note drops its references, leaving unreferenced attachments
+-- to the garbage collector.
+CREATE TABLE IF NOT EXISTS note_attachments (
+    note_id UUID NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    attachment_id UUID NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    PRIMARY KEY (note_id, attachment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_attachments_attachment ON note_attachments(attachment_id);
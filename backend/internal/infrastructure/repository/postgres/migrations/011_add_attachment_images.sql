@@ -0,0 +1,23 @@
+-- Uploaded images are processed in the background: processing moves from
+-- pending to done (or failed), recording the upright dimensions of the
+-- image and the smaller variants generated from it. Attachments that are
+-- not images keep an empty processing state.
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS width INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS height INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS processing TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_attachments_pending ON attachments(created_at)
+    WHERE processing = 'pending';
+
+-- The variants of an image, such as its thumbnail. Their content lives in
+-- the blob store under the attachment's storage key and the variant name.
+CREATE TABLE IF NOT EXISTS attachment_variants (
+    attachment_id UUID NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    PRIMARY KEY (attachment_id, name)
+);
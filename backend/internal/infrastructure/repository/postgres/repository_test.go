@@ -0,0 +1,117 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/audit"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/repository/postgres"
+	"notes-app/backend/internal/infrastructure/repository/repotest"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestUserRepository(t *testing.T) {
+	repotest.UserRepository(t, func(t *testing.T) domainUser.Repository {
+		return postgres.NewUserRepository(openTestDB(t), discard)
+	})
+}
+
+func TestAuditRepository(t *testing.T) {
+	repotest.AuditRepository(t, func(t *testing.T) audit.Repository {
+		return postgres.NewAuditRepository(openTestDB(t), discard)
+	})
+}
+
+func TestNoteRepository(t *testing.T) {
+	repotest.NoteRepository(t, newRepos)
+}
+
+func TestTagRepository(t *testing.T) {
+	repotest.TagRepository(t, newRepos)
+}
+
+func TestNotebookRepository(t *testing.T) {
+	repotest.NotebookRepository(t, newRepos)
+}
+
+func TestAttachmentRepository(t *testing.T) {
+	repotest.AttachmentRepository(t, newRepos)
+}
+
+func newRepos(t *testing.T) repotest.Repos {
+	db := openTestDB(t)
+	return repotest.Repos{
+		Users:       postgres.NewUserRepository(db, discard),
+		Notes:       postgres.NewNoteRepository(db, discard),
+		Tags:        postgres.NewTagRepository(db, discard),
+		Notebooks:   postgres.NewNotebookRepository(db, discard),
+		Attachments: postgres.NewAttachmentRepository(db, discard),
+	}
+}
+
+// openTestDB connects to TEST_DATABASE_URL with a fresh schema holding
+// every migration, dropped when the test ends. Tests are skipped when the
+// variable is not set.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// Unknown connection parameters are sent to the server as run-time
+	// parameters, so every pooled connection uses the schema
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "search_path=" + schema
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := filepath.Glob("migrations/*.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, path := range migrations {
+		script, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if _, err := db.ExecContext(context.Background(), string(script)); err != nil {
+			t.Fatalf("apply %s: %v", path, err)
+		}
+	}
+	return db
+}
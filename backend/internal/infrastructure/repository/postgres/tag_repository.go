@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// tagRepository implements domainTag.Repository for PostgreSQL
+type tagRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewTagRepository creates a new PostgreSQL tag repository
+func NewTagRepository(db *sql.DB, logger *slog.Logger) domainTag.Repository {
+	return &tagRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// tagColumns lists the columns read by scanTag, in order
+const tagColumns = `id, owner_id, name, color, created_at`
+
+func scanTag(row scanner) (*domainTag.Tag, error) {
+	tag := &domainTag.Tag{}
+	err := row.Scan(&tag.ID, &tag.OwnerID, &tag.Name, &tag.Color, &tag.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Create stores a new tag
+func (r *tagRepository) Create(ctx context.Context, tag *domainTag.Tag) (err error) {
+	query := `
+		INSERT INTO tags (id, owner_id, name, color, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	ctx, span := startSpan(ctx, "tags.Create", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, tag.ID, tag.OwnerID, tag.Name, tag.Color, tag.CreatedAt)
+	return translateError(err, "tags.Create")
+}
+
+// GetByID retrieves a tag by its ID. A missing tag is reported as
+// errs.ErrNotFound.
+func (r *tagRepository) GetByID(ctx context.Context, id string) (_ *domainTag.Tag, err error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE id = $1
+	`
+
+	ctx, span := startSpan(ctx, "tags.GetByID", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	tag, err := scanTag(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, translateError(err, "tags.GetByID")
+	}
+	return tag, nil
+}
+
+// Update renames or recolors a tag
+func (r *tagRepository) Update(ctx context.Context, tag *domainTag.Tag) (err error) {
+	query := `
+		UPDATE tags
+		SET name = $2, color = $3
+		WHERE id = $1
+	`
+
+	ctx, span := startSpan(ctx, "tags.Update", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tag.ID, tag.Name, tag.Color)
+	if err != nil {
+		return translateError(err, "tags.Update")
+	}
+	return expectRow(result, "tags.Update")
+}
+
+// Delete removes a tag; it is removed from every note as well
+func (r *tagRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM tags
+		WHERE id = $1
+	`
+
+	ctx, span := startSpan(ctx, "tags.Delete", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "tags.Delete")
+	}
+	return expectRow(result, "tags.Delete")
+}
+
+// List returns the tags of a user whose name starts with prefix
+func (r *tagRepository) List(ctx context.Context, ownerID, prefix string) (_ []*domainTag.Tag, err error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE owner_id = $1 AND lower(name) LIKE $2
+		ORDER BY lower(name), id
+	`
+
+	ctx, span := startSpan(ctx, "tags.List", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, ownerID, escapeLike(strings.ToLower(prefix))+"%")
+	if err != nil {
+		return nil, translateError(err, "tags.List")
+	}
+	defer rows.Close()
+
+	var tags []*domainTag.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"notes-app/backend/internal/domain/tx"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Postgres error codes of transactions worth retrying
+const (
+	codeSerializationFailure pq.ErrorCode = "40001"
+	codeDeadlockDetected     pq.ErrorCode = "40P01"
+)
+
+// TxConfig configures the transaction manager
+type TxConfig struct {
+	// Isolation is the isolation level of new transactions. The zero
+	// value uses the database default, READ COMMITTED for Postgres.
+	Isolation sql.IsolationLevel
+	// MaxRetries is how often a transaction failing with a serialization
+	// failure or deadlock is retried
+	MaxRetries int
+	// RetryDelay is the base of the exponential, jittered backoff
+	RetryDelay time.Duration
+}
+
+// executor is the part of *sql.DB and *sql.Tx used by repositories
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// conn returns the transaction carried by ctx, or db outside of one
+func conn(ctx context.Context, db *sql.DB) executor {
+	if t, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return t
+	}
+	return db
+}
+
+// txManager implements tx.Manager for PostgreSQL
+type txManager struct {
+	db     *sql.DB
+	cfg    TxConfig
+	logger *slog.Logger
+}
+
+// NewTxManager creates a transaction manager for db
+func NewTxManager(db *sql.DB, cfg TxConfig, logger *slog.Logger) tx.Manager {
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 10 * time.Millisecond
+	}
+	return &txManager{
+		db:     db,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// WithinTx runs fn in a transaction, retrying it on serialization
+// failures and deadlocks
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, "db.Transaction")
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 0; ; attempt++ {
+		err = m.run(ctx, fn)
+		if err == nil || !retryable(err) || attempt >= m.cfg.MaxRetries {
+			span.SetAttributes(attribute.Int("db.transaction.attempts", attempt+1))
+			return err
+		}
+
+		delay := m.cfg.RetryDelay << attempt
+		delay += rand.N(delay)
+		m.logger.DebugContext(ctx, "retrying transaction",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// run executes one attempt of fn
+func (m *txManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	t, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: m.cfg.Isolation})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			t.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := t.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				m.logger.ErrorContext(ctx, "transaction rollback failed", slog.Any("error", rbErr))
+			}
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, t)); err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+// retryable reports whether err asks for the transaction to be retried
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+}
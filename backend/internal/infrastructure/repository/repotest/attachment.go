@@ -0,0 +1,253 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+)
+
+// AttachmentRepository runs the domainAttachment.Repository contract.
+// newRepos must return empty repositories for every call.
+func AttachmentRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+	base := time.Now().UTC().Truncate(time.Microsecond)
+
+	t.Run("create and get", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		want := NewAttachment(owner, "photo.png", base)
+		if err := repos.Attachments.Create(ctx, want); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := repos.Attachments.GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, want.CreatedAt)
+		}
+		got.CreatedAt = want.CreatedAt
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("attachment = %+v, want %+v", got, want)
+		}
+
+		for _, id := range []string{uuid.NewString(), "not-a-uuid"} {
+			if _, err := repos.Attachments.GetByID(ctx, id); !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("GetByID(%q): err = %v, want ErrNotFound", id, err)
+			}
+		}
+	})
+
+	t.Run("images", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		photo := NewAttachment(owner, "photo.jpg", base)
+		photo.Processing = domainAttachment.ProcessingPending
+		if err := repos.Attachments.Create(ctx, photo); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		later := NewAttachment(owner, "later.jpg", base.Add(time.Second))
+		later.Processing = domainAttachment.ProcessingPending
+		if err := repos.Attachments.Create(ctx, later); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		mustCreateAttachment(t, repos, owner, "scan.pdf", base)
+		assertPending(t, repos, 0, photo, later)
+		assertPending(t, repos, 1, photo)
+
+		photo.Size, photo.Checksum = 900, "0f"
+		photo.Width, photo.Height = 3000, 4000
+		photo.Processing = domainAttachment.ProcessingDone
+		photo.Variants = []domainAttachment.Variant{
+			{Name: domainAttachment.VariantThumbnail, ContentType: "image/jpeg", Width: 192, Height: 256, Size: 20},
+			{Name: domainAttachment.VariantDisplay, ContentType: "image/jpeg", Width: 1280, Height: 1707, Size: 300},
+		}
+		if err := repos.Attachments.UpdateImage(ctx, photo); err != nil {
+			t.Fatalf("UpdateImage: %v", err)
+		}
+		got, err := repos.Attachments.GetByID(ctx, photo.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		got.CreatedAt = photo.CreatedAt
+		// Variants come sorted by name
+		photo.Variants[0], photo.Variants[1] = photo.Variants[1], photo.Variants[0]
+		if !reflect.DeepEqual(got, photo) {
+			t.Errorf("attachment = %+v, want %+v", got, photo)
+		}
+		assertPending(t, repos, 0, later)
+
+		usage, err := repos.Attachments.StorageUsage(ctx, owner)
+		if err != nil {
+			t.Fatalf("StorageUsage: %v", err)
+		}
+		if want := 900 + 320 + later.Size + int64(len("scan.pdf"))*100; usage.AttachmentBytes != want {
+			t.Errorf("attachment bytes = %d, want %d", usage.AttachmentBytes, want)
+		}
+
+		// Reprocessing replaces the variants
+		photo.Variants = photo.Variants[1:]
+		if err := repos.Attachments.UpdateImage(ctx, photo); err != nil {
+			t.Fatalf("UpdateImage: %v", err)
+		}
+		if got, _ := repos.Attachments.GetByID(ctx, photo.ID); len(got.Variants) != 1 || got.Variants[0].Name != domainAttachment.VariantThumbnail {
+			t.Errorf("variants = %+v, want the thumbnail", got.Variants)
+		}
+
+		photo.ID = uuid.NewString()
+		if err := repos.Attachments.UpdateImage(ctx, photo); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("UpdateImage of missing attachment: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		photo := mustCreateAttachment(t, repos, owner, "photo.png", base)
+		scan := mustCreateAttachment(t, repos, owner, "scan.pdf", base)
+		note := NewNote(owner, "Trip", domainNote.EmptyDelta(), base)
+		mustCreateNote(t, repos, note)
+
+		err := repos.Attachments.SetReferences(ctx, note.ID, []string{scan.ID, photo.ID, uuid.NewString(), photo.ID})
+		if err != nil {
+			t.Fatalf("SetReferences: %v", err)
+		}
+		assertReferences(t, repos, note.ID, photo.ID, scan.ID)
+		assertReferencingNotes(t, repos, photo.ID, note.ID)
+
+		if err := repos.Attachments.SetReferences(ctx, note.ID, []string{scan.ID}); err != nil {
+			t.Fatalf("SetReferences: %v", err)
+		}
+		assertReferences(t, repos, note.ID, scan.ID)
+		assertReferencingNotes(t, repos, photo.ID)
+
+		if err := repos.Attachments.SetReferences(ctx, uuid.NewString(), []string{scan.ID}); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("SetReferences of missing note: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("orphans", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		kept := mustCreateAttachment(t, repos, owner, "kept.png", base)
+		older := mustCreateAttachment(t, repos, owner, "older.png", base.Add(-time.Hour))
+		orphan := mustCreateAttachment(t, repos, owner, "orphan.png", base.Add(time.Second))
+		recent := mustCreateAttachment(t, repos, owner, "recent.png", base.Add(time.Hour))
+		note := NewNote(owner, "Trip", domainNote.EmptyDelta(), base)
+		mustCreateNote(t, repos, note)
+		if err := repos.Attachments.SetReferences(ctx, note.ID, []string{kept.ID}); err != nil {
+			t.Fatalf("SetReferences: %v", err)
+		}
+
+		cutoff := base.Add(time.Minute)
+		assertOrphans(t, repos, cutoff, 0, older, orphan)
+		assertOrphans(t, repos, cutoff, 1, older)
+
+		if err := repos.Attachments.Delete(ctx, kept.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Delete referenced: err = %v, want ErrNotFound", err)
+		}
+		if err := repos.Attachments.Delete(ctx, older.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repos.Attachments.Delete(ctx, older.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Delete twice: err = %v, want ErrNotFound", err)
+		}
+
+		// Trashed notes keep their attachments until they are deleted
+		if err := repos.Notes.Trash(ctx, note.ID, base); err != nil {
+			t.Fatalf("Trash: %v", err)
+		}
+		assertOrphans(t, repos, cutoff, 0, orphan)
+		if err := repos.Notes.Delete(ctx, note.ID); err != nil {
+			t.Fatalf("Delete note: %v", err)
+		}
+		assertOrphans(t, repos, cutoff, 0, kept, orphan)
+		assertReferencingNotes(t, repos, kept.ID)
+		assertOrphans(t, repos, cutoff.Add(2*time.Hour), 0, kept, orphan, recent)
+	})
+
+	t.Run("storage usage", func(t *testing.T) {
+		repos := newRepos(t)
+		jane := mustCreateUser(t, repos, "jane@example.com")
+		john := mustCreateUser(t, repos, "john@example.com")
+		a := mustCreateAttachment(t, repos, jane, "a.png", base)
+		b := mustCreateAttachment(t, repos, jane, "b.png", base)
+		mustCreateAttachment(t, repos, john, "c.png", base)
+
+		usage, err := repos.Attachments.StorageUsage(ctx, jane)
+		if err != nil {
+			t.Fatalf("StorageUsage: %v", err)
+		}
+		if usage.Attachments != 2 || usage.AttachmentBytes != a.Size+b.Size || usage.Notes != 0 {
+			t.Errorf("usage = %+v, want 2 attachments of %d bytes", usage, a.Size+b.Size)
+		}
+	})
+}
+
+// NewAttachment returns an attachment ready to be stored
+func NewAttachment(ownerID, filename string, now time.Time) *domainAttachment.Attachment {
+	return domainAttachment.NewAttachment(uuid.NewString(), ownerID, filename, "image/png",
+		int64(len(filename))*100, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", now)
+}
+
+func mustCreateAttachment(t *testing.T, repos Repos, ownerID, filename string, now time.Time) *domainAttachment.Attachment {
+	t.Helper()
+	a := NewAttachment(ownerID, filename, now)
+	if err := repos.Attachments.Create(context.Background(), a); err != nil {
+		t.Fatalf("Create attachment %s: %v", filename, err)
+	}
+	return a
+}
+
+func assertReferences(t *testing.T, repos Repos, noteID string, want ...string) {
+	t.Helper()
+	got, err := repos.Attachments.References(context.Background(), noteID)
+	if err != nil {
+		t.Fatalf("References: %v", err)
+	}
+	assertIDs(t, "references", got, sorted(want))
+}
+
+func assertReferencingNotes(t *testing.T, repos Repos, attachmentID string, want ...string) {
+	t.Helper()
+	got, err := repos.Attachments.ReferencingNotes(context.Background(), attachmentID)
+	if err != nil {
+		t.Fatalf("ReferencingNotes: %v", err)
+	}
+	assertIDs(t, "referencing notes", got, sorted(want))
+}
+
+func assertOrphans(t *testing.T, repos Repos, before time.Time, limit int, want ...*domainAttachment.Attachment) {
+	t.Helper()
+	orphans, err := repos.Attachments.ListOrphans(context.Background(), before, limit)
+	if err != nil {
+		t.Fatalf("ListOrphans: %v", err)
+	}
+	assertIDs(t, "orphans", attachmentIDs(orphans...), attachmentIDs(want...))
+}
+
+func assertPending(t *testing.T, repos Repos, limit int, want ...*domainAttachment.Attachment) {
+	t.Helper()
+	pending, err := repos.Attachments.ListPending(context.Background(), limit)
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	assertIDs(t, "pending", attachmentIDs(pending...), attachmentIDs(want...))
+}
+
+func attachmentIDs(attachments ...*domainAttachment.Attachment) []string {
+	var ids []string
+	for _, a := range attachments {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,141 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/errs"
+)
+
+// AuditRepository runs the audit.Repository contract. newRepo must return
+// an empty repository for every call.
+func AuditRepository(t *testing.T, newRepo func(t *testing.T) audit.Repository) {
+	ctx := context.Background()
+
+	t.Run("append and list", func(t *testing.T) {
+		repo := newRepo(t)
+		want := newEvent(audit.ActionLoginSucceeded, uuid.NewString(), time.Now())
+		want.TargetType = audit.TargetUser
+		want.TargetID = want.ActorID
+		want.IP = "203.0.113.7"
+		want.UserAgent = "curl/8.0"
+		want.RequestID = "req-1"
+		want.Metadata = map[string]string{"reason": "test"}
+		if err := repo.Append(ctx, want); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+
+		got, total, err := repo.List(ctx, audit.Filter{})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if total != 1 || len(got) != 1 {
+			t.Fatalf("got %d events (total %d), want 1", len(got), total)
+		}
+		e := got[0]
+		if e.ID != want.ID || e.Action != want.Action || e.ActorID != want.ActorID ||
+			e.TargetType != want.TargetType || e.TargetID != want.TargetID || e.IP != want.IP ||
+			e.UserAgent != want.UserAgent || e.RequestID != want.RequestID || e.Metadata["reason"] != "test" {
+			t.Errorf("event = %+v, want %+v", e, want)
+		}
+		if !e.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("CreatedAt = %v, want %v", e.CreatedAt, want.CreatedAt)
+		}
+	})
+
+	t.Run("anonymous actor", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Append(ctx, newEvent(audit.ActionLoginFailed, "", time.Now())); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		got, _, err := repo.List(ctx, audit.Filter{})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(got) != 1 || got[0].ActorID != "" {
+			t.Fatalf("events = %+v, want one without actor", got)
+		}
+	})
+
+	t.Run("duplicate id conflicts", func(t *testing.T) {
+		repo := newRepo(t)
+		event := newEvent(audit.ActionRegistered, uuid.NewString(), time.Now())
+		if err := repo.Append(ctx, event); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		if err := repo.Append(ctx, event); !errors.Is(err, errs.ErrConflict) {
+			t.Fatalf("err = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		repo := newRepo(t)
+		alice, bob := uuid.NewString(), uuid.NewString()
+		base := time.Now().Add(-time.Hour)
+
+		login := newEvent(audit.ActionLoginSucceeded, alice, base)
+		disable := newEvent(audit.ActionAdminUserDisabled, alice, base.Add(time.Minute))
+		disable.TargetType, disable.TargetID = audit.TargetUser, bob
+		failed := newEvent(audit.ActionLoginFailed, "", base.Add(2*time.Minute))
+		failed.TargetType, failed.TargetID = audit.TargetUser, bob
+		note := newEvent(audit.ActionNoteCreated, bob, base.Add(3*time.Minute))
+		note.TargetType, note.TargetID = audit.TargetNote, uuid.NewString()
+		for _, e := range []*audit.Event{login, disable, failed, note} {
+			if err := repo.Append(ctx, e); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+		}
+
+		tests := []struct {
+			name      string
+			filter    audit.Filter
+			want      []*audit.Event
+			wantTotal int
+		}{
+			{"all newest first", audit.Filter{}, []*audit.Event{note, failed, disable, login}, 4},
+			{"page", audit.Filter{Offset: 1, Limit: 2}, []*audit.Event{failed, disable}, 4},
+			{"actor", audit.Filter{ActorID: alice}, []*audit.Event{disable, login}, 2},
+			{"action", audit.Filter{Action: audit.ActionLoginFailed}, []*audit.Event{failed}, 1},
+			{"target", audit.Filter{TargetType: audit.TargetUser, TargetID: bob}, []*audit.Event{failed, disable}, 2},
+			{"user as actor or target", audit.Filter{UserID: bob}, []*audit.Event{note, failed, disable}, 3},
+			{"since", audit.Filter{Since: failed.CreatedAt}, []*audit.Event{note, failed}, 2},
+			{"until", audit.Filter{Until: failed.CreatedAt}, []*audit.Event{disable, login}, 2},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, total, err := repo.List(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if total != tt.wantTotal {
+					t.Errorf("total = %d, want %d", total, tt.wantTotal)
+				}
+				if gotIDs, wantIDs := eventIDs(got), eventIDs(tt.want); !equalStrings(gotIDs, wantIDs) {
+					t.Errorf("ids = %v, want %v", gotIDs, wantIDs)
+				}
+			})
+		}
+	})
+}
+
+func newEvent(action audit.Action, actorID string, createdAt time.Time) *audit.Event {
+	return &audit.Event{
+		ID:        uuid.NewString(),
+		Action:    action,
+		ActorID:   actorID,
+		CreatedAt: createdAt.UTC().Truncate(time.Microsecond),
+	}
+}
+
+func eventIDs(events []*audit.Event) []string {
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
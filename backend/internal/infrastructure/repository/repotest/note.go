@@ -0,0 +1,574 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	domainTag "notes-app/backend/internal/domain/tag"
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// Repos is a set of repositories sharing one store, as notes reference
+// users, tags and notebooks, and attachments are referenced by notes
+type Repos struct {
+	Users       domainUser.Repository
+	Notes       domainNote.Repository
+	Tags        domainTag.Repository
+	Notebooks   domainNotebook.Repository
+	Attachments domainAttachment.Repository
+}
+
+// NoteRepository runs the domainNote.Repository contract. newRepos must
+// return empty repositories for every call.
+func NoteRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+	base := time.Now().UTC().Truncate(time.Microsecond)
+
+	t.Run("create and get", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		red := mustCreateTag(t, repos, owner, "red")
+		blue := mustCreateTag(t, repos, owner, "blue")
+
+		content := domainNote.Delta{Ops: []domainNote.Op{
+			{Insert: "Shopping", Attributes: map[string]any{"bold": true}},
+			{Insert: "\n", Attributes: map[string]any{"header": float64(1)}},
+			{Insert: map[string]any{"image": "https://example.com/a.png"}},
+			{Insert: "milk\n"},
+		}}
+		want := NewNote(owner, "Groceries", content, base)
+		want.TagIDs = []string{red.ID, blue.ID}
+		mustCreateNote(t, repos, want)
+
+		got, err := repos.Notes.GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		want.TagIDs = sorted(want.TagIDs)
+		assertNote(t, got, want)
+	})
+
+	t.Run("get missing note", func(t *testing.T) {
+		repos := newRepos(t)
+		for _, id := range []string{uuid.NewString(), "not-a-uuid"} {
+			note, err := repos.Notes.GetByID(ctx, id)
+			if !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("GetByID(%q): err = %v, want ErrNotFound", id, err)
+			}
+			if note != nil {
+				t.Errorf("GetByID(%q): note = %v, want nil", id, note)
+			}
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		tag := mustCreateTag(t, repos, owner, "work")
+		note := NewNote(owner, "Draft", domainNote.EmptyDelta(), base)
+		note.TagIDs = []string{tag.ID}
+		mustCreateNote(t, repos, note)
+
+		note.Edit("Final", domainNote.Delta{Ops: []domainNote.Op{{Insert: "done\n"}}}, base.Add(time.Minute))
+		if err := repos.Notes.Update(ctx, note); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := repos.Notes.GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertNote(t, got, note)
+
+		missing := NewNote(owner, "Missing", domainNote.EmptyDelta(), base)
+		if err := repos.Notes.Update(ctx, missing); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Update missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		tag := mustCreateTag(t, repos, owner, "work")
+		note := NewNote(owner, "Old", domainNote.EmptyDelta(), base)
+		note.TagIDs = []string{tag.ID}
+		mustCreateNote(t, repos, note)
+
+		if err := repos.Notes.Delete(ctx, note.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repos.Notes.GetByID(ctx, note.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("GetByID after Delete: err = %v, want ErrNotFound", err)
+		}
+		if err := repos.Notes.Delete(ctx, note.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Delete twice: err = %v, want ErrNotFound", err)
+		}
+		counts, err := repos.Notes.CountByTag(ctx, []string{tag.ID})
+		if err != nil {
+			t.Fatalf("CountByTag: %v", err)
+		}
+		if counts[tag.ID] != 0 {
+			t.Errorf("count after Delete = %d, want 0", counts[tag.ID])
+		}
+	})
+
+	t.Run("trash and restore", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		tag := mustCreateTag(t, repos, owner, "work")
+		live := NewNote(owner, "Live", domainNote.EmptyDelta(), base)
+		first := NewNote(owner, "First", domainNote.EmptyDelta(), base)
+		second := NewNote(owner, "Second", domainNote.EmptyDelta(), base)
+		for _, n := range []*domainNote.Note{live, first, second} {
+			n.TagIDs = []string{tag.ID}
+			mustCreateNote(t, repos, n)
+		}
+
+		for i, n := range []*domainNote.Note{first, second} {
+			if err := repos.Notes.Trash(ctx, n.ID, base.Add(time.Duration(i+1)*time.Hour)); err != nil {
+				t.Fatalf("Trash: %v", err)
+			}
+		}
+		if err := repos.Notes.Trash(ctx, uuid.NewString(), base); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Trash missing note: err = %v, want ErrNotFound", err)
+		}
+
+		got, err := repos.Notes.GetByID(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.DeletedAt == nil || !got.DeletedAt.Equal(base.Add(time.Hour)) {
+			t.Errorf("DeletedAt = %v, want %v", got.DeletedAt, base.Add(time.Hour))
+		}
+		assertList(t, repos, domainNote.ListFilter{OwnerID: owner}, live)
+		assertList(t, repos, domainNote.ListFilter{OwnerID: owner, Trashed: true}, second, first)
+		assertCounts(t, repos, map[string]int{tag.ID: 1}, tag.ID)
+
+		if err := repos.Notes.Restore(ctx, second.ID); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if got, _ := repos.Notes.GetByID(ctx, second.ID); got.DeletedAt != nil {
+			t.Errorf("DeletedAt after Restore = %v, want nil", got.DeletedAt)
+		}
+		assertTags(t, repos, second.ID, tag.ID)
+		assertList(t, repos, domainNote.ListFilter{OwnerID: owner, Trashed: true}, first)
+	})
+
+	t.Run("purge trashed", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		live := NewNote(owner, "Live", domainNote.EmptyDelta(), base)
+		recent := NewNote(owner, "Recent", domainNote.EmptyDelta(), base)
+		var expired []*domainNote.Note
+		for _, n := range []*domainNote.Note{live, recent} {
+			mustCreateNote(t, repos, n)
+		}
+		for i := range 3 {
+			n := NewNote(owner, "Expired", domainNote.EmptyDelta(), base)
+			mustCreateNote(t, repos, n)
+			if err := repos.Notes.Trash(ctx, n.ID, base.Add(time.Duration(i)*time.Minute)); err != nil {
+				t.Fatalf("Trash: %v", err)
+			}
+			expired = append(expired, n)
+		}
+		if err := repos.Notes.Trash(ctx, recent.ID, base.Add(48*time.Hour)); err != nil {
+			t.Fatalf("Trash: %v", err)
+		}
+
+		cutoff := base.Add(24 * time.Hour)
+		n, err := repos.Notes.PurgeTrashed(ctx, cutoff, 2)
+		if err != nil || n != 2 {
+			t.Fatalf("PurgeTrashed = %d, %v, want 2", n, err)
+		}
+		// The oldest go first
+		assertList(t, repos, domainNote.ListFilter{OwnerID: owner, Trashed: true}, recent, expired[2])
+
+		if n, err = repos.Notes.PurgeTrashed(ctx, cutoff, 2); err != nil || n != 1 {
+			t.Fatalf("PurgeTrashed = %d, %v, want 1", n, err)
+		}
+		if n, err = repos.Notes.PurgeTrashed(ctx, cutoff, 2); err != nil || n != 0 {
+			t.Fatalf("PurgeTrashed = %d, %v, want 0", n, err)
+		}
+		if _, err := repos.Notes.GetByID(ctx, expired[0].ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("GetByID of purged note: err = %v, want ErrNotFound", err)
+		}
+		assertList(t, repos, domainNote.ListFilter{OwnerID: owner}, live)
+		assertList(t, repos, domainNote.ListFilter{OwnerID: owner, Trashed: true}, recent)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repos := newRepos(t)
+		jane := mustCreateUser(t, repos, "jane@example.com")
+		john := mustCreateUser(t, repos, "john@example.com")
+		oldest := NewNote(jane, "Oldest", domainNote.EmptyDelta(), base)
+		middle := NewNote(jane, "Middle", domainNote.EmptyDelta(), base.Add(time.Minute))
+		newest := NewNote(jane, "Newest", domainNote.EmptyDelta(), base.Add(2*time.Minute))
+		other := NewNote(john, "Other", domainNote.EmptyDelta(), base.Add(3*time.Minute))
+		for _, n := range []*domainNote.Note{middle, oldest, other, newest} {
+			mustCreateNote(t, repos, n)
+		}
+
+		tests := []struct {
+			name      string
+			filter    domainNote.ListFilter
+			want      []string
+			wantTotal int
+		}{
+			{"owner", domainNote.ListFilter{OwnerID: jane}, noteIDs(newest, middle, oldest), 3},
+			{"first page", domainNote.ListFilter{OwnerID: jane, Limit: 2}, noteIDs(newest, middle), 3},
+			{"second page", domainNote.ListFilter{OwnerID: jane, Offset: 2, Limit: 2}, noteIDs(oldest), 3},
+			{"past the end", domainNote.ListFilter{OwnerID: jane, Offset: 10, Limit: 2}, nil, 3},
+			{"no notes", domainNote.ListFilter{OwnerID: uuid.NewString()}, nil, 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				notes, total, err := repos.Notes.List(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := noteIDs(notes...); !equalStrings(got, tt.want) {
+					t.Errorf("notes = %v, want %v", got, tt.want)
+				}
+				if total != tt.wantTotal {
+					t.Errorf("total = %d, want %d", total, tt.wantTotal)
+				}
+			})
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		text := func(s string) domainNote.Delta {
+			return domainNote.Delta{Ops: []domainNote.Op{{Insert: s + "\n"}}}
+		}
+		groceries := NewNote(owner, "Groceries", text("Buy milk and eggs"), base)
+		recipe := NewNote(owner, "Pancakes", text("Mix flour, MILK and eggs"), base.Add(time.Minute))
+		meeting := NewNote(owner, "Meeting notes", text("Budget review"), base.Add(2*time.Minute))
+		for _, n := range []*domainNote.Note{groceries, recipe, meeting} {
+			mustCreateNote(t, repos, n)
+		}
+
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"milk", noteIDs(recipe, groceries)},
+			{"Milk eggs", noteIDs(recipe, groceries)},
+			{"milk flour", noteIDs(recipe)},
+			{"groceries", noteIDs(groceries)},
+			{"budget", noteIDs(meeting)},
+			{"bread", nil},
+			{`"quoted" OR -milk*`, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.query, func(t *testing.T) {
+				notes, total, err := repos.Notes.List(ctx, domainNote.ListFilter{OwnerID: owner, Query: tt.query})
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := noteIDs(notes...); !equalStrings(got, tt.want) {
+					t.Errorf("notes = %v, want %v", got, tt.want)
+				}
+				if total != len(tt.want) {
+					t.Errorf("total = %d, want %d", total, len(tt.want))
+				}
+			})
+		}
+
+		// The index follows edits
+		groceries.Edit("Groceries", text("Buy bread"), base.Add(3*time.Minute))
+		if err := repos.Notes.Update(ctx, groceries); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		notes, _, err := repos.Notes.List(ctx, domainNote.ListFilter{OwnerID: owner, Query: "bread"})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if got := noteIDs(notes...); !equalStrings(got, noteIDs(groceries)) {
+			t.Errorf("after edit: notes = %v, want %v", got, noteIDs(groceries))
+		}
+	})
+
+	t.Run("filter by tags", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		work := mustCreateTag(t, repos, owner, "work")
+		urgent := mustCreateTag(t, repos, owner, "urgent")
+		home := mustCreateTag(t, repos, owner, "home")
+
+		both := NewNote(owner, "Both", domainNote.EmptyDelta(), base)
+		both.TagIDs = []string{work.ID, urgent.ID}
+		workOnly := NewNote(owner, "Work", domainNote.EmptyDelta(), base.Add(time.Minute))
+		workOnly.TagIDs = []string{work.ID}
+		homeOnly := NewNote(owner, "Home", domainNote.EmptyDelta(), base.Add(2*time.Minute))
+		homeOnly.TagIDs = []string{home.ID}
+		untagged := NewNote(owner, "Untagged", domainNote.EmptyDelta(), base.Add(3*time.Minute))
+		for _, n := range []*domainNote.Note{both, workOnly, homeOnly, untagged} {
+			mustCreateNote(t, repos, n)
+		}
+
+		tests := []struct {
+			name string
+			tags []string
+			mode domainNote.TagMode
+			want []string
+		}{
+			{"one tag", []string{work.ID}, domainNote.TagModeAll, noteIDs(workOnly, both)},
+			{"all of two", []string{work.ID, urgent.ID}, domainNote.TagModeAll, noteIDs(both)},
+			{"any of two", []string{urgent.ID, home.ID}, domainNote.TagModeAny, noteIDs(homeOnly, both)},
+			{"all of disjoint", []string{work.ID, home.ID}, domainNote.TagModeAll, nil},
+			{"duplicate tag", []string{work.ID, work.ID}, domainNote.TagModeAll, noteIDs(workOnly, both)},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				notes, total, err := repos.Notes.List(ctx, domainNote.ListFilter{OwnerID: owner, TagIDs: tt.tags, TagMode: tt.mode})
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := noteIDs(notes...); !equalStrings(got, tt.want) {
+					t.Errorf("notes = %v, want %v", got, tt.want)
+				}
+				if total != len(tt.want) {
+					t.Errorf("total = %d, want %d", total, len(tt.want))
+				}
+			})
+		}
+	})
+
+	t.Run("per-user state", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		other := mustCreateUser(t, repos, "john@example.com")
+		var notes []*domainNote.Note
+		for i, title := range []string{"a", "b", "c", "d"} {
+			n := NewNote(owner, title, domainNote.EmptyDelta(), base.Add(time.Duration(i)*time.Minute))
+			mustCreateNote(t, repos, n)
+			notes = append(notes, n)
+		}
+		a, b, c, d := notes[0], notes[1], notes[2], notes[3]
+
+		state, err := repos.Notes.GetState(ctx, a.ID, owner)
+		if err != nil || state != (domainNote.State{}) {
+			t.Fatalf("GetState before SetState = %+v, %v, want zero", state, err)
+		}
+		setState := func(note *domainNote.Note, userID string, state domainNote.State) {
+			t.Helper()
+			if err := repos.Notes.SetState(ctx, note.ID, userID, state); err != nil {
+				t.Fatalf("SetState: %v", err)
+			}
+		}
+		setState(a, owner, domainNote.State{Pinned: true, Favourite: true, Position: "V"})
+		setState(b, owner, domainNote.State{Archived: true})
+		setState(c, owner, domainNote.State{Position: "Z"})
+		setState(d, owner, domainNote.State{Position: "V"})
+		setState(d, owner, domainNote.State{Favourite: true, Position: "a"})
+		setState(b, other, domainNote.State{Pinned: true})
+		if err := repos.Notes.SetState(ctx, uuid.NewString(), owner, domainNote.State{}); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("SetState on missing note: err = %v, want ErrNotFound", err)
+		}
+
+		state, err = repos.Notes.GetState(ctx, d.ID, owner)
+		if err != nil || state != (domainNote.State{Favourite: true, Position: "a"}) {
+			t.Errorf("GetState = %+v, %v", state, err)
+		}
+
+		tests := []struct {
+			name   string
+			filter domainNote.ListFilter
+			want   []*domainNote.Note
+		}{
+			{"pinned first", domainNote.ListFilter{OwnerID: owner, ViewerID: owner}, []*domainNote.Note{a, d, c}},
+			{"manual order", domainNote.ListFilter{OwnerID: owner, ViewerID: owner, Order: domainNote.OrderManual}, []*domainNote.Note{a, c, d}},
+			{"with archived", domainNote.ListFilter{OwnerID: owner, ViewerID: owner, Archived: domainNote.ArchivedInclude}, []*domainNote.Note{a, d, c, b}},
+			{"archived only", domainNote.ListFilter{OwnerID: owner, ViewerID: owner, Archived: domainNote.ArchivedOnly}, []*domainNote.Note{b}},
+			{"favourites", domainNote.ListFilter{OwnerID: owner, ViewerID: owner, Favourites: true}, []*domainNote.Note{a, d}},
+			{"other viewer", domainNote.ListFilter{OwnerID: owner, ViewerID: other}, []*domainNote.Note{b, d, c, a}},
+			{"no viewer", domainNote.ListFilter{OwnerID: owner}, []*domainNote.Note{d, c, b, a}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assertList(t, repos, tt.filter, tt.want...)
+			})
+		}
+
+		listed, _, err := repos.Notes.List(ctx, domainNote.ListFilter{OwnerID: owner, ViewerID: owner, Limit: 1})
+		if err != nil || len(listed) != 1 {
+			t.Fatalf("List = %v, %v", listed, err)
+		}
+		if want := (domainNote.State{Pinned: true, Favourite: true, Position: "V"}); listed[0].State != want {
+			t.Errorf("listed state = %+v, want %+v", listed[0].State, want)
+		}
+
+		if err := repos.Notes.Delete(ctx, a.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if state, err := repos.Notes.GetState(ctx, a.ID, owner); err != nil || state != (domainNote.State{}) {
+			t.Errorf("GetState after Delete = %+v, %v, want zero", state, err)
+		}
+	})
+
+	t.Run("set, replace and count tags", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		a := mustCreateTag(t, repos, owner, "a")
+		b := mustCreateTag(t, repos, owner, "b")
+		c := mustCreateTag(t, repos, owner, "c")
+		first := NewNote(owner, "First", domainNote.EmptyDelta(), base)
+		second := NewNote(owner, "Second", domainNote.EmptyDelta(), base)
+		mustCreateNote(t, repos, first)
+		mustCreateNote(t, repos, second)
+
+		if err := repos.Notes.SetTags(ctx, first.ID, []string{a.ID, b.ID}); err != nil {
+			t.Fatalf("SetTags: %v", err)
+		}
+		if err := repos.Notes.SetTags(ctx, second.ID, []string{b.ID, c.ID, b.ID}); err != nil {
+			t.Fatalf("SetTags: %v", err)
+		}
+		if err := repos.Notes.SetTags(ctx, uuid.NewString(), []string{a.ID}); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("SetTags on missing note: err = %v, want ErrNotFound", err)
+		}
+		assertTags(t, repos, first.ID, a.ID, b.ID)
+		assertCounts(t, repos, map[string]int{a.ID: 1, b.ID: 2, c.ID: 1}, a.ID, b.ID, c.ID)
+
+		// Merge a and b into c
+		if err := repos.Notes.ReplaceTags(ctx, []string{a.ID, b.ID}, c.ID); err != nil {
+			t.Fatalf("ReplaceTags: %v", err)
+		}
+		assertTags(t, repos, first.ID, c.ID)
+		assertTags(t, repos, second.ID, c.ID)
+		assertCounts(t, repos, map[string]int{c.ID: 2}, a.ID, b.ID, c.ID)
+
+		// Remove c
+		if err := repos.Notes.ReplaceTags(ctx, []string{c.ID}, ""); err != nil {
+			t.Fatalf("ReplaceTags: %v", err)
+		}
+		assertTags(t, repos, first.ID)
+		assertCounts(t, repos, map[string]int{}, a.ID, b.ID, c.ID)
+	})
+
+	t.Run("storage usage", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		notes := []*domainNote.Note{
+			NewNote(owner, "One", domainNote.EmptyDelta(), base),
+			NewNote(owner, "Two", domainNote.Delta{Ops: []domainNote.Op{{Insert: "some text\n"}}}, base),
+		}
+		var wantBytes int64
+		for _, n := range notes {
+			mustCreateNote(t, repos, n)
+			wantBytes += int64(n.Size())
+		}
+
+		usage, err := repos.Notes.StorageUsage(ctx, owner)
+		if err != nil {
+			t.Fatalf("StorageUsage: %v", err)
+		}
+		if usage.Notes != 2 || usage.NoteBytes != wantBytes {
+			t.Errorf("usage = %+v, want 2 notes and %d bytes", usage, wantBytes)
+		}
+
+		usage, err = repos.Notes.StorageUsage(ctx, uuid.NewString())
+		if err != nil {
+			t.Fatalf("StorageUsage: %v", err)
+		}
+		if usage != (domainUser.StorageUsage{}) {
+			t.Errorf("usage of unknown user = %+v, want zero", usage)
+		}
+	})
+}
+
+// NewNote returns a note ready to be stored, with times rounded to the
+// precision every store keeps
+func NewNote(ownerID, title string, content domainNote.Delta, now time.Time) *domainNote.Note {
+	n := domainNote.NewNote(ownerID, title, content, now.UTC().Truncate(time.Microsecond))
+	n.ID = uuid.NewString()
+	return n
+}
+
+func mustCreateUser(t *testing.T, repos Repos, email string) string {
+	t.Helper()
+	user := NewUser(email, time.Now())
+	mustCreate(t, repos.Users, user)
+	return user.ID
+}
+
+func mustCreateNote(t *testing.T, repos Repos, note *domainNote.Note) {
+	t.Helper()
+	if err := repos.Notes.Create(context.Background(), note); err != nil {
+		t.Fatalf("Create(%s): %v", note.Title, err)
+	}
+}
+
+func assertNote(t *testing.T, got, want *domainNote.Note) {
+	t.Helper()
+	if got.ID != want.ID || got.OwnerID != want.OwnerID || got.Title != want.Title || got.PlainText != want.PlainText {
+		t.Errorf("note = %+v, want %+v", got, want)
+	}
+	if g, w := string(got.Content.JSON()), string(want.Content.JSON()); g != w {
+		t.Errorf("content = %s, want %s", g, w)
+	}
+	if !equalStrings(got.TagIDs, want.TagIDs) {
+		t.Errorf("TagIDs = %v, want %v", got.TagIDs, want.TagIDs)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("times = %v, %v, want %v, %v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+}
+
+func assertList(t *testing.T, repos Repos, filter domainNote.ListFilter, want ...*domainNote.Note) {
+	t.Helper()
+	notes, total, err := repos.Notes.List(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if got, want := noteIDs(notes...), noteIDs(want...); !equalStrings(got, want) || total != len(want) {
+		t.Errorf("notes = %v (total %d), want %v", got, total, want)
+	}
+}
+
+func assertTags(t *testing.T, repos Repos, noteID string, want ...string) {
+	t.Helper()
+	note, err := repos.Notes.GetByID(context.Background(), noteID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !equalStrings(note.TagIDs, sorted(want)) {
+		t.Errorf("TagIDs = %v, want %v", note.TagIDs, sorted(want))
+	}
+}
+
+func assertCounts(t *testing.T, repos Repos, want map[string]int, tagIDs ...string) {
+	t.Helper()
+	counts, err := repos.Notes.CountByTag(context.Background(), tagIDs)
+	if err != nil {
+		t.Fatalf("CountByTag: %v", err)
+	}
+	for _, id := range tagIDs {
+		if counts[id] != want[id] {
+			t.Errorf("count of %s = %d, want %d", id, counts[id], want[id])
+		}
+	}
+}
+
+func noteIDs(notes ...*domainNote.Note) []string {
+	var ids []string
+	for _, n := range notes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func sorted(ids []string) []string {
+	c := slices.Clone(ids)
+	slices.Sort(c)
+	return c
+}
@@ -0,0 +1,301 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+)
+
+// NotebookRepository runs the domainNotebook.Repository contract.
+// newRepos must return empty repositories for every call.
+func NotebookRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+	base := time.Now().UTC().Truncate(time.Microsecond)
+
+	t.Run("create and get", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		parent := mustCreateNotebook(t, repos, owner, "Work", "")
+		want := NewNotebook(owner, "Projects", parent.ID)
+		if err := repos.Notebooks.Create(ctx, want); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := repos.Notebooks.GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertNotebook(t, got, want)
+
+		for _, id := range []string{uuid.NewString(), "not-a-uuid"} {
+			if _, err := repos.Notebooks.GetByID(ctx, id); !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("GetByID(%q): err = %v, want ErrNotFound", id, err)
+			}
+		}
+
+		orphan := NewNotebook(owner, "Orphan", uuid.NewString())
+		if err := repos.Notebooks.Create(ctx, orphan); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Create under missing parent: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		notebook := mustCreateNotebook(t, repos, owner, "Work", "")
+
+		notebook.Rename("Office", base.Add(time.Minute))
+		if err := repos.Notebooks.Update(ctx, notebook); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := repos.Notebooks.GetByID(ctx, notebook.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertNotebook(t, got, notebook)
+
+		if err := repos.Notebooks.Delete(ctx, notebook.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repos.Notebooks.Delete(ctx, notebook.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Delete twice: err = %v, want ErrNotFound", err)
+		}
+		if err := repos.Notebooks.Update(ctx, notebook); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Update deleted: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repos := newRepos(t)
+		jane := mustCreateUser(t, repos, "jane@example.com")
+		john := mustCreateUser(t, repos, "john@example.com")
+		work := mustCreateNotebook(t, repos, jane, "work", "")
+		archive := mustCreateNotebook(t, repos, jane, "Archive", "")
+		projects := mustCreateNotebook(t, repos, jane, "Projects", work.ID)
+		clients := mustCreateNotebook(t, repos, jane, "clients", work.ID)
+		mustCreateNotebook(t, repos, john, "Personal", "")
+
+		owned, err := repos.Notebooks.ListByOwner(ctx, jane)
+		if err != nil {
+			t.Fatalf("ListByOwner: %v", err)
+		}
+		assertIDs(t, "ListByOwner", notebookIDs(owned...), notebookIDs(archive, clients, projects, work))
+
+		children, err := repos.Notebooks.Children(ctx, work.ID)
+		if err != nil {
+			t.Fatalf("Children: %v", err)
+		}
+		assertIDs(t, "Children", notebookIDs(children...), notebookIDs(clients, projects))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		a := mustCreateNotebook(t, repos, owner, "A", "")
+		b := mustCreateNotebook(t, repos, owner, "B", a.ID)
+		c := mustCreateNotebook(t, repos, owner, "C", b.ID)
+		d := mustCreateNotebook(t, repos, owner, "D", "")
+
+		for _, parent := range []*domainNotebook.Notebook{a, b, c} {
+			if err := repos.Notebooks.Move(ctx, a.ID, parent.ID, base); !errors.Is(err, errs.ErrConflict) {
+				t.Errorf("Move A under %s: err = %v, want ErrConflict", parent.Name, err)
+			}
+		}
+		if err := repos.Notebooks.Move(ctx, a.ID, uuid.NewString(), base); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Move under missing parent: err = %v, want ErrNotFound", err)
+		}
+		if err := repos.Notebooks.Move(ctx, uuid.NewString(), d.ID, base); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Move missing notebook: err = %v, want ErrNotFound", err)
+		}
+
+		moved := base.Add(time.Hour)
+		if err := repos.Notebooks.Move(ctx, b.ID, d.ID, moved); err != nil {
+			t.Fatalf("Move B under D: %v", err)
+		}
+		got, _ := repos.Notebooks.GetByID(ctx, b.ID)
+		if got.ParentID != d.ID || !got.UpdatedAt.Equal(moved) {
+			t.Errorf("moved = %+v, want parent %s updated at %v", got, d.ID, moved)
+		}
+
+		// A no longer contains C, so it may move under it
+		if err := repos.Notebooks.Move(ctx, a.ID, c.ID, base); err != nil {
+			t.Errorf("Move A under C: %v", err)
+		}
+		if err := repos.Notebooks.Move(ctx, c.ID, "", base); err != nil {
+			t.Errorf("Move C to the top level: %v", err)
+		}
+		got, _ = repos.Notebooks.GetByID(ctx, c.ID)
+		if got.ParentID != "" {
+			t.Errorf("ParentID = %q, want top level", got.ParentID)
+		}
+	})
+
+	t.Run("collaborators and inherited access", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		john := mustCreateUser(t, repos, "john@example.com")
+		mary := mustCreateUser(t, repos, "mary@example.com")
+		work := mustCreateNotebook(t, repos, owner, "Work", "")
+		projects := mustCreateNotebook(t, repos, owner, "Projects", work.ID)
+		secret := mustCreateNotebook(t, repos, owner, "Secret", "")
+
+		share := func(notebookID, userID string, role domainNotebook.Role, at time.Time) {
+			t.Helper()
+			err := repos.Notebooks.AddCollaborator(ctx, &domainNotebook.Collaborator{
+				NotebookID: notebookID,
+				UserID:     userID,
+				Role:       role,
+				CreatedAt:  at,
+			})
+			if err != nil {
+				t.Fatalf("AddCollaborator: %v", err)
+			}
+		}
+		share(work.ID, john, domainNotebook.RoleViewer, base)
+		share(work.ID, mary, domainNotebook.RoleViewer, base.Add(time.Minute))
+		share(projects.ID, john, domainNotebook.RoleEditor, base)
+		// Sharing again changes the role
+		share(work.ID, mary, domainNotebook.RoleEditor, base.Add(time.Hour))
+
+		tests := []struct {
+			notebook string
+			user     string
+			want     domainNotebook.Role
+		}{
+			{work.ID, john, domainNotebook.RoleViewer},
+			{projects.ID, john, domainNotebook.RoleEditor},
+			{projects.ID, mary, domainNotebook.RoleEditor},
+			{secret.ID, john, ""},
+			{work.ID, owner, ""},
+			{uuid.NewString(), john, ""},
+		}
+		for _, tt := range tests {
+			got, err := repos.Notebooks.Access(ctx, tt.notebook, tt.user)
+			if err != nil {
+				t.Fatalf("Access: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Access(%s, %s) = %q, want %q", tt.notebook, tt.user, got, tt.want)
+			}
+		}
+
+		collaborators, err := repos.Notebooks.Collaborators(ctx, work.ID)
+		if err != nil {
+			t.Fatalf("Collaborators: %v", err)
+		}
+		if len(collaborators) != 2 || collaborators[0].UserID != john || collaborators[1].UserID != mary ||
+			collaborators[1].Role != domainNotebook.RoleEditor {
+			t.Errorf("collaborators = %+v", collaborators)
+		}
+
+		shared, err := repos.Notebooks.SharedWith(ctx, john)
+		if err != nil {
+			t.Fatalf("SharedWith: %v", err)
+		}
+		assertIDs(t, "SharedWith", notebookIDs(shared...), notebookIDs(projects, work))
+
+		if err := repos.Notebooks.RemoveCollaborator(ctx, work.ID, john); err != nil {
+			t.Fatalf("RemoveCollaborator: %v", err)
+		}
+		if err := repos.Notebooks.RemoveCollaborator(ctx, work.ID, john); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("RemoveCollaborator twice: err = %v, want ErrNotFound", err)
+		}
+		if role, _ := repos.Notebooks.Access(ctx, work.ID, john); role != "" {
+			t.Errorf("Access after removal = %q, want none", role)
+		}
+
+		if err := repos.Notebooks.Move(ctx, projects.ID, secret.ID, base); err != nil {
+			t.Fatalf("Move: %v", err)
+		}
+		if role, _ := repos.Notebooks.Access(ctx, projects.ID, mary); role != "" {
+			t.Errorf("Access after moving out of the shared notebook = %q, want none", role)
+		}
+	})
+
+	t.Run("notes in notebooks", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		work := mustCreateNotebook(t, repos, owner, "Work", "")
+		home := mustCreateNotebook(t, repos, owner, "Home", "")
+		report := NewNote(owner, "report", domainNote.EmptyDelta(), base)
+		report.NotebookID = work.ID
+		mustCreateNote(t, repos, report)
+		loose := NewNote(owner, "loose", domainNote.EmptyDelta(), base)
+		mustCreateNote(t, repos, loose)
+
+		got, err := repos.Notes.GetByID(ctx, report.ID)
+		if err != nil || got.NotebookID != work.ID {
+			t.Fatalf("GetByID = %+v, %v, want notebook %s", got, err, work.ID)
+		}
+
+		if err := repos.Notes.SetNotebook(ctx, loose.ID, home.ID); err != nil {
+			t.Fatalf("SetNotebook: %v", err)
+		}
+		if err := repos.Notes.SetNotebook(ctx, report.ID, ""); err != nil {
+			t.Fatalf("SetNotebook out: %v", err)
+		}
+		if err := repos.Notes.SetNotebook(ctx, uuid.NewString(), home.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("SetNotebook missing note: err = %v, want ErrNotFound", err)
+		}
+
+		notes, total, err := repos.Notes.List(ctx, domainNote.ListFilter{NotebookID: home.ID})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if total != 1 || len(notes) != 1 || notes[0].ID != loose.ID || notes[0].NotebookID != home.ID {
+			t.Errorf("List = %+v (total %d), want only %s", notes, total, loose.ID)
+		}
+		got, _ = repos.Notes.GetByID(ctx, report.ID)
+		if got.NotebookID != "" {
+			t.Errorf("NotebookID = %q, want none", got.NotebookID)
+		}
+	})
+}
+
+// NewNotebook returns a notebook with a fresh ID
+func NewNotebook(ownerID, name, parentID string) *domainNotebook.Notebook {
+	notebook := domainNotebook.NewNotebook(ownerID, name, parentID, time.Now().UTC().Truncate(time.Microsecond))
+	notebook.ID = uuid.NewString()
+	return notebook
+}
+
+func mustCreateNotebook(t *testing.T, repos Repos, ownerID, name, parentID string) *domainNotebook.Notebook {
+	t.Helper()
+	notebook := NewNotebook(ownerID, name, parentID)
+	if err := repos.Notebooks.Create(context.Background(), notebook); err != nil {
+		t.Fatalf("Create notebook %s: %v", name, err)
+	}
+	return notebook
+}
+
+func assertNotebook(t *testing.T, got, want *domainNotebook.Notebook) {
+	t.Helper()
+	if got.ID != want.ID || got.OwnerID != want.OwnerID || got.ParentID != want.ParentID || got.Name != want.Name {
+		t.Errorf("notebook = %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("times = %v, %v, want %v, %v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+}
+
+func assertIDs(t *testing.T, name string, got, want []string) {
+	t.Helper()
+	if !equalStrings(got, want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func notebookIDs(notebooks ...*domainNotebook.Notebook) []string {
+	var ids []string
+	for _, notebook := range notebooks {
+		ids = append(ids, notebook.ID)
+	}
+	return ids
+}
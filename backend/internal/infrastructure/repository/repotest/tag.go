@@ -0,0 +1,171 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/errs"
+	domainTag "notes-app/backend/internal/domain/tag"
+)
+
+// TagRepository runs the domainTag.Repository contract. newRepos must
+// return empty repositories for every call.
+func TagRepository(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		want := NewTag(owner, "Work", "#ff0000")
+		if err := repos.Tags.Create(ctx, want); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := repos.Tags.GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertTag(t, got, want)
+
+		for _, id := range []string{uuid.NewString(), "not-a-uuid"} {
+			if _, err := repos.Tags.GetByID(ctx, id); !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("GetByID(%q): err = %v, want ErrNotFound", id, err)
+			}
+		}
+	})
+
+	t.Run("names are unique per owner ignoring case", func(t *testing.T) {
+		repos := newRepos(t)
+		jane := mustCreateUser(t, repos, "jane@example.com")
+		john := mustCreateUser(t, repos, "john@example.com")
+		mustCreateTag(t, repos, jane, "Work")
+
+		if err := repos.Tags.Create(ctx, NewTag(jane, "work", "")); !errors.Is(err, errs.ErrConflict) {
+			t.Errorf("same name: err = %v, want ErrConflict", err)
+		}
+		if err := repos.Tags.Create(ctx, NewTag(john, "work", "")); err != nil {
+			t.Errorf("other owner: %v", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		tag := mustCreateTag(t, repos, owner, "Work")
+		mustCreateTag(t, repos, owner, "Home")
+
+		tag.Name = "Office"
+		tag.Color = "#00ff00"
+		if err := repos.Tags.Update(ctx, tag); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := repos.Tags.GetByID(ctx, tag.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertTag(t, got, tag)
+
+		// Changing only the case of the own name is allowed
+		tag.Name = "OFFICE"
+		if err := repos.Tags.Update(ctx, tag); err != nil {
+			t.Errorf("Update case: %v", err)
+		}
+
+		tag.Name = "home"
+		if err := repos.Tags.Update(ctx, tag); !errors.Is(err, errs.ErrConflict) {
+			t.Errorf("Update to taken name: err = %v, want ErrConflict", err)
+		}
+		if err := repos.Tags.Update(ctx, NewTag(owner, "Missing", "")); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Update missing: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos, "jane@example.com")
+		tag := mustCreateTag(t, repos, owner, "Work")
+
+		if err := repos.Tags.Delete(ctx, tag.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repos.Tags.GetByID(ctx, tag.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("GetByID after Delete: err = %v, want ErrNotFound", err)
+		}
+		if err := repos.Tags.Delete(ctx, tag.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Delete twice: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list by prefix", func(t *testing.T) {
+		repos := newRepos(t)
+		jane := mustCreateUser(t, repos, "jane@example.com")
+		john := mustCreateUser(t, repos, "john@example.com")
+		work := mustCreateTag(t, repos, jane, "Work")
+		workout := mustCreateTag(t, repos, jane, "workout")
+		home := mustCreateTag(t, repos, jane, "home")
+		percent := mustCreateTag(t, repos, jane, "100%_done")
+		mustCreateTag(t, repos, john, "work")
+
+		tests := []struct {
+			prefix string
+			want   []string
+		}{
+			{"", tagIDs(percent, home, work, workout)},
+			{"wo", tagIDs(work, workout)},
+			{"WORK", tagIDs(work, workout)},
+			{"h", tagIDs(home)},
+			{"100%", tagIDs(percent)},
+			{"1_", nil},
+			{"x", nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.prefix, func(t *testing.T) {
+				tags, err := repos.Tags.List(ctx, jane, tt.prefix)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := tagIDs(tags...); !equalStrings(got, tt.want) {
+					t.Errorf("tags = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+// NewTag returns a tag ready to be stored
+func NewTag(ownerID, name, color string) *domainTag.Tag {
+	tag := domainTag.NewTag(ownerID, name, color, time.Now().UTC().Truncate(time.Microsecond))
+	tag.ID = uuid.NewString()
+	return tag
+}
+
+func mustCreateTag(t *testing.T, repos Repos, ownerID, name string) *domainTag.Tag {
+	t.Helper()
+	tag := NewTag(ownerID, name, "")
+	if err := repos.Tags.Create(context.Background(), tag); err != nil {
+		t.Fatalf("Create tag %s: %v", name, err)
+	}
+	return tag
+}
+
+func assertTag(t *testing.T, got, want *domainTag.Tag) {
+	t.Helper()
+	if got.ID != want.ID || got.OwnerID != want.OwnerID || got.Name != want.Name || got.Color != want.Color {
+		t.Errorf("tag = %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+}
+
+func tagIDs(tags ...*domainTag.Tag) []string {
+	var ids []string
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	return ids
+}
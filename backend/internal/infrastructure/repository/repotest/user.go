@@ -0,0 +1,314 @@
+// Package repotest holds contract tests that every repository
+// implementation must pass, whatever its storage.
+//
+// Names and emails are compared ignoring case for ASCII letters only.
+// SQLite's NOCASE collation and LIKE fold no other letters, while
+// PostgreSQL's lower() and ILIKE fold them all: "Éte" and "éte" are the
+// same tag name on PostgreSQL but two on SQLite, and sort apart. The
+// contract leaves the case of other letters unspecified.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/errs"
+	domainUser "notes-app/backend/internal/domain/user"
+)
+
+// UserRepository runs the domainUser.Repository contract. newRepo must
+// return an empty repository for every call.
+func UserRepository(t *testing.T, newRepo func(t *testing.T) domainUser.Repository) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		repo := newRepo(t)
+		want := NewUser("jane@example.com", time.Now())
+		mustCreate(t, repo, want)
+
+		byID, err := repo.GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertUser(t, byID, want)
+
+		byEmail, err := repo.GetByEmail(ctx, want.Email)
+		if err != nil {
+			t.Fatalf("GetByEmail: %v", err)
+		}
+		assertUser(t, byEmail, want)
+	})
+
+	t.Run("get missing user", func(t *testing.T) {
+		repo := newRepo(t)
+		for name, get := range map[string]func() (*domainUser.User, error){
+			"by id":           func() (*domainUser.User, error) { return repo.GetByID(ctx, uuid.NewString()) },
+			"by malformed id": func() (*domainUser.User, error) { return repo.GetByID(ctx, "not-a-uuid") },
+			"by email":        func() (*domainUser.User, error) { return repo.GetByEmail(ctx, "nobody@example.com") },
+		} {
+			user, err := get()
+			if !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("%s: err = %v, want ErrNotFound", name, err)
+			}
+			if user != nil {
+				t.Errorf("%s: user = %v, want nil", name, user)
+			}
+		}
+	})
+
+	t.Run("duplicate email conflicts", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, NewUser("jane@example.com", time.Now()))
+
+		err := repo.Create(ctx, NewUser("jane@example.com", time.Now()))
+		if !errors.Is(err, errs.ErrConflict) {
+			t.Fatalf("err = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewUser("jane@example.com", time.Now())
+		mustCreate(t, repo, user)
+
+		now := time.Now()
+		user.Email = "jane.doe@example.com"
+		user.Role = domainUser.RoleAdmin
+		user.Disable(now)
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		assertUser(t, got, user)
+
+		if _, err := repo.GetByEmail(ctx, "jane@example.com"); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("old email: err = %v, want ErrNotFound", err)
+		}
+
+		user.Enable()
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, _ = repo.GetByID(ctx, user.ID)
+		if got.Disabled() {
+			t.Error("user still disabled after Enable")
+		}
+	})
+
+	t.Run("update missing user", func(t *testing.T) {
+		repo := newRepo(t)
+		err := repo.Update(ctx, NewUser("jane@example.com", time.Now()))
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("update to taken email conflicts", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, NewUser("jane@example.com", time.Now()))
+		john := NewUser("john@example.com", time.Now())
+		mustCreate(t, repo, john)
+
+		john.Email = "jane@example.com"
+		if err := repo.Update(ctx, john); !errors.Is(err, errs.ErrConflict) {
+			t.Fatalf("err = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewUser("jane@example.com", time.Now())
+		mustCreate(t, repo, user)
+
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("GetByID after Delete: err = %v, want ErrNotFound", err)
+		}
+		if err := repo.Delete(ctx, user.ID); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("second Delete: err = %v, want ErrNotFound", err)
+		}
+		// The email is free again
+		mustCreate(t, repo, NewUser("jane@example.com", time.Now()))
+	})
+
+	t.Run("returned users are copies", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewUser("jane@example.com", time.Now())
+		mustCreate(t, repo, user)
+		user.Email = "changed@example.com"
+
+		got, _ := repo.GetByID(ctx, user.ID)
+		got.Role = domainUser.RoleAdmin
+
+		again, _ := repo.GetByID(ctx, user.ID)
+		if again.Email != "jane@example.com" || again.Role != domainUser.RoleUser {
+			t.Fatalf("stored user changed through a pointer: %+v", again)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repo := newRepo(t)
+		base := time.Now().Add(-time.Hour)
+		var users []*domainUser.User
+		for i := 0; i < 5; i++ {
+			user := NewUser(fmt.Sprintf("user%d@example.com", i), base.Add(time.Duration(i)*time.Minute))
+			users = append(users, user)
+		}
+		users[1].Role = domainUser.RoleAdmin
+		users[2].Email = "under_score@example.com"
+		users[3].Disable(base)
+		for _, user := range users {
+			mustCreate(t, repo, user)
+		}
+
+		tests := []struct {
+			name      string
+			filter    domainUser.ListFilter
+			wantIDs   []string
+			wantTotal int
+		}{
+			{
+				name:      "all newest first",
+				filter:    domainUser.ListFilter{},
+				wantIDs:   ids(users[4], users[3], users[2], users[1], users[0]),
+				wantTotal: 5,
+			},
+			{
+				name:      "first page",
+				filter:    domainUser.ListFilter{Limit: 2},
+				wantIDs:   ids(users[4], users[3]),
+				wantTotal: 5,
+			},
+			{
+				name:      "last page",
+				filter:    domainUser.ListFilter{Offset: 4, Limit: 2},
+				wantIDs:   ids(users[0]),
+				wantTotal: 5,
+			},
+			{
+				name:      "past the end",
+				filter:    domainUser.ListFilter{Offset: 10, Limit: 2},
+				wantIDs:   nil,
+				wantTotal: 5,
+			},
+			{
+				name:      "query is case-insensitive",
+				filter:    domainUser.ListFilter{Query: "USER1"},
+				wantIDs:   ids(users[1]),
+				wantTotal: 1,
+			},
+			{
+				name:      "query wildcards are literal",
+				filter:    domainUser.ListFilter{Query: "_"},
+				wantIDs:   ids(users[2]),
+				wantTotal: 1,
+			},
+			{
+				name:      "role",
+				filter:    domainUser.ListFilter{Role: domainUser.RoleAdmin},
+				wantIDs:   ids(users[1]),
+				wantTotal: 1,
+			},
+			{
+				name:      "disabled",
+				filter:    domainUser.ListFilter{Status: domainUser.StatusDisabled},
+				wantIDs:   ids(users[3]),
+				wantTotal: 1,
+			},
+			{
+				name:      "active",
+				filter:    domainUser.ListFilter{Status: domainUser.StatusActive, Limit: 2},
+				wantIDs:   ids(users[4], users[2]),
+				wantTotal: 4,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, total, err := repo.List(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if total != tt.wantTotal {
+					t.Errorf("total = %d, want %d", total, tt.wantTotal)
+				}
+				if gotIDs := ids(got...); !equalStrings(gotIDs, tt.wantIDs) {
+					t.Errorf("ids = %v, want %v", gotIDs, tt.wantIDs)
+				}
+			})
+		}
+	})
+}
+
+// NewUser returns a user ready to be stored. Times are truncated to
+// microseconds, the precision every backend can store.
+func NewUser(email string, createdAt time.Time) *domainUser.User {
+	return &domainUser.User{
+		ID:        uuid.NewString(),
+		Email:     email,
+		Password:  "$2a$10$hash",
+		Role:      domainUser.RoleUser,
+		CreatedAt: createdAt.UTC().Truncate(time.Microsecond),
+	}
+}
+
+func mustCreate(t *testing.T, repo domainUser.Repository, user *domainUser.User) {
+	t.Helper()
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create(%s): %v", user.Email, err)
+	}
+}
+
+func assertUser(t *testing.T, got, want *domainUser.User) {
+	t.Helper()
+	if got.ID != want.ID || got.Email != want.Email || got.Password != want.Password || got.Role != want.Role {
+		t.Errorf("user = %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+	if !equalTimes(got.DisabledAt, want.DisabledAt) {
+		t.Errorf("DisabledAt = %v, want %v", got.DisabledAt, want.DisabledAt)
+	}
+	if !equalTimes(got.SessionsRevokedAt, want.SessionsRevokedAt) {
+		t.Errorf("SessionsRevokedAt = %v, want %v", got.SessionsRevokedAt, want.SessionsRevokedAt)
+	}
+}
+
+// equalTimes compares optional times at microsecond precision
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
+
+func ids(users ...*domainUser.User) []string {
+	var ids []string
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
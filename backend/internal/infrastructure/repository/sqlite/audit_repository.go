@@ -0,0 +1,158 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// auditRepository implements audit.Repository for SQLite
+type auditRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewAuditRepository creates a new SQLite audit repository
+func NewAuditRepository(db *sql.DB, logger *slog.Logger) audit.Repository {
+	return &auditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Append stores a new audit event
+func (r *auditRepository) Append(ctx context.Context, event *audit.Event) (err error) {
+	query := `
+		INSERT INTO audit_events (id, action, actor_id, target_type, target_id, ip, user_agent, request_id, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	ctx, span := startSpan(ctx, "audit_events.Append", "audit_events", query)
+	defer func() { tracing.End(span, err) }()
+
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return err
+		}
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		event.ID,
+		event.Action,
+		sql.NullString{String: event.ActorID, Valid: event.ActorID != ""},
+		event.TargetType,
+		event.TargetID,
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		string(metadata),
+		formatTime(event.CreatedAt),
+	)
+
+	return translateError(err, "audit_events.Append")
+}
+
+// List returns one page of the events matching filter, newest first
+func (r *auditRepository) List(ctx context.Context, filter audit.Filter) (_ []*audit.Event, total int, err error) {
+	var where []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		where = append(where, condition)
+	}
+	if filter.ActorID != "" {
+		add("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = ?", filter.TargetID)
+	}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID, filter.UserID)
+		where = append(where, fmt.Sprintf(
+			"(actor_id = ? OR (target_type = '%s' AND target_id = ?))", audit.TargetUser))
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= ?", formatTime(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < ?", formatTime(filter.Until))
+	}
+	conditions := ""
+	if len(where) > 0 {
+		conditions = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := `
+		SELECT id, action, actor_id, target_type, target_id, ip, user_agent, request_id, metadata, created_at, COUNT(*) OVER ()
+		FROM audit_events
+		` + conditions + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+	args = append(args, limitArg(filter.Limit), filter.Offset)
+
+	ctx, span := startSpan(ctx, "audit_events.List", "audit_events", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []*audit.Event
+	for rows.Next() {
+		event := &audit.Event{}
+		var actorID sql.NullString
+		var metadata, createdAt string
+		err = rows.Scan(
+			&event.ID,
+			&event.Action,
+			&actorID,
+			&event.TargetType,
+			&event.TargetID,
+			&event.IP,
+			&event.UserAgent,
+			&event.RequestID,
+			&metadata,
+			&createdAt,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		event.ActorID = actorID.String
+		if err = json.Unmarshal([]byte(metadata), &event.Metadata); err != nil {
+			return nil, 0, err
+		}
+		if event.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// A page past the end has no rows to carry the window count
+	if len(events) == 0 && filter.Offset > 0 {
+		countQuery := `SELECT COUNT(*) FROM audit_events ` + conditions
+		if err = conn(ctx, r.db).QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// translateError maps driver errors to domain errors, annotated with op:
+//   - no rows becomes errs.ErrNotFound
+//   - unique and primary key violations become errs.ErrConflict
+//   - foreign key violations become errs.ErrNotFound, since the
+//     referenced row is missing
+//
+// Other errors, including busy errors the transaction manager retries,
+// are returned unchanged.
+func translateError(err error, op string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.Wrap(errs.ErrNotFound, op)
+	}
+
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return errs.Wrap(errs.ErrConflict, op)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return errs.Wrap(errs.ErrNotFound, op)
+	}
+	return err
+}
+
+// expectRow reports errs.ErrNotFound when a statement affected no row
+func expectRow(result sql.Result, op string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errs.Wrap(errs.ErrNotFound, op)
+	}
+	return nil
+}
+
+// timeLayout stores times as fixed-width UTC text, so comparing and
+// sorting the strings orders the times
+const timeLayout = "2006-01-02T15:04:05.000000Z"
+
+func nowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(timeLayout, s)
+}
+
+// nullTime binds an optional time
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTime(*t), Valid: true}
+}
+
+// parseNullTime reads an optional time
+func parseNullTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := parseTime(s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
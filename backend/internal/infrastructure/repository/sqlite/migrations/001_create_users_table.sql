@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'support', 'admin')),
+    created_at TEXT NOT NULL,
+    disabled_at TEXT,
+    sessions_revoked_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at DESC);
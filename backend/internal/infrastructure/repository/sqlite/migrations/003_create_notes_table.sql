@@ -0,0 +1,44 @@
+-- Notes. content holds the Quill delta; plain_text is its text, kept for
+-- search and previews. size_bytes is the storage the note counts for.
+-- pk gives the full-text index a stable rowid, which VACUUM would
+-- otherwise be free to renumber.
+CREATE TABLE IF NOT EXISTS notes (
+    pk INTEGER PRIMARY KEY,
+    id TEXT NOT NULL UNIQUE,
+    owner_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    plain_text TEXT NOT NULL DEFAULT '',
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notes_owner_updated ON notes(owner_id, updated_at DESC);
+
+-- Full-text index over the title and text, kept in sync by triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+    title,
+    plain_text,
+    content = 'notes',
+    content_rowid = 'pk'
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_notes_fts_insert
+AFTER INSERT ON notes
+BEGIN
+    INSERT INTO notes_fts (rowid, title, plain_text) VALUES (new.pk, new.title, new.plain_text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_notes_fts_delete
+AFTER DELETE ON notes
+BEGIN
+    INSERT INTO notes_fts (notes_fts, rowid, title, plain_text) VALUES ('delete', old.pk, old.title, old.plain_text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_notes_fts_update
+AFTER UPDATE OF title, plain_text ON notes
+BEGIN
+    INSERT INTO notes_fts (notes_fts, rowid, title, plain_text) VALUES ('delete', old.pk, old.title, old.plain_text);
+    INSERT INTO notes_fts (rowid, title, plain_text) VALUES (new.pk, new.title, new.plain_text);
+END;
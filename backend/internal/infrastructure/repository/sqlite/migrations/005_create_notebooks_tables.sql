@@ -0,0 +1,28 @@
+-- Notebooks nest through parent_id. The application keeps the tree free
+-- of cycles; deleting a notebook is only allowed once it is empty.
+CREATE TABLE IF NOT EXISTS notebooks (
+    id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id TEXT REFERENCES notebooks(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notebooks_owner ON notebooks(owner_id);
+CREATE INDEX IF NOT EXISTS idx_notebooks_parent ON notebooks(parent_id);
+
+-- Collaborators can access a notebook and everything nested in it
+CREATE TABLE IF NOT EXISTS notebook_collaborators (
+    notebook_id TEXT NOT NULL REFERENCES notebooks(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')),
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (notebook_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notebook_collaborators_user ON notebook_collaborators(user_id);
+
+ALTER TABLE notes ADD COLUMN notebook_id TEXT REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notes_notebook_updated ON notes(notebook_id, updated_at DESC);
@@ -0,0 +1,7 @@
+-- Deleted notes go to the trash first: deleted_at is set when a note is
+-- trashed, and the purge job removes it for good once the retention period
+-- is over. Tags, and anything else stored with a note, cascade.
+ALTER TABLE notes ADD COLUMN deleted_at TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_notes_owner_deleted ON notes(owner_id, deleted_at DESC)
+    WHERE deleted_at IS NOT NULL;
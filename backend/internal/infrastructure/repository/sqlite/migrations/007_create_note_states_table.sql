@@ -0,0 +1,15 @@
+-- How each user files the notes they can open: pins, archive and
+-- favourites are personal, so a collaborator's pin does not affect the
+-- owner. position is a fractional index compared byte by byte; it is NULL
+-- until the user moves the note by hand.
+CREATE TABLE IF NOT EXISTS note_states (
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pinned INTEGER NOT NULL DEFAULT 0,
+    archived INTEGER NOT NULL DEFAULT 0,
+    favourite INTEGER NOT NULL DEFAULT 0,
+    position TEXT,
+    PRIMARY KEY (note_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_states_user ON note_states(user_id);
@@ -0,0 +1,579 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	domainNote "notes-app/backend/internal/domain/note"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// noteRepository implements domainNote.Repository for SQLite. Search
+// uses the FTS5 index notes_fts.
+type noteRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewNoteRepository creates a new SQLite note repository
+func NewNoteRepository(db *sql.DB, logger *slog.Logger) domainNote.Repository {
+	return &noteRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// noteColumns lists the columns read by scanNote, in order
+const noteColumns = `id, owner_id, notebook_id, title, content, plain_text, created_at, updated_at, deleted_at`
+
+// stateColumns lists the columns of the viewer's note state read by List,
+// from note_states joined as s
+const stateColumns = `COALESCE(s.pinned, 0), COALESCE(s.archived, 0), COALESCE(s.favourite, 0), COALESCE(s.position, '')`
+
+// scanNote reads a row selected with noteColumns, followed by any extra
+// columns into extra
+func scanNote(row scanner, extra ...any) (*domainNote.Note, error) {
+	note := &domainNote.Note{}
+	var content, createdAt, updatedAt string
+	var notebookID, deletedAt sql.NullString
+	dest := []any{
+		&note.ID,
+		&note.OwnerID,
+		&notebookID,
+		&note.Title,
+		&content,
+		&note.PlainText,
+		&createdAt,
+		&updatedAt,
+		&deletedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	note.NotebookID = notebookID.String
+	var err error
+	if err = json.Unmarshal([]byte(content), &note.Content); err != nil {
+		return nil, err
+	}
+	if note.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if note.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	if note.DeletedAt, err = parseNullTime(deletedAt); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Create stores a new note along with its tags. Call it within a
+// transaction so the note is never stored without its tags.
+func (r *noteRepository) Create(ctx context.Context, note *domainNote.Note) (err error) {
+	query := `
+		INSERT INTO notes (id, owner_id, notebook_id, title, content, plain_text, size_bytes, created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	ctx, span := startSpan(ctx, "notes.Create", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		note.ID,
+		note.OwnerID,
+		sql.NullString{String: note.NotebookID, Valid: note.NotebookID != ""},
+		note.Title,
+		string(note.Content.JSON()),
+		note.PlainText,
+		note.Size(),
+		formatTime(note.CreatedAt),
+		formatTime(note.UpdatedAt),
+		nullTime(note.DeletedAt),
+	)
+	if err != nil {
+		return translateError(err, "notes.Create")
+	}
+
+	return r.addTags(ctx, note.ID, note.TagIDs, "notes.Create")
+}
+
+// GetByID retrieves a note with its tags. A missing note is reported as
+// errs.ErrNotFound.
+func (r *noteRepository) GetByID(ctx context.Context, id string) (_ *domainNote.Note, err error) {
+	query := `
+		SELECT ` + noteColumns + `
+		FROM notes
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.GetByID", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	note, err := scanNote(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, translateError(err, "notes.GetByID")
+	}
+
+	if err = r.loadTags(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Update modifies the title and content of a note
+func (r *noteRepository) Update(ctx context.Context, note *domainNote.Note) (err error) {
+	query := `
+		UPDATE notes
+		SET title = ?, content = ?, plain_text = ?, size_bytes = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.Update", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		note.Title,
+		string(note.Content.JSON()),
+		note.PlainText,
+		note.Size(),
+		formatTime(note.UpdatedAt),
+		note.ID,
+	)
+	if err != nil {
+		return translateError(err, "notes.Update")
+	}
+
+	return expectRow(result, "notes.Update")
+}
+
+// Delete removes a note for good; its tags go with it
+func (r *noteRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM notes
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.Delete", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "notes.Delete")
+	}
+
+	return expectRow(result, "notes.Delete")
+}
+
+// Trash moves a note to the trash
+func (r *noteRepository) Trash(ctx context.Context, id string, at time.Time) (err error) {
+	query := `
+		UPDATE notes
+		SET deleted_at = ?
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.Trash", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, formatTime(at), id)
+	if err != nil {
+		return translateError(err, "notes.Trash")
+	}
+
+	return expectRow(result, "notes.Trash")
+}
+
+// Restore takes a note out of the trash
+func (r *noteRepository) Restore(ctx context.Context, id string) (err error) {
+	query := `
+		UPDATE notes
+		SET deleted_at = NULL
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.Restore", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "notes.Restore")
+	}
+
+	return expectRow(result, "notes.Restore")
+}
+
+// PurgeTrashed deletes for good up to limit notes trashed before the
+// given time
+func (r *noteRepository) PurgeTrashed(ctx context.Context, before time.Time, limit int) (_ int, err error) {
+	query := `
+		DELETE FROM notes
+		WHERE pk IN (
+			SELECT pk FROM notes
+			WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			ORDER BY deleted_at
+			LIMIT ?
+		)
+	`
+
+	ctx, span := startSpan(ctx, "notes.PurgeTrashed", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, formatTime(before), limitArg(limit))
+	if err != nil {
+		return 0, translateError(err, "notes.PurgeTrashed")
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// List returns one page of the notes matching filter, the viewer's pinned
+// notes first, or most recently trashed first for the trash
+func (r *noteRepository) List(ctx context.Context, filter domainNote.ListFilter) (_ []*domainNote.Note, total int, err error) {
+	where := []string{"deleted_at IS NULL"}
+	order := "updated_at DESC, id"
+	if filter.Order == domainNote.OrderManual {
+		order = "s.position IS NULL, s.position, " + order
+	}
+	order = "COALESCE(s.pinned, 0) DESC, " + order
+	if filter.Trashed {
+		where = []string{"deleted_at IS NOT NULL"}
+		order = "deleted_at DESC, id"
+	}
+	switch filter.Archived {
+	case domainNote.ArchivedInclude:
+	case domainNote.ArchivedOnly:
+		where = append(where, "COALESCE(s.archived, 0)")
+	default:
+		where = append(where, "NOT COALESCE(s.archived, 0)")
+	}
+	if filter.Favourites {
+		where = append(where, "COALESCE(s.favourite, 0)")
+	}
+
+	// Without a viewer the join matches nothing
+	args := []any{sql.NullString{String: filter.ViewerID, Valid: filter.ViewerID != ""}}
+	from := `notes LEFT JOIN note_states s ON s.note_id = notes.id AND s.user_id = ?`
+	if filter.OwnerID != "" {
+		args = append(args, filter.OwnerID)
+		where = append(where, "owner_id = ?")
+	}
+	if filter.NotebookID != "" {
+		args = append(args, filter.NotebookID)
+		where = append(where, "notebook_id = ?")
+	}
+	if terms := domainNote.SearchTerms(filter.Query); len(terms) > 0 {
+		args = append(args, matchQuery(terms))
+		where = append(where, "pk IN (SELECT rowid FROM notes_fts WHERE notes_fts MATCH ?)")
+	}
+	if tagIDs := uniqueIDs(filter.TagIDs); len(tagIDs) > 0 {
+		args = append(args, anySlice(tagIDs)...)
+		if filter.TagMode == domainNote.TagModeAny {
+			where = append(where, "EXISTS (SELECT 1 FROM note_tags nt WHERE nt.note_id = notes.id AND nt.tag_id IN ("+placeholders(len(tagIDs))+"))")
+		} else {
+			args = append(args, len(tagIDs))
+			where = append(where, "(SELECT COUNT(*) FROM note_tags nt WHERE nt.note_id = notes.id AND nt.tag_id IN ("+placeholders(len(tagIDs))+")) = ?")
+		}
+	}
+	conditions := "WHERE " + strings.Join(where, " AND ")
+
+	query := `
+		SELECT ` + noteColumns + `, ` + stateColumns + `, COUNT(*) OVER ()
+		FROM ` + from + `
+		` + conditions + `
+		ORDER BY ` + order + `
+		LIMIT ? OFFSET ?`
+	args = append(args, limitArg(filter.Limit), filter.Offset)
+
+	ctx, span := startSpan(ctx, "notes.List", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, translateError(err, "notes.List")
+	}
+	defer rows.Close()
+
+	var notes []*domainNote.Note
+	for rows.Next() {
+		var state domainNote.State
+		note, err := scanNote(rows, &state.Pinned, &state.Archived, &state.Favourite, &state.Position, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		note.State = state
+		notes = append(notes, note)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+
+	// A page past the end has no rows to carry the window count
+	if len(notes) == 0 && filter.Offset > 0 {
+		countQuery := `SELECT COUNT(*) FROM ` + from + ` ` + conditions
+		if err = conn(ctx, r.db).QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err = r.loadTags(ctx, notes...); err != nil {
+		return nil, 0, err
+	}
+	return notes, total, nil
+}
+
+// SetNotebook moves a note into a notebook, or out of any notebook
+func (r *noteRepository) SetNotebook(ctx context.Context, noteID, notebookID string) (err error) {
+	query := `
+		UPDATE notes
+		SET notebook_id = ?
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.SetNotebook", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		sql.NullString{String: notebookID, Valid: notebookID != ""},
+		noteID,
+	)
+	if err != nil {
+		return translateError(err, "notes.SetNotebook")
+	}
+
+	return expectRow(result, "notes.SetNotebook")
+}
+
+// GetState returns how a user files a note
+func (r *noteRepository) GetState(ctx context.Context, noteID, userID string) (_ domainNote.State, err error) {
+	query := `
+		SELECT pinned, archived, favourite, COALESCE(position, '')
+		FROM note_states
+		WHERE note_id = ? AND user_id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.GetState", "note_states", query)
+	defer func() { tracing.End(span, err) }()
+
+	var state domainNote.State
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, noteID, userID).
+		Scan(&state.Pinned, &state.Archived, &state.Favourite, &state.Position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domainNote.State{}, nil
+	}
+	return state, translateError(err, "notes.GetState")
+}
+
+// SetState stores how a user files a note
+func (r *noteRepository) SetState(ctx context.Context, noteID, userID string, state domainNote.State) (err error) {
+	query := `
+		INSERT INTO note_states (note_id, user_id, pinned, archived, favourite, position)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (note_id, user_id) DO UPDATE
+		SET pinned = excluded.pinned, archived = excluded.archived,
+			favourite = excluded.favourite, position = excluded.position
+	`
+
+	ctx, span := startSpan(ctx, "notes.SetState", "note_states", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		noteID,
+		userID,
+		state.Pinned,
+		state.Archived,
+		state.Favourite,
+		sql.NullString{String: state.Position, Valid: state.Position != ""},
+	)
+	return translateError(err, "notes.SetState")
+}
+
+// SetTags replaces the tags applied to a note
+func (r *noteRepository) SetTags(ctx context.Context, noteID string, tagIDs []string) (err error) {
+	query := `
+		DELETE FROM note_tags
+		WHERE note_id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.SetTags", "note_tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	var id string
+	err = conn(ctx, r.db).QueryRowContext(ctx, `SELECT id FROM notes WHERE id = ?`, noteID).Scan(&id)
+	if err != nil {
+		return translateError(err, "notes.SetTags")
+	}
+
+	if _, err = conn(ctx, r.db).ExecContext(ctx, query, noteID); err != nil {
+		return translateError(err, "notes.SetTags")
+	}
+	return r.addTags(ctx, noteID, tagIDs, "notes.SetTags")
+}
+
+// ReplaceTags moves every note tagged with one of from to the tag to
+func (r *noteRepository) ReplaceTags(ctx context.Context, from []string, to string) (err error) {
+	from = slices.DeleteFunc(uniqueIDs(from), func(id string) bool { return id == to })
+	if len(from) == 0 {
+		return nil
+	}
+
+	query := `
+		DELETE FROM note_tags
+		WHERE tag_id IN (` + placeholders(len(from)) + `)
+	`
+
+	ctx, span := startSpan(ctx, "notes.ReplaceTags", "note_tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	if to != "" {
+		_, err = conn(ctx, r.db).ExecContext(ctx, `
+			INSERT OR IGNORE INTO note_tags (note_id, tag_id)
+			SELECT DISTINCT note_id, ? FROM note_tags WHERE tag_id IN (`+placeholders(len(from))+`)
+		`, append([]any{to}, anySlice(from)...)...)
+		if err != nil {
+			return translateError(err, "notes.ReplaceTags")
+		}
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, anySlice(from)...)
+	return translateError(err, "notes.ReplaceTags")
+}
+
+// CountByTag returns how many live notes carry each of tagIDs
+func (r *noteRepository) CountByTag(ctx context.Context, tagIDs []string) (_ map[string]int, err error) {
+	counts := make(map[string]int)
+	tagIDs = uniqueIDs(tagIDs)
+	if len(tagIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT nt.tag_id, COUNT(*)
+		FROM note_tags nt
+		JOIN notes n ON n.id = nt.note_id
+		WHERE nt.tag_id IN (` + placeholders(len(tagIDs)) + `) AND n.deleted_at IS NULL
+		GROUP BY nt.tag_id
+	`
+
+	ctx, span := startSpan(ctx, "notes.CountByTag", "note_tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, anySlice(tagIDs)...)
+	if err != nil {
+		return nil, translateError(err, "notes.CountByTag")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var n int
+		if err = rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	return counts, rows.Err()
+}
+
+// StorageUsage reports the notes a user owns and their size, trashed
+// notes included
+func (r *noteRepository) StorageUsage(ctx context.Context, userID string) (_ domainUser.StorageUsage, err error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(size_bytes), 0)
+		FROM notes
+		WHERE owner_id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notes.StorageUsage", "notes", query)
+	defer func() { tracing.End(span, err) }()
+
+	var usage domainUser.StorageUsage
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&usage.Notes, &usage.NoteBytes)
+	return usage, translateError(err, "notes.StorageUsage")
+}
+
+// addTags applies tags to a note
+func (r *noteRepository) addTags(ctx context.Context, noteID string, tagIDs []string, op string) error {
+	for _, tagID := range uniqueIDs(tagIDs) {
+		_, err := conn(ctx, r.db).ExecContext(ctx,
+			`INSERT OR IGNORE INTO note_tags (note_id, tag_id) VALUES (?, ?)`, noteID, tagID)
+		if err != nil {
+			return translateError(err, op)
+		}
+	}
+	return nil
+}
+
+// loadTags fills in the TagIDs of notes with one query
+func (r *noteRepository) loadTags(ctx context.Context, notes ...*domainNote.Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	byID := make(map[string]*domainNote.Note, len(notes))
+	ids := make([]string, len(notes))
+	for i, note := range notes {
+		byID[note.ID] = note
+		ids[i] = note.ID
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT note_id, tag_id
+		FROM note_tags
+		WHERE note_id IN (`+placeholders(len(ids))+`)
+		ORDER BY note_id, tag_id
+	`, anySlice(ids)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteID, tagID string
+		if err := rows.Scan(&noteID, &tagID); err != nil {
+			return err
+		}
+		byID[noteID].TagIDs = append(byID[noteID].TagIDs, tagID)
+	}
+	return rows.Err()
+}
+
+// matchQuery builds an FTS5 query matching documents that contain every
+// term. Each term is quoted so it is never read as query syntax.
+func matchQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// placeholders returns n comma-separated bind parameters
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// anySlice converts values into query arguments
+func anySlice(values []string) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
+
+// uniqueIDs returns ids without duplicates, so that counting matches
+// against it is exact
+func uniqueIDs(ids []string) []string {
+	c := slices.Clone(ids)
+	slices.Sort(c)
+	return slices.Compact(c)
+}
@@ -0,0 +1,344 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// notebookRepository implements domainNotebook.Repository for SQLite
+type notebookRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewNotebookRepository creates a new SQLite notebook repository
+func NewNotebookRepository(db *sql.DB, logger *slog.Logger) domainNotebook.Repository {
+	return &notebookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// notebookColumns lists the columns read by scanNotebook, in order
+const notebookColumns = `id, owner_id, parent_id, name, created_at, updated_at`
+
+// ancestorsQuery selects the IDs of notebook ? and every notebook it is
+// nested in. UNION stops the recursion should a cycle ever be stored.
+const ancestorsQuery = `
+	WITH RECURSIVE ancestors(id, parent_id) AS (
+		SELECT id, parent_id FROM notebooks WHERE id = ?
+		UNION
+		SELECT n.id, n.parent_id FROM notebooks n JOIN ancestors a ON n.id = a.parent_id
+	)
+	SELECT id FROM ancestors`
+
+func scanNotebook(row scanner) (*domainNotebook.Notebook, error) {
+	notebook := &domainNotebook.Notebook{}
+	var parentID sql.NullString
+	var createdAt, updatedAt string
+	err := row.Scan(
+		&notebook.ID,
+		&notebook.OwnerID,
+		&parentID,
+		&notebook.Name,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	notebook.ParentID = parentID.String
+	if notebook.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if notebook.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return notebook, nil
+}
+
+// Create stores a new notebook
+func (r *notebookRepository) Create(ctx context.Context, notebook *domainNotebook.Notebook) (err error) {
+	query := `
+		INSERT INTO notebooks (id, owner_id, parent_id, name, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.Create", "notebooks", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		notebook.ID,
+		notebook.OwnerID,
+		sql.NullString{String: notebook.ParentID, Valid: notebook.ParentID != ""},
+		notebook.Name,
+		formatTime(notebook.CreatedAt),
+		formatTime(notebook.UpdatedAt),
+	)
+
+	return translateError(err, "notebooks.Create")
+}
+
+// GetByID retrieves a notebook by its ID. A missing notebook is reported
+// as errs.ErrNotFound.
+func (r *notebookRepository) GetByID(ctx context.Context, id string) (_ *domainNotebook.Notebook, err error) {
+	query := `
+		SELECT ` + notebookColumns + `
+		FROM notebooks
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.GetByID", "notebooks", query)
+	defer func() { tracing.End(span, err) }()
+
+	notebook, err := scanNotebook(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, translateError(err, "notebooks.GetByID")
+	}
+	return notebook, nil
+}
+
+// Update renames a notebook
+func (r *notebookRepository) Update(ctx context.Context, notebook *domainNotebook.Notebook) (err error) {
+	query := `
+		UPDATE notebooks
+		SET name = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.Update", "notebooks", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, notebook.Name, formatTime(notebook.UpdatedAt), notebook.ID)
+	if err != nil {
+		return translateError(err, "notebooks.Update")
+	}
+
+	return expectRow(result, "notebooks.Update")
+}
+
+// Delete removes a notebook; its collaborators go with it
+func (r *notebookRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM notebooks
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.Delete", "notebooks", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "notebooks.Delete")
+	}
+
+	return expectRow(result, "notebooks.Delete")
+}
+
+// Move places a notebook under parentID. SQLite runs one writer at a
+// time, so checking for a cycle in the UPDATE itself makes the check and
+// the move atomic.
+func (r *notebookRepository) Move(ctx context.Context, id, parentID string, now time.Time) (err error) {
+	query := `
+		UPDATE notebooks
+		SET parent_id = ?, updated_at = ?
+		WHERE id = ? AND NOT EXISTS (` + ancestorsQuery + ` WHERE id = ?)
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.Move", "notebooks", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		sql.NullString{String: parentID, Valid: parentID != ""},
+		formatTime(now),
+		id,
+		parentID,
+		id,
+	)
+	if err != nil {
+		return translateError(err, "notebooks.Move")
+	}
+
+	err = expectRow(result, "notebooks.Move")
+	if errors.Is(err, errs.ErrNotFound) {
+		// The notebook exists, so the move would have made a cycle
+		if _, getErr := r.GetByID(ctx, id); getErr == nil {
+			return errs.Wrap(errs.ErrConflict, "notebooks.Move")
+		}
+	}
+	return err
+}
+
+// ListByOwner returns every notebook of a user ordered by name
+func (r *notebookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*domainNotebook.Notebook, error) {
+	return r.list(ctx, "notebooks.ListByOwner", `
+		SELECT `+notebookColumns+`
+		FROM notebooks
+		WHERE owner_id = ?
+		ORDER BY name COLLATE NOCASE, id
+	`, ownerID)
+}
+
+// Children returns the notebooks directly under parentID ordered by name
+func (r *notebookRepository) Children(ctx context.Context, parentID string) ([]*domainNotebook.Notebook, error) {
+	return r.list(ctx, "notebooks.Children", `
+		SELECT `+notebookColumns+`
+		FROM notebooks
+		WHERE parent_id = ?
+		ORDER BY name COLLATE NOCASE, id
+	`, parentID)
+}
+
+// AddCollaborator shares a notebook with a user, or changes the role of
+// an existing collaborator
+func (r *notebookRepository) AddCollaborator(ctx context.Context, collaborator *domainNotebook.Collaborator) (err error) {
+	query := `
+		INSERT INTO notebook_collaborators (notebook_id, user_id, role, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (notebook_id, user_id) DO UPDATE SET role = excluded.role
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.AddCollaborator", "notebook_collaborators", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		collaborator.NotebookID,
+		collaborator.UserID,
+		collaborator.Role,
+		formatTime(collaborator.CreatedAt),
+	)
+
+	return translateError(err, "notebooks.AddCollaborator")
+}
+
+// RemoveCollaborator stops sharing a notebook with a user
+func (r *notebookRepository) RemoveCollaborator(ctx context.Context, notebookID, userID string) (err error) {
+	query := `
+		DELETE FROM notebook_collaborators
+		WHERE notebook_id = ? AND user_id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.RemoveCollaborator", "notebook_collaborators", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, notebookID, userID)
+	if err != nil {
+		return translateError(err, "notebooks.RemoveCollaborator")
+	}
+
+	return expectRow(result, "notebooks.RemoveCollaborator")
+}
+
+// Collaborators returns the users a notebook is shared with directly,
+// oldest first
+func (r *notebookRepository) Collaborators(ctx context.Context, notebookID string) (_ []*domainNotebook.Collaborator, err error) {
+	query := `
+		SELECT notebook_id, user_id, role, created_at
+		FROM notebook_collaborators
+		WHERE notebook_id = ?
+		ORDER BY created_at, user_id
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.Collaborators", "notebook_collaborators", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, notebookID)
+	if err != nil {
+		return nil, translateError(err, "notebooks.Collaborators")
+	}
+	defer rows.Close()
+
+	var collaborators []*domainNotebook.Collaborator
+	for rows.Next() {
+		c := &domainNotebook.Collaborator{}
+		var createdAt string
+		if err = rows.Scan(&c.NotebookID, &c.UserID, &c.Role, &createdAt); err != nil {
+			return nil, err
+		}
+		if c.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, c)
+	}
+	return collaborators, rows.Err()
+}
+
+// Access returns the strongest role userID was granted on the notebook or
+// any notebook it is nested in
+func (r *notebookRepository) Access(ctx context.Context, notebookID, userID string) (_ domainNotebook.Role, err error) {
+	query := `
+		SELECT role
+		FROM notebook_collaborators
+		WHERE notebook_id IN (` + ancestorsQuery + `) AND user_id = ?
+	`
+
+	ctx, span := startSpan(ctx, "notebooks.Access", "notebook_collaborators", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, notebookID, userID)
+	if err != nil {
+		return "", translateError(err, "notebooks.Access")
+	}
+	defer rows.Close()
+
+	var roles []domainNotebook.Role
+	for rows.Next() {
+		var role domainNotebook.Role
+		if err = rows.Scan(&role); err != nil {
+			return "", err
+		}
+		roles = append(roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	return domainNotebook.Strongest(roles...), nil
+}
+
+// SharedWith returns the notebooks shared directly with a user ordered by
+// name
+func (r *notebookRepository) SharedWith(ctx context.Context, userID string) ([]*domainNotebook.Notebook, error) {
+	return r.list(ctx, "notebooks.SharedWith", `
+		SELECT `+prefixColumns("n.", notebookColumns)+`
+		FROM notebooks n
+		JOIN notebook_collaborators c ON c.notebook_id = n.id
+		WHERE c.user_id = ?
+		ORDER BY n.name COLLATE NOCASE, n.id
+	`, userID)
+}
+
+// list runs a query selecting notebookColumns
+func (r *notebookRepository) list(ctx context.Context, op, query string, args ...any) (_ []*domainNotebook.Notebook, err error) {
+	ctx, span := startSpan(ctx, op, "notebooks", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err, op)
+	}
+	defer rows.Close()
+
+	var notebooks []*domainNotebook.Notebook
+	for rows.Next() {
+		notebook, err := scanNotebook(rows)
+		if err != nil {
+			return nil, err
+		}
+		notebooks = append(notebooks, notebook)
+	}
+	return notebooks, rows.Err()
+}
+
+// prefixColumns qualifies a comma-separated column list with a table
+// alias
+func prefixColumns(prefix, columns string) string {
+	return prefix + strings.ReplaceAll(columns, ", ", ", "+prefix)
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the SQLite database file at path, creating it if needed.
+// Every connection enforces foreign keys and waits for locks instead of
+// failing at once; WAL lets readers run alongside the single writer, and
+// transactions take the write lock when they begin so two of them never
+// deadlock upgrading a read lock.
+func Open(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+
+	// SQLite decodes URI file names, so characters such as ? and # in the
+	// path are escaped
+	dsn := url.URL{
+		Scheme:   "file",
+		Opaque:   (&url.URL{Path: path}).EscapedPath(),
+		RawQuery: params.Encode(),
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite has one writer at a time, so a large pool only adds waiting
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(4)
+
+	return db, nil
+}
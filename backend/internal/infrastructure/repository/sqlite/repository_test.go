@@ -0,0 +1,84 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"notes-app/backend/internal/domain/audit"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/repository/repotest"
+	"notes-app/backend/internal/infrastructure/repository/sqlite"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestUserRepository(t *testing.T) {
+	repotest.UserRepository(t, func(t *testing.T) domainUser.Repository {
+		return sqlite.NewUserRepository(openTestDB(t), discard)
+	})
+}
+
+func TestAuditRepository(t *testing.T) {
+	repotest.AuditRepository(t, func(t *testing.T) audit.Repository {
+		return sqlite.NewAuditRepository(openTestDB(t), discard)
+	})
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+	if err := sqlite.Migrate(context.Background(), db); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+}
+
+func TestNoteRepository(t *testing.T) {
+	repotest.NoteRepository(t, newRepos)
+}
+
+func TestTagRepository(t *testing.T) {
+	repotest.TagRepository(t, newRepos)
+}
+
+func TestNotebookRepository(t *testing.T) {
+	repotest.NotebookRepository(t, newRepos)
+}
+
+func TestAttachmentRepository(t *testing.T) {
+	repotest.AttachmentRepository(t, newRepos)
+}
+
+func newRepos(t *testing.T) repotest.Repos {
+	db := openTestDB(t)
+	return repotest.Repos{
+		Users:       sqlite.NewUserRepository(db, discard),
+		Notes:       sqlite.NewNoteRepository(db, discard),
+		Tags:        sqlite.NewTagRepository(db, discard),
+		Notebooks:   sqlite.NewNotebookRepository(db, discard),
+		Attachments: sqlite.NewAttachmentRepository(db, discard),
+	}
+}
+
+// openTestDB opens a migrated database in a temporary directory
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	// The name holds characters that have a meaning in URIs
+	path := filepath.Join(t.TempDir(), "test #1?.db")
+	db, err := sqlite.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := sqlite.Migrate(context.Background(), db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("database file: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// tagRepository implements domainTag.Repository for SQLite
+type tagRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewTagRepository creates a new SQLite tag repository
+func NewTagRepository(db *sql.DB, logger *slog.Logger) domainTag.Repository {
+	return &tagRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// tagColumns lists the columns read by scanTag, in order
+const tagColumns = `id, owner_id, name, color, created_at`
+
+func scanTag(row scanner) (*domainTag.Tag, error) {
+	tag := &domainTag.Tag{}
+	var createdAt string
+	err := row.Scan(&tag.ID, &tag.OwnerID, &tag.Name, &tag.Color, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if tag.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Create stores a new tag
+func (r *tagRepository) Create(ctx context.Context, tag *domainTag.Tag) (err error) {
+	query := `
+		INSERT INTO tags (id, owner_id, name, color, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	ctx, span := startSpan(ctx, "tags.Create", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, tag.ID, tag.OwnerID, tag.Name, tag.Color, formatTime(tag.CreatedAt))
+	return translateError(err, "tags.Create")
+}
+
+// GetByID retrieves a tag by its ID. A missing tag is reported as
+// errs.ErrNotFound.
+func (r *tagRepository) GetByID(ctx context.Context, id string) (_ *domainTag.Tag, err error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "tags.GetByID", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	tag, err := scanTag(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, translateError(err, "tags.GetByID")
+	}
+	return tag, nil
+}
+
+// Update renames or recolors a tag
+func (r *tagRepository) Update(ctx context.Context, tag *domainTag.Tag) (err error) {
+	query := `
+		UPDATE tags
+		SET name = ?, color = ?
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "tags.Update", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tag.Name, tag.Color, tag.ID)
+	if err != nil {
+		return translateError(err, "tags.Update")
+	}
+	return expectRow(result, "tags.Update")
+}
+
+// Delete removes a tag; it is removed from every note as well
+func (r *tagRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM tags
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "tags.Delete", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "tags.Delete")
+	}
+	return expectRow(result, "tags.Delete")
+}
+
+// List returns the tags of a user whose name starts with prefix. LIKE
+// ignores case for ASCII in SQLite.
+func (r *tagRepository) List(ctx context.Context, ownerID, prefix string) (_ []*domainTag.Tag, err error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE owner_id = ? AND name LIKE ? ESCAPE '\'
+		ORDER BY name COLLATE NOCASE, id
+	`
+
+	ctx, span := startSpan(ctx, "tags.List", "tags", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, ownerID, escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, translateError(err, "tags.List")
+	}
+	defer rows.Close()
+
+	var tags []*domainTag.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
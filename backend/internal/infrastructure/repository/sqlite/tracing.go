@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/infrastructure/repository/sqlite")
+
+// startSpan starts a client span for a query on table. The statement text
+// is recorded but never its arguments, so no user data reaches the trace.
+func startSpan(ctx context.Context, name, table, query string) (context.Context, trace.Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(statement, " ")
+
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemSqlite,
+			semconv.DBCollectionName(table),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(statement),
+		),
+	)
+}
@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"notes-app/backend/internal/domain/tx"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// TxConfig configures the transaction manager
+type TxConfig struct {
+	// MaxRetries is how often a transaction failing because the database
+	// is locked is retried
+	MaxRetries int
+	// RetryDelay is the base of the exponential, jittered backoff
+	RetryDelay time.Duration
+}
+
+// executor is the part of *sql.DB and *sql.Tx used by repositories
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// conn returns the transaction carried by ctx, or db outside of one
+func conn(ctx context.Context, db *sql.DB) executor {
+	if t, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return t
+	}
+	return db
+}
+
+// txManager implements tx.Manager for SQLite. SQLite transactions are
+// serializable; with the _txlock=immediate DSN option set by Open they
+// take the write lock when they begin.
+type txManager struct {
+	db     *sql.DB
+	cfg    TxConfig
+	logger *slog.Logger
+}
+
+// NewTxManager creates a transaction manager for db
+func NewTxManager(db *sql.DB, cfg TxConfig, logger *slog.Logger) tx.Manager {
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 10 * time.Millisecond
+	}
+	return &txManager{
+		db:     db,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// WithinTx runs fn in a transaction, retrying it while the database is
+// locked by another connection
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, "db.Transaction")
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 0; ; attempt++ {
+		err = m.run(ctx, fn)
+		if err == nil || !retryable(err) || attempt >= m.cfg.MaxRetries {
+			span.SetAttributes(attribute.Int("db.transaction.attempts", attempt+1))
+			return err
+		}
+
+		delay := m.cfg.RetryDelay << attempt
+		delay += rand.N(delay)
+		m.logger.DebugContext(ctx, "retrying transaction",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// run executes one attempt of fn
+func (m *txManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	t, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			t.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := t.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				m.logger.ErrorContext(ctx, "transaction rollback failed", slog.Any("error", rbErr))
+			}
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, t)); err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+// retryable reports whether err means the database was locked
+func retryable(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
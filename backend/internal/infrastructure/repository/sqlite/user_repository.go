@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// userRepository implements domainUser.Repository for SQLite
+type userRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewUserRepository creates a new SQLite user repository
+func NewUserRepository(db *sql.DB, logger *slog.Logger) domainUser.Repository {
+	return &userRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// userColumns lists the columns read by scanUser, in order
+const userColumns = `id, email, password, role, created_at, disabled_at, sessions_revoked_at`
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser reads a row selected with userColumns, followed by any extra
+// columns into extra
+func scanUser(row scanner, extra ...any) (*domainUser.User, error) {
+	user := &domainUser.User{}
+	var createdAt string
+	var disabledAt, revokedAt sql.NullString
+	dest := []any{
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&createdAt,
+		&disabledAt,
+		&revokedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if user.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if user.DisabledAt, err = parseNullTime(disabledAt); err != nil {
+		return nil, err
+	}
+	if user.SessionsRevokedAt, err = parseNullTime(revokedAt); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Create stores a new user in the database
+func (r *userRepository) Create(ctx context.Context, user *domainUser.User) (err error) {
+	query := `
+		INSERT INTO users (id, email, password, role, created_at, disabled_at, sessions_revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	ctx, span := startSpan(ctx, "users.Create", "users", query)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		user.ID,
+		user.Email,
+		user.Password,
+		user.Role,
+		formatTime(user.CreatedAt),
+		nullTime(user.DisabledAt),
+		nullTime(user.SessionsRevokedAt),
+	)
+
+	return translateError(err, "users.Create")
+}
+
+// GetByID retrieves a user by their ID. A missing user is reported as
+// errs.ErrNotFound.
+func (r *userRepository) GetByID(ctx context.Context, id string) (_ *domainUser.User, err error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "users.GetByID", "users", query)
+	defer func() { tracing.End(span, err) }()
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, translateError(err, "users.GetByID")
+	}
+
+	return user, nil
+}
+
+// GetByEmail retrieves a user by their email. A missing user is reported
+// as errs.ErrNotFound.
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (_ *domainUser.User, err error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE email = ?
+	`
+
+	ctx, span := startSpan(ctx, "users.GetByEmail", "users", query)
+	defer func() { tracing.End(span, err) }()
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, email))
+	if err != nil {
+		return nil, translateError(err, "users.GetByEmail")
+	}
+
+	r.logger.DebugContext(ctx, "user loaded by email", slog.Any("user", user))
+
+	return user, nil
+}
+
+// Update modifies an existing user
+func (r *userRepository) Update(ctx context.Context, user *domainUser.User) (err error) {
+	query := `
+		UPDATE users
+		SET email = ?, password = ?, role = ?, disabled_at = ?, sessions_revoked_at = ?
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "users.Update", "users", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		user.Email,
+		user.Password,
+		user.Role,
+		nullTime(user.DisabledAt),
+		nullTime(user.SessionsRevokedAt),
+		user.ID,
+	)
+	if err != nil {
+		return translateError(err, "users.Update")
+	}
+
+	return expectRow(result, "users.Update")
+}
+
+// Delete removes a user from the database
+func (r *userRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM users
+		WHERE id = ?
+	`
+
+	ctx, span := startSpan(ctx, "users.Delete", "users", query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return translateError(err, "users.Delete")
+	}
+
+	return expectRow(result, "users.Delete")
+}
+
+// List returns one page of the users matching filter, newest first
+func (r *userRepository) List(ctx context.Context, filter domainUser.ListFilter) (_ []*domainUser.User, total int, err error) {
+	var where []string
+	var args []any
+	if filter.Query != "" {
+		// LIKE is case-insensitive for ASCII in SQLite
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		where = append(where, `email LIKE ? ESCAPE '\'`)
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		where = append(where, "role = ?")
+	}
+	switch filter.Status {
+	case domainUser.StatusActive:
+		where = append(where, "disabled_at IS NULL")
+	case domainUser.StatusDisabled:
+		where = append(where, "disabled_at IS NOT NULL")
+	}
+	conditions := ""
+	if len(where) > 0 {
+		conditions = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	// The window count returns the total alongside the page in one query
+	query := `
+		SELECT ` + userColumns + `, COUNT(*) OVER ()
+		FROM users
+		` + conditions + `
+		ORDER BY created_at DESC, id
+		LIMIT ? OFFSET ?`
+	args = append(args, limitArg(filter.Limit), filter.Offset)
+
+	ctx, span := startSpan(ctx, "users.List", "users", query)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, translateError(err, "users.List")
+	}
+	defer rows.Close()
+
+	var users []*domainUser.User
+	for rows.Next() {
+		user, err := scanUser(rows, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// A page past the end has no rows to carry the window count
+	if len(users) == 0 && filter.Offset > 0 {
+		countQuery := `SELECT COUNT(*) FROM users ` + conditions
+		if err = conn(ctx, r.db).QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return users, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// limitArg binds a LIMIT, where zero means no limit as LIMIT -1 does
+func limitArg(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
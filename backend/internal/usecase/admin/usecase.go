@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/domain/tx"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/admin")
+
+var ErrCannotDisableSelf = errs.New(errs.CodeCannotDisableSelf, "cannot disable own account")
+
+// UseCase defines the account administration operations. Every call is
+// recorded in the audit trail with the calling principal as actor.
+type UseCase interface {
+	// ListUsers returns one page of the users matching filter and the
+	// total number of matches
+	ListUsers(ctx context.Context, filter domainUser.ListFilter) ([]*domainUser.User, int, error)
+
+	// DisableUser blocks an account and revokes its sessions
+	DisableUser(ctx context.Context, id string) (*domainUser.User, error)
+
+	// EnableUser lets a disabled account log in again
+	EnableUser(ctx context.Context, id string) (*domainUser.User, error)
+
+	// ForceLogout revokes every session of an account
+	ForceLogout(ctx context.Context, id string) error
+
+	// StorageUsage reports the storage an account uses
+	StorageUsage(ctx context.Context, id string) (domainUser.StorageUsage, error)
+}
+
+// Auditor records audit events
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event) error
+}
+
+// UsageSource reports the storage one kind of content takes for a user
+type UsageSource interface {
+	StorageUsage(ctx context.Context, userID string) (domainUser.StorageUsage, error)
+}
+
+type useCase struct {
+	userRepo domainUser.Repository
+	tx       tx.Manager
+	auditor  Auditor
+	usage    []UsageSource
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewUseCase creates a new instance of the admin use case. Account
+// changes and their audit events are written in one transaction. Storage
+// usage is the sum reported by every source.
+func NewUseCase(repo domainUser.Repository, txm tx.Manager, auditor Auditor, logger *slog.Logger, usage ...UsageSource) UseCase {
+	return &useCase{
+		userRepo: repo,
+		tx:       txm,
+		auditor:  auditor,
+		usage:    usage,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// ListUsers implements the user search use case
+func (uc *useCase) ListUsers(ctx context.Context, filter domainUser.ListFilter) (_ []*domainUser.User, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "admin.ListUsers")
+	defer func() { tracing.End(span, err) }()
+
+	users, total, err := uc.userRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "admin.ListUsers")
+	}
+
+	err = uc.auditor.Record(ctx, audit.Event{
+		Action: audit.ActionAdminUsersListed,
+		Metadata: map[string]string{
+			"query":  filter.Query,
+			"role":   string(filter.Role),
+			"status": string(filter.Status),
+			"offset": strconv.Itoa(filter.Offset),
+		},
+	})
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "admin.ListUsers")
+	}
+
+	return users, total, nil
+}
+
+// DisableUser implements the account suspension use case
+func (uc *useCase) DisableUser(ctx context.Context, id string) (_ *domainUser.User, err error) {
+	ctx, span := tracer.Start(ctx, "admin.DisableUser")
+	defer func() { tracing.End(span, err) }()
+
+	if principal, ok := auth.FromContext(ctx); ok && principal.UserID == id {
+		return nil, errs.Wrap(ErrCannotDisableSelf, "admin.DisableUser")
+	}
+
+	user, err := uc.updateUser(ctx, id, audit.ActionAdminUserDisabled, func(u *domainUser.User) {
+		u.Disable(uc.now())
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "admin.DisableUser")
+	}
+
+	uc.logger.InfoContext(ctx, "account disabled", slog.String("target_user_id", id))
+	return user, nil
+}
+
+// EnableUser implements the account reactivation use case
+func (uc *useCase) EnableUser(ctx context.Context, id string) (_ *domainUser.User, err error) {
+	ctx, span := tracer.Start(ctx, "admin.EnableUser")
+	defer func() { tracing.End(span, err) }()
+
+	user, err := uc.updateUser(ctx, id, audit.ActionAdminUserEnabled, func(u *domainUser.User) {
+		u.Enable()
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "admin.EnableUser")
+	}
+
+	uc.logger.InfoContext(ctx, "account enabled", slog.String("target_user_id", id))
+	return user, nil
+}
+
+// ForceLogout implements the session revocation use case
+func (uc *useCase) ForceLogout(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "admin.ForceLogout")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = uc.updateUser(ctx, id, audit.ActionAdminUserLoggedOut, func(u *domainUser.User) {
+		u.RevokeSessions(uc.now())
+	})
+	if err != nil {
+		return errs.Wrap(err, "admin.ForceLogout")
+	}
+
+	uc.logger.InfoContext(ctx, "sessions revoked", slog.String("target_user_id", id))
+	return nil
+}
+
+// StorageUsage implements the storage usage report use case
+func (uc *useCase) StorageUsage(ctx context.Context, id string) (_ domainUser.StorageUsage, err error) {
+	ctx, span := tracer.Start(ctx, "admin.StorageUsage")
+	defer func() { tracing.End(span, err) }()
+
+	var usage domainUser.StorageUsage
+	if _, err := uc.getUser(ctx, id); err != nil {
+		return usage, errs.Wrap(err, "admin.StorageUsage")
+	}
+
+	for _, source := range uc.usage {
+		u, err := source.StorageUsage(ctx, id)
+		if err != nil {
+			return usage, errs.Wrap(err, "admin.StorageUsage")
+		}
+		usage = usage.Add(u)
+	}
+
+	if err := uc.record(ctx, audit.ActionAdminUsageViewed, id); err != nil {
+		return usage, errs.Wrap(err, "admin.StorageUsage")
+	}
+
+	return usage, nil
+}
+
+// updateUser applies change to a user and audits action, all in one
+// transaction so neither happens without the other
+func (uc *useCase) updateUser(ctx context.Context, id string, action audit.Action, change func(*domainUser.User)) (*domainUser.User, error) {
+	var user *domainUser.User
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = uc.getUser(ctx, id); err != nil {
+			return err
+		}
+		change(user)
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return uc.record(ctx, action, id)
+	})
+	return user, err
+}
+
+// getUser loads a user, reporting a missing one as ErrUserNotFound
+func (uc *useCase) getUser(ctx context.Context, id string) (*domainUser.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, domainUser.ErrUserNotFound
+	}
+	return user, err
+}
+
+// record audits an action on a user account
+func (uc *useCase) record(ctx context.Context, action audit.Action, userID string) error {
+	return uc.auditor.Record(ctx, audit.Event{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+}
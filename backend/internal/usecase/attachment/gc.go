@@ -0,0 +1,108 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/domain/tx"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// collectBatchSize bounds how many orphans one pass of Collect loads
+const collectBatchSize = 100
+
+// Collector deletes attachments that no note references. Attachments
+// younger than the grace period are spared, so a file uploaded while a
+// note is being edited survives until the note is saved.
+type Collector struct {
+	attachmentRepo domainAttachment.Repository
+	blobs          domainAttachment.BlobStore
+	tx             tx.Manager
+	grace          time.Duration
+	logger         *slog.Logger
+	now            func() time.Time
+}
+
+// NewCollector creates a collector for attachments orphaned for longer
+// than grace
+func NewCollector(attachments domainAttachment.Repository, blobs domainAttachment.BlobStore, txm tx.Manager, grace time.Duration, logger *slog.Logger) *Collector {
+	return &Collector{
+		attachmentRepo: attachments,
+		blobs:          blobs,
+		tx:             txm,
+		grace:          grace,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Collect deletes every orphaned attachment along with its content and
+// that of its variants, and returns how many it deleted. Each attachment
+// is deleted in a transaction that only commits once its content is gone,
+// so a failed blob delete is retried by the next run.
+func (c *Collector) Collect(ctx context.Context) (collected int, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Collect")
+	defer func() { tracing.End(span, err) }()
+
+	before := c.now().UTC().Add(-c.grace)
+	for {
+		orphans, err := c.attachmentRepo.ListOrphans(ctx, before, collectBatchSize)
+		if err != nil {
+			return collected, errs.Wrap(err, "attachment.Collect")
+		}
+		for _, a := range orphans {
+			err := c.tx.WithinTx(ctx, func(ctx context.Context) error {
+				if err := c.attachmentRepo.Delete(ctx, a.ID); err != nil {
+					return err
+				}
+				for _, name := range domainAttachment.Variants {
+					if err := c.blobs.Delete(ctx, a.VariantKey(name)); err != nil {
+						return err
+					}
+				}
+				return c.blobs.Delete(ctx, a.Key)
+			})
+			if errors.Is(err, errs.ErrNotFound) {
+				// A note embedded it since it was listed
+				continue
+			}
+			if err != nil {
+				return collected, errs.Wrap(err, "attachment.Collect")
+			}
+			collected++
+		}
+		if len(orphans) < collectBatchSize {
+			return collected, nil
+		}
+	}
+}
+
+// Run collects once right away and then every interval until ctx is
+// done. A run has until the next tick to finish, so a stalled store
+// cannot hold it forever. Failures are logged and retried at the next
+// tick.
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		collected, err := c.Collect(runCtx)
+		cancel()
+		if err != nil {
+			c.logger.ErrorContext(ctx, "collecting orphaned attachments failed", slog.Any("error", err))
+		} else if collected > 0 {
+			c.logger.InfoContext(ctx, "orphaned attachments collected", slog.Int("attachments", collected))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
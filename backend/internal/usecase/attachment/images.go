@@ -0,0 +1,275 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"sync"
+	"time"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/domain/tx"
+	"notes-app/backend/internal/infrastructure/imaging"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+const (
+	// imageQueueSize bounds how many images wait for a worker in memory;
+	// the rest stay pending until the next scan
+	imageQueueSize = 256
+	// pendingScanInterval is how often Run looks for pending images that
+	// are not queued, such as those left by a restart
+	pendingScanInterval = time.Minute
+	// imageTimeout bounds the processing of an image, after which it is
+	// left pending for a later scan
+	imageTimeout = 5 * time.Minute
+)
+
+// ImageConfig holds the image processing settings
+type ImageConfig struct {
+	// Workers is how many images are processed at once
+	Workers int
+	// ThumbnailSize is the side of the square thumbnails fit in
+	ThumbnailSize int
+	// DisplayWidth is the widest the display variant gets
+	DisplayWidth int
+	// MaxPixels is the largest image processed; larger ones fail
+	MaxPixels int
+}
+
+// ImageProcessor processes uploaded images in the background: it turns
+// them upright, records their dimensions and stores their variants.
+// Uploads only queue their image, so they do not wait for it.
+type ImageProcessor struct {
+	attachmentRepo domainAttachment.Repository
+	blobs          domainAttachment.BlobStore
+	tx             tx.Manager
+	config         ImageConfig
+	logger         *slog.Logger
+
+	queue chan string
+	// queued holds the IDs in the queue or being processed, so scans do
+	// not queue them twice
+	mu     sync.Mutex
+	queued map[string]bool
+}
+
+// NewImageProcessor creates an image processor; Run starts its workers
+func NewImageProcessor(attachments domainAttachment.Repository, blobs domainAttachment.BlobStore, txm tx.Manager, config ImageConfig, logger *slog.Logger) *ImageProcessor {
+	return &ImageProcessor{
+		attachmentRepo: attachments,
+		blobs:          blobs,
+		tx:             txm,
+		config:         config,
+		logger:         logger,
+		queue:          make(chan string, imageQueueSize),
+		queued:         make(map[string]bool),
+	}
+}
+
+// Enqueue queues an image for processing without blocking. When the queue
+// is full the image stays pending until a later scan queues it.
+func (p *ImageProcessor) Enqueue(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.queued[id] {
+		return
+	}
+	select {
+	case p.queue <- id:
+		p.queued[id] = true
+	default:
+		p.logger.Warn("image queue full", slog.String("attachment_id", id))
+	}
+}
+
+// Run processes queued images on the configured number of workers until
+// ctx is done. Pending images are queued right away and then every
+// pendingScanInterval.
+func (p *ImageProcessor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range max(p.config.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	defer wg.Wait()
+
+	ticker := time.NewTicker(pendingScanInterval)
+	defer ticker.Stop()
+
+	for {
+		scanCtx, cancel := context.WithTimeout(ctx, pendingScanInterval)
+		pending, err := p.attachmentRepo.ListPending(scanCtx, imageQueueSize)
+		cancel()
+		if err != nil {
+			p.logger.ErrorContext(ctx, "listing pending images failed", slog.Any("error", err))
+		}
+		for _, a := range pending {
+			p.Enqueue(a.ID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// work processes queued images until ctx is done, each for at most
+// imageTimeout. Failures are logged and leave the image pending, to be
+// retried after the next scan.
+func (p *ImageProcessor) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-p.queue:
+			processCtx, cancel := context.WithTimeout(ctx, imageTimeout)
+			if err := p.Process(processCtx, id); err != nil {
+				p.logger.ErrorContext(ctx, "processing image failed",
+					slog.String("attachment_id", id), slog.Any("error", err))
+			}
+			cancel()
+			p.mu.Lock()
+			delete(p.queued, id)
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Process processes one pending image. The content of an image that had
+// to be turned is replaced by its upright rendition, and each variant is
+// stored next to it. Images that cannot be decoded are marked failed and
+// served as uploaded.
+func (p *ImageProcessor) Process(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "attachment.ProcessImage")
+	defer func() { tracing.End(span, err) }()
+
+	a, err := p.attachmentRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errs.Wrap(err, "attachment.ProcessImage")
+	}
+	if a.Processing != domainAttachment.ProcessingPending {
+		return nil
+	}
+
+	data, err := p.read(ctx, a.Key)
+	if errors.Is(err, errs.ErrNotFound) {
+		p.logger.WarnContext(ctx, "image content missing", slog.String("attachment_id", id))
+		return p.fail(ctx, a)
+	}
+	if err != nil {
+		return errs.Wrap(err, "attachment.ProcessImage")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(a.ContentType)
+	result, err := imaging.Process(mediaType, data, imaging.Options{
+		ThumbnailSize: p.config.ThumbnailSize,
+		DisplayWidth:  p.config.DisplayWidth,
+		MaxPixels:     p.config.MaxPixels,
+	})
+	if errors.Is(err, imaging.ErrInvalid) || errors.Is(err, imaging.ErrTooLarge) {
+		p.logger.WarnContext(ctx, "image cannot be processed", slog.String("attachment_id", id), slog.Any("error", err))
+		return p.fail(ctx, a)
+	}
+	if err != nil {
+		return errs.Wrap(err, "attachment.ProcessImage")
+	}
+
+	// Variants are stored first, so the attachment never lists a variant
+	// without content
+	var written []string
+	a.Variants = nil
+	for _, rendition := range []struct {
+		name string
+		*imaging.Rendition
+	}{
+		{domainAttachment.VariantDisplay, result.Display},
+		{domainAttachment.VariantThumbnail, result.Thumbnail},
+	} {
+		if rendition.Rendition == nil {
+			continue
+		}
+		key := a.VariantKey(rendition.name)
+		if err := p.put(ctx, key, rendition.Rendition); err != nil {
+			return errs.Wrap(err, "attachment.ProcessImage")
+		}
+		written = append(written, key)
+		a.Variants = append(a.Variants, domainAttachment.Variant{
+			Name:        rendition.name,
+			ContentType: rendition.ContentType,
+			Width:       rendition.Width,
+			Height:      rendition.Height,
+			Size:        int64(len(rendition.Data)),
+		})
+	}
+	if result.Upright != nil {
+		if err := p.put(ctx, a.Key, result.Upright); err != nil {
+			return errs.Wrap(err, "attachment.ProcessImage")
+		}
+		sum := sha256.Sum256(result.Upright.Data)
+		a.Size, a.Checksum = int64(len(result.Upright.Data)), hex.EncodeToString(sum[:])
+	}
+	a.Width, a.Height = result.Width, result.Height
+	a.Processing = domainAttachment.ProcessingDone
+
+	err = p.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return p.attachmentRepo.UpdateImage(ctx, a)
+	})
+	if errors.Is(err, errs.ErrNotFound) {
+		// The attachment was collected meanwhile; remove what was
+		// written for it
+		for _, key := range append(written, a.Key) {
+			if err := p.blobs.Delete(ctx, key); err != nil {
+				p.logger.ErrorContext(ctx, "removing content of collected image failed",
+					slog.String("key", key), slog.Any("error", err))
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return errs.Wrap(err, "attachment.ProcessImage")
+	}
+	return nil
+}
+
+// fail marks an image as failed
+func (p *ImageProcessor) fail(ctx context.Context, a *domainAttachment.Attachment) error {
+	a.Processing = domainAttachment.ProcessingFailed
+	err := p.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return p.attachmentRepo.UpdateImage(ctx, a)
+	})
+	if err != nil && !errors.Is(err, errs.ErrNotFound) {
+		return errs.Wrap(err, "attachment.ProcessImage")
+	}
+	return nil
+}
+
+// read loads the content stored under key
+func (p *ImageProcessor) read(ctx context.Context, key string) ([]byte, error) {
+	content, err := p.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return io.ReadAll(content)
+}
+
+// put stores a rendition under key
+func (p *ImageProcessor) put(ctx context.Context, key string, r *imaging.Rendition) error {
+	return p.blobs.Put(ctx, key, bytes.NewReader(r.Data), int64(len(r.Data)), r.ContentType)
+}
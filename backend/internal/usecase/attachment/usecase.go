@@ -0,0 +1,356 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/infrastructure/imaging"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/attachment")
+
+// ErrEmptyFile reports an upload without content
+var ErrEmptyFile = errs.Validation(
+	errs.New(errs.CodeFieldInvalid, "file is empty").
+		WithTarget("/file").
+		WithParam("field", "file"),
+)
+
+// maxFilenameLength bounds stored file names, in bytes
+const maxFilenameLength = 255
+
+// UseCase defines the attachment operations. Users can read the
+// attachments they uploaded and those embedded in notes they can open;
+// others get not found.
+type UseCase interface {
+	// Upload stores a file for the user and returns it with a download
+	// link. Its type is sniffed from the content and must be one of the
+	// allowed types. Images are stored without their metadata and queued
+	// for processing.
+	Upload(ctx context.Context, userID, filename string, content io.Reader) (*domainAttachment.Attachment, Link, error)
+
+	// Get returns an attachment the user can read, along with a fresh
+	// download link for it
+	Get(ctx context.Context, userID, id string) (*domainAttachment.Attachment, Link, error)
+
+	// Open returns an attachment the user can read and the content of the
+	// named variant, which the caller must close. The attachment's own
+	// content is returned, with a nil variant, when variant is empty or
+	// the image has no such variant, because it is small or not processed
+	// yet.
+	Open(ctx context.Context, userID, id, variant string) (*domainAttachment.Attachment, *domainAttachment.Variant, io.ReadCloser, error)
+
+	// OpenLink is Open for the attachment a download link grants access
+	// to
+	OpenLink(ctx context.Context, id, variant string, link Link) (*domainAttachment.Attachment, *domainAttachment.Variant, io.ReadCloser, error)
+
+	// Track records the attachments a note's content embeds, so they are
+	// kept as long as the note is. Attachments the user cannot read are
+	// ignored unless the note already referenced them.
+	Track(ctx context.Context, userID, noteID string, content domainNote.Delta) error
+}
+
+// ImageQueue takes images to process in the background
+type ImageQueue interface {
+	Enqueue(id string)
+}
+
+// Link grants reading one attachment without credentials until Expires
+type Link struct {
+	Expires   time.Time
+	Signature string
+}
+
+// Config holds the attachment limits and download link settings
+type Config struct {
+	// MaxBytes is the size of the largest file accepted
+	MaxBytes int64
+	// AllowedTypes lists the media types accepted, as sniffed from the
+	// content
+	AllowedTypes []string
+	// LinkSecret signs download links
+	LinkSecret string
+	// LinkTTL is how long download links stay valid
+	LinkTTL time.Duration
+}
+
+type useCase struct {
+	attachmentRepo domainAttachment.Repository
+	noteRepo       domainNote.Repository
+	notebookRepo   domainNotebook.Repository
+	blobs          domainAttachment.BlobStore
+	images         ImageQueue
+	config         Config
+	logger         *slog.Logger
+	now            func() time.Time
+}
+
+// NewUseCase creates a new instance of the attachment use case
+func NewUseCase(attachments domainAttachment.Repository, notes domainNote.Repository, notebooks domainNotebook.Repository, blobs domainAttachment.BlobStore, images ImageQueue, config Config, logger *slog.Logger) UseCase {
+	return &useCase{
+		attachmentRepo: attachments,
+		noteRepo:       notes,
+		notebookRepo:   notebooks,
+		blobs:          blobs,
+		images:         images,
+		config:         config,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Upload implements the attachment upload use case. The content is
+// stored before the attachment, so an attachment never lacks content.
+func (uc *useCase) Upload(ctx context.Context, userID, filename string, content io.Reader) (_ *domainAttachment.Attachment, _ Link, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Upload")
+	defer func() { tracing.End(span, err) }()
+
+	data, err := io.ReadAll(io.LimitReader(content, uc.config.MaxBytes+1))
+	if err != nil {
+		return nil, Link{}, errs.Wrap(err, "attachment.Upload")
+	}
+	if int64(len(data)) > uc.config.MaxBytes {
+		return nil, Link{}, errs.ErrPayloadTooLarge.WithParam("limit", strconv.FormatInt(uc.config.MaxBytes, 10))
+	}
+	if len(data) == 0 {
+		return nil, Link{}, ErrEmptyFile
+	}
+
+	contentType := http.DetectContentType(data)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !slices.Contains(uc.config.AllowedTypes, mediaType) {
+		return nil, Link{}, domainAttachment.ErrTypeNotAllowed.WithParam("type", mediaType)
+	}
+	// Metadata such as the GPS position never reaches the store
+	data, err = imaging.Strip(mediaType, data)
+	if errors.Is(err, imaging.ErrInvalid) {
+		return nil, Link{}, domainAttachment.ErrInvalidImage
+	}
+	if err != nil {
+		return nil, Link{}, errs.Wrap(err, "attachment.Upload")
+	}
+
+	sum := sha256.Sum256(data)
+	a := domainAttachment.NewAttachment(uuid.NewString(), userID, cleanFilename(filename), contentType,
+		int64(len(data)), hex.EncodeToString(sum[:]), uc.now().UTC())
+	if a.IsImage() {
+		a.Processing = domainAttachment.ProcessingPending
+	}
+
+	if err := uc.blobs.Put(ctx, a.Key, bytes.NewReader(data), a.Size, a.ContentType); err != nil {
+		return nil, Link{}, errs.Wrap(err, "attachment.Upload")
+	}
+	if err := uc.attachmentRepo.Create(ctx, a); err != nil {
+		if err := uc.blobs.Delete(ctx, a.Key); err != nil {
+			uc.logger.ErrorContext(ctx, "removing content of failed upload failed",
+				slog.String("key", a.Key), slog.Any("error", err))
+		}
+		return nil, Link{}, errs.Wrap(err, "attachment.Upload")
+	}
+	if a.Processing == domainAttachment.ProcessingPending {
+		uc.images.Enqueue(a.ID)
+	}
+	return a, uc.link(a.ID), nil
+}
+
+// Get implements the attachment retrieval use case
+func (uc *useCase) Get(ctx context.Context, userID, id string) (_ *domainAttachment.Attachment, _ Link, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Get")
+	defer func() { tracing.End(span, err) }()
+
+	a, err := uc.readable(ctx, userID, id)
+	if err != nil {
+		return nil, Link{}, errs.Wrap(err, "attachment.Get")
+	}
+	return a, uc.link(a.ID), nil
+}
+
+// Open implements the authenticated attachment download use case
+func (uc *useCase) Open(ctx context.Context, userID, id, variant string) (_ *domainAttachment.Attachment, _ *domainAttachment.Variant, _ io.ReadCloser, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Open")
+	defer func() { tracing.End(span, err) }()
+
+	a, err := uc.readable(ctx, userID, id)
+	if err != nil {
+		return nil, nil, nil, errs.Wrap(err, "attachment.Open")
+	}
+	v, content, err := uc.open(ctx, a, variant)
+	if err != nil {
+		return nil, nil, nil, errs.Wrap(err, "attachment.Open")
+	}
+	return a, v, content, nil
+}
+
+// OpenLink implements the signed attachment download use case
+func (uc *useCase) OpenLink(ctx context.Context, id, variant string, link Link) (_ *domainAttachment.Attachment, _ *domainAttachment.Variant, _ io.ReadCloser, err error) {
+	ctx, span := tracer.Start(ctx, "attachment.OpenLink")
+	defer func() { tracing.End(span, err) }()
+
+	if !uc.now().Before(link.Expires) || !hmac.Equal([]byte(link.Signature), []byte(uc.sign(id, link.Expires))) {
+		return nil, nil, nil, domainAttachment.ErrInvalidLink
+	}
+	a, err := uc.attachmentRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil, nil, domainAttachment.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, nil, errs.Wrap(err, "attachment.OpenLink")
+	}
+	v, content, err := uc.open(ctx, a, variant)
+	if err != nil {
+		return nil, nil, nil, errs.Wrap(err, "attachment.OpenLink")
+	}
+	return a, v, content, nil
+}
+
+// open opens the content of the named variant of an attachment, falling
+// back to the attachment's own content
+func (uc *useCase) open(ctx context.Context, a *domainAttachment.Attachment, variant string) (*domainAttachment.Variant, io.ReadCloser, error) {
+	if v := a.Variant(variant); v != nil {
+		content, err := uc.blobs.Get(ctx, a.VariantKey(v.Name))
+		return v, content, err
+	}
+	content, err := uc.blobs.Get(ctx, a.Key)
+	return nil, content, err
+}
+
+// Track implements the note attachment tracking use case
+func (uc *useCase) Track(ctx context.Context, userID, noteID string, content domainNote.Delta) (err error) {
+	ctx, span := tracer.Start(ctx, "attachment.Track")
+	defer func() { tracing.End(span, err) }()
+
+	current, err := uc.attachmentRepo.References(ctx, noteID)
+	if err != nil {
+		return errs.Wrap(err, "attachment.Track")
+	}
+
+	var ids []string
+	for _, id := range domainAttachment.References(content) {
+		if slices.Contains(current, id) {
+			ids = append(ids, id)
+			continue
+		}
+		_, err := uc.readable(ctx, userID, id)
+		if errors.Is(err, domainAttachment.ErrAttachmentNotFound) {
+			continue
+		}
+		if err != nil {
+			return errs.Wrap(err, "attachment.Track")
+		}
+		ids = append(ids, id)
+	}
+
+	if slices.Equal(ids, current) {
+		return nil
+	}
+	if err := uc.attachmentRepo.SetReferences(ctx, noteID, ids); err != nil {
+		return errs.Wrap(err, "attachment.Track")
+	}
+	return nil
+}
+
+// readable loads an attachment the user uploaded or that is embedded in
+// a note they can open: one they own, even in the trash, or one in a
+// notebook shared with them. Anything else is reported as
+// ErrAttachmentNotFound.
+func (uc *useCase) readable(ctx context.Context, userID, id string) (*domainAttachment.Attachment, error) {
+	if uuid.Validate(id) != nil {
+		return nil, domainAttachment.ErrAttachmentNotFound
+	}
+	a, err := uc.attachmentRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, domainAttachment.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.OwnerID == userID {
+		return a, nil
+	}
+
+	noteIDs, err := uc.attachmentRepo.ReferencingNotes(ctx, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, noteID := range noteIDs {
+		note, err := uc.noteRepo.GetByID(ctx, noteID)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if note.OwnerID == userID {
+			return a, nil
+		}
+		if note.Trashed() || note.NotebookID == "" {
+			continue
+		}
+		role, err := uc.notebookRepo.Access(ctx, note.NotebookID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
+			return a, nil
+		}
+	}
+	return nil, domainAttachment.ErrAttachmentNotFound
+}
+
+// link creates a download link for an attachment, valid for the
+// configured time
+func (uc *useCase) link(id string) Link {
+	expires := uc.now().Add(uc.config.LinkTTL).Truncate(time.Second)
+	return Link{Expires: expires, Signature: uc.sign(id, expires)}
+}
+
+// sign computes the signature of a download link
+func (uc *useCase) sign(id string, expires time.Time) string {
+	mac := hmac.New(sha256.New, []byte(uc.config.LinkSecret))
+	mac.Write([]byte(id + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cleanFilename keeps the last element of an uploaded file name, without
+// control characters and no longer than maxFilenameLength
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(name, ""))
+	name = strings.TrimSpace(name)
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+)
+
+// Recorder appends audit events, filling in the actor from the
+// authenticated principal and the client from the request context
+type Recorder struct {
+	repo   audit.Repository
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewRecorder creates a recorder storing events in repo
+func NewRecorder(repo audit.Repository, logger *slog.Logger) *Recorder {
+	return &Recorder{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Record stores event. ID, CreatedAt, actor and client fields that are
+// left empty are filled in from ctx.
+func (r *Recorder) Record(ctx context.Context, event audit.Event) error {
+	if event.ID == "" {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return errs.Wrap(err, "audit.Record")
+		}
+		event.ID = id.String()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = r.now().UTC()
+	}
+	if event.ActorID == "" {
+		if principal, ok := auth.FromContext(ctx); ok {
+			event.ActorID = principal.UserID
+		}
+	}
+
+	client := audit.ClientFromContext(ctx)
+	if event.IP == "" {
+		event.IP = client.IP
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = client.UserAgent
+	}
+	if event.RequestID == "" {
+		event.RequestID = client.RequestID
+	}
+
+	if err := r.repo.Append(ctx, &event); err != nil {
+		r.logger.ErrorContext(ctx, "failed to record audit event",
+			slog.String("action", string(event.Action)),
+			slog.Any("error", err),
+		)
+		return errs.Wrap(err, "audit.Record")
+	}
+	return nil
+}
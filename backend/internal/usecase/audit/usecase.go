@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/audit")
+
+// UseCase defines the audit log queries
+type UseCase interface {
+	// Query returns one page of the events matching filter for an
+	// administrator. The query itself is audited.
+	Query(ctx context.Context, filter audit.Filter) ([]*audit.Event, int, error)
+
+	// AccountActivity returns one page of the events a user performed or
+	// that targeted their account
+	AccountActivity(ctx context.Context, userID string, offset, limit int) ([]*audit.Event, int, error)
+}
+
+type useCase struct {
+	repo     audit.Repository
+	recorder *Recorder
+	logger   *slog.Logger
+}
+
+// NewUseCase creates a new instance of the audit use case
+func NewUseCase(repo audit.Repository, recorder *Recorder, logger *slog.Logger) UseCase {
+	return &useCase{
+		repo:     repo,
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// Query implements the audit log search use case
+func (uc *useCase) Query(ctx context.Context, filter audit.Filter) (_ []*audit.Event, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "audit.Query")
+	defer func() { tracing.End(span, err) }()
+
+	events, total, err := uc.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "audit.Query")
+	}
+
+	err = uc.recorder.Record(ctx, audit.Event{
+		Action:   audit.ActionAdminAuditQueried,
+		Metadata: filterMetadata(filter),
+	})
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "audit.Query")
+	}
+
+	return events, total, nil
+}
+
+// AccountActivity implements the account activity use case
+func (uc *useCase) AccountActivity(ctx context.Context, userID string, offset, limit int) (_ []*audit.Event, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "audit.AccountActivity")
+	defer func() { tracing.End(span, err) }()
+
+	events, total, err := uc.repo.List(ctx, audit.Filter{
+		UserID: userID,
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "audit.AccountActivity")
+	}
+	return events, total, nil
+}
+
+// filterMetadata lists the filter fields that were set
+func filterMetadata(filter audit.Filter) map[string]string {
+	metadata := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	set("actorId", filter.ActorID)
+	set("action", string(filter.Action))
+	set("targetType", filter.TargetType)
+	set("targetId", filter.TargetID)
+	set("userId", filter.UserID)
+	if !filter.Since.IsZero() {
+		set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		set("until", filter.Until.Format(time.RFC3339))
+	}
+	return metadata
+}
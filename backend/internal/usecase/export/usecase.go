@@ -0,0 +1,473 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/infrastructure/document"
+	"notes-app/backend/internal/infrastructure/tracing"
+	"notes-app/backend/internal/usecase/notebook"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/export")
+
+// maxNameLength bounds the file names of exported notes, in bytes,
+// before their extension
+const maxNameLength = 100
+
+// Format is a document format notes are exported to
+type Format string
+
+const (
+	// FormatMarkdown is CommonMark, with GitHub Flavored Markdown tables
+	// and task lists
+	FormatMarkdown Format = "markdown"
+	// FormatHTML is a standalone HTML page
+	FormatHTML Format = "html"
+	// FormatText is plain text
+	FormatText Format = "text"
+	// FormatJSON is the Quill delta of the note, as stored
+	FormatJSON Format = "json"
+	// FormatPDF is an A4 PDF document embedding the images of the note
+	FormatPDF Format = "pdf"
+)
+
+// Formats lists every export format
+var Formats = []Format{FormatMarkdown, FormatHTML, FormatText, FormatJSON, FormatPDF}
+
+// maxImageBytes bounds the images read for PDF documents
+const maxImageBytes = 32 << 20
+
+// Limits of PDF documents, which are built in memory: the notes of a
+// notebook and their size, and the images a document embeds altogether.
+// Images past the limit show their alt text.
+const (
+	maxNotebookNotes      = 500
+	maxNotebookBytes      = 16 << 20
+	maxDocumentImageBytes = 64 << 20
+)
+
+// contentsPage is how many entries of a notebook are loaded at a time
+const contentsPage = 100
+
+// maxMissingCharacters bounds the characters a warning lists
+const maxMissingCharacters = 20
+
+// ErrMissingCharacters warns of characters a PDF document prints as
+// boxes, as its fonts lack them
+var ErrMissingCharacters = errs.New(errs.CodeExportMissingCharacters, "characters cannot be printed")
+
+var (
+	// ErrUnknownFormat reports an export format that is not one of
+	// Formats
+	ErrUnknownFormat = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "unknown export format").
+			WithTarget("/format").
+			WithParam("field", "format"),
+	)
+	// ErrNotebookTooLarge reports a notebook past the limits of a PDF
+	// document
+	ErrNotebookTooLarge = errs.New(errs.CodeExportTooLarge, "notebook too large").
+				WithParam("limit", strconv.Itoa(maxNotebookNotes)).
+				WithParam("size", strconv.Itoa(maxNotebookBytes>>20))
+)
+
+// extension returns the file name extension of a format
+func (f Format) extension() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	case FormatJSON:
+		return ".json"
+	case FormatPDF:
+		return ".pdf"
+	}
+	return ".txt"
+}
+
+// ContentType returns the media type of a format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatJSON:
+		return "application/json"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// File is an exported note
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	// Warnings report what the file cannot show, such as characters
+	// PDF documents print as boxes
+	Warnings []error
+}
+
+// UseCase defines the export of notes. Users can export the notes they can
+// open; others get not found.
+type UseCase interface {
+	// Note exports a note. Links and images keep their addresses, but for
+	// PDF documents, which embed the images of attachments.
+	Note(ctx context.Context, userID, id string, format Format) (*File, error)
+
+	// Archive loads notes for a zip archive holding a file per note and
+	// the attachments they embed. Notes are loaded up front, so one the
+	// user cannot open fails the export before anything is written.
+	Archive(ctx context.Context, userID string, ids []string, format Format) (*Archive, error)
+
+	// Notebook exports the notes of a notebook as a single PDF document,
+	// a note after another in the order the notebook lists them. Notes of
+	// the notebooks it holds are left out. Notebooks of more than 500
+	// notes or 16 MB of them fail with ErrNotebookTooLarge.
+	Notebook(ctx context.Context, userID, id string) (*File, error)
+}
+
+// Notes loads the notes a user can open
+type Notes interface {
+	Get(ctx context.Context, userID, id string) (*domainNote.Note, domainNotebook.Role, error)
+}
+
+// Notebooks loads the notebooks a user can open
+type Notebooks interface {
+	Get(ctx context.Context, userID, id string) (*domainNotebook.Notebook, domainNotebook.Role, error)
+	Contents(ctx context.Context, userID, id string, offset, limit int) (*notebook.Contents, error)
+}
+
+// Attachments opens the attachments a user can read
+type Attachments interface {
+	Open(ctx context.Context, userID, id, variant string) (*domainAttachment.Attachment, *domainAttachment.Variant, io.ReadCloser, error)
+}
+
+type useCase struct {
+	notes       Notes
+	notebooks   Notebooks
+	attachments Attachments
+	logger      *slog.Logger
+}
+
+// NewUseCase creates a new instance of the export use case
+func NewUseCase(notes Notes, notebooks Notebooks, attachments Attachments, logger *slog.Logger) UseCase {
+	return &useCase{
+		notes:       notes,
+		notebooks:   notebooks,
+		attachments: attachments,
+		logger:      logger,
+	}
+}
+
+// Note implements the note export use case
+func (uc *useCase) Note(ctx context.Context, userID, id string, format Format) (_ *File, err error) {
+	ctx, span := tracer.Start(ctx, "export.Note")
+	defer func() { tracing.End(span, err) }()
+
+	n, _, err := uc.notes.Get(ctx, userID, id)
+	if err != nil {
+		return nil, errs.Wrap(err, "export.Note")
+	}
+	data, missing, err := render(n, format, document.Options{LoadImage: uc.images(ctx, userID)})
+	if err != nil {
+		return nil, errs.Wrap(err, "export.Note")
+	}
+	return &File{
+		Name:        fileName(n.Title) + format.extension(),
+		ContentType: format.ContentType(),
+		Data:        data,
+		Warnings:    warnings(missing),
+	}, nil
+}
+
+// Notebook implements the notebook export use case
+func (uc *useCase) Notebook(ctx context.Context, userID, id string) (_ *File, err error) {
+	ctx, span := tracer.Start(ctx, "export.Notebook")
+	defer func() { tracing.End(span, err) }()
+
+	nb, _, err := uc.notebooks.Get(ctx, userID, id)
+	if err != nil {
+		return nil, errs.Wrap(err, "export.Notebook")
+	}
+	var sections []document.Section
+	size := 0
+	for offset := 0; ; {
+		page, err := uc.notebooks.Contents(ctx, userID, id, offset, contentsPage)
+		if err != nil {
+			return nil, errs.Wrap(err, "export.Notebook")
+		}
+		for _, n := range page.Notes {
+			sections = append(sections, document.Section{Title: n.Title, Content: n.Content})
+			size += n.Size()
+		}
+		if len(sections) > maxNotebookNotes || size > maxNotebookBytes {
+			return nil, errs.Wrap(ErrNotebookTooLarge, "export.Notebook")
+		}
+		read := len(page.Notebooks) + len(page.Notes)
+		if offset += read; read == 0 || offset >= page.Total {
+			break
+		}
+	}
+	data, missing := document.PDF(nb.Name, sections, document.Options{LoadImage: uc.images(ctx, userID)})
+	return &File{
+		Name:        fileName(nb.Name) + FormatPDF.extension(),
+		ContentType: FormatPDF.ContentType(),
+		Data:        data,
+		Warnings:    warnings(missing),
+	}, nil
+}
+
+// warnings returns the warnings of a file whose fonts lack the missing
+// characters
+func warnings(missing []rune) []error {
+	if len(missing) == 0 {
+		return nil
+	}
+	listed := string(missing[:min(len(missing), maxMissingCharacters)])
+	if len(missing) > maxMissingCharacters {
+		listed += "…"
+	}
+	return []error{ErrMissingCharacters.WithParam("characters", listed)}
+}
+
+// images returns a loader of the images notes embed, reading the display
+// variant of the attachments the user can read. Other images are left
+// out, as are those of other sites and those past maxDocumentImageBytes
+// read by the loader.
+func (uc *useCase) images(ctx context.Context, userID string) func(string) []byte {
+	budget := maxDocumentImageBytes
+	return func(u string) []byte {
+		id, ok := domainAttachment.ReferenceID(u)
+		if !ok {
+			return nil
+		}
+		att, _, content, err := uc.attachments.Open(ctx, userID, id, domainAttachment.VariantDisplay)
+		if err != nil {
+			if !errors.Is(err, domainAttachment.ErrAttachmentNotFound) {
+				uc.logger.WarnContext(ctx, "loading image failed", slog.String("attachment_id", id), slog.Any("error", err))
+			}
+			return nil
+		}
+		defer content.Close()
+		if !att.IsImage() {
+			return nil
+		}
+		limit := min(maxImageBytes, budget)
+		data, err := io.ReadAll(io.LimitReader(content, int64(limit)+1))
+		if err != nil || len(data) > limit {
+			return nil
+		}
+		budget -= len(data)
+		return data
+	}
+}
+
+// Archive implements the bulk export use case
+func (uc *useCase) Archive(ctx context.Context, userID string, ids []string, format Format) (_ *Archive, err error) {
+	ctx, span := tracer.Start(ctx, "export.Archive")
+	defer func() { tracing.End(span, err) }()
+
+	if !slices.Contains(Formats, format) {
+		return nil, errs.Wrap(ErrUnknownFormat, "export.Archive")
+	}
+	archive := &Archive{uc: uc, userID: userID, format: format}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		n, _, err := uc.notes.Get(ctx, userID, id)
+		if err != nil {
+			return nil, errs.Wrap(err, "export.Archive")
+		}
+		archive.notes = append(archive.notes, n)
+	}
+	return archive, nil
+}
+
+// Archive is a zip archive of notes, loaded and ready to be written
+type Archive struct {
+	uc     *useCase
+	userID string
+	notes  []*domainNote.Note
+	format Format
+}
+
+// Write streams the archive to w. The attachments the notes embed and
+// the user can read come first, under attachments/, then a file per note
+// linking to them. Attachments the user cannot read keep their address.
+// PDF documents embed their images instead, and characters their fonts
+// lack are listed in a warnings.txt file closing the archive. An error
+// midway leaves a truncated archive in w.
+func (a *Archive) Write(ctx context.Context, w io.Writer) (err error) {
+	ctx, span := tracer.Start(ctx, "export.Archive.Write")
+	defer func() { tracing.End(span, err) }()
+
+	zw := zip.NewWriter(w)
+	var ids []string
+	if a.format != FormatPDF {
+		for _, n := range a.notes {
+			ids = append(ids, domainAttachment.References(n.Content)...)
+		}
+	}
+	slices.Sort(ids)
+
+	paths := make(map[string]string)
+	for _, id := range slices.Compact(ids) {
+		path, err := a.writeAttachment(ctx, zw, id)
+		if errors.Is(err, domainAttachment.ErrAttachmentNotFound) {
+			continue
+		}
+		if err != nil {
+			return errs.Wrap(err, "export.Archive")
+		}
+		paths[id] = path
+	}
+
+	opts := document.Options{
+		RewriteURL: func(u string) string {
+			if id, ok := domainAttachment.ReferenceID(u); ok && paths[id] != "" {
+				return paths[id]
+			}
+			return u
+		},
+		LoadImage: a.uc.images(ctx, a.userID),
+	}
+	names := make(map[string]bool, len(a.notes))
+	var report strings.Builder
+	for _, n := range a.notes {
+		data, missing, err := render(n, a.format, opts)
+		if err != nil {
+			return errs.Wrap(err, "export.Archive")
+		}
+		name := uniqueName(names, fileName(n.Title), a.format.extension())
+		for _, warning := range warnings(missing) {
+			fmt.Fprintf(&report, "%s: %s: %s\n", name, errs.CodeOf(warning), errs.ParamsOf(warning)["characters"])
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: n.UpdatedAt,
+		})
+		if err != nil {
+			return errs.Wrap(err, "export.Archive")
+		}
+		if _, err := f.Write(data); err != nil {
+			return errs.Wrap(err, "export.Archive")
+		}
+	}
+	if report.Len() > 0 {
+		f, err := zw.Create(uniqueName(names, "warnings", ".txt"))
+		if err != nil {
+			return errs.Wrap(err, "export.Archive")
+		}
+		if _, err := io.WriteString(f, report.String()); err != nil {
+			return errs.Wrap(err, "export.Archive")
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return errs.Wrap(err, "export.Archive")
+	}
+	return nil
+}
+
+// writeAttachment copies the content of an attachment into the archive
+// and returns the address notes link to it with
+func (a *Archive) writeAttachment(ctx context.Context, zw *zip.Writer, id string) (string, error) {
+	att, _, content, err := a.uc.attachments.Open(ctx, a.userID, id, "")
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	name := fileName(att.Filename)
+	method := zip.Deflate
+	if att.IsImage() {
+		// Images are compressed already
+		method = zip.Store
+	}
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     "attachments/" + id + "/" + name,
+		Method:   method,
+		Modified: att.CreatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		return "", err
+	}
+	return "attachments/" + id + "/" + url.PathEscape(name), nil
+}
+
+// render converts a note to a format, returning the characters a PDF
+// document prints as boxes. JSON exports are the stored delta, links
+// included.
+func render(n *domainNote.Note, format Format, opts document.Options) (data []byte, missing []rune, err error) {
+	switch format {
+	case FormatMarkdown:
+		return document.Markdown(n.Title, n.Content, opts), nil, nil
+	case FormatHTML:
+		return document.HTML(n.Title, n.Content, opts), nil, nil
+	case FormatText:
+		return document.Text(n.Title, n.Content), nil, nil
+	case FormatJSON:
+		return n.Content.JSON(), nil, nil
+	case FormatPDF:
+		data, missing := document.PDF(n.Title, []document.Section{{Title: n.Title, Content: n.Content}}, opts)
+		return data, missing, nil
+	}
+	return nil, nil, ErrUnknownFormat
+}
+
+// fileName turns a title into a file name that is safe on every common
+// file system, without extension
+func fileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.ToValidUTF8(title, ""))
+	for len(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// uniqueName returns name with ext, numbered when a file of the archive
+// has that name already, whatever its case
+func uniqueName(taken map[string]bool, name, ext string) string {
+	candidate := name + ext
+	for i := 2; taken[strings.ToLower(candidate)]; i++ {
+		candidate = name + " (" + strconv.Itoa(i) + ")" + ext
+	}
+	taken[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,99 @@
+package export_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/usecase/export"
+	"notes-app/backend/internal/usecase/notebook"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeNotebook is a notebook holding a nested notebook, then its notes
+type fakeNotebook struct {
+	notes []*domainNote.Note
+	pages int
+}
+
+func (f *fakeNotebook) Get(_ context.Context, _, id string) (*domainNotebook.Notebook, domainNotebook.Role, error) {
+	return &domainNotebook.Notebook{ID: id, Name: "Trip"}, domainNotebook.RoleOwner, nil
+}
+
+func (f *fakeNotebook) Contents(_ context.Context, _, _ string, offset, limit int) (*notebook.Contents, error) {
+	f.pages++
+	entries := make([]any, 0, len(f.notes)+1)
+	entries = append(entries, &domainNotebook.Notebook{ID: "nested"})
+	for _, n := range f.notes {
+		entries = append(entries, n)
+	}
+	contents := &notebook.Contents{Total: len(entries)}
+	for _, e := range entries[min(offset, len(entries)):min(offset+limit, len(entries))] {
+		switch e := e.(type) {
+		case *domainNotebook.Notebook:
+			contents.Notebooks = append(contents.Notebooks, e)
+		case *domainNote.Note:
+			contents.Notes = append(contents.Notes, e)
+		}
+	}
+	return contents, nil
+}
+
+type noNotes struct{}
+
+func (noNotes) Get(context.Context, string, string) (*domainNote.Note, domainNotebook.Role, error) {
+	return nil, "", domainNote.ErrNoteNotFound
+}
+
+type noAttachments struct{}
+
+func (noAttachments) Open(context.Context, string, string, string) (*domainAttachment.Attachment, *domainAttachment.Variant, io.ReadCloser, error) {
+	return nil, nil, nil, domainAttachment.ErrAttachmentNotFound
+}
+
+func notesOf(t *testing.T, count int) []*domainNote.Note {
+	t.Helper()
+	content, err := domainNote.ParseDelta([]byte(`{"ops":[{"insert":"Day\n"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := make([]*domainNote.Note, count)
+	for i := range notes {
+		notes[i] = &domainNote.Note{ID: strconv.Itoa(i), Title: "Day " + strconv.Itoa(i), Content: content}
+	}
+	return notes
+}
+
+func TestNotebook(t *testing.T) {
+	nb := &fakeNotebook{notes: notesOf(t, 250)}
+	uc := export.NewUseCase(noNotes{}, nb, noAttachments{}, discard)
+
+	file, err := uc.Notebook(context.Background(), "user", "trip")
+	if err != nil {
+		t.Fatalf("Notebook: %v", err)
+	}
+	if file.Name != "Trip.pdf" || len(file.Data) == 0 || nb.pages != 3 {
+		t.Errorf("file %q of %d bytes read in %d pages", file.Name, len(file.Data), nb.pages)
+	}
+
+	nb = &fakeNotebook{notes: notesOf(t, 501)}
+	uc = export.NewUseCase(noNotes{}, nb, noAttachments{}, discard)
+	if _, err := uc.Notebook(context.Background(), "user", "trip"); !errors.Is(err, export.ErrNotebookTooLarge) {
+		t.Errorf("501 notes: error %v, want ErrNotebookTooLarge", err)
+	}
+}
+
+func TestArchiveUnknownFormat(t *testing.T) {
+	nb := &fakeNotebook{}
+	uc := export.NewUseCase(noNotes{}, nb, noAttachments{}, discard)
+	if _, err := uc.Archive(context.Background(), "user", []string{"1"}, "docx"); !errors.Is(err, export.ErrUnknownFormat) {
+		t.Errorf("Archive(docx) error %v, want ErrUnknownFormat", err)
+	}
+}
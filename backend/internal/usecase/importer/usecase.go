@@ -0,0 +1,485 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/infrastructure/document"
+	"notes-app/backend/internal/infrastructure/tracing"
+	"notes-app/backend/internal/usecase/attachment"
+	"notes-app/backend/internal/usecase/note"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/importer")
+
+var (
+	// ErrUnsupportedFile reports a file in a format notes are not imported
+	// from
+	ErrUnsupportedFile = errs.New(errs.CodeImportUnsupported, "file format cannot be imported")
+	// ErrInvalidFile reports a file that cannot be read in its format
+	ErrInvalidFile = errs.New(errs.CodeImportInvalid, "file cannot be read")
+	// ErrTooManyNotes reports a document past the number of notes an
+	// import creates
+	ErrTooManyNotes = errs.New(errs.CodeImportTooManyNotes, "too many notes")
+	// ErrMissingFile reports a file a document refers to that the import
+	// does not hold
+	ErrMissingFile = errs.New(errs.CodeImportMissingFile, "referenced file is missing")
+)
+
+// maxTitleLength bounds the titles of imported notes, in characters, as
+// the note API does
+const maxTitleLength = 200
+
+// File is a file to import notes from
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Result reports the import of one document
+type Result struct {
+	// Name identifies the document, such as notes.zip/ideas/a.md for a
+	// file of an archive or export.enex/2 for the second note of an
+	// Evernote export
+	Name string
+	// Note is the note created, nil when the document failed with Err
+	Note *domainNote.Note
+	Err  error
+	// Warnings report what the note leaves out, such as images the import
+	// does not hold. Their target is the address the document used.
+	Warnings []error
+}
+
+// UseCase defines the import of notes from other applications
+type UseCase interface {
+	// Import creates a note in the notebook, or outside any when
+	// notebookID is empty, for every document of files: Markdown and HTML
+	// files, zip archives of them and Evernote exports. Files documents
+	// embed are uploaded as attachments and formats the editor does not
+	// know are dropped. A document that fails is reported in its result
+	// and does not stop the import. A notebook the user does not own fails
+	// the import before any note is created.
+	Import(ctx context.Context, userID, notebookID string, files []File) ([]Result, error)
+}
+
+// Notes creates the notes imported
+type Notes interface {
+	Create(ctx context.Context, userID string, input note.Input) (*domainNote.Note, error)
+}
+
+// Notebooks loads the notebooks a user can open
+type Notebooks interface {
+	Get(ctx context.Context, userID, id string) (*domainNotebook.Notebook, domainNotebook.Role, error)
+}
+
+// Attachments stores the files imported notes embed
+type Attachments interface {
+	Upload(ctx context.Context, userID, filename string, content io.Reader) (*domainAttachment.Attachment, attachment.Link, error)
+}
+
+// Config holds the limits of imports
+type Config struct {
+	// MaxBytes is the most the files of a zip archive may hold once
+	// uncompressed
+	MaxBytes int64
+	// MaxNotes is the most notes an import creates
+	MaxNotes int
+	// AttachmentURL returns the address notes embed an attachment with
+	AttachmentURL func(id string) string
+}
+
+type useCase struct {
+	notes       Notes
+	notebooks   Notebooks
+	attachments Attachments
+	config      Config
+	logger      *slog.Logger
+}
+
+// NewUseCase creates a new instance of the import use case
+func NewUseCase(notes Notes, notebooks Notebooks, attachments Attachments, config Config, logger *slog.Logger) UseCase {
+	return &useCase{
+		notes:       notes,
+		notebooks:   notebooks,
+		attachments: attachments,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+// Import implements the note import use case
+func (uc *useCase) Import(ctx context.Context, userID, notebookID string, files []File) (_ []Result, err error) {
+	ctx, span := tracer.Start(ctx, "importer.Import")
+	defer func() { tracing.End(span, err) }()
+
+	if err := uc.checkNotebook(ctx, userID, notebookID); err != nil {
+		return nil, errs.Wrap(err, "importer.Import")
+	}
+	var results []Result
+	created := 0
+	for _, f := range files {
+		sources, err := uc.open(f)
+		if err != nil {
+			results = append(results, Result{Name: f.Name, Err: err})
+			continue
+		}
+		for _, src := range sources {
+			if created >= uc.config.MaxNotes {
+				results = append(results, Result{
+					Name: src.name,
+					Err:  ErrTooManyNotes.WithParam("limit", strconv.Itoa(uc.config.MaxNotes)),
+				})
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, errs.Wrap(err, "importer.Import")
+			}
+			result := uc.create(ctx, userID, notebookID, src)
+			if result.Note != nil {
+				created++
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// checkNotebook confirms that the notebook notes are imported into, if
+// any, belongs to the user
+func (uc *useCase) checkNotebook(ctx context.Context, userID, notebookID string) error {
+	if notebookID == "" {
+		return nil
+	}
+	_, role, err := uc.notebooks.Get(ctx, userID, notebookID)
+	if errors.Is(err, domainNotebook.ErrNotebookNotFound) || (err == nil && role != domainNotebook.RoleOwner) {
+		return note.ErrInvalidNotebook
+	}
+	return err
+}
+
+// source is a document to create a note from
+type source struct {
+	name string
+	// title is the title of the note when the document has none
+	title string
+	// archive holds the files the document may refer to, nil outside zip
+	// archives, and dir is where the document lies in it
+	archive *archive
+	dir     string
+	parse   func(r *resolver) document.Document
+}
+
+// archive is the content of a zip archive
+type archive struct {
+	files     map[string][]byte
+	documents map[string]bool
+	// uploaded holds the address of the files uploaded so far, "" for
+	// those that failed
+	uploaded map[string]string
+}
+
+// open returns the documents of a file
+func (uc *useCase) open(f File) ([]source, error) {
+	ext := strings.ToLower(path.Ext(f.Name))
+	switch {
+	case isDocument(ext):
+		return []source{documentSource(f.Name, f.Data, nil, "")}, nil
+	case ext == ".enex":
+		return enexSources(f)
+	case ext == ".zip":
+		return uc.openArchive(f)
+	}
+	return nil, ErrUnsupportedFile.WithParam("name", f.Name)
+}
+
+// isDocument reports whether files with the extension are Markdown or
+// HTML documents
+func isDocument(ext string) bool {
+	switch ext {
+	case ".md", ".markdown", ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+// documentSource returns a Markdown or HTML document, named after its
+// file when it has no title
+func documentSource(name string, data []byte, a *archive, dir string) source {
+	markdown := strings.HasPrefix(strings.ToLower(path.Ext(name)), ".m")
+	return source{
+		name:    name,
+		title:   strings.TrimSuffix(path.Base(name), path.Ext(name)),
+		archive: a,
+		dir:     dir,
+		parse: func(r *resolver) document.Document {
+			opts := document.ParseOptions{Resolve: r.resolve}
+			if markdown {
+				return document.ParseMarkdown(data, opts)
+			}
+			return document.ParseHTML(data, opts)
+		},
+	}
+}
+
+// enexSources returns the notes of an Evernote export
+func enexSources(f File) ([]source, error) {
+	notes, err := document.ParseENEX(bytes.NewReader(f.Data))
+	if errors.Is(err, document.ErrNotENEX) || (err == nil && len(notes) == 0) {
+		return nil, ErrInvalidFile.WithParam("name", f.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]source, len(notes))
+	for i, n := range notes {
+		sources[i] = source{
+			name: f.Name + "/" + strconv.Itoa(i+1),
+			parse: func(r *resolver) document.Document {
+				return n.Document(document.ParseOptions{Resolve: r.resolve}, r.attach)
+			},
+		}
+	}
+	return sources, nil
+}
+
+// openArchive returns the documents of a zip archive, in the order of
+// their paths. Its other files are only imported when a document refers
+// to them.
+func (uc *useCase) openArchive(f File) ([]source, error) {
+	zr, err := zip.NewReader(bytes.NewReader(f.Data), int64(len(f.Data)))
+	if err != nil {
+		return nil, ErrInvalidFile.WithParam("name", f.Name)
+	}
+	a := &archive{
+		files:     make(map[string][]byte),
+		documents: make(map[string]bool),
+		uploaded:  make(map[string]string),
+	}
+	var documents []string
+	remaining := uc.config.MaxBytes
+	for _, zf := range zr.File {
+		name, ok := entryPath(zf.Name)
+		if !ok || zf.FileInfo().IsDir() {
+			continue
+		}
+		data, err := readEntry(zf, remaining)
+		if errors.Is(err, errEntryTooLarge) {
+			return nil, errs.ErrPayloadTooLarge.WithParam("limit", strconv.FormatInt(uc.config.MaxBytes, 10))
+		}
+		if err != nil {
+			return nil, ErrInvalidFile.WithParam("name", f.Name)
+		}
+		remaining -= int64(len(data))
+		a.files[name] = data
+		if isDocument(strings.ToLower(path.Ext(name))) && !a.documents[name] {
+			a.documents[name] = true
+			documents = append(documents, name)
+		}
+	}
+	if len(documents) == 0 {
+		return nil, ErrUnsupportedFile.WithParam("name", f.Name)
+	}
+
+	slices.Sort(documents)
+	sources := make([]source, len(documents))
+	for i, name := range documents {
+		sources[i] = documentSource(f.Name+"/"+name, a.files[name], a, path.Dir(name))
+	}
+	return sources, nil
+}
+
+// entryPath returns the path of a zip entry relative to the root of the
+// archive, and false for the metadata files macOS adds
+func entryPath(name string) (string, bool) {
+	name = path.Clean("/" + strings.ReplaceAll(name, `\`, "/"))[1:]
+	base := path.Base(name)
+	if name == "" || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, "._") || base == ".DS_Store" {
+		return "", false
+	}
+	return name, true
+}
+
+// errEntryTooLarge reports a zip entry larger than what is left of the
+// limit
+var errEntryTooLarge = errors.New("zip entry too large")
+
+// readEntry reads a zip entry of at most limit bytes. Sizes in the header
+// are not trusted.
+func readEntry(zf *zip.File, limit int64) ([]byte, error) {
+	if zf.UncompressedSize64 > uint64(max(limit, 0)) {
+		return nil, errEntryTooLarge
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errEntryTooLarge
+	}
+	return data, nil
+}
+
+// create creates the note of a document. Failures are reported in the
+// result.
+func (uc *useCase) create(ctx context.Context, userID, notebookID string, src source) Result {
+	r := &resolver{uc: uc, ctx: ctx, userID: userID, archive: src.archive, dir: src.dir}
+	doc := src.parse(r)
+	result := Result{Name: src.name, Warnings: r.warnings}
+	if r.err != nil {
+		uc.logger.WarnContext(ctx, "uploading imported file failed",
+			slog.String("document", src.name), slog.Any("error", r.err))
+		result.Err = r.err
+		return result
+	}
+
+	title := doc.Title
+	if title == "" {
+		title = src.title
+	}
+	n, err := uc.notes.Create(ctx, userID, note.Input{
+		Title:      cleanTitle(title),
+		Content:    domainNote.Sanitize(doc.Content),
+		NotebookID: notebookID,
+	})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Note = n
+	return result
+}
+
+// cleanTitle returns title on a single line, cut to maxTitleLength
+func cleanTitle(title string) string {
+	title = strings.Join(strings.Fields(strings.ToValidUTF8(title, "")), " ")
+	if utf8.RuneCountInString(title) <= maxTitleLength {
+		return title
+	}
+	return strings.TrimSpace(string([]rune(title)[:maxTitleLength]))
+}
+
+// resolver maps the addresses a document refers to to those its note
+// keeps, uploading the files of the import it embeds
+type resolver struct {
+	uc      *useCase
+	ctx     context.Context
+	userID  string
+	archive *archive
+	dir     string
+
+	warnings []error
+	// err is the first upload that failed for another reason than the
+	// file itself
+	err error
+}
+
+// resolve implements document.ParseOptions.Resolve. Web and mail
+// addresses are kept, data URIs and files of the archive uploaded and
+// links to other documents or anchors dropped.
+func (r *resolver) resolve(ref string) string {
+	if r.err != nil || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	if len(ref) > 5 && strings.EqualFold(ref[:5], "data:") {
+		data, ok := decodeDataURI(ref)
+		if !ok {
+			return ""
+		}
+		return r.upload("image", data, "data:")
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if u.Scheme != "" && domainNote.SafeURL(ref) {
+			return ref
+		}
+		return ""
+	}
+	if u.Path == "" {
+		return ""
+	}
+
+	name := path.Clean("/" + path.Join(r.dir, u.Path))[1:]
+	if r.archive != nil {
+		if r.archive.documents[name] {
+			return ""
+		}
+		if data, ok := r.archive.files[name]; ok {
+			address, uploaded := r.archive.uploaded[name]
+			if !uploaded {
+				address = r.upload(path.Base(name), data, ref)
+				r.archive.uploaded[name] = address
+			}
+			return address
+		}
+	}
+	r.warnings = append(r.warnings, ErrMissingFile.WithParam("ref", ref).WithTarget(ref))
+	return ""
+}
+
+// attach uploads a file an Evernote note embeds
+func (r *resolver) attach(res document.Resource) string {
+	if r.err != nil {
+		return ""
+	}
+	name := res.Name
+	if name == "" {
+		name = "attachment"
+	}
+	return r.upload(name, res.Data, name)
+}
+
+// upload stores a file and returns the address to embed it with, or ""
+// when it cannot be attached
+func (r *resolver) upload(name string, data []byte, ref string) string {
+	a, _, err := r.uc.attachments.Upload(r.ctx, r.userID, name, bytes.NewReader(data))
+	if err != nil && errs.CodeOf(err) == errs.CodeInternal {
+		r.err = err
+		return ""
+	}
+	if err != nil {
+		r.warnings = append(r.warnings, errs.Wrap(err, "importer.upload").WithTarget(ref))
+		return ""
+	}
+	return r.uc.config.AttachmentURL(a.ID)
+}
+
+// decodeDataURI returns the content of a data URI
+func decodeDataURI(ref string) ([]byte, bool) {
+	meta, data, ok := strings.Cut(ref[len("data:"):], ",")
+	if !ok {
+		return nil, false
+	}
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+		return decoded, err == nil
+	}
+	decoded, err := url.PathUnescape(data)
+	return []byte(decoded), err == nil
+}
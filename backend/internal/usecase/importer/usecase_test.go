@@ -0,0 +1,126 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	domainAttachment "notes-app/backend/internal/domain/attachment"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/usecase/attachment"
+	"notes-app/backend/internal/usecase/importer"
+	"notes-app/backend/internal/usecase/note"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// maxContent is the largest note fakeNotes accepts, in bytes of plain
+// text
+const maxContent = 100
+
+// errContentTooLong is what fakeNotes fails oversized notes with
+var errContentTooLong = errs.Validation(
+	errs.New(errs.CodeFieldTooLong, "too long").WithTarget("/content"),
+)
+
+// fakeNotes creates notes, rejecting those over maxContent
+type fakeNotes struct {
+	created []note.Input
+}
+
+func (n *fakeNotes) Create(_ context.Context, userID string, input note.Input) (*domainNote.Note, error) {
+	if len(input.Content.PlainText()) > maxContent {
+		return nil, errs.Wrap(errContentTooLong, "note.Create")
+	}
+	n.created = append(n.created, input)
+	return &domainNote.Note{OwnerID: userID, Title: input.Title, Content: input.Content, NotebookID: input.NotebookID}, nil
+}
+
+// fakeNotebooks holds notebooks by ID with the role of the caller
+type fakeNotebooks map[string]domainNotebook.Role
+
+func (n fakeNotebooks) Get(_ context.Context, _, id string) (*domainNotebook.Notebook, domainNotebook.Role, error) {
+	role, ok := n[id]
+	if !ok {
+		return nil, "", domainNotebook.ErrNotebookNotFound
+	}
+	return &domainNotebook.Notebook{ID: id}, role, nil
+}
+
+type noAttachments struct{}
+
+func (noAttachments) Upload(context.Context, string, string, io.Reader) (*domainAttachment.Attachment, attachment.Link, error) {
+	return nil, attachment.Link{}, errors.New("no uploads")
+}
+
+func newUseCase(notes *fakeNotes) importer.UseCase {
+	notebooks := fakeNotebooks{"own": domainNotebook.RoleOwner, "shared": domainNotebook.RoleEditor}
+	return importer.NewUseCase(notes, notebooks, noAttachments{}, importer.Config{
+		MaxBytes:      1 << 20,
+		MaxNotes:      10,
+		AttachmentURL: func(id string) string { return "/api/v1/attachments/" + id + "/content" },
+	}, discard)
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImportReportsFailedNotes(t *testing.T) {
+	notes := &fakeNotes{}
+	archive := zipOf(t, map[string]string{
+		"a.md":   "# A\n\nshort",
+		"big.md": "# Big\n\n" + strings.Repeat("word ", maxContent),
+		"c.html": "<h1>C</h1><p>short</p>",
+	})
+
+	results, err := newUseCase(notes).Import(context.Background(), "user", "own", []importer.File{{Name: "notes.zip", Data: archive}})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(results) != 3 || len(notes.created) != 2 {
+		t.Fatalf("%d results, %d notes created, want 3 and 2", len(results), len(notes.created))
+	}
+	for _, r := range results {
+		switch r.Name {
+		case "notes.zip/big.md":
+			if !errors.Is(r.Err, errContentTooLong) || r.Note != nil {
+				t.Errorf("oversized note: %+v", r)
+			}
+		default:
+			if r.Err != nil || r.Note == nil || r.Note.NotebookID != "own" {
+				t.Errorf("%s: %+v", r.Name, r)
+			}
+		}
+	}
+}
+
+func TestImportChecksNotebook(t *testing.T) {
+	for _, id := range []string{"unknown", "shared"} {
+		notes := &fakeNotes{}
+		_, err := newUseCase(notes).Import(context.Background(), "user", id, []importer.File{{Name: "a.md", Data: []byte("text")}})
+		if !errors.Is(err, note.ErrInvalidNotebook) || len(notes.created) != 0 {
+			t.Errorf("notebook %q: error %v, %d notes created", id, err, len(notes.created))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package note
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	"notes-app/backend/internal/infrastructure/tracing"
+)
+
+// purgeBatchSize bounds how many notes one purge statement deletes, so a
+// large backlog never holds locks for long
+const purgeBatchSize = 500
+
+// Purger permanently deletes notes that stayed in the trash longer than
+// the retention period
+type Purger struct {
+	noteRepo  domainNote.Repository
+	retention time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// NewPurger creates a purger for notes trashed more than retention ago
+func NewPurger(notes domainNote.Repository, retention time.Duration, logger *slog.Logger) *Purger {
+	return &Purger{
+		noteRepo:  notes,
+		retention: retention,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Purge deletes every expired note and returns how many it deleted
+func (p *Purger) Purge(ctx context.Context) (purged int, err error) {
+	ctx, span := tracer.Start(ctx, "note.Purge")
+	defer func() { tracing.End(span, err) }()
+
+	before := p.now().UTC().Add(-p.retention)
+	for {
+		n, err := p.noteRepo.PurgeTrashed(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, errs.Wrap(err, "note.Purge")
+		}
+		purged += n
+		if n < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Run purges once right away and then every interval until ctx is done.
+// Failures are logged and retried at the next tick.
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.Purge(ctx)
+		if err != nil {
+			p.logger.ErrorContext(ctx, "purging trash failed", slog.Any("error", err))
+		} else if purged > 0 {
+			p.logger.InfoContext(ctx, "trash purged", slog.Int("notes", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,615 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/domain/tx"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/note")
+
+var (
+	// ErrInvalidNotebook reports a notebook the caller does not own
+	ErrInvalidNotebook = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "unknown notebook").
+			WithTarget("/notebookId").
+			WithParam("field", "notebookId"),
+	)
+	// ErrInvalidPrevious reports a note to place after that the caller
+	// cannot open or never placed by hand
+	ErrInvalidPrevious = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "the previous note has no position").
+			WithTarget("/previousId").
+			WithParam("field", "previousId"),
+	)
+	// ErrInvalidNext reports a note to place before that the caller cannot
+	// open, never placed by hand, or that does not come after the previous
+	// note
+	ErrInvalidNext = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "the next note has no position or does not follow the previous one").
+			WithTarget("/nextId").
+			WithParam("field", "nextId"),
+	)
+)
+
+// UseCase defines the note operations of a user. Notes are accessible to
+// their owner and to collaborators of a notebook holding them; others get
+// not found. Operations reserved to the owner fail with errs.ErrForbidden
+// for collaborators.
+type UseCase interface {
+	// Create stores a new note
+	Create(ctx context.Context, userID string, input Input) (*domainNote.Note, error)
+
+	// Get returns a note, with how the caller files it, and the caller's
+	// role on it
+	Get(ctx context.Context, userID, id string) (*domainNote.Note, domainNotebook.Role, error)
+
+	// Update replaces the title and content of a note, and its tags when
+	// input.TagIDs is not nil. Editors may change everything but the tags.
+	Update(ctx context.Context, userID, id string, input Input) (*domainNote.Note, error)
+
+	// Delete moves a note to the trash
+	Delete(ctx context.Context, userID, id string) error
+
+	// Trash returns one page of the user's trashed notes, most recently
+	// trashed first, and the total number of them
+	Trash(ctx context.Context, userID string, offset, limit int) ([]*domainNote.Note, int, error)
+
+	// Restore takes a note out of the trash. A note whose notebook was
+	// deleted meanwhile comes back outside any notebook.
+	Restore(ctx context.Context, userID, id string) (*domainNote.Note, error)
+
+	// DeletePermanently removes a note in the trash for good
+	DeletePermanently(ctx context.Context, userID, id string) error
+
+	// List returns one page of the user's notes matching filter and the
+	// total number of matches. The user's pins, archive and order apply.
+	List(ctx context.Context, userID string, filter domainNote.ListFilter) ([]*domainNote.Note, int, error)
+
+	// SetTags replaces the tags applied to a note
+	SetTags(ctx context.Context, userID, id string, tagIDs []string) (*domainNote.Note, error)
+
+	// Move puts a note in one of the user's notebooks, or takes it out of
+	// any notebook when notebookID is empty
+	Move(ctx context.Context, userID, id, notebookID string) (*domainNote.Note, error)
+
+	// UpdateState changes how the user files a note they can open
+	UpdateState(ctx context.Context, userID, id string, input StateInput) (*domainNote.Note, error)
+
+	// Reorder places a note between two others in the user's manual
+	// order. previousID or nextID may be empty to place the note first or
+	// after every note placed so far.
+	Reorder(ctx context.Context, userID, id, previousID, nextID string) (*domainNote.Note, error)
+}
+
+// StateInput holds the flags of a note to change; nil fields are left
+// unchanged
+type StateInput struct {
+	Pinned    *bool
+	Archived  *bool
+	Favourite *bool
+}
+
+// Input holds the editable fields of a note
+type Input struct {
+	Title   string
+	Content domainNote.Delta
+	// TagIDs lists tags of the user to apply. A nil slice leaves the tags
+	// of an existing note unchanged.
+	TagIDs []string
+	// NotebookID places a new note in a notebook of the user. Updates
+	// ignore it; notes change notebooks with Move.
+	NotebookID string
+}
+
+// Metrics records note-related domain events
+type Metrics interface {
+	ObserveNoteSave(bytes int)
+}
+
+// Attachments keeps track of the attachments note contents embed
+type Attachments interface {
+	Track(ctx context.Context, userID, noteID string, content domainNote.Delta) error
+}
+
+// Auditor records audit events
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event) error
+}
+
+type useCase struct {
+	noteRepo     domainNote.Repository
+	tagRepo      domainTag.Repository
+	notebookRepo domainNotebook.Repository
+	attachments  Attachments
+	tx           tx.Manager
+	auditor      Auditor
+	metrics      Metrics
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// NewUseCase creates a new instance of the note use case. Note changes,
+// the attachments they embed and their audit events are written in one
+// transaction.
+func NewUseCase(notes domainNote.Repository, tags domainTag.Repository, notebooks domainNotebook.Repository, attachments Attachments, txm tx.Manager, auditor Auditor, metrics Metrics, logger *slog.Logger) UseCase {
+	return &useCase{
+		noteRepo:     notes,
+		tagRepo:      tags,
+		notebookRepo: notebooks,
+		attachments:  attachments,
+		tx:           txm,
+		auditor:      auditor,
+		metrics:      metrics,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Create implements the note creation use case
+func (uc *useCase) Create(ctx context.Context, userID string, input Input) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Create")
+	defer func() { tracing.End(span, err) }()
+
+	note := domainNote.NewNote(userID, input.Title, input.Content, uc.now().UTC())
+	note.ID = uuid.NewString()
+	note.NotebookID = input.NotebookID
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.checkNotebook(ctx, userID, note.NotebookID); err != nil {
+			return err
+		}
+		var err error
+		if note.TagIDs, err = uc.checkTags(ctx, userID, input.TagIDs); err != nil {
+			return err
+		}
+		if err := uc.noteRepo.Create(ctx, note); err != nil {
+			return err
+		}
+		if err := uc.attachments.Track(ctx, userID, note.ID, note.Content); err != nil {
+			return err
+		}
+		return uc.record(ctx, audit.ActionNoteCreated, note)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.Create")
+	}
+
+	uc.metrics.ObserveNoteSave(note.Size())
+	return note, nil
+}
+
+// Get implements the note retrieval use case
+func (uc *useCase) Get(ctx context.Context, userID, id string) (_ *domainNote.Note, _ domainNotebook.Role, err error) {
+	ctx, span := tracer.Start(ctx, "note.Get")
+	defer func() { tracing.End(span, err) }()
+
+	note, role, err := uc.getNote(ctx, userID, id)
+	if err != nil {
+		return nil, "", errs.Wrap(err, "note.Get")
+	}
+	return note, role, nil
+}
+
+// Update implements the note editing use case
+func (uc *useCase) Update(ctx context.Context, userID, id string, input Input) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Update")
+	defer func() { tracing.End(span, err) }()
+
+	var note *domainNote.Note
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var role domainNotebook.Role
+		var err error
+		if note, role, err = uc.getNote(ctx, userID, id); err != nil {
+			return err
+		}
+		if !role.CanEdit() || (input.TagIDs != nil && role != domainNotebook.RoleOwner) {
+			return errs.ErrForbidden
+		}
+		note.Edit(input.Title, input.Content, uc.now().UTC())
+		if err := uc.noteRepo.Update(ctx, note); err != nil {
+			return err
+		}
+		if err := uc.attachments.Track(ctx, userID, note.ID, note.Content); err != nil {
+			return err
+		}
+		if input.TagIDs != nil {
+			if note.TagIDs, err = uc.checkTags(ctx, userID, input.TagIDs); err != nil {
+				return err
+			}
+			if err := uc.noteRepo.SetTags(ctx, note.ID, note.TagIDs); err != nil {
+				return err
+			}
+		}
+		return uc.record(ctx, audit.ActionNoteUpdated, note)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.Update")
+	}
+
+	uc.metrics.ObserveNoteSave(note.Size())
+	return note, nil
+}
+
+// Delete implements the note deletion use case
+func (uc *useCase) Delete(ctx context.Context, userID, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "note.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		note, err := uc.ownNote(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := uc.noteRepo.Trash(ctx, note.ID, uc.now().UTC()); err != nil {
+			return err
+		}
+		return uc.record(ctx, audit.ActionNoteTrashed, note)
+	})
+	if err != nil {
+		return errs.Wrap(err, "note.Delete")
+	}
+	return nil
+}
+
+// Trash implements the trash listing use case
+func (uc *useCase) Trash(ctx context.Context, userID string, offset, limit int) (_ []*domainNote.Note, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "note.Trash")
+	defer func() { tracing.End(span, err) }()
+
+	notes, total, err := uc.noteRepo.List(ctx, domainNote.ListFilter{
+		OwnerID: userID,
+		Trashed: true,
+		Offset:  offset,
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "note.Trash")
+	}
+	return notes, total, nil
+}
+
+// Restore implements the note restore use case
+func (uc *useCase) Restore(ctx context.Context, userID, id string) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Restore")
+	defer func() { tracing.End(span, err) }()
+
+	var note *domainNote.Note
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if note, err = uc.trashedNote(ctx, userID, id); err != nil {
+			return err
+		}
+		if err := uc.noteRepo.Restore(ctx, note.ID); err != nil {
+			return err
+		}
+		note.DeletedAt = nil
+
+		if note.NotebookID != "" {
+			_, err := uc.notebookRepo.GetByID(ctx, note.NotebookID)
+			if errors.Is(err, errs.ErrNotFound) {
+				err = uc.noteRepo.SetNotebook(ctx, note.ID, "")
+				note.NotebookID = ""
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return uc.record(ctx, audit.ActionNoteRestored, note)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.Restore")
+	}
+	return note, nil
+}
+
+// DeletePermanently implements the permanent note deletion use case
+func (uc *useCase) DeletePermanently(ctx context.Context, userID, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "note.DeletePermanently")
+	defer func() { tracing.End(span, err) }()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		note, err := uc.trashedNote(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := uc.noteRepo.Delete(ctx, note.ID); err != nil {
+			return err
+		}
+		return uc.record(ctx, audit.ActionNoteDeleted, note)
+	})
+	if err != nil {
+		return errs.Wrap(err, "note.DeletePermanently")
+	}
+	return nil
+}
+
+// List implements the note listing use case
+func (uc *useCase) List(ctx context.Context, userID string, filter domainNote.ListFilter) (_ []*domainNote.Note, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "note.List")
+	defer func() { tracing.End(span, err) }()
+
+	filter.OwnerID = userID
+	filter.ViewerID = userID
+	notes, total, err := uc.noteRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, errs.Wrap(err, "note.List")
+	}
+	return notes, total, nil
+}
+
+// SetTags implements the note tagging use case
+func (uc *useCase) SetTags(ctx context.Context, userID, id string, tagIDs []string) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.SetTags")
+	defer func() { tracing.End(span, err) }()
+
+	var note *domainNote.Note
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if note, err = uc.ownNote(ctx, userID, id); err != nil {
+			return err
+		}
+		if note.TagIDs, err = uc.checkTags(ctx, userID, tagIDs); err != nil {
+			return err
+		}
+		return uc.noteRepo.SetTags(ctx, note.ID, note.TagIDs)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.SetTags")
+	}
+	return note, nil
+}
+
+// Move implements the note move use case
+func (uc *useCase) Move(ctx context.Context, userID, id, notebookID string) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Move")
+	defer func() { tracing.End(span, err) }()
+
+	var note *domainNote.Note
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if note, err = uc.ownNote(ctx, userID, id); err != nil {
+			return err
+		}
+		if err := uc.checkNotebook(ctx, userID, notebookID); err != nil {
+			return err
+		}
+		if err := uc.noteRepo.SetNotebook(ctx, note.ID, notebookID); err != nil {
+			return err
+		}
+		note.NotebookID = notebookID
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.Move")
+	}
+	return note, nil
+}
+
+// UpdateState implements the note filing use case
+func (uc *useCase) UpdateState(ctx context.Context, userID, id string, input StateInput) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.UpdateState")
+	defer func() { tracing.End(span, err) }()
+
+	var note *domainNote.Note
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if note, _, err = uc.getNote(ctx, userID, id); err != nil {
+			return err
+		}
+		if input.Pinned != nil {
+			note.State.Pinned = *input.Pinned
+		}
+		if input.Archived != nil {
+			note.State.Archived = *input.Archived
+		}
+		if input.Favourite != nil {
+			note.State.Favourite = *input.Favourite
+		}
+		return uc.noteRepo.SetState(ctx, note.ID, userID, note.State)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.UpdateState")
+	}
+	return note, nil
+}
+
+// Reorder implements the manual note ordering use case
+func (uc *useCase) Reorder(ctx context.Context, userID, id, previousID, nextID string) (_ *domainNote.Note, err error) {
+	ctx, span := tracer.Start(ctx, "note.Reorder")
+	defer func() { tracing.End(span, err) }()
+
+	var note *domainNote.Note
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if note, _, err = uc.getNote(ctx, userID, id); err != nil {
+			return err
+		}
+		after, err := uc.position(ctx, userID, previousID, id, ErrInvalidPrevious)
+		if err != nil {
+			return err
+		}
+		if previousID != "" && after == "" {
+			return ErrInvalidPrevious
+		}
+		before, err := uc.position(ctx, userID, nextID, id, ErrInvalidNext)
+		if err != nil {
+			return err
+		}
+		if nextID != "" && before == "" {
+			return ErrInvalidNext
+		}
+
+		position, ok := domainNote.KeyBetween(after, before)
+		if !ok {
+			return ErrInvalidNext
+		}
+		note.State.Position = position
+		return uc.noteRepo.SetState(ctx, note.ID, userID, note.State)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "note.Reorder")
+	}
+	return note, nil
+}
+
+// position returns where the user placed a neighbouring note, empty when
+// there is no neighbour or it was never placed. A neighbour the user
+// cannot open, or the moved note itself, is reported as invalid.
+func (uc *useCase) position(ctx context.Context, userID, neighbourID, movedID string, invalid error) (string, error) {
+	if neighbourID == "" {
+		return "", nil
+	}
+	if neighbourID == movedID {
+		return "", invalid
+	}
+	neighbour, _, err := uc.getNote(ctx, userID, neighbourID)
+	if errors.Is(err, domainNote.ErrNoteNotFound) {
+		return "", invalid
+	}
+	if err != nil {
+		return "", err
+	}
+	return neighbour.State.Position, nil
+}
+
+// getNote loads a live note the user can access, with how the user files
+// it, and the user's role on it, inherited from the notebooks holding it.
+// A missing or trashed note, or one the user cannot access, is reported as
+// ErrNoteNotFound.
+func (uc *useCase) getNote(ctx context.Context, userID, id string) (*domainNote.Note, domainNotebook.Role, error) {
+	if uuid.Validate(id) != nil {
+		return nil, "", domainNote.ErrNoteNotFound
+	}
+	note, err := uc.noteRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && note.Trashed()) {
+		return nil, "", domainNote.ErrNoteNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	role := domainNotebook.RoleOwner
+	if note.OwnerID != userID {
+		if note.NotebookID == "" {
+			return nil, "", domainNote.ErrNoteNotFound
+		}
+		if role, err = uc.notebookRepo.Access(ctx, note.NotebookID, userID); err != nil {
+			return nil, "", err
+		}
+		if role == "" {
+			return nil, "", domainNote.ErrNoteNotFound
+		}
+	}
+
+	if note.State, err = uc.noteRepo.GetState(ctx, note.ID, userID); err != nil {
+		return nil, "", err
+	}
+	return note, role, nil
+}
+
+// ownNote loads a note the user owns. Collaborators get
+// errs.ErrForbidden.
+func (uc *useCase) ownNote(ctx context.Context, userID, id string) (*domainNote.Note, error) {
+	note, role, err := uc.getNote(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if role != domainNotebook.RoleOwner {
+		return nil, errs.ErrForbidden
+	}
+	return note, nil
+}
+
+// trashedNote loads a note the user owns from their trash. Live notes
+// are reported as ErrNoteNotFound, as the trash is private to the owner.
+func (uc *useCase) trashedNote(ctx context.Context, userID, id string) (*domainNote.Note, error) {
+	if uuid.Validate(id) != nil {
+		return nil, domainNote.ErrNoteNotFound
+	}
+	note, err := uc.noteRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && (note.OwnerID != userID || !note.Trashed())) {
+		return nil, domainNote.ErrNoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if note.State, err = uc.noteRepo.GetState(ctx, note.ID, userID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// checkNotebook confirms that a notebook, if any, belongs to the user
+func (uc *useCase) checkNotebook(ctx context.Context, userID, notebookID string) error {
+	if notebookID == "" {
+		return nil
+	}
+	if uuid.Validate(notebookID) != nil {
+		return ErrInvalidNotebook
+	}
+	notebook, err := uc.notebookRepo.GetByID(ctx, notebookID)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && notebook.OwnerID != userID) {
+		return ErrInvalidNotebook
+	}
+	return err
+}
+
+// checkTags confirms that every tag exists and belongs to the user, and
+// returns the IDs sorted without duplicates. Unknown tags are reported
+// as invalid fields at /tagIds/<index>.
+func (uc *useCase) checkTags(ctx context.Context, userID string, tagIDs []string) ([]string, error) {
+	var details []*errs.Error
+	seen := make(map[string]bool, len(tagIDs))
+	for i, id := range tagIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if uuid.Validate(id) == nil {
+			tag, err := uc.tagRepo.GetByID(ctx, id)
+			if err == nil && tag.OwnerID == userID {
+				continue
+			}
+			if err != nil && !errors.Is(err, errs.ErrNotFound) {
+				return nil, err
+			}
+		}
+		details = append(details, errs.New(errs.CodeFieldInvalid, "unknown tag").
+			WithTarget("/tagIds/"+strconv.Itoa(i)).
+			WithParam("field", "tagIds"))
+	}
+	if len(details) > 0 {
+		return nil, errs.Validation(details...)
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// record audits action on note
+func (uc *useCase) record(ctx context.Context, action audit.Action, note *domainNote.Note) error {
+	return uc.auditor.Record(ctx, audit.Event{
+		Action:     action,
+		TargetType: audit.TargetNote,
+		TargetID:   note.ID,
+	})
+}
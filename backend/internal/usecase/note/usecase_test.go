@@ -0,0 +1,98 @@
+package note_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	domainNote "notes-app/backend/internal/domain/note"
+	"notes-app/backend/internal/infrastructure/repository/memory"
+	auditUseCase "notes-app/backend/internal/usecase/audit"
+	"notes-app/backend/internal/usecase/note"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type nopAttachments struct{}
+
+func (nopAttachments) Track(context.Context, string, string, domainNote.Delta) error { return nil }
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveNoteSave(int) {}
+
+func newUseCase() note.UseCase {
+	notes := memory.NewNoteRepository()
+	recorder := auditUseCase.NewRecorder(memory.NewAuditRepository(), discard)
+	return note.NewUseCase(notes, memory.NewTagRepository(), memory.NewNotebookRepository(), nopAttachments{}, memory.NewTxManager(), recorder, nopMetrics{}, discard)
+}
+
+func TestReorder(t *testing.T) {
+	ctx := context.Background()
+	uc := newUseCase()
+	create := func(t *testing.T, userID, title string) string {
+		t.Helper()
+		n, err := uc.Create(ctx, userID, note.Input{Title: title, Content: domainNote.Delta{}})
+		if err != nil {
+			t.Fatalf("Create(%s): %v", title, err)
+		}
+		return n.ID
+	}
+	first, second, moved := create(t, "jane", "first"), create(t, "jane", "second"), create(t, "jane", "moved")
+	unplaced := create(t, "jane", "unplaced")
+	other := create(t, "john", "other")
+	if _, err := uc.Reorder(ctx, "jane", first, "", ""); err != nil {
+		t.Fatalf("place first: %v", err)
+	}
+	if _, err := uc.Reorder(ctx, "jane", second, first, ""); err != nil {
+		t.Fatalf("place second: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		previous, next string
+		wantErr        error
+	}{
+		{name: "between placed notes", previous: first, next: second},
+		{name: "after the last note", previous: second},
+		{name: "before the first note", next: first},
+		{name: "after an unplaced note", previous: unplaced, wantErr: note.ErrInvalidPrevious},
+		{name: "before an unplaced note", next: unplaced, wantErr: note.ErrInvalidNext},
+		{name: "next before previous", previous: second, next: first, wantErr: note.ErrInvalidNext},
+		{name: "after itself", previous: moved, wantErr: note.ErrInvalidPrevious},
+		{name: "before another user's note", next: other, wantErr: note.ErrInvalidNext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := uc.Reorder(ctx, "jane", moved, tt.previous, tt.next)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reorder: %v", err)
+			}
+			after, before := position(t, uc, tt.previous), position(t, uc, tt.next)
+			if got := n.State.Position; got <= after || (before != "" && got >= before) {
+				t.Errorf("position %q not between %q and %q", got, after, before)
+			}
+		})
+	}
+}
+
+// position returns where jane placed a note, empty for no note
+func position(t *testing.T, uc note.UseCase, id string) string {
+	t.Helper()
+	if id == "" {
+		return ""
+	}
+	n, _, err := uc.Get(context.Background(), "jane", id)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", id, err)
+	}
+	return n.State.Position
+}
@@ -0,0 +1,477 @@
+package notebook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	"notes-app/backend/internal/domain/tx"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/notebook")
+
+var (
+	// ErrNameRequired reports a notebook name that is empty once
+	// whitespace is removed
+	ErrNameRequired = errs.Validation(
+		errs.New(errs.CodeFieldRequired, "name is required").
+			WithTarget("/name").
+			WithParam("field", "name"),
+	)
+
+	// ErrInvalidParent reports a parent notebook the caller does not own
+	ErrInvalidParent = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "unknown notebook").
+			WithTarget("/parentId").
+			WithParam("field", "parentId"),
+	)
+
+	// ErrCannotShare reports an email the notebook cannot be shared with:
+	// unknown, disabled or the owner's own
+	ErrCannotShare = errs.Validation(
+		errs.New(errs.CodeFieldInvalid, "the notebook cannot be shared with this email").
+			WithTarget("/email").
+			WithParam("field", "email"),
+	)
+)
+
+// UseCase defines the notebook operations of a user. Notebooks the caller
+// cannot access are reported as not found; operations reserved to the
+// owner fail with errs.ErrForbidden for collaborators.
+type UseCase interface {
+	// Create stores a new notebook, at the top level when parentID is
+	// empty
+	Create(ctx context.Context, userID, name, parentID string) (*domainNotebook.Notebook, error)
+
+	// Get returns a notebook and the caller's role on it
+	Get(ctx context.Context, userID, id string) (*domainNotebook.Notebook, domainNotebook.Role, error)
+
+	// List returns every notebook the user owns
+	List(ctx context.Context, userID string) ([]*domainNotebook.Notebook, error)
+
+	// SharedWithMe returns the notebooks shared directly with the user
+	SharedWithMe(ctx context.Context, userID string) ([]*domainNotebook.Notebook, error)
+
+	// Rename changes the name of a notebook
+	Rename(ctx context.Context, userID, id, name string) (*domainNotebook.Notebook, error)
+
+	// Move places a notebook under another notebook of the owner, or at
+	// the top level when parentID is empty
+	Move(ctx context.Context, userID, id, parentID string) (*domainNotebook.Notebook, error)
+
+	// Delete removes an empty notebook
+	Delete(ctx context.Context, userID, id string) error
+
+	// Contents returns one page of what a notebook holds: its notebooks by
+	// name, then its notes with the user's pinned notes first and the
+	// others most recently updated first
+	Contents(ctx context.Context, userID, id string, offset, limit int) (*Contents, error)
+
+	// Collaborators returns the users a notebook is shared with directly
+	Collaborators(ctx context.Context, userID, id string) ([]*domainNotebook.Collaborator, error)
+
+	// Share gives a user access to a notebook and everything in it, or
+	// changes the role of an existing collaborator
+	Share(ctx context.Context, userID, id, email string, role domainNotebook.Role) (*domainNotebook.Collaborator, error)
+
+	// Unshare removes a collaborator
+	Unshare(ctx context.Context, userID, id, collaboratorID string) error
+}
+
+// Contents is one page of the items in a notebook
+type Contents struct {
+	Notebooks []*domainNotebook.Notebook
+	Notes     []*domainNote.Note
+	// Total counts every notebook and note in the notebook
+	Total int
+}
+
+// Auditor records audit events
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event) error
+}
+
+type useCase struct {
+	notebookRepo domainNotebook.Repository
+	noteRepo     domainNote.Repository
+	userRepo     domainUser.Repository
+	tx           tx.Manager
+	auditor      Auditor
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// NewUseCase creates a new instance of the notebook use case
+func NewUseCase(notebooks domainNotebook.Repository, notes domainNote.Repository, users domainUser.Repository, txm tx.Manager, auditor Auditor, logger *slog.Logger) UseCase {
+	return &useCase{
+		notebookRepo: notebooks,
+		noteRepo:     notes,
+		userRepo:     users,
+		tx:           txm,
+		auditor:      auditor,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Create implements the notebook creation use case
+func (uc *useCase) Create(ctx context.Context, userID, name, parentID string) (_ *domainNotebook.Notebook, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Create")
+	defer func() { tracing.End(span, err) }()
+
+	notebook := domainNotebook.NewNotebook(userID, name, parentID, uc.now().UTC())
+	if notebook.Name == "" {
+		return nil, errs.Wrap(ErrNameRequired, "notebook.Create")
+	}
+	notebook.ID = uuid.NewString()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.checkParent(ctx, userID, parentID); err != nil {
+			return err
+		}
+		return uc.notebookRepo.Create(ctx, notebook)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.Create")
+	}
+	return notebook, nil
+}
+
+// Get implements the notebook retrieval use case
+func (uc *useCase) Get(ctx context.Context, userID, id string) (_ *domainNotebook.Notebook, _ domainNotebook.Role, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Get")
+	defer func() { tracing.End(span, err) }()
+
+	notebook, role, err := uc.getNotebook(ctx, userID, id)
+	if err != nil {
+		return nil, "", errs.Wrap(err, "notebook.Get")
+	}
+	return notebook, role, nil
+}
+
+// List implements the owned notebooks listing use case
+func (uc *useCase) List(ctx context.Context, userID string) (_ []*domainNotebook.Notebook, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.List")
+	defer func() { tracing.End(span, err) }()
+
+	notebooks, err := uc.notebookRepo.ListByOwner(ctx, userID)
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.List")
+	}
+	return notebooks, nil
+}
+
+// SharedWithMe implements the shared notebooks listing use case
+func (uc *useCase) SharedWithMe(ctx context.Context, userID string) (_ []*domainNotebook.Notebook, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.SharedWithMe")
+	defer func() { tracing.End(span, err) }()
+
+	notebooks, err := uc.notebookRepo.SharedWith(ctx, userID)
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.SharedWithMe")
+	}
+	return notebooks, nil
+}
+
+// Rename implements the notebook rename use case
+func (uc *useCase) Rename(ctx context.Context, userID, id, name string) (_ *domainNotebook.Notebook, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Rename")
+	defer func() { tracing.End(span, err) }()
+
+	var notebook *domainNotebook.Notebook
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if notebook, err = uc.ownNotebook(ctx, userID, id); err != nil {
+			return err
+		}
+		if notebook.Rename(name, uc.now().UTC()); notebook.Name == "" {
+			return ErrNameRequired
+		}
+		return uc.notebookRepo.Update(ctx, notebook)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.Rename")
+	}
+	return notebook, nil
+}
+
+// Move implements the notebook move use case
+func (uc *useCase) Move(ctx context.Context, userID, id, parentID string) (_ *domainNotebook.Notebook, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Move")
+	defer func() { tracing.End(span, err) }()
+
+	var notebook *domainNotebook.Notebook
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if notebook, err = uc.ownNotebook(ctx, userID, id); err != nil {
+			return err
+		}
+		if err := uc.checkParent(ctx, userID, parentID); err != nil {
+			return err
+		}
+
+		now := uc.now().UTC()
+		err = uc.notebookRepo.Move(ctx, id, parentID, now)
+		if errors.Is(err, errs.ErrConflict) {
+			return domainNotebook.ErrNotebookCycle
+		}
+		if err != nil {
+			return err
+		}
+		notebook.ParentID, notebook.UpdatedAt = parentID, now
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.Move")
+	}
+	return notebook, nil
+}
+
+// Delete implements the notebook deletion use case
+func (uc *useCase) Delete(ctx context.Context, userID, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := uc.ownNotebook(ctx, userID, id); err != nil {
+			return err
+		}
+		contents, err := uc.contents(ctx, "", id, 0, 1)
+		if err != nil {
+			return err
+		}
+		if contents.Total > 0 {
+			return domainNotebook.ErrNotebookNotEmpty
+		}
+		return uc.notebookRepo.Delete(ctx, id)
+	})
+	if err != nil {
+		return errs.Wrap(err, "notebook.Delete")
+	}
+
+	uc.logger.InfoContext(ctx, "notebook deleted", slog.String("notebook_id", id))
+	return nil
+}
+
+// Contents implements the notebook browsing use case
+func (uc *useCase) Contents(ctx context.Context, userID, id string, offset, limit int) (_ *Contents, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Contents")
+	defer func() { tracing.End(span, err) }()
+
+	if _, _, err := uc.getNotebook(ctx, userID, id); err != nil {
+		return nil, errs.Wrap(err, "notebook.Contents")
+	}
+	contents, err := uc.contents(ctx, userID, id, offset, limit)
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.Contents")
+	}
+	return contents, nil
+}
+
+// Collaborators implements the collaborator listing use case
+func (uc *useCase) Collaborators(ctx context.Context, userID, id string) (_ []*domainNotebook.Collaborator, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Collaborators")
+	defer func() { tracing.End(span, err) }()
+
+	if _, _, err := uc.getNotebook(ctx, userID, id); err != nil {
+		return nil, errs.Wrap(err, "notebook.Collaborators")
+	}
+	collaborators, err := uc.notebookRepo.Collaborators(ctx, id)
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.Collaborators")
+	}
+	for _, c := range collaborators {
+		user, err := uc.userRepo.GetByID(ctx, c.UserID)
+		if err != nil {
+			return nil, errs.Wrap(err, "notebook.Collaborators")
+		}
+		c.Email = user.Email
+	}
+	return collaborators, nil
+}
+
+// Share implements the notebook sharing use case
+func (uc *useCase) Share(ctx context.Context, userID, id, email string, role domainNotebook.Role) (_ *domainNotebook.Collaborator, err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Share")
+	defer func() { tracing.End(span, err) }()
+
+	var collaborator *domainNotebook.Collaborator
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := uc.ownNotebook(ctx, userID, id); err != nil {
+			return err
+		}
+		user, err := uc.userRepo.GetByEmail(ctx, email)
+		if errors.Is(err, errs.ErrNotFound) {
+			return ErrCannotShare
+		}
+		if err != nil {
+			return err
+		}
+		if user.ID == userID || user.Disabled() {
+			return ErrCannotShare
+		}
+
+		collaborator = &domainNotebook.Collaborator{
+			NotebookID: id,
+			UserID:     user.ID,
+			Role:       role,
+			CreatedAt:  uc.now().UTC(),
+			Email:      user.Email,
+		}
+		if err := uc.notebookRepo.AddCollaborator(ctx, collaborator); err != nil {
+			return err
+		}
+		return uc.record(ctx, audit.ActionNotebookShared, id, map[string]string{
+			"user_id": user.ID,
+			"role":    string(role),
+		})
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "notebook.Share")
+	}
+	return collaborator, nil
+}
+
+// Unshare implements the collaborator removal use case
+func (uc *useCase) Unshare(ctx context.Context, userID, id, collaboratorID string) (err error) {
+	ctx, span := tracer.Start(ctx, "notebook.Unshare")
+	defer func() { tracing.End(span, err) }()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := uc.ownNotebook(ctx, userID, id); err != nil {
+			return err
+		}
+		err := uc.notebookRepo.RemoveCollaborator(ctx, id, collaboratorID)
+		if errors.Is(err, errs.ErrNotFound) {
+			return domainNotebook.ErrCollaboratorNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return uc.record(ctx, audit.ActionNotebookUnshared, id, map[string]string{
+			"user_id": collaboratorID,
+		})
+	})
+	if err != nil {
+		return errs.Wrap(err, "notebook.Unshare")
+	}
+	return nil
+}
+
+// contents loads one page of a notebook's child notebooks followed by
+// its notes, the viewer's pinned notes first
+func (uc *useCase) contents(ctx context.Context, viewerID, id string, offset, limit int) (*Contents, error) {
+	children, err := uc.notebookRepo.Children(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &Contents{}
+	if offset < len(children) {
+		end := len(children)
+		if limit > 0 && offset+limit < end {
+			end = offset + limit
+		}
+		contents.Notebooks = children[offset:end]
+	}
+
+	// The notes page starts where the notebooks end. A page filled by
+	// notebooks still asks for one note to learn the total.
+	noteLimit := limit - len(contents.Notebooks)
+	filter := domainNote.ListFilter{
+		NotebookID: id,
+		ViewerID:   viewerID,
+		Archived:   domainNote.ArchivedInclude,
+		Offset:     max(offset-len(children), 0),
+		Limit:      max(noteLimit, 1),
+	}
+	if limit == 0 {
+		filter.Limit = 0
+	}
+	notes, total, err := uc.noteRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || noteLimit > 0 {
+		contents.Notes = notes
+	}
+	contents.Total = len(children) + total
+	return contents, nil
+}
+
+// getNotebook loads a notebook the user can access and the user's role
+// on it. A missing notebook or one the user cannot access is reported as
+// ErrNotebookNotFound.
+func (uc *useCase) getNotebook(ctx context.Context, userID, id string) (*domainNotebook.Notebook, domainNotebook.Role, error) {
+	if uuid.Validate(id) != nil {
+		return nil, "", domainNotebook.ErrNotebookNotFound
+	}
+	notebook, err := uc.notebookRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, "", domainNotebook.ErrNotebookNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if notebook.OwnerID == userID {
+		return notebook, domainNotebook.RoleOwner, nil
+	}
+
+	role, err := uc.notebookRepo.Access(ctx, id, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == "" {
+		return nil, "", domainNotebook.ErrNotebookNotFound
+	}
+	return notebook, role, nil
+}
+
+// ownNotebook loads a notebook the user owns. Collaborators get
+// errs.ErrForbidden.
+func (uc *useCase) ownNotebook(ctx context.Context, userID, id string) (*domainNotebook.Notebook, error) {
+	notebook, role, err := uc.getNotebook(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if role != domainNotebook.RoleOwner {
+		return nil, errs.ErrForbidden
+	}
+	return notebook, nil
+}
+
+// checkParent confirms that a parent notebook, if any, belongs to the
+// user
+func (uc *useCase) checkParent(ctx context.Context, userID, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	if uuid.Validate(parentID) != nil {
+		return ErrInvalidParent
+	}
+	parent, err := uc.notebookRepo.GetByID(ctx, parentID)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && parent.OwnerID != userID) {
+		return ErrInvalidParent
+	}
+	return err
+}
+
+// record audits action on a notebook
+func (uc *useCase) record(ctx context.Context, action audit.Action, notebookID string, metadata map[string]string) error {
+	return uc.auditor.Record(ctx, audit.Event{
+		Action:     action,
+		TargetType: audit.TargetNotebook,
+		TargetID:   notebookID,
+		Metadata:   metadata,
+	})
+}
@@ -0,0 +1,145 @@
+package notebook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNotebook "notes-app/backend/internal/domain/notebook"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/repository/memory"
+	auditUseCase "notes-app/backend/internal/usecase/audit"
+	"notes-app/backend/internal/usecase/notebook"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type fixture struct {
+	uc    notebook.UseCase
+	users domainUser.Repository
+}
+
+func newFixture() *fixture {
+	users := memory.NewUserRepository()
+	recorder := auditUseCase.NewRecorder(memory.NewAuditRepository(), discard)
+	return &fixture{
+		uc:    notebook.NewUseCase(memory.NewNotebookRepository(), memory.NewNoteRepository(), users, memory.NewTxManager(), recorder, discard),
+		users: users,
+	}
+}
+
+// user stores an account and returns its ID
+func (f *fixture) user(t *testing.T, email string, disabled bool) string {
+	t.Helper()
+	u := &domainUser.User{ID: uuid.NewString(), Email: email, Role: domainUser.RoleUser, CreatedAt: time.Now()}
+	if disabled {
+		u.Disable(time.Now())
+	}
+	if err := f.users.Create(context.Background(), u); err != nil {
+		t.Fatalf("Create(%s): %v", email, err)
+	}
+	return u.ID
+}
+
+// notebook creates a notebook through the use case and returns its ID
+func (f *fixture) notebook(t *testing.T, userID, name, parentID string) string {
+	t.Helper()
+	nb, err := f.uc.Create(context.Background(), userID, name, parentID)
+	if err != nil {
+		t.Fatalf("Create(%s): %v", name, err)
+	}
+	return nb.ID
+}
+
+func TestShare(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	jane := f.user(t, "jane@example.com", false)
+	john := f.user(t, "john@example.com", false)
+	f.user(t, "gone@example.com", true)
+	f.user(t, "anna@example.com", false)
+	work := f.notebook(t, jane, "Work", "")
+	johns := f.notebook(t, john, "John's", "")
+	if _, err := f.uc.Share(ctx, jane, work, "john@example.com", domainNotebook.RoleEditor); err != nil {
+		t.Fatalf("share with john: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		notebook string
+		email    string
+		wantErr  error
+	}{
+		{name: "enabled account", userID: jane, notebook: work, email: "anna@example.com"},
+		{name: "existing collaborator", userID: jane, notebook: work, email: "john@example.com"},
+		{name: "unknown email", userID: jane, notebook: work, email: "nobody@example.com", wantErr: notebook.ErrCannotShare},
+		{name: "disabled account", userID: jane, notebook: work, email: "gone@example.com", wantErr: notebook.ErrCannotShare},
+		{name: "owner's own email", userID: jane, notebook: work, email: "jane@example.com", wantErr: notebook.ErrCannotShare},
+		{name: "by a collaborator", userID: john, notebook: work, email: "anna@example.com", wantErr: errs.ErrForbidden},
+		{name: "notebook of another user", userID: jane, notebook: johns, email: "anna@example.com", wantErr: domainNotebook.ErrNotebookNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collaborator, err := f.uc.Share(ctx, tt.userID, tt.notebook, tt.email, domainNotebook.RoleViewer)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Share: %v", err)
+			}
+			if collaborator.Email != tt.email || collaborator.Role != domainNotebook.RoleViewer {
+				t.Errorf("collaborator = %+v", collaborator)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	f := newFixture()
+	jane := f.user(t, "jane@example.com", false)
+	john := f.user(t, "john@example.com", false)
+	projects := f.notebook(t, jane, "Projects", "")
+	work := f.notebook(t, jane, "Work", projects)
+	report := f.notebook(t, jane, "Report", work)
+	johns := f.notebook(t, john, "John's", "")
+
+	tests := []struct {
+		name     string
+		id       string
+		parentID string
+		wantErr  error
+	}{
+		{name: "to the top level", id: report},
+		{name: "under a sibling", id: report, parentID: projects},
+		{name: "into itself", id: work, parentID: work, wantErr: domainNotebook.ErrNotebookCycle},
+		{name: "into a descendant", id: projects, parentID: work, wantErr: domainNotebook.ErrNotebookCycle},
+		{name: "under another user's notebook", id: work, parentID: johns, wantErr: notebook.ErrInvalidParent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moved, err := f.uc.Move(context.Background(), jane, tt.id, tt.parentID)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Move: %v", err)
+			}
+			if moved.ParentID != tt.parentID {
+				t.Errorf("parent = %q, want %q", moved.ParentID, tt.parentID)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/domain/tx"
+	"notes-app/backend/internal/infrastructure/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/tag")
+
+// ErrNameRequired reports a tag name that is empty once whitespace is
+// removed
+var ErrNameRequired = errs.Validation(
+	errs.New(errs.CodeFieldRequired, "name is required").
+		WithTarget("/name").
+		WithParam("field", "name"),
+)
+
+// UseCase defines the tag operations of a user. Tags owned by someone
+// else are reported as not found.
+type UseCase interface {
+	// List returns every tag of the user with its note count, ordered by
+	// name
+	List(ctx context.Context, userID string) ([]*domainTag.Tag, error)
+
+	// Create stores a new tag
+	Create(ctx context.Context, userID, name, color string) (*domainTag.Tag, error)
+
+	// Update renames or recolors a tag. Nil fields are left unchanged; an
+	// empty color removes it.
+	Update(ctx context.Context, userID, id string, name, color *string) (*domainTag.Tag, error)
+
+	// Delete removes a tag from every note and deletes it
+	Delete(ctx context.Context, userID, id string) error
+
+	// Merge moves the notes of the source tags to the target tag and
+	// deletes the sources
+	Merge(ctx context.Context, userID, targetID string, sourceIDs []string) (*domainTag.Tag, error)
+
+	// Autocomplete returns up to limit tags whose name starts with prefix,
+	// most used first
+	Autocomplete(ctx context.Context, userID, prefix string, limit int) ([]*domainTag.Tag, error)
+}
+
+type useCase struct {
+	tagRepo  domainTag.Repository
+	noteRepo domainNote.Repository
+	tx       tx.Manager
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewUseCase creates a new instance of the tag use case. Tag assignments
+// are stored with the notes, so merges and deletions go through both
+// repositories in one transaction.
+func NewUseCase(tags domainTag.Repository, notes domainNote.Repository, txm tx.Manager, logger *slog.Logger) UseCase {
+	return &useCase{
+		tagRepo:  tags,
+		noteRepo: notes,
+		tx:       txm,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// List implements the tag listing use case
+func (uc *useCase) List(ctx context.Context, userID string) (_ []*domainTag.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "tag.List")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := uc.tagRepo.List(ctx, userID, "")
+	if err != nil {
+		return nil, errs.Wrap(err, "tag.List")
+	}
+	if err := uc.countNotes(ctx, tags...); err != nil {
+		return nil, errs.Wrap(err, "tag.List")
+	}
+	return tags, nil
+}
+
+// Create implements the tag creation use case
+func (uc *useCase) Create(ctx context.Context, userID, name, color string) (_ *domainTag.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "tag.Create")
+	defer func() { tracing.End(span, err) }()
+
+	tag := domainTag.NewTag(userID, name, color, uc.now().UTC())
+	if tag.Name == "" {
+		return nil, errs.Wrap(ErrNameRequired, "tag.Create")
+	}
+	tag.ID = uuid.NewString()
+
+	if err := uc.tagRepo.Create(ctx, tag); err != nil {
+		return nil, errs.Wrap(nameTaken(err, tag.Name), "tag.Create")
+	}
+
+	uc.logger.InfoContext(ctx, "tag created", slog.String("tag_id", tag.ID))
+	return tag, nil
+}
+
+// Update implements the tag rename and recolor use case
+func (uc *useCase) Update(ctx context.Context, userID, id string, name, color *string) (_ *domainTag.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "tag.Update")
+	defer func() { tracing.End(span, err) }()
+
+	var tag *domainTag.Tag
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if tag, err = uc.getTag(ctx, userID, id); err != nil {
+			return err
+		}
+		if name != nil {
+			if tag.Name = domainTag.NormalizeName(*name); tag.Name == "" {
+				return ErrNameRequired
+			}
+		}
+		if color != nil {
+			tag.Color = strings.ToLower(*color)
+		}
+		if err := uc.tagRepo.Update(ctx, tag); err != nil {
+			return nameTaken(err, tag.Name)
+		}
+		return uc.countNotes(ctx, tag)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "tag.Update")
+	}
+	return tag, nil
+}
+
+// Delete implements the tag deletion use case
+func (uc *useCase) Delete(ctx context.Context, userID, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "tag.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := uc.getTag(ctx, userID, id); err != nil {
+			return err
+		}
+		if err := uc.noteRepo.ReplaceTags(ctx, []string{id}, ""); err != nil {
+			return err
+		}
+		return uc.tagRepo.Delete(ctx, id)
+	})
+	if err != nil {
+		return errs.Wrap(err, "tag.Delete")
+	}
+
+	uc.logger.InfoContext(ctx, "tag deleted", slog.String("tag_id", id))
+	return nil
+}
+
+// Merge implements the tag merge use case. Unknown sources are reported
+// as invalid fields at /sourceIds/<index>; the target among the sources
+// is ignored.
+func (uc *useCase) Merge(ctx context.Context, userID, targetID string, sourceIDs []string) (_ *domainTag.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "tag.Merge")
+	defer func() { tracing.End(span, err) }()
+
+	var target *domainTag.Tag
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if target, err = uc.getTag(ctx, userID, targetID); err != nil {
+			return err
+		}
+
+		var sources []string
+		var details []*errs.Error
+		for i, id := range sourceIDs {
+			if id == targetID || slices.Contains(sources, id) {
+				continue
+			}
+			_, err := uc.getTag(ctx, userID, id)
+			if errors.Is(err, domainTag.ErrTagNotFound) {
+				details = append(details, errs.New(errs.CodeFieldInvalid, "unknown tag").
+					WithTarget("/sourceIds/"+strconv.Itoa(i)).
+					WithParam("field", "sourceIds"))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			sources = append(sources, id)
+		}
+		if len(details) > 0 {
+			return errs.Validation(details...)
+		}
+
+		if err := uc.noteRepo.ReplaceTags(ctx, sources, targetID); err != nil {
+			return err
+		}
+		for _, id := range sources {
+			if err := uc.tagRepo.Delete(ctx, id); err != nil {
+				return err
+			}
+		}
+		return uc.countNotes(ctx, target)
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "tag.Merge")
+	}
+
+	uc.logger.InfoContext(ctx, "tags merged", slog.String("tag_id", targetID), slog.Int("merged", len(sourceIDs)))
+	return target, nil
+}
+
+// Autocomplete implements the tag suggestion use case. Ties in usage are
+// broken by name.
+func (uc *useCase) Autocomplete(ctx context.Context, userID, prefix string, limit int) (_ []*domainTag.Tag, err error) {
+	ctx, span := tracer.Start(ctx, "tag.Autocomplete")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := uc.tagRepo.List(ctx, userID, domainTag.NormalizeName(prefix))
+	if err != nil {
+		return nil, errs.Wrap(err, "tag.Autocomplete")
+	}
+	if err := uc.countNotes(ctx, tags...); err != nil {
+		return nil, errs.Wrap(err, "tag.Autocomplete")
+	}
+
+	// List orders by name, so a stable sort keeps names ordered among
+	// equally used tags
+	slices.SortStableFunc(tags, func(a, b *domainTag.Tag) int {
+		return b.NoteCount - a.NoteCount
+	})
+	if limit > 0 && len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+// getTag loads a tag of the user, reporting a missing tag or one owned by
+// someone else as ErrTagNotFound
+func (uc *useCase) getTag(ctx context.Context, userID, id string) (*domainTag.Tag, error) {
+	if uuid.Validate(id) != nil {
+		return nil, domainTag.ErrTagNotFound
+	}
+	tag, err := uc.tagRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && tag.OwnerID != userID) {
+		return nil, domainTag.ErrTagNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// countNotes fills in the NoteCount of tags
+func (uc *useCase) countNotes(ctx context.Context, tags ...*domainTag.Tag) error {
+	ids := make([]string, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	counts, err := uc.noteRepo.CountByTag(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		tag.NoteCount = counts[tag.ID]
+	}
+	return nil
+}
+
+// nameTaken reports a unique name violation as ErrTagExists
+func nameTaken(err error, name string) error {
+	if errors.Is(err, errs.ErrConflict) {
+		return domainTag.ErrTagExists.WithParam("name", name)
+	}
+	return err
+}
@@ -0,0 +1,200 @@
+package tag_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"notes-app/backend/internal/domain/errs"
+	domainNote "notes-app/backend/internal/domain/note"
+	domainTag "notes-app/backend/internal/domain/tag"
+	"notes-app/backend/internal/infrastructure/repository/memory"
+	"notes-app/backend/internal/usecase/tag"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type fixture struct {
+	uc    tag.UseCase
+	notes domainNote.Repository
+}
+
+func newFixture() *fixture {
+	notes := memory.NewNoteRepository()
+	return &fixture{
+		uc:    tag.NewUseCase(memory.NewTagRepository(), notes, memory.NewTxManager(), discard),
+		notes: notes,
+	}
+}
+
+// create adds a tag through the use case and returns its ID
+func (f *fixture) create(t *testing.T, userID, name string) string {
+	t.Helper()
+	created, err := f.uc.Create(context.Background(), userID, name, "")
+	if err != nil {
+		t.Fatalf("Create(%s): %v", name, err)
+	}
+	return created.ID
+}
+
+// note stores a note of jane carrying tags
+func (f *fixture) note(t *testing.T, tagIDs ...string) {
+	t.Helper()
+	ctx := context.Background()
+	n := domainNote.NewNote("jane", "note", domainNote.Delta{}, time.Now())
+	n.ID = uuid.NewString()
+	if err := f.notes.Create(ctx, n); err != nil {
+		t.Fatalf("Create note: %v", err)
+	}
+	if err := f.notes.SetTags(ctx, n.ID, tagIDs); err != nil {
+		t.Fatalf("SetTags: %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		sources    []string
+		wantCode   errs.Code
+		wantTarget string // target of the single validation detail
+		wantCount  int
+		wantLeft   []string
+	}{
+		{name: "sources into target", target: "work", sources: []string{"job", "office"}, wantCount: 4, wantLeft: []string{"work"}},
+		{name: "into itself", target: "work", sources: []string{"work"}, wantCount: 2, wantLeft: []string{"job", "office", "work"}},
+		{name: "itself and repeated sources", target: "work", sources: []string{"work", "job", "job"}, wantCount: 4, wantLeft: []string{"office", "work"}},
+		{name: "source of another user", target: "work", sources: []string{"job", "john's"}, wantCode: errs.CodeValidationFailed, wantTarget: "/sourceIds/1"},
+		{name: "unknown source", target: "work", sources: []string{"missing"}, wantCode: errs.CodeValidationFailed, wantTarget: "/sourceIds/0"},
+		{name: "target of another user", target: "john's", sources: []string{"job"}, wantCode: errs.CodeTagNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			ids := map[string]string{
+				"work":    f.create(t, "jane", "work"),
+				"job":     f.create(t, "jane", "job"),
+				"office":  f.create(t, "jane", "office"),
+				"john's":  f.create(t, "john", "john's"),
+				"missing": uuid.NewString(),
+			}
+			f.note(t, ids["work"])
+			f.note(t, ids["job"])
+			f.note(t, ids["office"], ids["work"])
+			f.note(t, ids["job"], ids["office"])
+
+			var sources []string
+			for _, s := range tt.sources {
+				sources = append(sources, ids[s])
+			}
+			merged, err := f.uc.Merge(context.Background(), "jane", ids[tt.target], sources)
+			if tt.wantCode != "" {
+				if code := errs.CodeOf(err); code != tt.wantCode {
+					t.Fatalf("code = %s, want %s (err %v)", code, tt.wantCode, err)
+				}
+				if details := errs.DetailsOf(err); tt.wantTarget != "" && (len(details) != 1 || errs.TargetOf(details[0]) != tt.wantTarget) {
+					t.Errorf("details = %v, want one at %s", details, tt.wantTarget)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			if merged.NoteCount != tt.wantCount {
+				t.Errorf("target used by %d notes, want %d", merged.NoteCount, tt.wantCount)
+			}
+			tags, err := f.uc.List(context.Background(), "jane")
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			var left []string
+			for _, tg := range tags {
+				left = append(left, tg.Name)
+			}
+			if !slices.Equal(left, tt.wantLeft) {
+				t.Errorf("tags left = %q, want %q", left, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		rename  string
+		wantErr error
+	}{
+		{name: "free name", tag: "work", rename: "career"},
+		{name: "own name in other case", tag: "work", rename: "WORK"},
+		{name: "name of another user's tag", tag: "work", rename: "john's"},
+		{name: "name of another tag", tag: "work", rename: " Job ", wantErr: domainTag.ErrTagExists},
+		{name: "blank name", tag: "work", rename: "  ", wantErr: tag.ErrNameRequired},
+		{name: "tag of another user", tag: "john's", rename: "career", wantErr: domainTag.ErrTagNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			ids := map[string]string{
+				"work":   f.create(t, "jane", "work"),
+				"job":    f.create(t, "jane", "job"),
+				"john's": f.create(t, "john", "john's"),
+			}
+
+			updated, err := f.uc.Update(context.Background(), "jane", ids[tt.tag], &tt.rename, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if updated.Name != domainTag.NormalizeName(tt.rename) {
+				t.Errorf("name = %q", updated.Name)
+			}
+		})
+	}
+}
+
+func TestAutocomplete(t *testing.T) {
+	f := newFixture()
+	work, worry := f.create(t, "jane", "work"), f.create(t, "jane", "worry")
+	f.create(t, "jane", "workshop")
+	f.create(t, "jane", "home")
+	f.create(t, "john", "world")
+	f.note(t, worry)
+	f.note(t, worry, work)
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"wor", 0, []string{"worry", "work", "workshop"}},
+		{"WOR", 2, []string{"worry", "work"}},
+		{"work", 0, []string{"work", "workshop"}},
+		{"", 1, []string{"worry"}},
+		{"x", 0, nil},
+	}
+	for _, tt := range tests {
+		tags, err := f.uc.Autocomplete(context.Background(), "jane", tt.prefix, tt.limit)
+		if err != nil {
+			t.Fatalf("Autocomplete(%q): %v", tt.prefix, err)
+		}
+		var names []string
+		for _, tg := range tags {
+			names = append(names, tg.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("Autocomplete(%q, %d) = %q, want %q", tt.prefix, tt.limit, names, tt.want)
+		}
+	}
+}
@@ -3,18 +3,29 @@ package user
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+	"notes-app/backend/internal/domain/tx"
 	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/logger"
+	"notes-app/backend/internal/infrastructure/tracing"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("notes-app/backend/internal/usecase/user")
+
 var (
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserAlreadyExists  = errs.New(errs.CodeUserExists, "user already exists")
+	ErrInvalidCredentials = errs.New(errs.CodeInvalidCredentials, "invalid credentials")
 )
 
 // UseCase defines the interface for user-related operations
@@ -24,6 +35,30 @@ type UseCase interface {
 
 	// Login authenticates a user and returns a JWT token
 	Login(ctx context.Context, email, password string) (string, error)
+
+	// ChangePassword replaces the password after checking the current
+	// one, revokes every existing session and returns a new token
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (string, error)
+
+	// Logout revokes every session of the user
+	Logout(ctx context.Context, userID string) error
+
+	// CheckAccount confirms that the account behind a verified token may
+	// still use it and sets the principal's current role
+	CheckAccount(ctx context.Context, principal *auth.Principal) error
+}
+
+// Metrics records user-related domain events
+type Metrics interface {
+	LoginSucceeded()
+	LoginFailed(reason string)
+	RegistrationSucceeded()
+	RegistrationFailed(reason string)
+}
+
+// Auditor records audit events
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event) error
 }
 
 // Config holds the configuration for the use case
@@ -34,67 +69,251 @@ type Config struct {
 type useCase struct {
 	userRepo  domainUser.Repository
 	jwtSecret string
+	logger    *slog.Logger
+	metrics   Metrics
+	auditor   Auditor
+	tx        tx.Manager
+	now       func() time.Time
 }
 
 // NewUseCase creates a new instance of the user use case
-func NewUseCase(repo domainUser.Repository, cfg Config) UseCase {
+func NewUseCase(repo domainUser.Repository, txm tx.Manager, cfg Config, logger *slog.Logger, metrics Metrics, auditor Auditor) UseCase {
 	return &useCase{
 		userRepo:  repo,
 		jwtSecret: cfg.JWTSecret,
+		logger:    logger,
+		metrics:   metrics,
+		auditor:   auditor,
+		tx:        txm,
+		now:       time.Now,
 	}
 }
 
 // Register implements the user registration use case
-func (uc *useCase) Register(ctx context.Context, email, password string) error {
-	// Check if user already exists
-	existingUser, err := uc.userRepo.GetByEmail(ctx, email)
-	if err == nil && existingUser != nil {
-		return ErrUserAlreadyExists
-	}
+func (uc *useCase) Register(ctx context.Context, email, password string) (err error) {
+	ctx, span := tracer.Start(ctx, "user.Register")
+	defer func() { tracing.End(span, err) }()
 
-	// Create new user
+	// Create new user (hashes the password)
+	_, hashSpan := tracer.Start(ctx, "bcrypt.GenerateFromPassword")
 	user, err := domainUser.NewUser(email, password)
+	hashSpan.End()
 	if err != nil {
-		return err
+		uc.metrics.RegistrationFailed("invalid")
+		return errs.Wrap(err, "user.Register")
 	}
 
 	// Generate UUID for the user
 	user.ID = uuid.New().String()
 
-	// Save user to repository
-	return uc.userRepo.Create(ctx, user)
+	// Save user to repository; the unique email constraint rejects
+	// duplicates, including concurrent registrations
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, errs.ErrConflict) {
+			uc.metrics.RegistrationFailed("exists")
+			return errs.Wrap(ErrUserAlreadyExists, "user.Register")
+		}
+		uc.metrics.RegistrationFailed("error")
+		return errs.Wrap(err, "user.Register")
+	}
+
+	uc.metrics.RegistrationSucceeded()
+	uc.record(ctx, audit.Event{Action: audit.ActionRegistered, ActorID: user.ID}, user.ID)
+	return nil
 }
 
 // Login implements the user login use case
-func (uc *useCase) Login(ctx context.Context, email, password string) (string, error) {
+func (uc *useCase) Login(ctx context.Context, email, password string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "user.Login")
+	defer func() { tracing.End(span, err) }()
+
 	// Get user by email
 	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, errs.ErrNotFound) {
+		uc.logger.InfoContext(ctx, "login for unknown email", slog.String("email", email))
+		uc.metrics.LoginFailed("unknown_user")
+		uc.loginFailed(ctx, "", email, "unknown_user")
+		return "", errs.Wrap(ErrInvalidCredentials, "user.Login")
+	}
 	if err != nil {
-		log.Printf("Error retrieving user by email: %v", err)
-		return "", ErrInvalidCredentials
+		// A database failure is not a wrong password; report it as such
+		uc.logger.ErrorContext(ctx, "failed to retrieve user by email", slog.Any("error", err))
+		uc.metrics.LoginFailed("error")
+		return "", errs.Wrap(err, "user.Login")
 	}
 
-	if user == nil {
-		log.Printf("User not found for email: %s", email)
-		return "", ErrInvalidCredentials
+	// Validate password
+	_, compareSpan := tracer.Start(ctx, "bcrypt.CompareHashAndPassword")
+	valid := user.ValidatePassword(password)
+	compareSpan.End()
+	if !valid {
+		uc.logger.InfoContext(ctx, "login with wrong password", slog.String("user_id", user.ID))
+		uc.metrics.LoginFailed("wrong_password")
+		uc.loginFailed(ctx, user.ID, email, "wrong_password")
+		return "", errs.Wrap(ErrInvalidCredentials, "user.Login")
 	}
 
-	// Validate password
-	if !user.ValidatePassword(password) {
-		return "", ErrInvalidCredentials
+	// Checked after the password so the response does not reveal which
+	// emails belong to disabled accounts
+	if user.Disabled() {
+		uc.logger.InfoContext(ctx, "login to disabled account", slog.String("user_id", user.ID))
+		uc.metrics.LoginFailed("disabled")
+		uc.loginFailed(ctx, user.ID, email, "disabled")
+		return "", errs.Wrap(domainUser.ErrAccountDisabled, "user.Login")
 	}
 
-	// Generate JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-	})
+	tokenString, err := uc.issueToken(user)
+	if err != nil {
+		uc.metrics.LoginFailed("error")
+		return "", errs.Wrap(err, "user.Login")
+	}
+
+	uc.metrics.LoginSucceeded()
+	uc.record(ctx, audit.Event{Action: audit.ActionLoginSucceeded, ActorID: user.ID}, user.ID)
+	return tokenString, nil
+}
+
+// ChangePassword implements the password change use case
+func (uc *useCase) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "user.ChangePassword")
+	defer func() { tracing.End(span, err) }()
 
-	// Sign the token
-	tokenString, err := token.SignedString([]byte(uc.jwtSecret))
+	var user *domainUser.User
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = uc.getUser(ctx, userID); err != nil {
+			return err
+		}
+
+		_, compareSpan := tracer.Start(ctx, "bcrypt.CompareHashAndPassword")
+		valid := user.ValidatePassword(currentPassword)
+		compareSpan.End()
+		if !valid {
+			// Reported as a field error rather than a 401 so clients do not
+			// mistake a typo for an expired session
+			return errs.Validation(
+				errs.New(errs.CodeFieldInvalid, "current password is incorrect").
+					WithTarget("/currentPassword").
+					WithParam("field", "currentPassword"),
+			)
+		}
+
+		_, hashSpan := tracer.Start(ctx, "bcrypt.GenerateFromPassword")
+		err = user.UpdatePassword(newPassword)
+		hashSpan.End()
+		if err != nil {
+			return err
+		}
+
+		// Tokens that may have leaked with the old password stop working
+		user.RevokeSessions(uc.now())
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return uc.auditor.Record(ctx, userEvent(audit.ActionPasswordChanged, user.ID))
+	})
 	if err != nil {
-		return "", err
+		return "", errs.Wrap(err, "user.ChangePassword")
 	}
 
+	tokenString, err := uc.issueToken(user)
+	if err != nil {
+		return "", errs.Wrap(err, "user.ChangePassword")
+	}
 	return tokenString, nil
 }
+
+// Logout implements the session revocation use case
+func (uc *useCase) Logout(ctx context.Context, userID string) (err error) {
+	ctx, span := tracer.Start(ctx, "user.Logout")
+	defer func() { tracing.End(span, err) }()
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := uc.getUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		user.RevokeSessions(uc.now())
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return uc.auditor.Record(ctx, userEvent(audit.ActionSessionsRevoked, user.ID))
+	})
+	if err != nil {
+		return errs.Wrap(err, "user.Logout")
+	}
+	return nil
+}
+
+// CheckAccount implements the per-request account status check
+func (uc *useCase) CheckAccount(ctx context.Context, principal *auth.Principal) (err error) {
+	ctx, span := tracer.Start(ctx, "user.CheckAccount")
+	defer func() { tracing.End(span, err) }()
+
+	user, err := uc.userRepo.GetByID(ctx, principal.UserID)
+	if errors.Is(err, errs.ErrNotFound) {
+		// The account was deleted after the token was issued
+		return errs.Wrap(errs.ErrInvalidToken, "user.CheckAccount")
+	}
+	if err != nil {
+		return errs.Wrap(err, "user.CheckAccount")
+	}
+	if user.Disabled() {
+		return errs.Wrap(domainUser.ErrAccountDisabled, "user.CheckAccount")
+	}
+	if !user.SessionValid(principal.IssuedAt) {
+		return errs.Wrap(errs.ErrSessionRevoked, "user.CheckAccount")
+	}
+
+	principal.Role = user.Role
+	return nil
+}
+
+// issueToken signs a new access token for user. The session ID lets a
+// single token be told apart; the issue time has millisecond precision
+// so it can be compared with session revocations.
+func (uc *useCase) issueToken(user *domainUser.User) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		auth.ClaimUserID:    user.ID,
+		auth.ClaimEmail:     user.Email,
+		auth.ClaimSessionID: uuid.NewString(),
+		auth.ClaimScope:     strings.Join([]string{auth.ScopeNotesRead, auth.ScopeNotesWrite}, " "),
+		auth.ClaimIssuedAt:  float64(uc.now().UnixMilli()) / 1000,
+	})
+	return token.SignedString([]byte(uc.jwtSecret))
+}
+
+// loginFailed audits a rejected login. userID is empty for unknown emails,
+// and the email attempted is masked as anyone can submit one.
+func (uc *useCase) loginFailed(ctx context.Context, userID, email, reason string) {
+	uc.record(ctx, audit.Event{
+		Action:   audit.ActionLoginFailed,
+		Metadata: map[string]string{"email": logger.MaskEmail(email), "reason": reason},
+	}, userID)
+}
+
+// getUser loads a user, reporting a missing one as ErrUserNotFound
+func (uc *useCase) getUser(ctx context.Context, id string) (*domainUser.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, domainUser.ErrUserNotFound
+	}
+	return user, err
+}
+
+// userEvent is an audit event targeting a user account
+func userEvent(action audit.Action, userID string) audit.Event {
+	return audit.Event{Action: action, TargetType: audit.TargetUser, TargetID: userID}
+}
+
+// record audits an event on a user account. Login and registration
+// events are best effort: the recorder logs failures, and a login is not
+// refused because the audit store is down.
+func (uc *useCase) record(ctx context.Context, event audit.Event, userID string) {
+	if userID != "" {
+		event.TargetType = audit.TargetUser
+		event.TargetID = userID
+	}
+	_ = uc.auditor.Record(ctx, event)
+}
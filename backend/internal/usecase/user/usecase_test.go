@@ -0,0 +1,342 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"notes-app/backend/internal/domain/audit"
+	"notes-app/backend/internal/domain/auth"
+	"notes-app/backend/internal/domain/errs"
+	domainUser "notes-app/backend/internal/domain/user"
+	"notes-app/backend/internal/infrastructure/repository/memory"
+	auditUseCase "notes-app/backend/internal/usecase/audit"
+	"notes-app/backend/internal/usecase/user"
+)
+
+const secret = "test-secret"
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeMetrics counts the recorded events
+type fakeMetrics struct {
+	loginOK, registrationOK int
+	loginFailures           []string
+	registrationFailures    []string
+}
+
+func (m *fakeMetrics) LoginSucceeded()           { m.loginOK++ }
+func (m *fakeMetrics) LoginFailed(reason string) { m.loginFailures = append(m.loginFailures, reason) }
+func (m *fakeMetrics) RegistrationSucceeded()    { m.registrationOK++ }
+func (m *fakeMetrics) RegistrationFailed(reason string) {
+	m.registrationFailures = append(m.registrationFailures, reason)
+}
+
+type fixture struct {
+	uc      user.UseCase
+	users   domainUser.Repository
+	events  audit.Repository
+	metrics *fakeMetrics
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		users:   memory.NewUserRepository(),
+		events:  memory.NewAuditRepository(),
+		metrics: &fakeMetrics{},
+	}
+	recorder := auditUseCase.NewRecorder(f.events, discard)
+	f.uc = user.NewUseCase(f.users, memory.NewTxManager(), user.Config{JWTSecret: secret}, discard, f.metrics, recorder)
+	return f
+}
+
+// register creates an account through the use case and returns it
+func (f *fixture) register(t *testing.T, email, password string) *domainUser.User {
+	t.Helper()
+	ctx := context.Background()
+	if err := f.uc.Register(ctx, email, password); err != nil {
+		t.Fatalf("Register(%s): %v", email, err)
+	}
+	u, err := f.users.GetByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("GetByEmail(%s): %v", email, err)
+	}
+	return u
+}
+
+// actions lists the audited actions, newest first
+func (f *fixture) actions(t *testing.T) []audit.Action {
+	t.Helper()
+	events, _, err := f.events.List(context.Background(), audit.Filter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var actions []audit.Action
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string
+		email      string
+		password   string
+		wantCode   errs.Code
+		wantMetric string
+	}{
+		{name: "new account", email: "jane@example.com", password: "password123"},
+		{name: "duplicate email", existing: "jane@example.com", email: "jane@example.com", password: "password123", wantCode: errs.CodeUserExists, wantMetric: "exists"},
+		{name: "empty email", email: "", password: "password123", wantCode: errs.CodeInvalidEmail, wantMetric: "invalid"},
+		{name: "empty password", email: "jane@example.com", password: "", wantCode: errs.CodeInvalidPassword, wantMetric: "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.existing != "" {
+				f.register(t, tt.existing, "existing-password")
+			}
+
+			err := f.uc.Register(context.Background(), tt.email, tt.password)
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Register: %v", err)
+				}
+				u, err := f.users.GetByEmail(context.Background(), tt.email)
+				if err != nil {
+					t.Fatalf("user not stored: %v", err)
+				}
+				if u.Role != domainUser.RoleUser || u.Password == tt.password || !u.ValidatePassword(tt.password) {
+					t.Errorf("stored user = %+v", u)
+				}
+				if got := f.actions(t); len(got) != 1 || got[0] != audit.ActionRegistered {
+					t.Errorf("audit = %v, want [%s]", got, audit.ActionRegistered)
+				}
+				return
+			}
+
+			if code := errs.CodeOf(err); code != tt.wantCode {
+				t.Fatalf("code = %s, want %s (err %v)", code, tt.wantCode, err)
+			}
+			if n := len(f.metrics.registrationFailures); n != 1 || f.metrics.registrationFailures[0] != tt.wantMetric {
+				t.Errorf("failures = %v, want [%s]", f.metrics.registrationFailures, tt.wantMetric)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		email      string
+		password   string
+		disabled   bool
+		wantErr    error
+		wantMetric string
+	}{
+		{name: "valid credentials", email: "jane@example.com", password: "password123"},
+		{name: "unknown email", email: "john@example.com", password: "password123", wantErr: user.ErrInvalidCredentials, wantMetric: "unknown_user"},
+		{name: "wrong password", email: "jane@example.com", password: "wrong-password", wantErr: user.ErrInvalidCredentials, wantMetric: "wrong_password"},
+		{name: "disabled account", email: "jane@example.com", password: "password123", disabled: true, wantErr: domainUser.ErrAccountDisabled, wantMetric: "disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			jane := f.register(t, "jane@example.com", "password123")
+			if tt.disabled {
+				jane.Disable(time.Now())
+				if err := f.users.Update(context.Background(), jane); err != nil {
+					t.Fatalf("Update: %v", err)
+				}
+			}
+
+			token, err := f.uc.Login(context.Background(), tt.email, tt.password)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if token != "" {
+					t.Error("token returned on failure")
+				}
+				if len(f.metrics.loginFailures) != 1 || f.metrics.loginFailures[0] != tt.wantMetric {
+					t.Errorf("failures = %v, want [%s]", f.metrics.loginFailures, tt.wantMetric)
+				}
+				events, _, err := f.events.List(context.Background(), audit.Filter{})
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if events[0].Action != audit.ActionLoginFailed {
+					t.Errorf("last audit action = %s, want %s", events[0].Action, audit.ActionLoginFailed)
+				}
+				if email := events[0].Metadata["email"]; email == "" || email == tt.email {
+					t.Errorf("audited email %q is not masked", email)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			claims := parseToken(t, token)
+			if claims[auth.ClaimUserID] != jane.ID || claims[auth.ClaimEmail] != jane.Email {
+				t.Errorf("claims = %v", claims)
+			}
+			if claims[auth.ClaimSessionID] == "" || claims[auth.ClaimScope] != "notes:read notes:write" {
+				t.Errorf("claims = %v", claims)
+			}
+			if f.metrics.loginOK != 1 {
+				t.Errorf("successful logins = %d, want 1", f.metrics.loginOK)
+			}
+		})
+	}
+}
+
+func TestLoginRepositoryFailureIsNotInvalidCredentials(t *testing.T) {
+	f := newFixture(t)
+	failing := failingRepo{Repository: f.users, err: errors.New("connection refused")}
+	uc := user.NewUseCase(failing, memory.NewTxManager(), user.Config{JWTSecret: secret}, discard, f.metrics,
+		auditUseCase.NewRecorder(f.events, discard))
+
+	_, err := uc.Login(context.Background(), "jane@example.com", "password123")
+	if errors.Is(err, user.ErrInvalidCredentials) {
+		t.Fatal("database failure reported as invalid credentials")
+	}
+	if code := errs.CodeOf(err); code != errs.CodeInternal {
+		t.Fatalf("code = %s, want %s", code, errs.CodeInternal)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+
+	principalFor := func(t *testing.T, token string) *auth.Principal {
+		claims := parseToken(t, token)
+		iat := claims[auth.ClaimIssuedAt].(float64)
+		return &auth.Principal{
+			UserID:   claims[auth.ClaimUserID].(string),
+			IssuedAt: time.UnixMilli(int64(iat * 1000)),
+		}
+	}
+
+	t.Run("fresh token is accepted with the stored role", func(t *testing.T) {
+		f := newFixture(t)
+		jane := f.register(t, "jane@example.com", "password123")
+		jane.Role = domainUser.RoleSupport
+		f.users.Update(ctx, jane)
+
+		token, _ := f.uc.Login(ctx, "jane@example.com", "password123")
+		p := principalFor(t, token)
+		if err := f.uc.CheckAccount(ctx, p); err != nil {
+			t.Fatalf("CheckAccount: %v", err)
+		}
+		if p.Role != domainUser.RoleSupport {
+			t.Errorf("role = %s, want %s", p.Role, domainUser.RoleSupport)
+		}
+	})
+
+	t.Run("logout revokes earlier tokens", func(t *testing.T) {
+		f := newFixture(t)
+		jane := f.register(t, "jane@example.com", "password123")
+		token, _ := f.uc.Login(ctx, "jane@example.com", "password123")
+		time.Sleep(2 * time.Millisecond)
+
+		if err := f.uc.Logout(ctx, jane.ID); err != nil {
+			t.Fatalf("Logout: %v", err)
+		}
+		if err := f.uc.CheckAccount(ctx, principalFor(t, token)); !errors.Is(err, errs.ErrSessionRevoked) {
+			t.Fatalf("err = %v, want ErrSessionRevoked", err)
+		}
+		if got := f.actions(t); got[0] != audit.ActionSessionsRevoked {
+			t.Errorf("last audit action = %s, want %s", got[0], audit.ActionSessionsRevoked)
+		}
+	})
+
+	t.Run("password change revokes old tokens and issues a new one", func(t *testing.T) {
+		f := newFixture(t)
+		jane := f.register(t, "jane@example.com", "password123")
+		oldToken, _ := f.uc.Login(ctx, "jane@example.com", "password123")
+		time.Sleep(2 * time.Millisecond)
+
+		newToken, err := f.uc.ChangePassword(ctx, jane.ID, "password123", "new-password")
+		if err != nil {
+			t.Fatalf("ChangePassword: %v", err)
+		}
+		if err := f.uc.CheckAccount(ctx, principalFor(t, oldToken)); !errors.Is(err, errs.ErrSessionRevoked) {
+			t.Errorf("old token: err = %v, want ErrSessionRevoked", err)
+		}
+		if err := f.uc.CheckAccount(ctx, principalFor(t, newToken)); err != nil {
+			t.Errorf("new token: %v", err)
+		}
+		if _, err := f.uc.Login(ctx, "jane@example.com", "new-password"); err != nil {
+			t.Errorf("login with new password: %v", err)
+		}
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		f := newFixture(t)
+		jane := f.register(t, "jane@example.com", "password123")
+
+		_, err := f.uc.ChangePassword(ctx, jane.ID, "wrong-password", "new-password")
+		if code := errs.CodeOf(err); code != errs.CodeValidationFailed {
+			t.Fatalf("code = %s, want %s", code, errs.CodeValidationFailed)
+		}
+		if details := errs.DetailsOf(err); len(details) != 1 || details[0].Target != "/currentPassword" {
+			t.Errorf("details = %v", details)
+		}
+	})
+
+	t.Run("disabled account", func(t *testing.T) {
+		f := newFixture(t)
+		jane := f.register(t, "jane@example.com", "password123")
+		token, _ := f.uc.Login(ctx, "jane@example.com", "password123")
+		jane.Disable(time.Now())
+		f.users.Update(ctx, jane)
+
+		if err := f.uc.CheckAccount(ctx, principalFor(t, token)); !errors.Is(err, domainUser.ErrAccountDisabled) {
+			t.Fatalf("err = %v, want ErrAccountDisabled", err)
+		}
+	})
+
+	t.Run("deleted account", func(t *testing.T) {
+		f := newFixture(t)
+		jane := f.register(t, "jane@example.com", "password123")
+		token, _ := f.uc.Login(ctx, "jane@example.com", "password123")
+		f.users.Delete(ctx, jane.ID)
+
+		if err := f.uc.CheckAccount(ctx, principalFor(t, token)); !errors.Is(err, errs.ErrInvalidToken) {
+			t.Fatalf("err = %v, want ErrInvalidToken", err)
+		}
+	})
+}
+
+// failingRepo fails every lookup with err
+type failingRepo struct {
+	domainUser.Repository
+	err error
+}
+
+func (r failingRepo) GetByEmail(ctx context.Context, email string) (*domainUser.User, error) {
+	return nil, r.err
+}
+
+func parseToken(t *testing.T, token string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	return claims
+}
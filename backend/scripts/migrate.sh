@@ -3,5 +3,30 @@
 # Read environment variables from .env file
 export $(cat .env | xargs)
 
-# Run the migration
-psql -h $DB_HOST -p $DB_PORT -U $DB_USER -d $DB_NAME -f internal/infrastructure/repository/postgres/migrations/001_create_users_table.sql 
\ No newline at end of file
+run_psql() {
+	psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" -v ON_ERROR_STOP=1 "$@"
+}
+
+# Databases migrated before applied migrations were tracked already have
+# the users table; the first migration recreates it, so it is recorded as
+# applied instead of being run again
+untracked=$(run_psql -tAc "SELECT to_regclass('schema_migrations') IS NULL AND to_regclass('users') IS NOT NULL") || exit 1
+run_psql -qc "CREATE TABLE IF NOT EXISTS schema_migrations (
+	version TEXT PRIMARY KEY,
+	applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+)" || exit 1
+if [ "$untracked" = "t" ]; then
+	run_psql -qc "INSERT INTO schema_migrations (version) VALUES ('001_create_users_table.sql')" || exit 1
+fi
+
+# Run the migrations that have not run yet, in order, each in its own
+# transaction
+for migration in internal/infrastructure/repository/postgres/migrations/*.sql; do
+	version=$(basename "$migration")
+	applied=$(run_psql -tAc "SELECT COUNT(*) FROM schema_migrations WHERE version = '$version'") || exit 1
+	if [ "$applied" != "0" ]; then
+		continue
+	fi
+	echo "Applying $migration"
+	run_psql --single-transaction -f "$migration" -c "INSERT INTO schema_migrations (version) VALUES ('$version')" || exit 1
+done